@@ -3,16 +3,30 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
 	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/discord"
+	"github.com/codex-k8s/telegram-approver/internal/email"
 	httpapi "github.com/codex-k8s/telegram-approver/internal/http"
 	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/kube"
 	"github.com/codex-k8s/telegram-approver/internal/log"
+	"github.com/codex-k8s/telegram-approver/internal/matrix"
+	"github.com/codex-k8s/telegram-approver/internal/mattermost"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/slack"
+	"github.com/codex-k8s/telegram-approver/internal/storage"
 	"github.com/codex-k8s/telegram-approver/internal/telegram"
+	"github.com/codex-k8s/telegram-approver/internal/tracing"
 )
 
 func main() {
@@ -28,28 +42,199 @@ func main() {
 		logger.Error("failed to load i18n", "error", err)
 		os.Exit(1)
 	}
+	messages, err := i18n.NewLive(bundle, cfg.I18nDir)
+	if err != nil {
+		logger.Error("failed to load i18n", "error", err)
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingEnabled, "telegram-approver")
+	if err != nil {
+		logger.Error("failed to init tracing", "error", err)
+		os.Exit(1)
+	}
 
-	registry := approvals.NewRegistry()
-	service, err := telegram.New(cfg, bundle, registry, logger)
+	store, err := storage.New(cfg)
+	if err != nil {
+		logger.Error("failed to init approval store", "error", err)
+		os.Exit(1)
+	}
+	registry := approvals.NewRegistry(store, logger)
+	cache := approvals.NewDecisionCache(cfg.DecisionCacheTTL)
+	history := approvals.NewHistory(cfg.HistorySize, cfg.HistoryRetention)
+	approvalMetrics := metrics.New(metrics.Options{
+		Tools:    cfg.MetricsTools,
+		MaxTools: cfg.MetricsMaxTools,
+		Tenants:  slices.Collect(maps.Keys(cfg.File.Tenants)),
+	})
+	var cluster telegram.Cluster
+	if cfg.Standby {
+		var lease approvals.Lease
+		var err error
+		if cfg.StandbyLease == config.LeaseKubernetes {
+			lease, err = kube.NewLease(cfg.StandbyLeaseNamespace, cfg.StandbyLeaseName)
+		} else {
+			lease, err = storage.NewLease(store, cfg.StandbyLeaseKey)
+		}
+		if err != nil {
+			logger.Error("failed to init standby lease", "error", err)
+			os.Exit(1)
+		}
+		cluster.Lease = lease
+		cluster.UpdatesOnly = cfg.StandbyLease == config.LeaseKubernetes
+	}
+	if cfg.WebhookHandoff() {
+		presence, err := storage.NewPresence(store, cfg.InstanceKeyPrefix)
+		if err != nil {
+			logger.Error("failed to init instance presence", "error", err)
+			os.Exit(1)
+		}
+		cluster.Presence = presence
+	}
+	var channels []channel.Channel
+	if cfg.SlackEnabled() {
+		channels = append(channels, slack.New(slack.Options{
+			Token:         cfg.SlackBotToken,
+			SigningSecret: cfg.SlackSigningSecret,
+			Channel:       cfg.SlackChannel,
+			AllowedUsers:  cfg.SlackAllowedUsers,
+			APIURL:        cfg.SlackAPIURL,
+			Messages:      messages,
+			DefaultLang:   cfg.Lang,
+			Log:           logger,
+		}))
+	}
+	if cfg.MattermostEnabled() {
+		channels = append(channels, mattermost.New(mattermost.Options{
+			URL:          cfg.MattermostURL,
+			Token:        cfg.MattermostToken,
+			ChannelID:    cfg.MattermostChannelID,
+			ActionsURL:   cfg.MattermostActionsURL,
+			AllowedUsers: cfg.MattermostAllowedUsers,
+			Messages:     messages,
+			DefaultLang:  cfg.Lang,
+			Log:          logger,
+		}))
+	}
+	if cfg.DiscordEnabled() {
+		channels = append(channels, discord.New(discord.Options{
+			Token:        cfg.DiscordBotToken,
+			PublicKey:    cfg.DiscordPublicKeyBytes,
+			ChannelID:    cfg.DiscordChannelID,
+			AllowedUsers: cfg.DiscordAllowedUsers,
+			APIURL:       cfg.DiscordAPIURL,
+			Messages:     messages,
+			DefaultLang:  cfg.Lang,
+			Log:          logger,
+		}))
+	}
+	if cfg.MatrixEnabled() {
+		channels = append(channels, matrix.New(matrix.Options{
+			HomeserverURL: cfg.MatrixHomeserverURL,
+			Token:         cfg.MatrixAccessToken,
+			RoomID:        cfg.MatrixRoomID,
+			AllowedUsers:  cfg.MatrixAllowedUsers,
+			Messages:      messages,
+			DefaultLang:   cfg.Lang,
+			Log:           logger,
+		}))
+	}
+	if cfg.EmailEnabled() {
+		channels = append(channels, email.New(email.Options{
+			SMTPAddr:    cfg.EmailSMTPAddr,
+			Username:    cfg.EmailSMTPUsername,
+			Password:    cfg.EmailSMTPPassword,
+			From:        cfg.EmailFrom,
+			To:          cfg.EmailTo,
+			PublicURL:   cfg.EmailPublicURL,
+			Secret:      []byte(cfg.EmailLinkSecret),
+			Messages:    messages,
+			DefaultLang: cfg.Lang,
+			Log:         logger,
+		}))
+	}
+	service, err := telegram.New(cfg, messages, registry, cache, history, approvalMetrics, cluster, channels, logger)
 	if err != nil {
 		logger.Error("failed to init telegram service", "error", err)
 		os.Exit(1)
 	}
 
 	server := httpapi.New(cfg.HTTPAddr(), logger)
-	server.Handle("/approve", httpapi.NewApproveHandler(service, cfg, logger))
+	server.Handle("/metrics", approvalMetrics.Handler())
+	server.SetChecks(cfg.HealthCacheTTL,
+		httpapi.Check{Name: "telegram", Run: service.CheckTelegram},
+		httpapi.Check{Name: "webhook", Run: service.CheckWebhook},
+		httpapi.Check{Name: "store", Run: func(ctx context.Context) error { return storage.Ping(ctx, store) }},
+	)
+	idempotency := httpapi.NewIdempotencyCache(cfg.IdempotencyTTL)
+	approve := httpapi.NewApproveHandler(service, cfg, logger)
+	server.Handle("/approve", httpapi.RequireAPIAuth(cfg, httpapi.WithIdempotency(idempotency, approve)))
+	server.Handle("/approvals", httpapi.RequireAPIAuth(cfg, httpapi.NewApprovalsHandler(registry, cfg)))
+	server.Handle("/approvals/{correlation_id}", httpapi.RequireAPIAuth(cfg, httpapi.NewCancelHandler(service, cfg)))
+	server.Handle("/approvals/{correlation_id}/wait", httpapi.RequireAPIAuth(cfg, httpapi.NewWaitHandler(service, history, cfg)))
+	server.Handle("/sessions/{session_id}/cancel", httpapi.RequireAPIAuth(cfg, httpapi.NewSessionCancelHandler(service, cfg)))
+	if cfg.AdminEnabled() {
+		server.Handle("/admin/decision-cache", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewDecisionCacheHandler(cache)))
+		server.Handle("/admin/cleanup", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewCleanupHandler(service, logger)))
+		if cfg.Standby {
+			server.Handle("/admin/promote", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewPromoteHandler(service, logger)))
+		}
+		if cfg.JournalEnabled {
+			server.Handle("/admin/journal", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewJournalHandler(service.Journal())))
+		}
+		if cfg.AuditFile != "" {
+			server.Handle("/admin/audit/verify", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewAuditVerifyHandler(service.Audit())))
+			server.Handle("/audit", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewAuditHandler(service.Audit())))
+		}
+		server.Handle("/admin/snapshot", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewSnapshotHandler(service, logger)))
+		server.Handle("/admin/purge", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewPurgeHandler(service, logger)))
+		server.Handle("/admin/approvals/{correlation_id}", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewApprovalHandler(service)))
+		server.Handle("/admin/approvals/{correlation_id}/transfer", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewTransferHandler(service, logger)))
+		server.Handle("/admin/approvals/{correlation_id}/force", httpapi.RequireBearer(cfg.AdminToken, httpapi.NewForceHandler(service, logger)))
+	}
 	if webhook := service.WebhookHandler(); webhook != nil {
 		server.Handle("/webhook", webhook)
 	}
+	for _, ch := range channels {
+		if handler := ch.Handler(service); handler != nil {
+			server.Handle("/"+ch.Name()+"/interactions", handler)
+		}
+	}
 
 	baseCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := service.Restore(baseCtx); err != nil {
+		logger.Error("failed to restore pending approvals", "error", err)
+		os.Exit(1)
+	}
+	service.OnRoleChange(func(bool) { server.SetReady(service.Serving()) })
 	if err := service.Start(baseCtx); err != nil {
 		logger.Error("failed to start telegram updates", "error", err)
 		os.Exit(1)
 	}
-	server.SetReady(true)
+	server.SetReady(service.Serving())
+	for _, ch := range channels {
+		if poller, ok := ch.(channel.Poller); ok {
+			go poller.Poll(baseCtx, service)
+		}
+	}
+	if cfg.KubeController {
+		controller, err := kube.New(kube.Options{
+			Namespace: cfg.KubeNamespace,
+			Resync:    cfg.KubeResync,
+			Approve:   approve,
+			Approvals: service,
+			History:   history,
+			Tenants:   func() map[string]config.Tenant { return cfg.CurrentFile().Tenants },
+			Log:       logger,
+		})
+		if err != nil {
+			logger.Error("failed to init kubernetes controller", "error", err)
+			os.Exit(1)
+		}
+		go controller.Run(baseCtx)
+	}
 
 	errCh := make(chan error, 1)
 	go func() { errCh <- server.ListenAndServe() }()
@@ -57,17 +242,49 @@ func main() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
 
-	select {
-	case sig := <-sigCh:
-		logger.Info("shutdown requested", "signal", sig.String())
-	case err := <-errCh:
-		logger.Error("http server stopped", "error", err)
+wait:
+	for {
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				reload(cfg, messages, service, logger)
+				continue
+			}
+			logger.Info("shutdown requested", "signal", sig.String())
+			break wait
+		case err := <-errCh:
+			logger.Error("http server stopped", "error", err)
+			break wait
+		}
 	}
 
-	cancel()
 	server.SetReady(false)
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
-	_ = server.Shutdown(shutdownCtx)
+	// Stop before cancelling so queued updates are processed and the webhook is handed off.
 	_ = service.Stop(shutdownCtx)
+	cancel()
+	_ = server.Shutdown(shutdownCtx)
+	_ = shutdownTracing(shutdownCtx)
+}
+
+// reload re-reads the config file and the i18n files. A file that fails to load or validate is skipped and the
+// settings loaded before stay in effect; pending approvals are never touched.
+func reload(cfg config.Config, messages *i18n.Live, service *telegram.Service, logger *slog.Logger) {
+	if strings.TrimSpace(cfg.ConfigFile) != "" {
+		file, err := config.LoadFile(cfg.ConfigFile)
+		if err == nil {
+			err = service.Reload(file)
+		}
+		if err != nil {
+			logger.Error("failed to reload config file, keeping the current one", "error", err)
+		} else {
+			logger.Info("config file reloaded", "path", cfg.ConfigFile)
+		}
+	}
+	if err := messages.Reload(); err != nil {
+		logger.Error("failed to reload i18n files, keeping the current ones", "error", err)
+	} else if cfg.I18nDir != "" {
+		logger.Info("i18n files reloaded", "dir", cfg.I18nDir)
+	}
 }
@@ -1,7 +1,10 @@
 package approvals
 
 import (
-	"errors"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 )
@@ -18,6 +21,8 @@ const (
 	DecisionError Decision = "error"
 	// DecisionPending means the request is queued for async approval.
 	DecisionPending Decision = "pending"
+	// DecisionCancelled means the requester withdrew the request before it was answered.
+	DecisionCancelled Decision = "cancelled"
 )
 
 // Link points to a code reference.
@@ -28,34 +33,172 @@ type Link struct {
 	URL string `json:"url"`
 }
 
+// Attachment is a file uploaded as a Telegram document in reply to the approval message.
+type Attachment struct {
+	// Name is the file name shown in Telegram.
+	Name string
+	// Data is the file content; empty when URL is set.
+	Data []byte
+	// URL is a file Telegram downloads by itself.
+	URL string
+	// Caption is an optional document caption.
+	Caption string
+}
+
 // Callback defines async approval callback settings.
 type Callback struct {
 	// URL is the webhook callback URL.
 	URL string `json:"url"`
+	// IncludeDiscussion adds captured discussion notes to the callback payload.
+	IncludeDiscussion bool `json:"include_discussion,omitempty"`
+	// Secret overrides the global HMAC secret used to sign the callback body.
+	Secret string `json:"secret,omitempty"`
+	// Template is a Go text/template rendering the callback body; it wins over tenant and global templates.
+	Template string `json:"template,omitempty"`
+	// Headers are extra callback headers; values are templates rendered like the body.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Format overrides the global callback encoding (json or cloudevents) when set.
+	Format string `json:"format,omitempty"`
+}
+
+// Escalation defines where an unanswered approval is escalated.
+type Escalation struct {
+	// ChatID is the escalation chat; zero uses the configured default.
+	ChatID int64 `json:"chat_id,omitempty"`
+	// After is how long after submission the approval is escalated; zero uses the configured fraction of the timeout.
+	After time.Duration `json:"after,omitempty"`
+	// Mentions are Telegram usernames mentioned in the escalation message.
+	Mentions []string `json:"mentions,omitempty"`
+	// Disabled turns escalation off for the request.
+	Disabled bool `json:"disabled,omitempty"`
+	// At is when the approval is escalated; zero means it is not escalated.
+	At time.Time `json:"at,omitempty"`
 }
 
 // Request holds data required for approval.
 type Request struct {
 	// CorrelationID links related requests.
-	CorrelationID string
+	CorrelationID string `json:"correlation_id"`
 	// Tool is the tool name.
-	Tool string
+	Tool string `json:"tool"`
 	// Arguments are tool arguments.
-	Arguments map[string]any
+	Arguments map[string]any `json:"arguments"`
 	// Justification is a short reason from the model.
-	Justification string
+	Justification string `json:"justification"`
 	// ApprovalRequest describes the requested action.
-	ApprovalRequest string
+	ApprovalRequest string `json:"approval_request"`
 	// RiskAssessment describes potential risks.
-	RiskAssessment string
+	RiskAssessment string `json:"risk_assessment"`
 	// LinksToCode are optional references.
-	LinksToCode []Link
+	LinksToCode []Link `json:"links_to_code,omitempty"`
 	// Lang selects message language.
-	Lang string
+	Lang string `json:"lang,omitempty"`
 	// Markup selects message formatting.
-	Markup string
+	Markup string `json:"markup,omitempty"`
 	// Callback contains webhook details.
-	Callback Callback
+	Callback Callback `json:"callback"`
+	// NotifyURL receives a human-readable summary of the final decision.
+	NotifyURL string `json:"notify_url,omitempty"`
+	// Tenant selects tenant-specific settings such as the callback template.
+	Tenant string `json:"tenant,omitempty"`
+	// RequestedBy identifies the human or agent on whose behalf the request was made.
+	RequestedBy string `json:"requested_by,omitempty"`
+	// ArgumentLanguages marks string arguments as code in the given language, e.g. {"query": "sql"}.
+	ArgumentLanguages map[string]string `json:"argument_languages,omitempty"`
+	// RequiredApprovals is how many distinct approvals finalize the request; 0 or 1 means a single approval.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+	// Approvers limits voting to these Telegram user IDs when set.
+	Approvers []int64 `json:"approvers,omitempty"`
+	// Keyboard overrides the configured button layout of the Telegram message, e.g. "approve,deny".
+	Keyboard string `json:"keyboard,omitempty"`
+	// Diff is the code change, shown in the message as a diff code block; a long diff keeps only its leading part.
+	Diff string `json:"diff,omitempty"`
+	// DiffOmitted is how many trailing lines of Diff were cut; the full diff is attached as a document.
+	DiffOmitted int `json:"diff_omitted,omitempty"`
+	// MessageThreadID is the forum topic the message is posted into, overriding the configured topic.
+	MessageThreadID int `json:"message_thread_id,omitempty"`
+	// Split marks a message posted as a thread of parts; only the last part, which has the keyboard, is updated.
+	Split bool `json:"split,omitempty"`
+	// Attachments are uploaded after the message is posted; they are not persisted.
+	Attachments []Attachment `json:"-"`
+	// Headline replaces ApprovalRequest in the Telegram message; it is rendered from the tool profile template.
+	Headline string `json:"headline,omitempty"`
+	// Summary is the plain-language summary generated for the Telegram message; empty when summaries are off.
+	Summary string `json:"summary,omitempty"`
+	// RiskHighlights are the main risks generated together with Summary.
+	RiskHighlights []string `json:"risk_highlights,omitempty"`
+	// Emoji is prepended to the Telegram message title by the tool profile.
+	Emoji string `json:"emoji,omitempty"`
+	// Critical adds an Ack button so approvers can confirm they have seen the request without deciding.
+	Critical bool `json:"critical,omitempty"`
+	// Severity is the risk class shown as a colored badge; it orders reminders and may select the timeout.
+	Severity Severity `json:"severity,omitempty"`
+	// TraceContext holds W3C trace context captured from the /approve request.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	// Target selects the chat the request is routed to.
+	Target string `json:"target,omitempty"`
+	// SessionID identifies the agent run the request belongs to.
+	SessionID string `json:"session_id,omitempty"`
+	// ExecuteAfter is when the action runs once approved; zero means right away.
+	ExecuteAfter time.Time `json:"execute_after,omitzero"`
+	// QueuedUntil is when the timeout of a request made outside working hours starts ticking; zero otherwise.
+	QueuedUntil time.Time `json:"queued_until,omitzero"`
+	// WorkflowID groups requests of one workflow, e.g. into a forum topic.
+	WorkflowID string `json:"workflow_id,omitempty"`
+	// TaskSummary briefly describes what the agent run is working on.
+	TaskSummary string `json:"task_summary,omitempty"`
+	// Escalation controls forwarding of an unanswered approval to the escalation chat.
+	Escalation Escalation `json:"escalation"`
+	// TimeoutMessage overrides the note appended to the message on timeout.
+	TimeoutMessage string `json:"timeout_message,omitempty"`
+	// OnTimeout is the decision made when nobody answers in time: approve, deny, or error when empty.
+	OnTimeout Decision `json:"on_timeout,omitempty"`
+	// ReminderMessage overrides the note posted with the escalation copy of the message.
+	ReminderMessage string `json:"reminder_message,omitempty"`
+	// Fingerprint is the stable hash of Tool and Arguments.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Sensitive hides arguments and other details from the chat message.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Channel names the channel the approval is posted to; empty means Telegram.
+	Channel string `json:"channel,omitempty"`
+}
+
+// Reason codes classify results independently of the human-readable reason.
+const (
+	// ReasonApproved marks an approval by button press.
+	ReasonApproved = "approved"
+	// ReasonDenied marks a denial without a reason; Reason holds the default deny text.
+	ReasonDenied = "denied"
+	// ReasonDeniedWithMessage marks a denial with a reason written by the approver.
+	ReasonDeniedWithMessage = "denied_with_message"
+	// ReasonTimeout marks an approval that expired without a decision.
+	ReasonTimeout = "timeout"
+	// ReasonMessageTooLong marks a request whose rendered message exceeds the Telegram limit.
+	ReasonMessageTooLong = "message_too_long"
+)
+
+// Failure classes describe why an approval message could not be posted.
+const (
+	// FailureRateLimited means Telegram flood control rejected the message.
+	FailureRateLimited = "rate_limited"
+	// FailureForbidden means the bot cannot write to the chat.
+	FailureForbidden = "forbidden"
+	// FailureEntityParse means Telegram could not parse the message markup.
+	FailureEntityParse = "entity_parse"
+	// FailureBadRequest means Telegram rejected the message for another reason.
+	FailureBadRequest = "bad_request"
+	// FailureUnavailable means Telegram failed or could not be reached.
+	FailureUnavailable = "unavailable"
+)
+
+// Failure details an error decision caused by a failed message send.
+type Failure struct {
+	// Class is one of the Failure* classes.
+	Class string `json:"class"`
+	// Retryable reports whether re-submitting the same request may succeed; the service does not retry itself.
+	Retryable bool `json:"retryable"`
+	// RetryAfterSec is how long to wait before re-submitting a rate-limited request.
+	RetryAfterSec int `json:"retry_after_sec,omitempty"`
 }
 
 // Result represents the approval result.
@@ -64,144 +207,651 @@ type Result struct {
 	Decision Decision
 	// Reason contains human-readable details.
 	Reason string
+	// ReasonCode classifies the reason for machines; empty when there is no code.
+	ReasonCode string
+	// Failure details why the approval message could not be posted; nil for other results.
+	Failure *Failure
+	// Actor is the user who made the decision; nil for timeouts and automatic results.
+	Actor *Actor
+	// Cached marks a decision reused from the decision cache.
+	Cached bool
+	// AttachedTo is the pending approval an identical request was attached to instead of being posted.
+	AttachedTo string
+	// ForcedBy names the administrator who resolved the approval through the admin API.
+	ForcedBy string
+}
+
+// Actor identifies the user behind a decision.
+type Actor struct {
+	// ID is the user ID in the channel the decision was made in, e.g. the Telegram user ID.
+	ID string `json:"id"`
+	// Username is the user display name.
+	Username string `json:"username,omitempty"`
+}
+
+// MessageRef identifies a Telegram message.
+type MessageRef struct {
+	// ChatID is the chat the message belongs to.
+	ChatID int64 `json:"chat_id"`
+	// MessageID is the message ID within the chat.
+	MessageID int `json:"message_id"`
+}
+
+// Valid reports whether the reference points to a message.
+func (m MessageRef) Valid() bool {
+	return m.MessageID > 0
+}
+
+// ChannelRef identifies a message posted to a channel other than Telegram.
+type ChannelRef struct {
+	// Channel is the channel name.
+	Channel string `json:"channel"`
+	// Conversation is the channel-specific conversation ID.
+	Conversation string `json:"conversation"`
+	// Message is the channel-specific message ID.
+	Message string `json:"message"`
+}
+
+// Note is a discussion message captured on a pending approval.
+type Note struct {
+	// UserID is the Telegram user ID of the author.
+	UserID int64 `json:"user_id"`
+	// Username is the Telegram username or display name of the author.
+	Username string `json:"username,omitempty"`
+	// Text is the message text.
+	Text string `json:"text"`
+	// At is the time the note was posted.
+	At time.Time `json:"at"`
+}
+
+// Vote is a single approval in quorum mode.
+type Vote struct {
+	// UserID is the Telegram user ID of the voter.
+	UserID int64 `json:"user_id"`
+	// Username is the voter display name.
+	Username string `json:"username,omitempty"`
+	// At is when the vote was cast.
+	At time.Time `json:"at"`
 }
 
 // Approval stores state for a single approval request.
 type Approval struct {
 	// Request is the approval request payload.
-	Request Request
+	Request Request `json:"request"`
 	// CreatedAt is the request creation time.
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at"`
+	// Deadline is the time the approval times out.
+	Deadline time.Time `json:"deadline"`
+	// ChatID is the Telegram chat holding the approval message.
+	ChatID int64 `json:"chat_id"`
 	// MessageID is the Telegram message ID.
-	MessageID int
+	MessageID int `json:"message_id"`
+	// ThreadID is the forum topic of the approval message.
+	ThreadID int `json:"thread_id,omitempty"`
 	// MessageText is the Telegram message text.
-	MessageText string
+	MessageText string `json:"message_text"`
+	// Escalated is the copy of the approval message posted to the escalation chat.
+	Escalated MessageRef `json:"escalated,omitempty"`
+	// Direct is the copy of the approval message opened in a private chat through a deep link.
+	Direct MessageRef `json:"direct,omitzero"`
+	// LinkToken is the unused one-time token of the approval deep link.
+	LinkToken string `json:"link_token,omitempty"`
+	// Snoozes counts how many times approvers postponed the timeout.
+	Snoozes int `json:"snoozes,omitempty"`
+	// RemindAt is when a snoozed approval is reposted; zero when no reminder is pending.
+	RemindAt time.Time `json:"remind_at,omitzero"`
+	// DiscussionMessageID is the root message of the discussion thread.
+	DiscussionMessageID int `json:"discussion_message_id,omitempty"`
+	// Discussion holds notes posted in the discussion thread.
+	Discussion []Note `json:"discussion,omitempty"`
+	// Votes are approvals collected so far in quorum mode.
+	Votes []Vote `json:"votes,omitempty"`
+	// Acks are users who acknowledged a critical request without deciding.
+	Acks []Vote `json:"acks,omitempty"`
+	// ChannelRef is the message posted to a channel other than Telegram.
+	ChannelRef ChannelRef `json:"channel_ref,omitzero"`
+	// Duplicates are identical requests attached to this approval; they receive its decision.
+	Duplicates []Request `json:"duplicates,omitempty"`
 	// AwaitingReason marks that a deny reason is pending.
-	AwaitingReason bool
+	AwaitingReason bool `json:"-"`
+	// Prompt is the message asking for a deny reason, if any.
+	Prompt MessageRef `json:"-"`
+}
+
+// Message returns a reference to the approval message.
+func (a *Approval) Message() MessageRef {
+	return MessageRef{ChatID: a.ChatID, MessageID: a.MessageID}
+}
+
+// Posted reports whether the approval message was sent to its channel.
+func (a *Approval) Posted() bool {
+	return a.MessageID != 0 || a.ChannelRef.Message != ""
+}
+
+// clone returns a copy that callers may read after the shard lock is released.
+func (a *Approval) clone() *Approval {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.Discussion = slices.Clone(a.Discussion)
+	c.Votes = slices.Clone(a.Votes)
+	c.Acks = slices.Clone(a.Acks)
+	c.Duplicates = slices.Clone(a.Duplicates)
+	return &c
+}
+
+// Store persists pending approvals so they survive restarts.
+type Store interface {
+	// Save creates or replaces the approval.
+	Save(approval Approval) error
+	// Delete removes the approval.
+	Delete(correlationID string) error
+	// Load returns all stored approvals.
+	Load() ([]Approval, error)
 }
 
+// SharedStore is a Store shared by several replicas; the registry treats it as the source of truth.
+type SharedStore interface {
+	Store
+	// Create saves the approval only if the correlation ID is unused.
+	Create(approval Approval) (bool, error)
+	// Get returns the approval or nil when it is missing.
+	Get(correlationID string) (*Approval, error)
+	// Claim deletes the approval and reports whether this caller removed it.
+	Claim(correlationID string) (bool, error)
+}
+
+// Lease elects the single active instance among replicas sharing a store.
+type Lease interface {
+	// Acquire takes a free lease or renews one held by holder and reports whether holder owns it.
+	Acquire(holder string, ttl time.Duration) (bool, error)
+	// Force hands the lease to holder regardless of the current owner.
+	Force(holder string, ttl time.Duration) error
+	// Release frees the lease if holder owns it.
+	Release(holder string) error
+}
+
+// Presence tracks live instances that receive Telegram updates.
+type Presence interface {
+	// Register announces holder for ttl.
+	Register(holder string, ttl time.Duration) error
+	// Deregister removes holder.
+	Deregister(holder string) error
+	// Count returns the number of announced instances.
+	Count() (int, error)
+}
+
+// registryShards is the number of independently locked partitions of the registry.
+const registryShards = 64
+
 // Registry stores active approval requests.
+// Approvals are spread over shards so that requests for different approvals rarely contend for a lock.
 type Registry struct {
-	mu                sync.Mutex
-	approvals         map[string]*Approval
-	promptMessageID   int
-	promptCorrelation string
+	shards     [registryShards]registryShard
+	store      Store
+	shared     SharedStore
+	duplicates DuplicateIndex
+	log        *slog.Logger
+}
+
+type registryShard struct {
+	mu        sync.Mutex
+	approvals map[string]*Approval
 }
 
-// ErrAlreadyExists is returned when the correlation id is already used.
-var ErrAlreadyExists = errors.New("approval already exists")
+// NewRegistry creates a new approval registry; a nil store keeps state in memory only.
+func NewRegistry(store Store, log *slog.Logger) *Registry {
+	registry := &Registry{store: store, duplicates: newMemoryIndex(), log: log}
+	for i := range registry.shards {
+		registry.shards[i].approvals = make(map[string]*Approval)
+	}
+	if shared, ok := store.(SharedStore); ok {
+		registry.shared = shared
+		// Replicas share the index so a duplicate is found whichever replica created the approval.
+		if index, ok := store.(DuplicateIndex); ok {
+			registry.duplicates = index
+		}
+	}
+	return registry
+}
+
+// Shared reports whether approvals are shared with other replicas.
+func (r *Registry) Shared() bool {
+	return r.shared != nil
+}
+
+// Sync reconciles local state with a shared store and returns approvals created by other replicas.
+func (r *Registry) Sync() ([]Approval, error) {
+	if r.shared == nil {
+		return nil, nil
+	}
+	loaded, err := r.shared.Load()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(loaded))
+	var added []Approval
+	for i := range loaded {
+		approval := loaded[i]
+		id := approval.Request.CorrelationID
+		seen[id] = struct{}{}
+		sh := r.shard(id)
+		sh.mu.Lock()
+		if _, ok := sh.approvals[id]; !ok {
+			added = append(added, approval)
+		}
+		sh.replace(&approval)
+		sh.mu.Unlock()
+	}
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for id := range sh.approvals {
+			if _, ok := seen[id]; !ok {
+				delete(sh.approvals, id)
+			}
+		}
+		sh.mu.Unlock()
+	}
+	return added, nil
+}
 
-// NewRegistry creates a new approval registry.
-func NewRegistry() *Registry {
-	return &Registry{approvals: make(map[string]*Approval)}
+// Restore loads persisted approvals into the registry and returns them.
+func (r *Registry) Restore() ([]Approval, error) {
+	if r.store == nil {
+		return nil, nil
+	}
+	loaded, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range loaded {
+		approval := loaded[i]
+		sh := r.shard(approval.Request.CorrelationID)
+		sh.mu.Lock()
+		sh.approvals[approval.Request.CorrelationID] = &approval
+		sh.mu.Unlock()
+		if r.shared == nil {
+			r.indexRequest(&approval)
+		}
+	}
+	return loaded, nil
 }
 
-// Add registers a new approval request.
-func (r *Registry) Add(req Request) (*Approval, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, exists := r.approvals[req.CorrelationID]; exists {
+// Add registers a new approval request that times out at deadline.
+func (r *Registry) Add(req Request, deadline time.Time) (*Approval, error) {
+	sh := r.shard(req.CorrelationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, exists := sh.approvals[req.CorrelationID]; exists {
 		return nil, ErrAlreadyExists
 	}
 	approval := &Approval{
 		Request:   req,
 		CreatedAt: time.Now(),
+		Deadline:  deadline,
+	}
+	if r.shared != nil {
+		created, err := r.shared.Create(*approval)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			return nil, ErrAlreadyExists
+		}
+		sh.approvals[req.CorrelationID] = approval
+		r.indexRequest(approval)
+		return approval.clone(), nil
 	}
-	r.approvals[req.CorrelationID] = approval
-	return approval, nil
+	sh.approvals[req.CorrelationID] = approval
+	r.persist(approval)
+	r.indexRequest(approval)
+	return approval.clone(), nil
 }
 
-// Get returns the approval by correlation id.
+// Get returns a copy of the approval by correlation id; changes go through the registry methods.
 func (r *Registry) Get(correlationID string) *Approval {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	return r.approvals[correlationID]
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, _ := r.lookup(sh, correlationID)
+	return approval.clone()
+}
+
+// List returns copies of pending approvals ordered by creation time.
+func (r *Registry) List() []Approval {
+	if r.shared != nil {
+		if _, err := r.Sync(); err != nil {
+			r.log.Error("Failed to sync shared approvals", "error", err)
+		}
+	}
+	list := make([]Approval, 0)
+	r.each(func(approval *Approval) bool {
+		list = append(list, *approval.clone())
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
 }
 
-// SetMessage stores Telegram message metadata for the approval.
-func (r *Registry) SetMessage(correlationID string, messageID int, messageText string) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if approval, ok := r.approvals[correlationID]; ok {
-		approval.MessageID = messageID
-		approval.MessageText = messageText
+// SetMessage stores Telegram message metadata for the approval and reports whether it is still pending.
+func (r *Registry) SetMessage(correlationID string, message MessageRef, threadID int, messageText string) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	if approval.ChatID != message.ChatID {
+		// The discussion thread stays behind in the previous chat.
+		approval.DiscussionMessageID = 0
+	}
+	approval.ChatID = message.ChatID
+	approval.MessageID = message.MessageID
+	approval.ThreadID = threadID
+	approval.MessageText = messageText
+	r.persist(approval)
+	return true
+}
+
+// SetLang switches the language of a pending approval together with its re-rendered message text.
+func (r *Registry) SetLang(correlationID, lang, messageText string) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	approval.Request.Lang = lang
+	approval.MessageText = messageText
+	r.persist(approval)
+	return true
+}
+
+// SetChannelRef stores the message posted to a channel other than Telegram.
+func (r *Registry) SetChannelRef(correlationID string, ref ChannelRef, messageText string) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	approval.ChannelRef = ref
+	approval.MessageText = messageText
+	r.persist(approval)
+	return true
+}
+
+// SetEscalated stores the escalation message and reports whether the approval is still pending.
+func (r *Registry) SetEscalated(correlationID string, message MessageRef) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	approval.Escalated = message
+	r.persist(approval)
+	return true
+}
+
+// SetDiscussion stores the discussion thread root message.
+func (r *Registry) SetDiscussion(correlationID string, messageID int) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
 	}
+	approval.DiscussionMessageID = messageID
+	r.persist(approval)
+	return true
 }
 
-// StartReason marks approval as waiting for a deny reason and returns prompt to delete.
-func (r *Registry) StartReason(correlationID string) (int, bool) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	approval, ok := r.approvals[correlationID]
+// FindByMessage returns a copy of the pending approval whose message or discussion root matches ref.
+func (r *Registry) FindByMessage(ref MessageRef) *Approval {
+	if r.shared != nil {
+		if _, err := r.Sync(); err != nil {
+			r.log.Error("Failed to sync shared approvals", "error", err)
+		}
+	}
+	var found *Approval
+	r.each(func(approval *Approval) bool {
+		if approval.ChatID == ref.ChatID && (approval.MessageID == ref.MessageID || approval.DiscussionMessageID == ref.MessageID) {
+			found = approval.clone()
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// AddNote appends a discussion note to the approval.
+func (r *Registry) AddNote(correlationID string, note Note) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
 	if !ok {
-		return 0, false
+		return false
 	}
-	var previousPrompt int
-	if r.promptCorrelation != "" && r.promptCorrelation != correlationID {
-		if prevApproval, exists := r.approvals[r.promptCorrelation]; exists {
-			prevApproval.AwaitingReason = false
+	approval.Discussion = append(approval.Discussion, note)
+	r.persist(approval)
+	return true
+}
+
+// AddVote records an approval vote and returns all votes so far.
+// added is false when the user has already voted; ok is false when the approval is gone.
+func (r *Registry) AddVote(correlationID string, vote Vote) (votes []Vote, added bool, ok bool) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return nil, false, false
+	}
+	for _, existing := range approval.Votes {
+		if existing.UserID == vote.UserID {
+			return slices.Clone(approval.Votes), false, true
+		}
+	}
+	approval.Votes = append(approval.Votes, vote)
+	r.persist(approval)
+	return slices.Clone(approval.Votes), true, true
+}
+
+// AddAck records an acknowledgement once per user and returns the acknowledgements so far.
+// added is false when the user has already acknowledged; ok is false when the approval is not pending.
+func (r *Registry) AddAck(correlationID string, ack Vote) (acks []Vote, added bool, ok bool) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return nil, false, false
+	}
+	for _, existing := range approval.Acks {
+		if existing.UserID == ack.UserID {
+			return slices.Clone(approval.Acks), false, true
 		}
-		previousPrompt = r.promptMessageID
 	}
+	approval.Acks = append(approval.Acks, ack)
+	r.persist(approval)
+	return slices.Clone(approval.Acks), true, true
+}
+
+// Progress returns copies of the quorum votes and acknowledgements of a pending approval.
+func (r *Registry) Progress(correlationID string) (votes, acks []Vote) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone(approval.Votes), slices.Clone(approval.Acks)
+}
+
+// StartReason marks approval as waiting for a deny reason and returns its previous prompt to delete.
+func (r *Registry) StartReason(correlationID string) (MessageRef, bool) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return MessageRef{}, false
+	}
+	previousPrompt := approval.Prompt
 	approval.AwaitingReason = true
-	r.promptCorrelation = correlationID
-	r.promptMessageID = 0
+	approval.Prompt = MessageRef{}
 	return previousPrompt, true
 }
 
-// SetPromptMessage stores the prompt message ID for the current deny flow.
-func (r *Registry) SetPromptMessage(correlationID string, messageID int) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if r.promptCorrelation == correlationID {
-		r.promptMessageID = messageID
+// SetPromptMessage stores the deny prompt message of the approval.
+func (r *Registry) SetPromptMessage(correlationID string, message MessageRef) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if approval, ok := sh.approvals[correlationID]; ok && approval.AwaitingReason {
+		approval.Prompt = message
 	}
 }
 
-// ClearPrompt removes the active deny prompt if it matches correlationID.
-func (r *Registry) ClearPrompt(correlationID string) int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if r.promptCorrelation != correlationID {
-		return 0
-	}
-	if approval, ok := r.approvals[correlationID]; ok {
-		approval.AwaitingReason = false
+// ClearPrompt cancels the deny flow of the approval and returns its prompt message.
+func (r *Registry) ClearPrompt(correlationID string) MessageRef {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := sh.approvals[correlationID]
+	if !ok {
+		return MessageRef{}
 	}
-	removed := r.promptMessageID
-	r.promptMessageID = 0
-	r.promptCorrelation = ""
+	removed := approval.Prompt
+	approval.AwaitingReason = false
+	approval.Prompt = MessageRef{}
 	return removed
 }
 
-// CurrentPrompt returns the approval awaiting a deny reason and its prompt message id.
-func (r *Registry) CurrentPrompt() (*Approval, int) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if r.promptCorrelation == "" {
-		return nil, 0
+// PromptFor returns a copy of the approval whose deny prompt is the given message.
+func (r *Registry) PromptFor(prompt MessageRef) *Approval {
+	if !prompt.Valid() {
+		return nil
 	}
-	approval := r.approvals[r.promptCorrelation]
-	if approval == nil || !approval.AwaitingReason {
-		return nil, 0
-	}
-	return approval, r.promptMessageID
+	var found *Approval
+	r.each(func(approval *Approval) bool {
+		if approval.AwaitingReason && approval.Prompt == prompt {
+			found = approval.clone()
+			return false
+		}
+		return true
+	})
+	return found
 }
 
 // Resolve removes the approval from the registry and clears prompt if needed.
-func (r *Registry) Resolve(correlationID string) (*Approval, int, bool) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	approval, ok := r.approvals[correlationID]
+func (r *Registry) Resolve(correlationID string) (*Approval, MessageRef, bool) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
 	if !ok {
-		return nil, 0, false
+		return nil, MessageRef{}, false
+	}
+	if r.shared != nil {
+		claimed, err := r.shared.Claim(correlationID)
+		if err != nil {
+			// Without a confirmed claim another replica may finalize it too; keep it pending for a retry.
+			r.log.Error("Failed to claim shared approval", "error", err, "correlation_id", correlationID)
+			return nil, MessageRef{}, false
+		}
+		if !claimed {
+			// Another replica resolved it first.
+			delete(sh.approvals, correlationID)
+			return nil, MessageRef{}, false
+		}
+	} else {
+		r.forget(correlationID)
+	}
+	delete(sh.approvals, correlationID)
+	r.unindexRequest(approval)
+	prompt := approval.Prompt
+	approval.AwaitingReason = false
+	approval.Prompt = MessageRef{}
+	return approval, prompt, true
+}
+
+// shard returns the partition that holds the approval.
+func (r *Registry) shard(correlationID string) *registryShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(correlationID))
+	return &r.shards[hash.Sum32()%registryShards]
+}
+
+// each calls fn for pending approvals shard by shard until it returns false.
+func (r *Registry) each(fn func(approval *Approval) bool) {
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, approval := range sh.approvals {
+			if !fn(approval) {
+				sh.mu.Unlock()
+				return
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// lookup returns the approval from a locked shard, refreshing it from a shared store first.
+func (r *Registry) lookup(sh *registryShard, correlationID string) (*Approval, bool) {
+	if r.shared != nil {
+		stored, err := r.shared.Get(correlationID)
+		switch {
+		case err != nil:
+			r.log.Error("Failed to read shared approval", "error", err, "correlation_id", correlationID)
+		case stored == nil:
+			delete(sh.approvals, correlationID)
+			return nil, false
+		default:
+			sh.replace(stored)
+		}
+	}
+	approval, ok := sh.approvals[correlationID]
+	return approval, ok
+}
+
+// replace swaps in a fresh copy while keeping process-local prompt state.
+func (sh *registryShard) replace(fresh *Approval) {
+	id := fresh.Request.CorrelationID
+	if current, ok := sh.approvals[id]; ok {
+		fresh.AwaitingReason = current.AwaitingReason
+		fresh.Prompt = current.Prompt
+		*current = *fresh
+		return
+	}
+	sh.approvals[id] = fresh
+}
+
+func (r *Registry) persist(approval *Approval) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.Save(*approval); err != nil {
+		r.log.Error("Failed to persist approval", "error", err, "correlation_id", approval.Request.CorrelationID)
+	}
+}
+
+func (r *Registry) forget(correlationID string) {
+	if r.store == nil {
+		return
 	}
-	delete(r.approvals, correlationID)
-	promptID := 0
-	if r.promptCorrelation == correlationID {
-		promptID = r.promptMessageID
-		r.promptMessageID = 0
-		r.promptCorrelation = ""
+	if err := r.store.Delete(correlationID); err != nil {
+		r.log.Error("Failed to remove persisted approval", "error", err, "correlation_id", correlationID)
 	}
-	return approval, promptID, true
 }
@@ -0,0 +1,178 @@
+package approvals
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CachedDecision is a recently made decision keyed by request fingerprint, tenant, requester and destination.
+type CachedDecision struct {
+	// Fingerprint is the tool and arguments hash.
+	Fingerprint string `json:"fingerprint"`
+	// Tool is the tool name.
+	Tool string `json:"tool"`
+	// Tenant is the tenant the decision was made for.
+	Tenant string `json:"tenant,omitempty"`
+	// RequestedBy is the requester the decision was made for.
+	RequestedBy string `json:"requested_by,omitempty"`
+	// CorrelationID is the approval that produced the decision.
+	CorrelationID string `json:"correlation_id"`
+	// Decision is the cached decision.
+	Decision Decision `json:"decision"`
+	// Reason is the cached decision reason.
+	Reason string `json:"reason,omitempty"`
+	// ResolvedAt is the time the decision was made.
+	ResolvedAt time.Time `json:"resolved_at"`
+	// ExpiresAt is the time the entry stops being reused.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DecisionCache keeps approve/deny decisions for identical re-submissions.
+// A nil cache is valid and disables caching.
+type DecisionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[cacheKey]CachedDecision
+}
+
+// cacheKey scopes a decision to the tenant, requester and destination it was made for, so a decision of one tenant
+// or its approvers is never reused for another tenant's identical request.
+type cacheKey struct {
+	fingerprint string
+	tenant      string
+	requestedBy string
+	channel     string
+	target      string
+}
+
+func keyOf(req Request) cacheKey {
+	return cacheKey{
+		fingerprint: req.Fingerprint,
+		tenant:      req.Tenant,
+		requestedBy: req.RequestedBy,
+		channel:     req.Channel,
+		target:      req.Target,
+	}
+}
+
+// NewDecisionCache creates a cache; it returns nil when ttl is not positive.
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &DecisionCache{ttl: ttl, entries: make(map[cacheKey]CachedDecision)}
+}
+
+// Put stores a final human decision for the approval request.
+func (c *DecisionCache) Put(req Request, result Result) {
+	if c == nil || req.Fingerprint == "" {
+		return
+	}
+	if result.Decision != DecisionApprove && result.Decision != DecisionDeny {
+		return
+	}
+	if result.ReasonCode == ReasonTimeout {
+		// Nobody looked at the request, so identical ones must be asked again.
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[keyOf(req)] = CachedDecision{
+		Fingerprint:   req.Fingerprint,
+		Tool:          req.Tool,
+		Tenant:        req.Tenant,
+		RequestedBy:   req.RequestedBy,
+		CorrelationID: req.CorrelationID,
+		Decision:      result.Decision,
+		Reason:        result.Reason,
+		ResolvedAt:    now,
+		ExpiresAt:     now.Add(c.ttl),
+	}
+}
+
+// Get returns a non-expired decision made for a request with the same fingerprint, tenant, requester and
+// destination as req.
+func (c *DecisionCache) Get(req Request) (CachedDecision, bool) {
+	if c == nil || req.Fingerprint == "" {
+		return CachedDecision{}, false
+	}
+	key := keyOf(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return CachedDecision{}, false
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		delete(c.entries, key)
+		return CachedDecision{}, false
+	}
+	return entry, true
+}
+
+// List returns non-expired entries ordered by resolution time.
+func (c *DecisionCache) List() []CachedDecision {
+	if c == nil {
+		return nil
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]CachedDecision, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ResolvedAt.Before(list[j].ResolvedAt) })
+	return list
+}
+
+// Forget removes the entries of the fingerprint for every tenant and requester and reports whether any existed.
+func (c *DecisionCache) Forget(fingerprint string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := false
+	for key := range c.entries {
+		if key.fingerprint == fingerprint {
+			delete(c.entries, key)
+			found = true
+		}
+	}
+	return found
+}
+
+// ForgetCorrelations removes entries produced by the given approvals and returns how many were removed.
+func (c *DecisionCache) ForgetCorrelations(correlationIDs ...string) int {
+	if c == nil || len(correlationIDs) == 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, entry := range c.entries {
+		if slices.Contains(correlationIDs, entry.CorrelationID) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Clear removes all entries.
+func (c *DecisionCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]CachedDecision)
+}
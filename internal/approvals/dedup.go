@@ -0,0 +1,209 @@
+package approvals
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DuplicateIndex maps requests and attached duplicates to the pending approval they belong to, so duplicates are
+// found with point lookups instead of scanning every approval. Entries may outlive their approval; the registry
+// checks the approval before using one.
+type DuplicateIndex interface {
+	// Index points key at the approval, replacing an older entry; the entry may expire after deadline.
+	Index(key, correlationID string, deadline time.Time) error
+	// Indexed returns the correlation ID key points at, or "" when there is none.
+	Indexed(key string) (string, error)
+	// Unindex removes key if it still points at the approval.
+	Unindex(key, correlationID string) error
+}
+
+// Attach joins req to a pending approval with the same fingerprint, tenant, requester, channel, and target, so the
+// identical request is decided together with it instead of being posted again. It returns the approval req was
+// attached to, or nil when there is none.
+func (r *Registry) Attach(req Request) (*Approval, error) {
+	if req.Fingerprint == "" {
+		return nil, nil
+	}
+	if r.Get(req.CorrelationID) != nil || r.AttachedTo(req.CorrelationID) != nil {
+		return nil, ErrAlreadyExists
+	}
+	primary, err := r.duplicates.Indexed(requestKey(req))
+	if err != nil {
+		r.log.Error("Failed to look up duplicate approvals", "error", err, "correlation_id", req.CorrelationID)
+		return nil, nil
+	}
+	if primary == "" {
+		return nil, nil
+	}
+	sh := r.shard(primary)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, primary)
+	if !ok || !sameRequest(approval.Request, req) {
+		return nil, nil
+	}
+	approval.Duplicates = append(approval.Duplicates, req)
+	r.persist(approval)
+	r.index(duplicateKey(req.CorrelationID), primary, approval.Deadline)
+	return approval.clone(), nil
+}
+
+// Detach removes a duplicate request from the approval it was attached to and returns that approval as the
+// duplicate sees it.
+func (r *Registry) Detach(correlationID string) (*Approval, bool) {
+	primary := r.AttachedTo(correlationID)
+	if primary == nil {
+		return nil, false
+	}
+	sh := r.shard(primary.Request.CorrelationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, primary.Request.CorrelationID)
+	if !ok {
+		return nil, false
+	}
+	i := slices.IndexFunc(approval.Duplicates, func(req Request) bool { return req.CorrelationID == correlationID })
+	if i < 0 {
+		return nil, false
+	}
+	duplicate := approval.duplicate(approval.Duplicates[i])
+	approval.Duplicates = slices.Delete(approval.Duplicates, i, i+1)
+	r.persist(approval)
+	r.unindex(duplicateKey(correlationID), approval.Request.CorrelationID)
+	return duplicate, true
+}
+
+// AttachedTo returns the pending approval a duplicate request is attached to, or nil.
+func (r *Registry) AttachedTo(correlationID string) *Approval {
+	primary, err := r.duplicates.Indexed(duplicateKey(correlationID))
+	if err != nil {
+		r.log.Error("Failed to look up duplicate approvals", "error", err, "correlation_id", correlationID)
+		return nil
+	}
+	if primary == "" {
+		return nil
+	}
+	sh := r.shard(primary)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, primary)
+	if !ok || !approval.attached(correlationID) {
+		return nil
+	}
+	return approval.clone()
+}
+
+// indexRequest makes the approval findable by requests identical to it.
+func (r *Registry) indexRequest(approval *Approval) {
+	if approval.Request.Fingerprint == "" {
+		return
+	}
+	id := approval.Request.CorrelationID
+	r.index(requestKey(approval.Request), id, approval.Deadline)
+	for _, req := range approval.Duplicates {
+		r.index(duplicateKey(req.CorrelationID), id, approval.Deadline)
+	}
+}
+
+// unindexRequest drops the index entries of a resolved approval and its duplicates.
+func (r *Registry) unindexRequest(approval *Approval) {
+	if approval.Request.Fingerprint == "" {
+		return
+	}
+	id := approval.Request.CorrelationID
+	r.unindex(requestKey(approval.Request), id)
+	for _, req := range approval.Duplicates {
+		r.unindex(duplicateKey(req.CorrelationID), id)
+	}
+}
+
+func (r *Registry) index(key, correlationID string, deadline time.Time) {
+	if err := r.duplicates.Index(key, correlationID, deadline); err != nil {
+		r.log.Error("Failed to index approval", "error", err, "correlation_id", correlationID)
+	}
+}
+
+func (r *Registry) unindex(key, correlationID string) {
+	if err := r.duplicates.Unindex(key, correlationID); err != nil {
+		r.log.Error("Failed to unindex approval", "error", err, "correlation_id", correlationID)
+	}
+}
+
+// requestKey identifies requests that sameRequest treats as identical.
+func requestKey(req Request) string {
+	hash := sha256.New()
+	for _, field := range []string{req.Fingerprint, req.Tenant, req.RequestedBy, req.Channel, req.Target} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return "request:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func duplicateKey(correlationID string) string {
+	return "duplicate:" + correlationID
+}
+
+// memoryIndex is the duplicate index of a registry whose store is not shared.
+type memoryIndex struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{entries: make(map[string]string)}
+}
+
+func (m *memoryIndex) Index(key, correlationID string, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = correlationID
+	return nil
+}
+
+func (m *memoryIndex) Indexed(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[key], nil
+}
+
+func (m *memoryIndex) Unindex(key, correlationID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[key] == correlationID {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Attached returns a copy of the approval for each attached duplicate, carrying the duplicate request.
+func (a *Approval) Attached() []*Approval {
+	attached := make([]*Approval, 0, len(a.Duplicates))
+	for _, req := range a.Duplicates {
+		attached = append(attached, a.duplicate(req))
+	}
+	return attached
+}
+
+func (a *Approval) attached(correlationID string) bool {
+	return slices.ContainsFunc(a.Duplicates, func(req Request) bool { return req.CorrelationID == correlationID })
+}
+
+// duplicate returns the approval as seen by an attached request; the messages stay with the original request.
+func (a *Approval) duplicate(req Request) *Approval {
+	return &Approval{
+		Request:    req,
+		CreatedAt:  a.CreatedAt,
+		Deadline:   a.Deadline,
+		Discussion: slices.Clone(a.Discussion),
+		Votes:      slices.Clone(a.Votes),
+		Acks:       slices.Clone(a.Acks),
+	}
+}
+
+func sameRequest(pending, req Request) bool {
+	return pending.Fingerprint == req.Fingerprint && pending.Tenant == req.Tenant &&
+		pending.RequestedBy == req.RequestedBy && pending.Channel == req.Channel && pending.Target == req.Target
+}
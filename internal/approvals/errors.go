@@ -0,0 +1,46 @@
+package approvals
+
+import "errors"
+
+// Errors shared by the registry, the service, and the HTTP layer. Callers match them with errors.Is;
+// the service wraps the underlying cause, so the message keeps the details.
+var (
+	// ErrAlreadyExists is returned when the correlation id is already used.
+	ErrAlreadyExists = errors.New("approval already exists")
+	// ErrNotFound is returned when no pending approval matches the correlation id.
+	ErrNotFound = errors.New("approval not found")
+	// ErrAlreadyResolved is returned when the approval is no longer pending because a decision was made.
+	ErrAlreadyResolved = errors.New("approval already resolved")
+	// ErrTelegramUnavailable is returned when Telegram could not be reached or asked to retry later.
+	ErrTelegramUnavailable = errors.New("telegram is unavailable")
+	// ErrStoreUnavailable is returned when the shared store could not confirm the claim on a decision; the approval
+	// stays pending and the decision may be retried.
+	ErrStoreUnavailable = errors.New("approval store is unavailable")
+	// ErrCallbackFailed is returned when the decision could not be delivered to the callback URL.
+	ErrCallbackFailed = errors.New("callback delivery failed")
+)
+
+// NotPending reports whether err means that the approval cannot be decided any more.
+func NotPending(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrAlreadyResolved)
+}
+
+// ErrorKind returns a short metric label for errors of the taxonomy and "other" for the rest.
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrAlreadyExists):
+		return "already_exists"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrAlreadyResolved):
+		return "already_resolved"
+	case errors.Is(err, ErrTelegramUnavailable):
+		return "telegram_unavailable"
+	case errors.Is(err, ErrStoreUnavailable):
+		return "store_unavailable"
+	case errors.Is(err, ErrCallbackFailed):
+		return "callback_failed"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,24 @@
+package approvals
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Fingerprint returns a stable hash of the tool name and its arguments.
+func Fingerprint(tool string, arguments map[string]any) string {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	// encoding/json sorts map keys, so equal arguments always produce equal bytes.
+	data, err := json.Marshal(struct {
+		Tool      string         `json:"tool"`
+		Arguments map[string]any `json:"arguments"`
+	}{Tool: tool, Arguments: arguments})
+	if err != nil {
+		data = []byte(tool)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
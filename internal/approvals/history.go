@@ -0,0 +1,193 @@
+package approvals
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Resolved describes a finished approval and its Telegram message.
+type Resolved struct {
+	// CorrelationID is the approval correlation ID.
+	CorrelationID string `json:"correlation_id"`
+	// Tool is the tool name.
+	Tool string `json:"tool"`
+	// SessionID is the agent run the request belonged to.
+	SessionID string `json:"session_id,omitempty"`
+	// Tenant is the tenant the request belonged to.
+	Tenant string `json:"tenant,omitempty"`
+	// Decision is the final decision.
+	Decision Decision `json:"decision"`
+	// Reason is the final decision reason.
+	Reason string `json:"reason,omitempty"`
+	// Message references the approval message.
+	Message MessageRef `json:"message"`
+	// ResolvedAt is the time the decision was made.
+	ResolvedAt time.Time `json:"resolved_at"`
+	// MessageDeleted marks that the approval message was removed from the chat.
+	MessageDeleted bool `json:"message_deleted"`
+	// Discussion holds notes captured before resolution.
+	Discussion []Note `json:"discussion,omitempty"`
+	// ForcedBy names the administrator who force-resolved the approval.
+	ForcedBy string `json:"forced_by,omitempty"`
+}
+
+// PurgeFilter selects resolved approvals to remove; set fields are combined with AND.
+type PurgeFilter struct {
+	// CorrelationID matches a single approval.
+	CorrelationID string
+	// SessionID matches approvals of an agent run.
+	SessionID string
+	// From matches approvals resolved at or after this time.
+	From time.Time
+	// To matches approvals resolved before this time.
+	To time.Time
+}
+
+// Empty reports whether the filter has no criteria.
+func (f PurgeFilter) Empty() bool {
+	return f.CorrelationID == "" && f.SessionID == "" && f.From.IsZero() && f.To.IsZero()
+}
+
+func (f PurgeFilter) matches(entry Resolved) bool {
+	switch {
+	case f.CorrelationID != "" && entry.CorrelationID != f.CorrelationID:
+		return false
+	case f.SessionID != "" && entry.SessionID != f.SessionID:
+		return false
+	case !f.From.IsZero() && entry.ResolvedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !entry.ResolvedAt.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// History keeps a bounded list of recently resolved approvals.
+type History struct {
+	mu        sync.Mutex
+	limit     int
+	retention time.Duration
+	entries   []Resolved
+}
+
+// NewHistory creates a history holding at most limit entries; entries older than a positive retention are dropped.
+func NewHistory(limit int, retention time.Duration) *History {
+	if limit <= 0 {
+		limit = 1000
+	}
+	return &History{limit: limit, retention: retention}
+}
+
+// Record appends a resolved approval, evicting the oldest entry when full.
+func (h *History) Record(approval *Approval, result Result) {
+	if h == nil || approval == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+	if len(h.entries) >= h.limit {
+		h.entries = h.entries[1:]
+	}
+	h.entries = append(h.entries, Resolved{
+		CorrelationID: approval.Request.CorrelationID,
+		Tool:          approval.Request.Tool,
+		SessionID:     approval.Request.SessionID,
+		Tenant:        approval.Request.Tenant,
+		Decision:      result.Decision,
+		Reason:        result.Reason,
+		Message:       approval.Message(),
+		ResolvedAt:    time.Now(),
+		Discussion:    approval.Discussion,
+		ForcedBy:      result.ForcedBy,
+	})
+}
+
+// Lookup returns the most recent entry of the approval.
+func (h *History) Lookup(correlationID string) (Resolved, bool) {
+	if h == nil {
+		return Resolved{}, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].CorrelationID == correlationID {
+			return h.entries[i], true
+		}
+	}
+	return Resolved{}, false
+}
+
+// Stale returns messages of approvals resolved before cutoff that are still in the chat.
+func (h *History) Stale(cutoff time.Time) []MessageRef {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+	var refs []MessageRef
+	for _, entry := range h.entries {
+		if entry.MessageDeleted || !entry.Message.Valid() || !entry.ResolvedAt.Before(cutoff) {
+			continue
+		}
+		refs = append(refs, entry.Message)
+	}
+	return refs
+}
+
+// MarkDeleted flags entries whose message was removed from the chat.
+func (h *History) MarkDeleted(refs ...MessageRef) {
+	if h == nil || len(refs) == 0 {
+		return
+	}
+	deleted := make(map[MessageRef]struct{}, len(refs))
+	for _, ref := range refs {
+		deleted[ref] = struct{}{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.entries {
+		if _, ok := deleted[h.entries[i].Message]; ok {
+			h.entries[i].MessageDeleted = true
+		}
+	}
+}
+
+// Purge removes entries matching the filter and returns them.
+func (h *History) Purge(filter PurgeFilter) []Resolved {
+	if h == nil || filter.Empty() {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var removed []Resolved
+	kept := h.entries[:0]
+	for _, entry := range h.entries {
+		if filter.matches(entry) {
+			removed = append(removed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	clear(h.entries[len(kept):])
+	h.entries = kept
+	return removed
+}
+
+// prune drops entries past the retention period; callers must hold the lock.
+func (h *History) prune() {
+	if h.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-h.retention)
+	idx := 0
+	for idx < len(h.entries) && h.entries[idx].ResolvedAt.Before(cutoff) {
+		idx++
+	}
+	if idx > 0 {
+		h.entries = slices.Delete(h.entries, 0, idx)
+	}
+}
@@ -0,0 +1,81 @@
+package approvals
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// linkTokenSize is the number of random bytes in a deep link token.
+const linkTokenSize = 16
+
+// IssueLink returns the one-time deep link token of a pending approval, creating one when there is no unused token.
+// Duplicates attached to the approval get the same token, so issuing their links does not invalidate earlier ones.
+func (r *Registry) IssueLink(correlationID string) (string, error) {
+	raw := make([]byte, linkTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return "", ErrNotFound
+	}
+	if approval.LinkToken != "" {
+		return approval.LinkToken, nil
+	}
+	approval.LinkToken = token
+	r.persist(approval)
+	return token, nil
+}
+
+// LinkApproval returns a copy of the pending approval a deep link token belongs to, or nil.
+func (r *Registry) LinkApproval(token string) *Approval {
+	if token == "" {
+		return nil
+	}
+	if r.shared != nil {
+		if _, err := r.Sync(); err != nil {
+			r.log.Error("Failed to sync shared approvals", "error", err)
+		}
+	}
+	var found *Approval
+	r.each(func(approval *Approval) bool {
+		if approval.LinkToken == token {
+			found = approval.clone()
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// ClaimLink invalidates the deep link token of the approval and reports whether it was still valid.
+func (r *Registry) ClaimLink(correlationID, token string) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok || token == "" || approval.LinkToken != token {
+		return false
+	}
+	approval.LinkToken = ""
+	r.persist(approval)
+	return true
+}
+
+// SetDirect stores the private chat copy of the approval message and reports whether the approval is still pending.
+func (r *Registry) SetDirect(correlationID string, message MessageRef) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	approval.Direct = message
+	r.persist(approval)
+	return true
+}
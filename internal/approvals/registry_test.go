@@ -0,0 +1,247 @@
+package approvals
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// throughput enables TestRegistryThroughput, whose time limit depends on the machine running it.
+var throughput = flag.Bool("throughput", false, "check that the registry handles 10k approvals within a second")
+
+func newTestRegistry() *Registry {
+	return NewRegistry(nil, slog.New(slog.DiscardHandler))
+}
+
+func TestRegistryGetReturnsCopy(t *testing.T) {
+	r := newTestRegistry()
+	if _, err := r.Add(Request{CorrelationID: "a"}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	got := r.Get("a")
+	got.MessageText = "changed"
+	got.Votes = append(got.Votes, Vote{UserID: 1})
+	if again := r.Get("a"); again.MessageText != "" || len(again.Votes) != 0 {
+		t.Fatalf("changing the result of Get changed the registry: %+v", again)
+	}
+}
+
+func TestRegistryAddDuplicate(t *testing.T) {
+	r := newTestRegistry()
+	if _, err := r.Add(Request{CorrelationID: "a"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Add(Request{CorrelationID: "a"}, time.Now()); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("second Add = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestRegistryAttach(t *testing.T) {
+	r := newTestRegistry()
+	primary := Request{CorrelationID: "a", Fingerprint: "f", Tenant: "t", RequestedBy: "alice"}
+	if _, err := r.Add(primary, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	other := primary
+	other.CorrelationID, other.RequestedBy = "b", "bob"
+	if attached, err := r.Attach(other); err != nil || attached != nil {
+		t.Fatalf("Attach of another requester = %v, %v; want no approval", attached, err)
+	}
+	same := primary
+	same.CorrelationID = "c"
+	if attached, err := r.Attach(same); err != nil || attached == nil || attached.Request.CorrelationID != "a" {
+		t.Fatalf("Attach of the same request = %v, %v; want approval a", attached, err)
+	}
+	if _, err := r.Attach(same); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("second Attach = %v, want ErrAlreadyExists", err)
+	}
+	if got := r.AttachedTo("c"); got == nil || got.Request.CorrelationID != "a" {
+		t.Fatalf("AttachedTo(c) = %v, want approval a", got)
+	}
+	if _, _, ok := r.Resolve("a"); !ok {
+		t.Fatal("Resolve(a) failed")
+	}
+	if got := r.AttachedTo("c"); got != nil {
+		t.Fatalf("AttachedTo(c) after resolve = %v, want nil", got)
+	}
+	if attached, err := r.Attach(Request{CorrelationID: "d", Fingerprint: "f", Tenant: "t", RequestedBy: "alice"}); err != nil || attached != nil {
+		t.Fatalf("Attach after resolve = %v, %v; want no approval", attached, err)
+	}
+}
+
+// TestRegistryConcurrent mixes every kind of registry call on shared approvals; run it with -race.
+func TestRegistryConcurrent(t *testing.T) {
+	const (
+		workers   = 32
+		approvals = 200
+	)
+	r := newTestRegistry()
+	for i := range approvals {
+		if _, err := r.Add(Request{CorrelationID: fmt.Sprintf("id-%d", i)}, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var resolved atomic.Int64
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range approvals {
+				id := fmt.Sprintf("id-%d", (i+w)%approvals)
+				switch (i + w) % 8 {
+				case 0:
+					r.SetMessage(id, MessageRef{ChatID: 1, MessageID: i + 1}, 0, "text")
+				case 1:
+					r.AddVote(id, Vote{UserID: int64(w)})
+				case 2:
+					r.AddNote(id, Note{UserID: int64(w), Text: "note"})
+				case 3:
+					if approval := r.Get(id); approval != nil {
+						_ = len(approval.Votes) + len(approval.Discussion) + len(approval.MessageText)
+					}
+				case 4:
+					for _, approval := range r.List() {
+						_ = len(approval.Votes)
+					}
+				case 5:
+					r.StartReason(id)
+					r.SetPromptMessage(id, MessageRef{ChatID: 1, MessageID: 1000 + w})
+				case 6:
+					if approval := r.FindByMessage(MessageRef{ChatID: 1, MessageID: i + 1}); approval != nil {
+						_ = approval.MessageText
+					}
+				case 7:
+					if i%25 == 0 {
+						if _, _, ok := r.Resolve(id); ok {
+							resolved.Add(1)
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if pending := int64(len(r.List())); pending+resolved.Load() != approvals {
+		t.Fatalf("pending %d + resolved %d != %d", pending, resolved.Load(), approvals)
+	}
+}
+
+// TestRegistryThroughput checks the target of the sharded registry: 10k approvals added, read and resolved by
+// 64 concurrent callers well within a second. It runs only with -throughput and without the race detector.
+func TestRegistryThroughput(t *testing.T) {
+	if !*throughput {
+		t.Skip("timing test; enable with -throughput")
+	}
+	const (
+		workers = 64
+		total   = 10000
+	)
+	r := newTestRegistry()
+	deadline := time.Now().Add(time.Hour)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < total; i += workers {
+				id := fmt.Sprintf("id-%d", i)
+				if _, err := r.Add(Request{CorrelationID: id}, deadline); err != nil {
+					t.Error(err)
+					return
+				}
+				r.Get(id)
+				r.AddVote(id, Vote{UserID: int64(w)})
+				if _, _, ok := r.Resolve(id); !ok {
+					t.Errorf("resolve %s failed", id)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("%d approvals took %s, want under 1s", total, elapsed)
+	}
+}
+
+func BenchmarkRegistryAdd(b *testing.B) {
+	r := newTestRegistry()
+	deadline := time.Now().Add(time.Hour)
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := r.Add(Request{CorrelationID: fmt.Sprintf("id-%d", n.Add(1))}, deadline); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRegistryGet(b *testing.B) {
+	const pending = 10000
+	r := newTestRegistry()
+	for i := range pending {
+		_, _ = r.Add(Request{CorrelationID: fmt.Sprintf("id-%d", i)}, time.Now().Add(time.Hour))
+	}
+	var n atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if r.Get(fmt.Sprintf("id-%d", n.Add(1)%pending)) == nil {
+				b.Fatal("approval not found")
+			}
+		}
+	})
+}
+
+func BenchmarkRegistryAddResolve(b *testing.B) {
+	r := newTestRegistry()
+	deadline := time.Now().Add(time.Hour)
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := fmt.Sprintf("id-%d", n.Add(1))
+			if _, err := r.Add(Request{CorrelationID: id}, deadline); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, ok := r.Resolve(id); !ok {
+				b.Fatal("resolve failed")
+			}
+		}
+	})
+}
+
+func TestRegistryIssueLinkKeepsUnusedToken(t *testing.T) {
+	r := newTestRegistry()
+	if _, err := r.Add(Request{CorrelationID: "a"}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	first, err := r.IssueLink("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.IssueLink("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Fatalf("second IssueLink = %q, want the unused token %q", second, first)
+	}
+	if !r.ClaimLink("a", first) {
+		t.Fatal("ClaimLink rejected the issued token")
+	}
+	third, err := r.IssueLink("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Fatal("IssueLink returned a claimed token")
+	}
+}
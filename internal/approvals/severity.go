@@ -0,0 +1,57 @@
+package approvals
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies the risk of a request.
+type Severity string
+
+const (
+	// SeverityLow marks routine requests.
+	SeverityLow Severity = "low"
+	// SeverityMedium marks requests that deserve a closer look.
+	SeverityMedium Severity = "medium"
+	// SeverityHigh marks requests that can cause real damage.
+	SeverityHigh Severity = "high"
+	// SeverityCritical marks requests that can cause an outage or data loss.
+	SeverityCritical Severity = "critical"
+)
+
+// Severities lists the severity classes from the lowest to the highest.
+var Severities = []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity parses a severity class case-insensitively; an empty value is the unset severity.
+func ParseSeverity(value string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(value)))
+	if severity == "" || severity.Rank() > 0 {
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q, expected low, medium, high or critical", value)
+}
+
+// Rank orders severities: 1 for low up to 4 for critical, 0 when unset or unknown.
+func (s Severity) Rank() int {
+	for i, severity := range Severities {
+		if s == severity {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Badge returns the colored emoji of the severity, or "" when it is unset.
+func (s Severity) Badge() string {
+	switch s {
+	case SeverityLow:
+		return "🟢"
+	case SeverityMedium:
+		return "🟡"
+	case SeverityHigh:
+		return "🟠"
+	case SeverityCritical:
+		return "🔴"
+	}
+	return ""
+}
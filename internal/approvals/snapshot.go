@@ -0,0 +1,101 @@
+package approvals
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the format version written into snapshots.
+const SnapshotVersion = 1
+
+// ErrInvalidSnapshot is returned when a snapshot cannot be imported as a whole.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Snapshot is a portable copy of pending approvals used to move them between instances or stores.
+type Snapshot struct {
+	// Version is the snapshot format version.
+	Version int `json:"version"`
+	// CreatedAt is when the snapshot was taken.
+	CreatedAt time.Time `json:"created_at"`
+	// Approvals are the pending approvals.
+	Approvals []SnapshotEntry `json:"approvals"`
+}
+
+// SnapshotEntry is a pending approval together with its deny prompt state, which stores do not keep.
+type SnapshotEntry struct {
+	// Approval is the pending approval.
+	Approval Approval `json:"approval"`
+	// AwaitingReason marks that a deny reason is pending.
+	AwaitingReason bool `json:"awaiting_reason,omitempty"`
+	// Prompt is the message asking for the deny reason.
+	Prompt MessageRef `json:"prompt,omitzero"`
+}
+
+// ImportResult reports the outcome of a snapshot import.
+type ImportResult struct {
+	// Imported are the approvals added to the registry.
+	Imported []Approval
+	// Skipped lists correlation IDs that were already pending.
+	Skipped []string
+}
+
+// Export returns a snapshot of pending approvals ordered by creation time.
+func (r *Registry) Export() Snapshot {
+	pending := r.List()
+	snapshot := Snapshot{Version: SnapshotVersion, CreatedAt: time.Now().UTC(), Approvals: make([]SnapshotEntry, 0, len(pending))}
+	for _, approval := range pending {
+		snapshot.Approvals = append(snapshot.Approvals, SnapshotEntry{
+			Approval:       approval,
+			AwaitingReason: approval.AwaitingReason,
+			Prompt:         approval.Prompt,
+		})
+	}
+	return snapshot
+}
+
+// Import adds the approvals of a snapshot to the registry and its store. Approvals that are already pending are
+// skipped, so an import can be repeated safely.
+func (r *Registry) Import(snapshot Snapshot) (ImportResult, error) {
+	if snapshot.Version != SnapshotVersion {
+		return ImportResult{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, snapshot.Version)
+	}
+	for _, entry := range snapshot.Approvals {
+		if entry.Approval.Request.CorrelationID == "" {
+			return ImportResult{}, fmt.Errorf("%w: approval without correlation id", ErrInvalidSnapshot)
+		}
+	}
+	var result ImportResult
+	for _, entry := range snapshot.Approvals {
+		approval := entry.Approval
+		approval.AwaitingReason = entry.AwaitingReason
+		approval.Prompt = entry.Prompt
+		id := approval.Request.CorrelationID
+		sh := r.shard(id)
+		sh.mu.Lock()
+		if _, exists := r.lookup(sh, id); exists {
+			sh.mu.Unlock()
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		if r.shared != nil {
+			created, err := r.shared.Create(approval)
+			if err != nil {
+				sh.mu.Unlock()
+				return result, fmt.Errorf("import approval %s: %w", id, err)
+			}
+			if !created {
+				sh.mu.Unlock()
+				result.Skipped = append(result.Skipped, id)
+				continue
+			}
+		} else {
+			r.persist(&approval)
+		}
+		sh.approvals[id] = &approval
+		r.indexRequest(&approval)
+		sh.mu.Unlock()
+		result.Imported = append(result.Imported, approval)
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package approvals
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSnoozeLimit means the approval has been snoozed as many times as allowed.
+var ErrSnoozeLimit = errors.New("snooze limit reached")
+
+// Snooze moves the deadline of a pending approval with postpone and records when approvers are reminded of it.
+// limit bounds the number of snoozes; 0 means no limit. It returns a copy of the updated approval.
+func (r *Registry) Snooze(correlationID string, postpone func(deadline time.Time) time.Time, remindAt time.Time, limit int) (Approval, error) {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return Approval{}, ErrNotFound
+	}
+	if limit > 0 && approval.Snoozes >= limit {
+		return Approval{}, ErrSnoozeLimit
+	}
+	approval.Deadline = postpone(approval.Deadline)
+	approval.Snoozes++
+	approval.RemindAt = remindAt
+	r.persist(approval)
+	return *approval, nil
+}
+
+// Reminded clears the reminder of a snoozed approval and reports whether the approval is still pending.
+func (r *Registry) Reminded(correlationID string) bool {
+	sh := r.shard(correlationID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	approval, ok := r.lookup(sh, correlationID)
+	if !ok {
+		return false
+	}
+	approval.RemindAt = time.Time{}
+	r.persist(approval)
+	return true
+}
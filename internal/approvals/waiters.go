@@ -0,0 +1,52 @@
+package approvals
+
+import "sync"
+
+// Waiters hands decisions to callers blocked on pending approvals.
+type Waiters struct {
+	mu      sync.Mutex
+	waiting map[string]map[chan Result]struct{}
+}
+
+// NewWaiters creates an empty waiter set.
+func NewWaiters() *Waiters {
+	return &Waiters{waiting: make(map[string]map[chan Result]struct{})}
+}
+
+// Wait returns a channel that receives the decision of the approval and a function that stops waiting.
+func (w *Waiters) Wait(correlationID string) (<-chan Result, func()) {
+	ch := make(chan Result, 1)
+	if w == nil {
+		return ch, func() {}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiting[correlationID] == nil {
+		w.waiting[correlationID] = make(map[chan Result]struct{})
+	}
+	w.waiting[correlationID][ch] = struct{}{}
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.waiting[correlationID], ch)
+		if len(w.waiting[correlationID]) == 0 {
+			delete(w.waiting, correlationID)
+		}
+	}
+}
+
+// Notify delivers the decision to everyone waiting for the approval.
+func (w *Waiters) Notify(correlationID string, result Result) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.waiting[correlationID] {
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+	delete(w.waiting, correlationID)
+}
@@ -0,0 +1,457 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// Record events.
+const (
+	// EventRequested is recorded when an approval is posted.
+	EventRequested = "requested"
+	// EventVote is recorded for each approval vote of a quorum request.
+	EventVote = "vote"
+	// EventAck is recorded when an approver acknowledges a critical request without deciding.
+	EventAck = "ack"
+	// EventResolved is recorded when an approval gets its final decision, times out, or is cancelled.
+	EventResolved = "resolved"
+	// EventCallback is recorded for each decision callback delivery attempt.
+	EventCallback = "callback"
+)
+
+// sinkQueue bounds records waiting for the external sink.
+const sinkQueue = 1024
+
+// Record is a single audit entry. Hash covers the record without Hash, and PrevHash links it to the previous record.
+type Record struct {
+	Seq           uint64           `json:"seq"`
+	At            time.Time        `json:"at"`
+	Event         string           `json:"event"`
+	CorrelationID string           `json:"correlation_id"`
+	Tool          string           `json:"tool,omitempty"`
+	Tenant        string           `json:"tenant,omitempty"`
+	RequestedBy   string           `json:"requested_by,omitempty"`
+	Fingerprint   string           `json:"fingerprint,omitempty"`
+	Channel       string           `json:"channel,omitempty"`
+	Decision      string           `json:"decision,omitempty"`
+	Reason        string           `json:"reason,omitempty"`
+	ReasonCode    string           `json:"reason_code,omitempty"`
+	Actor         *approvals.Actor `json:"actor,omitempty"`
+	ForcedBy      string           `json:"forced_by,omitempty"`
+	Votes         []approvals.Vote `json:"votes,omitempty"`
+	CallbackURL   string           `json:"callback_url,omitempty"`
+	StatusCode    int              `json:"status_code,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	PrevHash      string           `json:"prev_hash"`
+	Hash          string           `json:"hash,omitempty"`
+}
+
+// Options configures the audit log.
+type Options struct {
+	// Path is the JSON lines file records are appended to; empty disables the file.
+	Path string
+	// URL receives every record as a JSON POST; empty disables the sink.
+	URL string
+	// Token is sent as a bearer token to URL.
+	Token string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Log appends audit records to a file and an external sink. A nil Log records nothing.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	seq  uint64
+	prev string
+
+	client *http.Client
+	url    string
+	token  string
+	queue  chan []byte
+	done   chan struct{}
+	log    *slog.Logger
+}
+
+// New opens the audit log and continues the hash chain of an existing file.
+// It returns nil when neither a path nor a URL is configured.
+func New(opts Options) (*Log, error) {
+	if opts.Path == "" && opts.URL == "" {
+		return nil, nil
+	}
+	l := &Log{log: opts.Log}
+	if opts.Path != "" {
+		last, err := lastRecord(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.prev = last.Seq, last.Hash
+		}
+		file, err := os.OpenFile(opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+		l.path, l.file = opts.Path, file
+	}
+	if opts.URL != "" {
+		l.client = &http.Client{Timeout: 10 * time.Second}
+		l.url, l.token = opts.URL, opts.Token
+		l.queue = make(chan []byte, sinkQueue)
+		l.done = make(chan struct{})
+		go l.deliver()
+	}
+	return l, nil
+}
+
+// Requested records a posted approval.
+func (l *Log) Requested(approval *approvals.Approval) {
+	if l == nil || approval == nil {
+		return
+	}
+	l.append(recordFor(EventRequested, approval))
+}
+
+// Vote records a quorum vote.
+func (l *Log) Vote(approval *approvals.Approval, vote approvals.Vote) {
+	if l == nil || approval == nil {
+		return
+	}
+	record := recordFor(EventVote, approval)
+	record.Decision = string(approvals.DecisionApprove)
+	record.Votes = []approvals.Vote{vote}
+	l.append(record)
+}
+
+// Ack records an acknowledgement of a critical approval.
+func (l *Log) Ack(approval *approvals.Approval, actor *approvals.Actor) {
+	if l == nil || approval == nil {
+		return
+	}
+	record := recordFor(EventAck, approval)
+	record.Actor = actor
+	l.append(record)
+}
+
+// Resolved records the final result of an approval, including who decided and why.
+func (l *Log) Resolved(approval *approvals.Approval, result approvals.Result) {
+	if l == nil || approval == nil {
+		return
+	}
+	record := recordFor(EventResolved, approval)
+	record.Decision = string(result.Decision)
+	record.Reason = result.Reason
+	record.ReasonCode = result.ReasonCode
+	record.Actor = result.Actor
+	record.ForcedBy = result.ForcedBy
+	record.Votes = approval.Votes
+	l.append(record)
+}
+
+// Callback records a callback delivery; statusCode is 0 when no response was received.
+func (l *Log) Callback(approval *approvals.Approval, result approvals.Result, statusCode int, err error) {
+	if l == nil || approval == nil {
+		return
+	}
+	record := recordFor(EventCallback, approval)
+	record.Decision = string(result.Decision)
+	record.CallbackURL = redactURL(approval.Request.Callback.URL)
+	record.StatusCode = statusCode
+	if err != nil {
+		record.Error = err.Error()
+	}
+	l.append(record)
+}
+
+// Close flushes records queued for the sink and closes the file.
+func (l *Log) Close(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.queue != nil {
+		close(l.queue)
+		l.queue = nil
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+		}
+	}
+	var err error
+	if l.file != nil {
+		err = l.file.Close()
+		l.file = nil
+	}
+	l.mu.Unlock()
+	return err
+}
+
+func (l *Log) append(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	record.Seq = l.seq
+	record.At = time.Now().UTC()
+	record.PrevHash = l.prev
+	hash, err := hashRecord(record)
+	if err != nil {
+		l.log.Error("Failed to hash audit record", "error", err)
+		return
+	}
+	record.Hash = hash
+	l.prev = hash
+	line, err := json.Marshal(record)
+	if err != nil {
+		l.log.Error("Failed to encode audit record", "error", err)
+		return
+	}
+	if l.file != nil {
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			l.log.Error("Failed to write audit record", "error", err, "seq", record.Seq)
+		} else if err := l.file.Sync(); err != nil {
+			l.log.Error("Failed to sync audit log", "error", err)
+		}
+	}
+	if l.queue != nil {
+		select {
+		case l.queue <- line:
+		default:
+			l.log.Error("Audit sink queue is full, record dropped", "seq", record.Seq)
+		}
+	}
+}
+
+// deliver posts queued records to the sink in order.
+func (l *Log) deliver() {
+	defer close(l.done)
+	for line := range l.queue {
+		req, err := http.NewRequest(http.MethodPost, l.url, bytes.NewReader(line))
+		if err != nil {
+			l.log.Error("Failed to build audit sink request", "error", err)
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if l.token != "" {
+			req.Header.Set("Authorization", "Bearer "+l.token)
+		}
+		resp, err := l.client.Do(req)
+		if err != nil {
+			l.log.Error("Audit sink delivery failed", "error", err)
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			l.log.Error("Audit sink rejected record", "status", resp.StatusCode)
+		}
+	}
+}
+
+// Verify checks the hash chain of the audit file and returns the number of valid records.
+// It fails at the first record that was changed, removed, or reordered.
+func (l *Log) Verify() (int, error) {
+	if l == nil || l.path == "" {
+		return 0, errors.New("audit file is not configured")
+	}
+	// Holding the lock keeps a half-written record out of the check.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.path)
+	if err != nil {
+		return 0, fmt.Errorf("open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	return verify(file)
+}
+
+func verify(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	count := 0
+	var prev *Record
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return count, fmt.Errorf("line %d: %w", count+1, err)
+		}
+		if prev != nil && (record.Seq != prev.Seq+1 || record.PrevHash != prev.Hash) {
+			return count, fmt.Errorf("record %d does not follow record %d", record.Seq, prev.Seq)
+		}
+		hash, err := hashRecord(record)
+		if err != nil {
+			return count, err
+		}
+		if hash != record.Hash {
+			return count, fmt.Errorf("record %d hash mismatch", record.Seq)
+		}
+		count++
+		prev = &record
+	}
+	return count, scanner.Err()
+}
+
+// Filter selects audit records; zero fields match everything.
+type Filter struct {
+	// After skips records up to and including this sequence number.
+	After uint64
+	// From and To bound the record time; To is exclusive.
+	From, To time.Time
+	Event    string
+	Decision string
+	Tool     string
+	// Approver matches the deciding user ID or username, a voter, or the administrator who forced the decision.
+	Approver string
+	// Limit caps the number of returned records.
+	Limit int
+}
+
+// Query returns records of the audit file matching the filter in sequence order.
+// more reports whether further matching records follow the last one returned.
+func (l *Log) Query(filter Filter) (records []Record, more bool, err error) {
+	if l == nil || l.path == "" {
+		return nil, false, errors.New("audit file is not configured")
+	}
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, false, fmt.Errorf("open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record Record
+		// A record being appended right now may be incomplete; it shows up in the next query.
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if record.Seq <= filter.After || !filter.match(record) {
+			continue
+		}
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			return records, true, nil
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("read audit log: %w", err)
+	}
+	return records, false, nil
+}
+
+func (f Filter) match(record Record) bool {
+	switch {
+	case !f.From.IsZero() && record.At.Before(f.From):
+		return false
+	case !f.To.IsZero() && !record.At.Before(f.To):
+		return false
+	case f.Event != "" && record.Event != f.Event:
+		return false
+	case f.Decision != "" && record.Decision != f.Decision:
+		return false
+	case f.Tool != "" && record.Tool != f.Tool:
+		return false
+	}
+	return f.Approver == "" || record.involves(f.Approver)
+}
+
+// involves reports whether the user took part in the decision of the record.
+func (r Record) involves(user string) bool {
+	same := func(id, name string) bool {
+		return id == user || (name != "" && strings.EqualFold(strings.TrimPrefix(name, "@"), strings.TrimPrefix(user, "@")))
+	}
+	if r.Actor != nil && same(r.Actor.ID, r.Actor.Username) {
+		return true
+	}
+	if r.ForcedBy != "" && same("", r.ForcedBy) {
+		return true
+	}
+	for _, vote := range r.Votes {
+		if same(strconv.FormatInt(vote.UserID, 10), vote.Username) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashRecord returns the SHA-256 of the record encoded without its hash.
+func hashRecord(record Record) (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastRecord returns the last record of an existing audit log, or nil when there is none.
+func lastRecord(path string) (*Record, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var last []byte
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	if last == nil {
+		return nil, nil
+	}
+	var record Record
+	if err := json.Unmarshal(last, &record); err != nil || record.Hash == "" {
+		return nil, fmt.Errorf("audit log %s ends with an invalid record", path)
+	}
+	return &record, nil
+}
+
+func recordFor(event string, approval *approvals.Approval) Record {
+	req := approval.Request
+	return Record{
+		Event:         event,
+		CorrelationID: req.CorrelationID,
+		Tool:          req.Tool,
+		Tenant:        req.Tenant,
+		RequestedBy:   req.RequestedBy,
+		Fingerprint:   req.Fingerprint,
+		Channel:       req.Channel,
+	}
+}
+
+// redactURL drops the query and credentials that may carry secrets.
+func redactURL(raw string) string {
+	raw, _, _ = strings.Cut(raw, "?")
+	if scheme, rest, ok := strings.Cut(raw, "://"); ok {
+		if at := strings.Index(rest, "@"); at >= 0 && at < strings.IndexByte(rest+"/", '/') {
+			rest = rest[at+1:]
+		}
+		return scheme + "://" + rest
+	}
+	return raw
+}
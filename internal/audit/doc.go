@@ -0,0 +1,2 @@
+// Package audit keeps a hash-chained, append-only record of approval requests, decisions, and callback deliveries.
+package audit
@@ -0,0 +1,259 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/callback"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// publishQueue bounds decisions waiting to be published.
+	publishQueue = 1024
+	// publishTimeout bounds connecting to the server and publishing one decision.
+	publishTimeout = 10 * time.Second
+	// reconnectWait is the pause between attempts to reach the server.
+	reconnectWait = 5 * time.Second
+)
+
+// Event is the JSON message published for each decision; with TG_APPROVER_CALLBACK_FORMAT=cloudevents it is the
+// data of a CloudEvents envelope.
+type Event struct {
+	CorrelationID string           `json:"correlation_id"`
+	Decision      string           `json:"decision"`
+	Reason        string           `json:"reason,omitempty"`
+	ReasonCode    string           `json:"reason_code,omitempty"`
+	Tool          string           `json:"tool,omitempty"`
+	Tenant        string           `json:"tenant,omitempty"`
+	RequestedBy   string           `json:"requested_by,omitempty"`
+	Fingerprint   string           `json:"fingerprint,omitempty"`
+	Actor         *approvals.Actor `json:"actor,omitempty"`
+	ForcedBy      string           `json:"forced_by,omitempty"`
+	CreatedAt     time.Time        `json:"created_at,omitzero"`
+	At            time.Time        `json:"at"`
+}
+
+// message is a queued event.
+type message struct {
+	subject       string
+	correlationID string
+	data          []byte
+}
+
+// Publisher publishes decisions to a NATS subject in the background, in order. A publish the server does not
+// confirm is repeated until it is, so a queued decision is delivered at least once. Decisions that find the queue
+// full, for instance during a long outage, are dropped and counted. A nil Publisher does nothing.
+type Publisher struct {
+	conn    *nats.Conn
+	subject string
+	redact  []string
+	cfg     config.Config
+	metrics *metrics.Metrics
+	log     *slog.Logger
+
+	mu    sync.Mutex
+	queue chan message
+	done  chan struct{}
+	// stop ends retries once Close gives up waiting.
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a publisher from runtime configuration; it returns nil when TG_APPROVER_NATS_URL is empty.
+// User info of the URL authenticates the client unless TG_APPROVER_NATS_TOKEN is set. An unreachable server does
+// not fail startup: the client keeps reconnecting in the background.
+func New(cfg config.Config, metrics *metrics.Metrics, log *slog.Logger) (*Publisher, error) {
+	if strings.TrimSpace(cfg.NATSURL) == "" {
+		return nil, nil
+	}
+	opts := []nats.Option{
+		nats.Name("telegram-approver"),
+		nats.Timeout(publishTimeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(reconnectWait),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn("Disconnected from the bus", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Info("Reconnected to the bus", "server", conn.ConnectedUrlRedacted())
+		}),
+	}
+	if cfg.NATSToken != "" {
+		opts = append(opts, nats.Token(cfg.NATSToken))
+	}
+	conn, err := nats.Connect(cfg.NATSURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	p := &Publisher{
+		conn:    conn,
+		subject: cfg.NATSSubject,
+		redact:  cfg.CallbackRedact,
+		cfg:     cfg,
+		metrics: metrics,
+		log:     log,
+		queue:   make(chan message, publishQueue),
+		done:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	go p.run(p.queue)
+	return p, nil
+}
+
+// Resolved queues the decision of an approval for publishing.
+func (p *Publisher) Resolved(approval *approvals.Approval, result approvals.Result) {
+	if p == nil || approval == nil {
+		return
+	}
+	event := Event{
+		CorrelationID: approval.Request.CorrelationID,
+		Decision:      string(result.Decision),
+		Reason:        result.Reason,
+		ReasonCode:    result.ReasonCode,
+		Tool:          approval.Request.Tool,
+		Tenant:        approval.Request.Tenant,
+		RequestedBy:   approval.Request.RequestedBy,
+		Fingerprint:   approval.Request.Fingerprint,
+		Actor:         result.Actor,
+		ForcedBy:      result.ForcedBy,
+		CreatedAt:     approval.CreatedAt,
+		At:            time.Now().UTC(),
+	}
+	subject := p.subjectFor(event)
+	p.redactEvent(&event)
+	data, err := json.Marshal(event)
+	if err == nil && p.cfg.CallbackFormat == config.CallbackFormatCloudEvents {
+		data, err = callback.EncodeCloudEvent(p.cfg.CloudEventsSource, event.CorrelationID, data)
+	}
+	if err != nil {
+		p.log.Error("Failed to encode bus event", "error", err, "correlation_id", event.CorrelationID)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == nil {
+		return
+	}
+	select {
+	case p.queue <- message{subject: subject, correlationID: event.CorrelationID, data: data}:
+	default:
+		p.metrics.BusMessage(metrics.BusDropped)
+		p.log.Error("Bus queue is full, decision dropped", "correlation_id", event.CorrelationID)
+	}
+}
+
+// Close publishes the queued decisions until ctx is done and closes the connection. Decisions still queued when
+// ctx is done are dropped.
+func (p *Publisher) Close(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if p.queue != nil {
+		close(p.queue)
+		p.queue = nil
+	}
+	p.mu.Unlock()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.stopOnce.Do(func() { close(p.stop) })
+		return ctx.Err()
+	}
+}
+
+func (p *Publisher) run(queue <-chan message) {
+	defer close(p.done)
+	for msg := range queue {
+		p.publish(msg)
+	}
+	p.conn.Close()
+}
+
+// publish sends one message and waits until the server has processed it, repeating the publish while the server
+// cannot be reached. A repeat after an unconfirmed publish may deliver the message twice.
+func (p *Publisher) publish(msg message) {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-p.stop:
+			p.metrics.BusMessage(metrics.BusDropped)
+			p.log.Error("Service stopped before the decision was published to the bus", "subject", msg.subject, "correlation_id", msg.correlationID)
+			return
+		default:
+		}
+		err := p.conn.Publish(msg.subject, msg.data)
+		if err == nil {
+			err = p.conn.FlushTimeout(publishTimeout)
+		}
+		if err == nil {
+			p.metrics.BusMessage(metrics.BusPublished)
+			return
+		}
+		if errors.Is(err, nats.ErrMaxPayload) {
+			p.metrics.BusMessage(metrics.BusDropped)
+			p.log.Error("Decision is too large for the bus, dropped", "error", err, "subject", msg.subject, "correlation_id", msg.correlationID)
+			return
+		}
+		if attempt == 0 {
+			p.log.Warn("Failed to publish decision to the bus, retrying", "error", err, "subject", msg.subject, "correlation_id", msg.correlationID)
+		}
+		p.metrics.BusMessage(metrics.BusRetried)
+		select {
+		case <-p.stop:
+		case <-time.After(reconnectWait):
+		}
+	}
+}
+
+// subjectFor fills the {tenant} and {decision} placeholders of the configured subject.
+func (p *Publisher) subjectFor(event Event) string {
+	return strings.NewReplacer("{tenant}", subjectToken(event.Tenant), "{decision}", subjectToken(event.Decision)).Replace(p.subject)
+}
+
+// subjectToken turns value into a single subject token: separators and wildcards become underscores.
+func subjectToken(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '*' || r == '>' || r <= ' ' {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// redactEvent clears the fields the tenant, or TG_APPROVER_CALLBACK_REDACT, keeps out of callbacks.
+func (p *Publisher) redactEvent(event *Event) {
+	fields := p.redact
+	if settings, ok := p.cfg.CurrentFile().Tenants[event.Tenant]; ok && settings.CallbackRedact != nil {
+		fields = settings.CallbackRedact
+	}
+	if slices.Contains(fields, "tool") {
+		event.Tool = ""
+	}
+	if slices.Contains(fields, "tenant") {
+		event.Tenant = ""
+	}
+	if slices.Contains(fields, "requested_by") {
+		event.RequestedBy = ""
+	}
+	if slices.Contains(fields, "fingerprint") {
+		event.Fingerprint = ""
+	}
+}
@@ -0,0 +1,2 @@
+// Package bus publishes approval decisions to a NATS subject so several consumers can receive them.
+package bus
@@ -0,0 +1,185 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxDays bounds the search for open hours; a valid calendar has some every week.
+const maxDays = 3660
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Options describes working hours.
+type Options struct {
+	// Timezone is the IANA time zone of the hours, e.g. "Europe/Berlin".
+	Timezone string
+	// Days are working weekdays such as "mon-fri" or "mon,wed,fri".
+	Days string
+	// Hours are daily working hours such as "09:00-18:00"; empty disables the calendar.
+	Hours string
+	// Holidays are closed dates as YYYY-MM-DD.
+	Holidays []string
+}
+
+// Calendar is a weekly working-hours schedule with holidays. A nil Calendar is always open.
+type Calendar struct {
+	loc      *time.Location
+	days     [7]bool
+	start    time.Duration
+	end      time.Duration
+	holidays map[string]struct{}
+}
+
+// New parses working hours; it returns nil when no hours are set.
+func New(opts Options) (*Calendar, error) {
+	if strings.TrimSpace(opts.Hours) == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(opts.Timezone))
+	if err != nil {
+		return nil, fmt.Errorf("invalid business timezone %q", opts.Timezone)
+	}
+	c := &Calendar{loc: loc, holidays: make(map[string]struct{}, len(opts.Holidays))}
+	if c.start, c.end, err = parseHours(opts.Hours); err != nil {
+		return nil, err
+	}
+	if c.days, err = parseDays(opts.Days); err != nil {
+		return nil, err
+	}
+	for _, day := range opts.Holidays {
+		day = strings.TrimSpace(day)
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return nil, fmt.Errorf("invalid business holiday %q", day)
+		}
+		c.holidays[day] = struct{}{}
+	}
+	return c, nil
+}
+
+// Location returns the time zone of the calendar, or UTC for a nil calendar.
+func (c *Calendar) Location() *time.Location {
+	if c == nil {
+		return time.UTC
+	}
+	return c.loc
+}
+
+// Add returns the moment d of working time after from.
+func (c *Calendar) Add(from time.Time, d time.Duration) time.Time {
+	if c == nil {
+		return from.Add(d)
+	}
+	t := from.In(c.loc)
+	for range maxDays {
+		year, month, day := t.Date()
+		start := time.Date(year, month, day, 0, 0, 0, 0, c.loc).Add(c.start)
+		end := time.Date(year, month, day, 0, 0, 0, 0, c.loc).Add(c.end)
+		if c.workday(t) && t.Before(end) {
+			if t.Before(start) {
+				t = start
+			}
+			left := end.Sub(t)
+			if d <= left {
+				return t.Add(d)
+			}
+			d -= left
+		}
+		t = time.Date(year, month, day+1, 0, 0, 0, 0, c.loc)
+	}
+	return from.Add(d)
+}
+
+// Open reports whether t falls into working hours.
+func (c *Calendar) Open(t time.Time) bool {
+	return c.Add(t, 0).Equal(t)
+}
+
+// NextOpen returns t when it falls into working hours, or the start of the next working hours otherwise.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	return c.Add(t, 0)
+}
+
+func (c *Calendar) workday(t time.Time) bool {
+	if !c.days[t.Weekday()] {
+		return false
+	}
+	_, holiday := c.holidays[t.Format(time.DateOnly)]
+	return !holiday
+}
+
+// parseHours parses "HH:MM-HH:MM" into offsets from midnight.
+func parseHours(hours string) (time.Duration, time.Duration, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(hours), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("business hours must look like 09:00-18:00")
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("business hours must end after they start")
+	}
+	return start, end, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid business hours time %q", value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// parseDays parses comma-separated weekdays and ranges such as "mon-fri,sun".
+func parseDays(days string) ([7]bool, error) {
+	var set [7]bool
+	empty := true
+	for _, item := range strings.Split(strings.ToLower(days), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(item, "-")
+		if !isRange {
+			to = from
+		}
+		first, okFirst := weekdays[strings.TrimSpace(from)]
+		last, okLast := weekdays[strings.TrimSpace(to)]
+		if !okFirst || !okLast {
+			return set, fmt.Errorf("invalid business day %q", item)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			set[day] = true
+			if day == last {
+				break
+			}
+		}
+		empty = false
+	}
+	if empty {
+		return set, fmt.Errorf("business days must not be empty")
+	}
+	return set, nil
+}
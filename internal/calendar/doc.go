@@ -0,0 +1,2 @@
+// Package calendar counts durations in business hours, so approval timeouts only tick while approvers are at work.
+package calendar
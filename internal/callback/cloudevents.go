@@ -0,0 +1,55 @@
+package callback
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+const (
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsSpecVersion = "1.0"
+	// EventTypeDecision is the CloudEvents type for approval decisions.
+	EventTypeDecision = "io.github.codex-k8s.telegram-approver.decision"
+)
+
+// CloudEvent is a CloudEvents 1.0 envelope in structured JSON mode.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func (s *Sender) cloudEvent(approval *approvals.Approval, data []byte) ([]byte, error) {
+	return EncodeCloudEvent(s.source, approval.Request.CorrelationID, data)
+}
+
+// EncodeCloudEvent wraps the JSON data of a decision into a CloudEvents 1.0 envelope in structured mode. The subject
+// is the correlation ID of the approval.
+func EncodeCloudEvent(source, correlationID string, data []byte) ([]byte, error) {
+	event := CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              newEventID(),
+		Source:          source,
+		Type:            EventTypeDecision,
+		Subject:         correlationID,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	return json.Marshal(event)
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
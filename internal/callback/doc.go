@@ -0,0 +1,2 @@
+// Package callback delivers approval decisions to requester webhooks.
+package callback
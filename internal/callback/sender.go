@@ -0,0 +1,465 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/audit"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Payload is the data available to callback templates.
+type Payload struct {
+	// CorrelationID is the approval correlation ID.
+	CorrelationID string
+	// Decision is the approval decision.
+	Decision string
+	// Reason contains human-readable details.
+	Reason string
+	// ReasonCode classifies the reason, e.g. denied or timeout.
+	ReasonCode string
+	// Failure details why the approval message could not be posted; nil for other decisions.
+	Failure *approvals.Failure
+	// ExecuteAfter is when the approved action runs; zero means right away.
+	ExecuteAfter time.Time
+	// Tool is the tool name.
+	Tool string
+	// Tenant is the tenant the request belongs to.
+	Tenant string
+	// RequestedBy identifies the human or agent that made the request.
+	RequestedBy string
+	// Arguments are tool arguments.
+	Arguments map[string]any
+	// Fingerprint is the stable hash of Tool and Arguments.
+	Fingerprint string
+	// Discussion holds notes captured before resolution when requested by the caller.
+	Discussion []approvals.Note
+	// Actor is the user who made the decision; nil for timeouts and automatic decisions.
+	Actor *approvals.Actor
+	// Approval holds further details of the resolved approval that are safe to echo back.
+	Approval ApprovalView
+}
+
+// ApprovalView is the part of a resolved approval exposed to callback templates. It leaves out the rendered message
+// text, the diff, the raw arguments and the callback settings, so templates cannot echo back more than redaction
+// allows.
+type ApprovalView struct {
+	// Target is the route the request was sent to.
+	Target string
+	// Channel is the channel the approval was posted to; empty means Telegram.
+	Channel string
+	// SessionID identifies the agent run the request belongs to.
+	SessionID string
+	// WorkflowID groups requests of one workflow.
+	WorkflowID string
+	// Severity is the risk class of the request.
+	Severity string
+	// ApprovalRequest describes the requested action; cleared when arguments are redacted.
+	ApprovalRequest string
+	// Justification is the reason given for the request; cleared when arguments are redacted.
+	Justification string
+	// RiskAssessment describes potential risks; cleared when arguments are redacted.
+	RiskAssessment string
+	// CreatedAt is the request creation time.
+	CreatedAt time.Time
+	// Deadline is the time the approval would have timed out.
+	Deadline time.Time
+	// Snoozes counts how many times approvers postponed the timeout.
+	Snoozes int
+	// Votes are the quorum approvals collected before the decision.
+	Votes []approvals.Vote
+	// Acks are users who acknowledged a critical request.
+	Acks []approvals.Vote
+}
+
+// viewOf builds the template view of an approval.
+func viewOf(approval *approvals.Approval) ApprovalView {
+	req := approval.Request
+	return ApprovalView{
+		Target:          req.Target,
+		Channel:         req.Channel,
+		SessionID:       req.SessionID,
+		WorkflowID:      req.WorkflowID,
+		Severity:        string(req.Severity),
+		ApprovalRequest: req.ApprovalRequest,
+		Justification:   req.Justification,
+		RiskAssessment:  req.RiskAssessment,
+		CreatedAt:       approval.CreatedAt,
+		Deadline:        approval.Deadline,
+		Snoozes:         approval.Snoozes,
+		Votes:           approval.Votes,
+		Acks:            approval.Acks,
+	}
+}
+
+// Sender delivers decision callbacks to requester webhooks.
+type Sender struct {
+	client   *http.Client
+	settings atomic.Pointer[settings]
+	format   string
+	source   string
+	secret   string
+	redact   []string
+	audit    *audit.Log
+	metrics  *metrics.Metrics
+	log      *slog.Logger
+
+	queue   chan delivery
+	workers sync.WaitGroup
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// delivery is a queued callback.
+type delivery struct {
+	ctx      context.Context
+	approval *approvals.Approval
+	result   approvals.Result
+}
+
+// settings are the parsed callback templates and headers of the config file.
+type settings struct {
+	templates map[string]*template.Template
+	template  *template.Template
+	headers   map[string]map[string]*template.Template
+	tenants   map[string]config.Tenant
+}
+
+// NewSender creates a callback sender from runtime configuration.
+// Delivery results are recorded in trail when it is not nil.
+func NewSender(cfg config.Config, trail *audit.Log, metrics *metrics.Metrics, log *slog.Logger) (*Sender, error) {
+	parsed, err := parseSettings(cfg.CurrentFile())
+	if err != nil {
+		return nil, err
+	}
+	sender := &Sender{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		format:  cfg.CallbackFormat,
+		source:  cfg.CloudEventsSource,
+		secret:  cfg.CallbackSecret,
+		redact:  cfg.CallbackRedact,
+		audit:   trail,
+		metrics: metrics,
+		log:     log,
+		queue:   make(chan delivery, cfg.CallbackQueue),
+	}
+	sender.settings.Store(parsed)
+	for range max(cfg.CallbackWorkers, 1) {
+		sender.workers.Add(1)
+		go sender.work()
+	}
+	return sender, nil
+}
+
+// Reload replaces the tenant and global callback templates and headers with those of file. On error the current
+// ones are kept. Callbacks queued before the reload are rendered with the new settings.
+func (s *Sender) Reload(file config.File) error {
+	parsed, err := parseSettings(file)
+	if err != nil {
+		return err
+	}
+	s.settings.Store(parsed)
+	return nil
+}
+
+// parseSettings parses the callback templates and headers of a config file.
+func parseSettings(file config.File) (*settings, error) {
+	templates := make(map[string]*template.Template)
+	// Global headers are kept under the empty tenant name, which config files do not allow.
+	headers := make(map[string]map[string]*template.Template)
+	for name, tenant := range file.Tenants {
+		parsed, err := ParseHeaders(tenant.CallbackHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", name, err)
+		}
+		if parsed != nil {
+			headers[name] = parsed
+		}
+		if strings.TrimSpace(tenant.CallbackTemplate) == "" {
+			continue
+		}
+		tmpl, err := ParseTemplate(name, tenant.CallbackTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse callback template for tenant %q: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+	var global *template.Template
+	if strings.TrimSpace(file.Callback.Template) != "" {
+		tmpl, err := ParseTemplate("callback", file.Callback.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parse callback template: %w", err)
+		}
+		global = tmpl
+	}
+	parsed, err := ParseHeaders(file.Callback.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if parsed != nil {
+		headers[""] = parsed
+	}
+	return &settings{templates: templates, template: global, headers: headers, tenants: file.Tenants}, nil
+}
+
+// Send queues the decision for delivery to the approval callback URL and returns without waiting for it.
+// When the queue is full the callback is delivered by the caller, so no decision is dropped.
+func (s *Sender) Send(ctx context.Context, approval *approvals.Approval, result approvals.Result) {
+	if approval == nil {
+		return
+	}
+	if strings.TrimSpace(approval.Request.Callback.URL) == "" {
+		return
+	}
+	// The delivery outlives the update that made the decision; keep its trace but not its cancellation.
+	job := delivery{ctx: context.WithoutCancel(ctx), approval: approval, result: result}
+	s.closeMu.RLock()
+	if !s.closed {
+		select {
+		case s.queue <- job:
+			s.closeMu.RUnlock()
+			return
+		default:
+			s.log.Warn("Callback queue is full, delivering inline", "correlation_id", approval.Request.CorrelationID)
+		}
+	}
+	s.closeMu.RUnlock()
+	s.record(s.deliver(job.ctx, approval, result))
+}
+
+// Close stops accepting queued callbacks and waits until the queued ones are delivered or ctx is done.
+func (s *Sender) Close(ctx context.Context) error {
+	s.closeMu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.closeMu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("callbacks still queued: %d: %w", len(s.queue), ctx.Err())
+	}
+}
+
+func (s *Sender) work() {
+	defer s.workers.Done()
+	for job := range s.queue {
+		s.record(s.deliver(job.ctx, job.approval, job.result))
+	}
+}
+
+// record counts a failed delivery.
+func (s *Sender) record(err error) {
+	s.metrics.Failed(err)
+}
+
+// deliver posts the decision to the approval callback URL. Failures wrap approvals.ErrCallbackFailed.
+func (s *Sender) deliver(ctx context.Context, approval *approvals.Approval, result approvals.Result) error {
+	body, header, err := s.body(approval, result)
+	if err != nil {
+		s.log.Error("Failed to build webhook payload", "error", err, "correlation_id", approval.Request.CorrelationID)
+		return fmt.Errorf("%w: build payload: %w", approvals.ErrCallbackFailed, err)
+	}
+	contentType := "application/json"
+	format := approval.Request.Callback.Format
+	if format == "" {
+		format = s.format
+	}
+	if format == config.CallbackFormatCloudEvents {
+		body, err = s.cloudEvent(approval, body)
+		if err != nil {
+			s.log.Error("Failed to build cloudevent", "error", err, "correlation_id", approval.Request.CorrelationID)
+			return fmt.Errorf("%w: build cloudevent: %w", approvals.ErrCallbackFailed, err)
+		}
+		contentType = cloudEventsContentType
+	}
+	ctx, span := tracing.Start(ctx, "callback.deliver", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, approval.Request.Callback.URL, bytes.NewReader(body))
+	if err != nil {
+		s.audit.Callback(approval, result, 0, err)
+		return fmt.Errorf("%w: %w", approvals.ErrCallbackFailed, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	tracing.Inject(ctx, req.Header)
+	secret := approval.Request.Callback.Secret
+	if secret == "" {
+		secret = s.secret
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.log.Error("Webhook delivery failed", "error", err, "correlation_id", approval.Request.CorrelationID)
+		s.audit.Callback(approval, result, 0, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "delivery failed")
+		return fmt.Errorf("%w: %w", approvals.ErrCallbackFailed, err)
+	}
+	_ = resp.Body.Close()
+	s.audit.Callback(approval, result, resp.StatusCode, nil)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		s.log.Warn("Webhook receiver rejected the callback", "status", resp.StatusCode, "correlation_id", approval.Request.CorrelationID)
+		return fmt.Errorf("%w: status %d", approvals.ErrCallbackFailed, resp.StatusCode)
+	}
+	return nil
+}
+
+// body renders the callback body and the configured headers. The request template wins over the tenant template,
+// which wins over the global one; headers are merged in the reverse order so the request has the last word.
+func (s *Sender) body(approval *approvals.Approval, result approvals.Result) ([]byte, http.Header, error) {
+	payload := Payload{
+		CorrelationID: approval.Request.CorrelationID,
+		Decision:      string(result.Decision),
+		Reason:        result.Reason,
+		ReasonCode:    result.ReasonCode,
+		Failure:       result.Failure,
+		ExecuteAfter:  approval.Request.ExecuteAfter,
+		Tool:          approval.Request.Tool,
+		Tenant:        approval.Request.Tenant,
+		RequestedBy:   approval.Request.RequestedBy,
+		Arguments:     approval.Request.Arguments,
+		Fingerprint:   approval.Request.Fingerprint,
+		Actor:         result.Actor,
+		Approval:      viewOf(approval),
+	}
+	if approval.Request.Callback.IncludeDiscussion {
+		payload.Discussion = approval.Discussion
+	}
+	current := s.settings.Load()
+	redacted := s.redactedFields(current, approval.Request.Tenant)
+	redactPayload(&payload, redacted)
+	callback := approval.Request.Callback
+	requestHeaders, err := ParseHeaders(callback.Headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := renderHeaders(payload, current.headers[""], current.headers[approval.Request.Tenant], requestHeaders)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, source := current.templates[approval.Request.Tenant], fmt.Sprintf("tenant %q", approval.Request.Tenant)
+	switch {
+	case strings.TrimSpace(callback.Template) != "":
+		if tmpl, err = ParseTemplate("request", callback.Template); err != nil {
+			return nil, nil, fmt.Errorf("parse request callback template: %w", err)
+		}
+		source = "the request"
+	case tmpl == nil && current.template != nil:
+		tmpl, source = current.template, "the config file"
+	}
+	if tmpl == nil {
+		body := map[string]any{
+			"correlation_id": payload.CorrelationID,
+			"decision":       payload.Decision,
+			"reason":         payload.Reason,
+		}
+		if payload.ReasonCode != "" {
+			body["reason_code"] = payload.ReasonCode
+		}
+		if payload.Failure != nil {
+			body["error"] = payload.Failure
+		}
+		if !payload.ExecuteAfter.IsZero() {
+			body["execute_after"] = payload.ExecuteAfter.Format(time.RFC3339)
+		}
+		if !slices.Contains(redacted, "tool") {
+			body["tool"] = payload.Tool
+		}
+		if !slices.Contains(redacted, "fingerprint") {
+			body["fingerprint"] = payload.Fingerprint
+		}
+		if payload.RequestedBy != "" {
+			body["requested_by"] = payload.RequestedBy
+		}
+		if approval.Request.Callback.IncludeDiscussion && !slices.Contains(redacted, "discussion") {
+			discussion := payload.Discussion
+			if discussion == nil {
+				discussion = []approvals.Note{}
+			}
+			body["discussion"] = discussion
+		}
+		data, err := json.Marshal(body)
+		return data, header, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, nil, fmt.Errorf("render callback template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, nil, fmt.Errorf("callback template from %s produced invalid json", source)
+	}
+	return buf.Bytes(), header, nil
+}
+
+// redactedFields returns the fields removed from callbacks for the tenant.
+func (s *Sender) redactedFields(current *settings, tenant string) []string {
+	if custom, ok := current.tenants[tenant]; ok && custom.CallbackRedact != nil {
+		return custom.CallbackRedact
+	}
+	return s.redact
+}
+
+// redactPayload clears redacted fields so neither the default body nor templates can echo them.
+func redactPayload(payload *Payload, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case "tool":
+			payload.Tool = ""
+		case "tenant":
+			payload.Tenant = ""
+		case "requested_by":
+			payload.RequestedBy = ""
+		case "arguments":
+			// The request texts usually quote the arguments.
+			payload.Arguments = nil
+			payload.Approval.ApprovalRequest = ""
+			payload.Approval.Justification = ""
+			payload.Approval.RiskAssessment = ""
+		case "fingerprint":
+			payload.Fingerprint = ""
+		case "discussion":
+			payload.Discussion = nil
+		}
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
@@ -0,0 +1,17 @@
+package callback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of the callback body.
+const SignatureHeader = "X-Approver-Signature"
+
+// Sign returns the "sha256=<hex>" HMAC-SHA256 signature of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,71 @@
+package callback
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// reservedHeaders are set by the sender and cannot be overridden by configured headers.
+var reservedHeaders = []string{SignatureHeader, "Content-Length", "Host", "Traceparent", "Tracestate"}
+
+// ParseTemplate parses a callback body or header template with the callback helpers.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// ParseHeaders validates header names and parses their values as templates. Names are canonicalized.
+func ParseHeaders(headers map[string]string) (map[string]*template.Template, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]*template.Template, len(headers))
+	for name, value := range headers {
+		key := http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if !validHeaderName(key) {
+			return nil, fmt.Errorf("invalid callback header name %q", name)
+		}
+		for _, reserved := range reservedHeaders {
+			if strings.EqualFold(key, reserved) {
+				return nil, fmt.Errorf("callback header %q cannot be overridden", key)
+			}
+		}
+		tmpl, err := ParseTemplate(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("parse callback header %q: %w", key, err)
+		}
+		parsed[key] = tmpl
+	}
+	return parsed, nil
+}
+
+// validHeaderName reports whether name is a non-empty RFC 9110 token.
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		if r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return false
+		}
+		return !strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+	})
+}
+
+// renderHeaders renders each header layer over payload; later layers override earlier ones.
+func renderHeaders(payload Payload, layers ...map[string]*template.Template) (http.Header, error) {
+	header := make(http.Header)
+	for _, layer := range layers {
+		for name, tmpl := range layer {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, payload); err != nil {
+				return nil, fmt.Errorf("render callback header %q: %w", name, err)
+			}
+			value := strings.TrimSpace(buf.String())
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("callback header %q must be a single line", name)
+			}
+			header.Set(name, value)
+		}
+	}
+	return header, nil
+}
@@ -0,0 +1,102 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+// Telegram is the name of the built-in channel.
+const Telegram = "telegram"
+
+// Channel posts approval requests to a chat system and reflects decisions there.
+type Channel interface {
+	// Name returns the channel name used in requests and configuration.
+	Name() string
+	// Post publishes the approval with Approve and Deny actions and returns the posted message and its text.
+	Post(ctx context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error)
+	// Resolve replaces the actions of the posted message with the final decision.
+	Resolve(ctx context.Context, approval *approvals.Approval, result approvals.Result) error
+	// Handler returns the HTTP handler that receives interactions from the chat system and passes decisions to decider.
+	// Channels that implement Poller return nil.
+	Handler(decider Decider) http.Handler
+}
+
+// Poller is implemented by channels that fetch interactions from the chat system instead of receiving them over HTTP.
+type Poller interface {
+	// Poll passes decisions to decider until ctx is done.
+	Poll(ctx context.Context, decider Decider)
+}
+
+// Decider applies decisions made in a channel.
+type Decider interface {
+	// Decide resolves a pending approval; it returns approvals.ErrNotFound or approvals.ErrAlreadyResolved
+	// when it is not pending.
+	Decide(ctx context.Context, correlationID string, result approvals.Result) error
+}
+
+// DenyResult returns a denial with the reason written by an approver, or a default-reason denial when it is empty.
+func DenyResult(reason string) approvals.Result {
+	if reason = strings.TrimSpace(reason); reason == "" {
+		return approvals.Result{Decision: approvals.DecisionDeny, ReasonCode: approvals.ReasonDenied}
+	}
+	return approvals.Result{Decision: approvals.DecisionDeny, Reason: reason, ReasonCode: approvals.ReasonDeniedWithMessage}
+}
+
+// ExecuteAfterNote returns the localized "will run after" line, or an empty string when the action runs right away.
+// The time is shown in the offset the requester used.
+func ExecuteAfterNote(msg i18n.Messages, req approvals.Request) string {
+	if req.ExecuteAfter.IsZero() || msg.ExecuteAfterNote == "" {
+		return ""
+	}
+	return fmt.Sprintf(msg.ExecuteAfterNote, req.ExecuteAfter.Format("2006-01-02 15:04 MST"))
+}
+
+// QueuedNote returns the localized "queued until" line of a request made outside working hours, or an empty string.
+func QueuedNote(msg i18n.Messages, req approvals.Request) string {
+	if req.QueuedUntil.IsZero() || msg.QueuedNote == "" {
+		return ""
+	}
+	return fmt.Sprintf(msg.QueuedNote, req.QueuedUntil.Format("2006-01-02 15:04 MST"))
+}
+
+// DecisionNote returns the localized line describing the final decision.
+// A non-empty timeoutMessage replaces the default timeout note.
+func DecisionNote(msg i18n.Messages, result approvals.Result, timeoutMessage string) string {
+	if result.ReasonCode == approvals.ReasonTimeout && result.Decision != approvals.DecisionError {
+		// Decided by the on_timeout policy of the request.
+		note := "⏱️ ✅ " + msg.TimeoutApprovedNote
+		if result.Decision == approvals.DecisionDeny {
+			note = "⏱️ ❌ " + msg.TimeoutDeniedNote
+		}
+		if timeoutMessage = strings.TrimSpace(timeoutMessage); timeoutMessage != "" {
+			note += " " + timeoutMessage
+		}
+		return note
+	}
+	switch result.Decision {
+	case approvals.DecisionApprove:
+		return "✅ " + msg.ApprovedNote
+	case approvals.DecisionDeny:
+		if reason := strings.TrimSpace(result.Reason); reason != "" && result.ReasonCode != approvals.ReasonDenied {
+			return fmt.Sprintf("❌ %s: %s", msg.DeniedNote, reason)
+		}
+		return "❌ " + msg.DeniedNote
+	case approvals.DecisionCancelled:
+		return "🚫 " + msg.CancelledNote
+	case approvals.DecisionError:
+		if result.ReasonCode == approvals.ReasonTimeout {
+			if timeoutMessage = strings.TrimSpace(timeoutMessage); timeoutMessage != "" {
+				return "⏱️ " + timeoutMessage
+			}
+			return "⏱️ " + msg.TimeoutNote
+		}
+		return "⚠️ " + msg.ErrorNote
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,2 @@
+// Package channel defines approval channels other than the built-in Telegram bot.
+package channel
@@ -0,0 +1,224 @@
+package chats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/config"
+)
+
+var (
+	// ErrKnownChat is returned when a chat the bot already serves is added again.
+	ErrKnownChat = errors.New("chat is already configured")
+	// ErrLabelTaken is returned when the routing label is already used by a chat or route.
+	ErrLabelTaken = errors.New("label is already in use")
+	// ErrInvalidLabel is returned for labels that are not usable as routes.
+	ErrInvalidLabel = errors.New("label must be 1-64 lowercase letters, digits, dashes, or underscores")
+)
+
+var labelPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// Chat is a chat added through onboarding.
+type Chat struct {
+	// ID is the Telegram chat ID.
+	ID int64 `json:"id"`
+	// Label is the chat name and the route that selects it.
+	Label string `json:"label"`
+	// Title is the chat title at the time it was added.
+	Title string `json:"title,omitempty"`
+	// Lang is the default language of approvals routed to the chat.
+	Lang string `json:"lang,omitempty"`
+	// AddedBy is the Telegram user who completed the setup.
+	AddedBy int64 `json:"added_by"`
+	// AddedAt is when the chat was added.
+	AddedAt time.Time `json:"added_at"`
+}
+
+type fileSnapshot struct {
+	Chats []Chat `json:"chats"`
+}
+
+// Directory resolves chat names, routes, and IDs across configured and onboarded chats.
+type Directory struct {
+	cfg  config.Config
+	path string
+
+	mu    sync.RWMutex
+	added map[string]Chat
+}
+
+// New creates a directory of the configured chats and loads onboarded chats from TG_APPROVER_CHATS_FILE.
+func New(cfg config.Config) (*Directory, error) {
+	d := &Directory{
+		cfg:   cfg,
+		path:  cfg.ChatsFile,
+		added: make(map[string]Chat),
+	}
+	if d.path == "" {
+		return d, nil
+	}
+	data, err := os.ReadFile(d.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return d, nil
+	case err != nil:
+		return nil, fmt.Errorf("read chats file: %w", err)
+	}
+	if len(data) == 0 {
+		return d, nil
+	}
+	var snapshot fileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parse chats file: %w", err)
+	}
+	for _, chat := range snapshot.Chats {
+		if d.static(chat.ID) || d.taken(chat.Label) {
+			// The chat was moved into the configuration; the configured entry wins.
+			continue
+		}
+		d.added[chat.Label] = chat
+	}
+	return d, nil
+}
+
+// Known reports whether the bot serves the chat.
+func (d *Directory) Known(chatID int64) bool {
+	if d.static(chatID) {
+		return true
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.find(chatID)
+	return ok
+}
+
+// Lookup returns the chat ID of a configured chat name or onboarding label.
+func (d *Directory) Lookup(name string) (int64, bool) {
+	if id, ok := d.cfg.CurrentFile().Chats[name]; ok {
+		return id, true
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	chat, ok := d.added[name]
+	return chat.ID, ok
+}
+
+// Route returns the chat ID for a request target or team; an empty route selects the primary chat.
+// Onboarded chats are selected by their label.
+func (d *Directory) Route(route string) (int64, bool) {
+	if id, ok := d.cfg.RouteChat(route); ok {
+		return id, true
+	}
+	route = strings.TrimSpace(route)
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	chat, ok := d.added[route]
+	return chat.ID, ok
+}
+
+// Lang returns the language picked for an onboarded chat; empty for other chats.
+func (d *Directory) Lang(chatID int64) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	chat, _ := d.find(chatID)
+	return chat.Lang
+}
+
+// Add registers an onboarded chat and persists it. The label must be free among chat names and routes.
+func (d *Directory) Add(chat Chat) error {
+	chat.Label = strings.ToLower(strings.TrimSpace(chat.Label))
+	if !labelPattern.MatchString(chat.Label) {
+		return ErrInvalidLabel
+	}
+	if d.static(chat.ID) {
+		return ErrKnownChat
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.find(chat.ID); ok {
+		return ErrKnownChat
+	}
+	if d.taken(chat.Label) {
+		return ErrLabelTaken
+	}
+	if _, ok := d.added[chat.Label]; ok {
+		return ErrLabelTaken
+	}
+	if chat.AddedAt.IsZero() {
+		chat.AddedAt = time.Now().UTC()
+	}
+	d.added[chat.Label] = chat
+	if err := d.flush(); err != nil {
+		delete(d.added, chat.Label)
+		return fmt.Errorf("write chats file: %w", err)
+	}
+	return nil
+}
+
+// static reports whether the chat comes from the configuration.
+func (d *Directory) static(chatID int64) bool {
+	return slices.Contains(d.cfg.ChatIDs(), chatID)
+}
+
+// taken reports whether a configured chat name or route uses the label.
+func (d *Directory) taken(label string) bool {
+	file := d.cfg.CurrentFile()
+	_, named := file.Chats[label]
+	_, routed := file.Routes[label]
+	return named || routed
+}
+
+// find returns the onboarded chat with the ID; the caller holds the lock.
+func (d *Directory) find(chatID int64) (Chat, bool) {
+	for _, chat := range d.added {
+		if chat.ID == chatID {
+			return chat, true
+		}
+	}
+	return Chat{}, false
+}
+
+// flush rewrites the chats file atomically.
+func (d *Directory) flush() error {
+	if d.path == "" {
+		return nil
+	}
+	snapshot := fileSnapshot{Chats: make([]Chat, 0, len(d.added))}
+	for _, chat := range d.added {
+		snapshot.Chats = append(snapshot.Chats, chat)
+	}
+	sort.Slice(snapshot.Chats, func(i, j int) bool { return snapshot.Chats[i].Label < snapshot.Chats[j].Label })
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), filepath.Base(d.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path)
+}
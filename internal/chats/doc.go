@@ -0,0 +1,3 @@
+// Package chats keeps the Telegram chats the bot serves: those from the configuration and those added through
+// onboarding, which are persisted to a JSON file.
+package chats
@@ -1,12 +1,79 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/codex-k8s/telegram-approver/internal/calendar"
+)
+
+const (
+	// CallbackFormatJSON sends callbacks as plain JSON.
+	CallbackFormatJSON = "json"
+	// CallbackFormatCloudEvents sends callbacks as CloudEvents 1.0 in structured mode.
+	CallbackFormatCloudEvents = "cloudevents"
+)
+
+// CallbackFields lists request fields that can be redacted from decision callbacks.
+var CallbackFields = []string{"tool", "tenant", "requested_by", "arguments", "fingerprint", "discussion"}
+
+const (
+	// StoreMemory keeps pending approvals in memory only.
+	StoreMemory = "memory"
+	// StoreFile persists pending approvals to a JSON file.
+	StoreFile = "file"
+	// StoreRedis shares pending approvals between replicas via Redis.
+	StoreRedis = "redis"
+)
+
+const (
+	// LeaseRedis keeps the standby lease under a Redis key of the redis store.
+	LeaseRedis = "redis"
+	// LeaseKubernetes keeps the standby lease in a coordination.k8s.io/v1 Lease object.
+	LeaseKubernetes = "kubernetes"
+)
+
+const (
+	// ChannelTelegram posts approvals to Telegram chats.
+	ChannelTelegram = "telegram"
+	// ChannelSlack posts approvals to a Slack channel.
+	ChannelSlack = "slack"
+	// ChannelMattermost posts approvals to a Mattermost channel.
+	ChannelMattermost = "mattermost"
+	// ChannelDiscord posts approvals to a Discord channel.
+	ChannelDiscord = "discord"
+	// ChannelEmail emails approvals with signed approve and deny links.
+	ChannelEmail = "email"
+	// ChannelMatrix posts approvals to a Matrix room.
+	ChannelMatrix = "matrix"
+)
+
+const (
+	// TopicsTool posts approvals into one forum topic per tool.
+	TopicsTool = "tool"
+	// TopicsWorkflow posts approvals into one forum topic per workflow ID.
+	TopicsWorkflow = "workflow"
+)
+
+const (
+	// LongMessagesReject rejects requests whose message exceeds the Telegram limit.
+	LongMessagesReject = "reject"
+	// LongMessagesSplit posts a long message as a thread of parts with the keyboard on the last one.
+	LongMessagesSplit = "split"
+	// LongMessagesAttach shortens the approval request text and attaches it in full as a document.
+	LongMessagesAttach = "attach"
 )
 
 // Config describes runtime configuration for telegram-approver.
@@ -21,26 +88,317 @@ type Config struct {
 	LogLevel string `env:"TG_APPROVER_LOG_LEVEL" envDefault:"info"`
 	// Lang selects i18n language (en or ru).
 	Lang string `env:"TG_APPROVER_LANG" envDefault:"en"`
-	// Token is the Telegram bot token.
-	Token string `env:"TG_APPROVER_TOKEN,required"`
+	// I18nDir holds <lang>.yaml files overriding the bundled messages or adding languages; they are reloaded on SIGHUP.
+	I18nDir string `env:"TG_APPROVER_I18N_DIR"`
+	// Token is the Telegram bot token; it may also come from TG_APPROVER_TOKEN_FILE or Vault.
+	Token string `env:"TG_APPROVER_TOKEN"`
+	// APIURL points the bot at a self-hosted Telegram Bot API server.
+	APIURL string `env:"TG_APPROVER_API_URL"`
+	// TelegramProxyURL routes Telegram API traffic through an HTTP(S) or SOCKS5 proxy.
+	TelegramProxyURL string `env:"TG_APPROVER_TELEGRAM_PROXY_URL"`
+	// TelegramMaxIdleConns limits idle connections kept for the Telegram client.
+	TelegramMaxIdleConns int `env:"TG_APPROVER_TELEGRAM_MAX_IDLE_CONNS" envDefault:"100"`
+	// TelegramMaxIdleConnsPerHost limits idle connections kept per Telegram API host.
+	TelegramMaxIdleConnsPerHost int `env:"TG_APPROVER_TELEGRAM_MAX_IDLE_CONNS_PER_HOST" envDefault:"32"`
+	// TelegramIdleConnTimeout closes idle Telegram connections after this duration.
+	TelegramIdleConnTimeout time.Duration `env:"TG_APPROVER_TELEGRAM_IDLE_CONN_TIMEOUT" envDefault:"90s"`
+	// TelegramTLSHandshakeTimeout limits TLS handshakes with the Telegram API.
+	TelegramTLSHandshakeTimeout time.Duration `env:"TG_APPROVER_TELEGRAM_TLS_HANDSHAKE_TIMEOUT" envDefault:"10s"`
+	// TelegramSendRate is how many message calls per second are sent to Telegram in total (0 disables).
+	TelegramSendRate int `env:"TG_APPROVER_TELEGRAM_SEND_RATE" envDefault:"25"`
+	// TelegramChatRate is how many message calls per minute are sent to one chat (0 disables).
+	TelegramChatRate int `env:"TG_APPROVER_TELEGRAM_CHAT_RATE" envDefault:"20"`
+	// TelegramRetries is how many times a call rejected by Telegram flood control is repeated.
+	TelegramRetries int `env:"TG_APPROVER_TELEGRAM_RETRIES" envDefault:"3"`
 	// ChatID is the allowed Telegram chat ID.
 	ChatID int64 `env:"TG_APPROVER_CHAT_ID,required"`
+	// AdminUserIDs are Telegram users allowed to run admin commands in the chat.
+	AdminUserIDs []int64 `env:"TG_APPROVER_ADMIN_USER_IDS" envSeparator:","`
+	// AllowedUserIDs restricts who may press approval buttons; empty allows every chat member.
+	AllowedUserIDs []int64 `env:"TG_APPROVER_ALLOWED_USER_IDS" envSeparator:","`
+	// SuperAdminUserIDs are Telegram users who can add new chats to the bot through /start onboarding.
+	SuperAdminUserIDs []int64 `env:"TG_APPROVER_SUPER_ADMIN_USER_IDS" envSeparator:","`
+	// ChatsFile is the JSON file that keeps chats added through onboarding.
+	ChatsFile string `env:"TG_APPROVER_CHATS_FILE"`
+	// HistorySize limits how many resolved approvals are kept in memory.
+	HistorySize int `env:"TG_APPROVER_HISTORY_SIZE" envDefault:"1000"`
+	// JournalEnabled records Telegram updates and Bot API calls for the admin journal endpoint.
+	JournalEnabled bool `env:"TG_APPROVER_JOURNAL_ENABLED" envDefault:"false"`
+	// JournalSize is how many journal entries are kept.
+	JournalSize int `env:"TG_APPROVER_JOURNAL_SIZE" envDefault:"500"`
+	// AuditFile is the append-only JSON lines audit log; empty disables the file.
+	AuditFile string `env:"TG_APPROVER_AUDIT_FILE"`
+	// AuditURL receives every audit record as a JSON POST.
+	AuditURL string `env:"TG_APPROVER_AUDIT_URL"`
+	// AuditToken is sent as a bearer token to AuditURL.
+	AuditToken string `env:"TG_APPROVER_AUDIT_TOKEN"`
+	// HistoryRetention drops resolved approvals older than this from history; 0 keeps them until evicted by size.
+	HistoryRetention time.Duration `env:"TG_APPROVER_HISTORY_RETENTION" envDefault:"0"`
 	// ApprovalTimeout is the maximum time to wait for user decision.
 	ApprovalTimeout time.Duration `env:"TG_APPROVER_APPROVAL_TIMEOUT" envDefault:"1h"`
 	// TimeoutMessage overrides the timeout message appended to Telegram messages.
 	TimeoutMessage string `env:"TG_APPROVER_TIMEOUT_MESSAGE"`
+	// DenyReason overrides the localized reason sent for denials without a message.
+	DenyReason string `env:"TG_APPROVER_DENY_REASON"`
 	// WebhookURL enables webhook mode when set with WebhookSecret.
 	WebhookURL string `env:"TG_APPROVER_WEBHOOK_URL"`
 	// WebhookSecret is the Telegram webhook secret token.
 	WebhookSecret string `env:"TG_APPROVER_WEBHOOK_SECRET"`
+	// WebhookQueue is how many webhook updates may wait for the handler.
+	WebhookQueue int `env:"TG_APPROVER_WEBHOOK_QUEUE" envDefault:"1024"`
+	// WebhookQueueWait is how long a webhook delivery waits for room in a full queue before Telegram retries it.
+	WebhookQueueWait time.Duration `env:"TG_APPROVER_WEBHOOK_QUEUE_WAIT" envDefault:"5s"`
+	// WebhookMaxConnections bounds concurrent webhook deliveries from Telegram; 0 keeps the Telegram default.
+	WebhookMaxConnections int `env:"TG_APPROVER_WEBHOOK_MAX_CONNECTIONS" envDefault:"0"`
 	// OpenAIAPIKey enables voice transcription.
 	OpenAIAPIKey string `env:"TG_APPROVER_OPENAI_API_KEY"`
+	// OpenAIProxyURL routes OpenAI API traffic through an HTTP(S) or SOCKS5 proxy.
+	OpenAIProxyURL string `env:"TG_APPROVER_OPENAI_PROXY_URL"`
 	// STTModel is the OpenAI model for transcription.
 	STTModel string `env:"TG_APPROVER_STT_MODEL" envDefault:"gpt-4o-mini-transcribe"`
 	// STTTimeout is the OpenAI transcription timeout.
 	STTTimeout time.Duration `env:"TG_APPROVER_STT_TIMEOUT" envDefault:"30s"`
+	// VoiceCommands lets approvers approve or deny pending approvals by voice messages.
+	VoiceCommands bool `env:"TG_APPROVER_VOICE_COMMANDS" envDefault:"false"`
+	// SummaryModel is the OpenAI chat model that summarizes requests at the top of Telegram messages; empty disables it.
+	SummaryModel string `env:"TG_APPROVER_SUMMARY_MODEL"`
+	// SummaryTimeout bounds generating one summary; the message is posted without it when the model is slower.
+	SummaryTimeout time.Duration `env:"TG_APPROVER_SUMMARY_TIMEOUT" envDefault:"10s"`
+	// VoiceIntentModel is the OpenAI chat model that detects decisions in voice commands; empty uses keywords.
+	VoiceIntentModel string `env:"TG_APPROVER_VOICE_INTENT_MODEL"`
 	// ShutdownTimeout is the graceful shutdown timeout.
 	ShutdownTimeout time.Duration `env:"TG_APPROVER_SHUTDOWN_TIMEOUT" envDefault:"10s"`
+	// CallbackFormat selects callback encoding (json or cloudevents).
+	CallbackFormat string `env:"TG_APPROVER_CALLBACK_FORMAT" envDefault:"json"`
+	// CloudEventsSource is the CloudEvents source attribute for callbacks.
+	CloudEventsSource string `env:"TG_APPROVER_CLOUDEVENTS_SOURCE" envDefault:"telegram-approver"`
+	// CallbackSecret signs callback bodies with HMAC-SHA256 when set.
+	CallbackSecret string `env:"TG_APPROVER_CALLBACK_SECRET"`
+	// CallbackRedact lists request fields never echoed back in callbacks; tenants may override it.
+	CallbackRedact []string `env:"TG_APPROVER_CALLBACK_REDACT" envSeparator:","`
+	// OperationTimeout bounds the message edits, deletes and notifications that finish an approval.
+	OperationTimeout time.Duration `env:"TG_APPROVER_OPERATION_TIMEOUT" envDefault:"30s"`
+	// HandlerWorkers is how many Telegram updates are handled concurrently; updates of one approval keep their order.
+	HandlerWorkers int `env:"TG_APPROVER_HANDLER_WORKERS" envDefault:"4"`
+	// CallbackWorkers is how many callbacks are delivered concurrently.
+	CallbackWorkers int `env:"TG_APPROVER_CALLBACK_WORKERS" envDefault:"8"`
+	// CallbackQueue is how many callbacks may wait for a worker before delivery falls back to the caller.
+	CallbackQueue int `env:"TG_APPROVER_CALLBACK_QUEUE" envDefault:"1024"`
+	// DecisionCacheTTL reuses approve/deny decisions for identical requests within the window (0 disables).
+	DecisionCacheTTL time.Duration `env:"TG_APPROVER_DECISION_CACHE_TTL" envDefault:"0"`
+	// Dedup attaches a request identical to a pending one to it instead of posting a second message.
+	Dedup bool `env:"TG_APPROVER_DEDUP" envDefault:"false"`
+	// RateLimit is how many approval requests per minute /approve accepts from all sources (0 disables).
+	RateLimit int `env:"TG_APPROVER_RATE_LIMIT" envDefault:"0"`
+	// RateBurst is how many approval requests may arrive at once before RateLimit applies.
+	RateBurst int `env:"TG_APPROVER_RATE_BURST" envDefault:"10"`
+	// SourceRateLimit is how many approval requests per minute /approve accepts from one source (0 disables).
+	SourceRateLimit int `env:"TG_APPROVER_SOURCE_RATE_LIMIT" envDefault:"0"`
+	// SourceRateBurst is how many approval requests one source may send at once before SourceRateLimit applies.
+	SourceRateBurst int `env:"TG_APPROVER_SOURCE_RATE_BURST" envDefault:"5"`
+	// RateQueue is how many rate-limited requests wait for their turn before new ones are rejected with 429.
+	RateQueue int `env:"TG_APPROVER_RATE_QUEUE" envDefault:"50"`
+
+	// HealthCacheTTL is how long /healthz and /readyz reuse the result of their dependency checks (0 checks on every probe).
+	HealthCacheTTL time.Duration `env:"TG_APPROVER_HEALTH_CACHE_TTL" envDefault:"10s"`
+
+	// IdempotencyTTL is how long /approve responses are replayed for a repeated Idempotency-Key (0 disables).
+	IdempotencyTTL time.Duration `env:"TG_APPROVER_IDEMPOTENCY_TTL" envDefault:"24h"`
+	// MetricsTools is an allowlist of tool names used as metric labels; other tools are reported as "other".
+	MetricsTools []string `env:"TG_APPROVER_METRICS_TOOLS" envSeparator:","`
+	// MetricsMaxTools bounds distinct tool labels when MetricsTools is empty.
+	MetricsMaxTools int `env:"TG_APPROVER_METRICS_MAX_TOOLS" envDefault:"50"`
+	// TracingEnabled exports OpenTelemetry spans via OTLP/HTTP (configured with OTEL_EXPORTER_OTLP_* variables).
+	TracingEnabled bool `env:"TG_APPROVER_TRACING_ENABLED" envDefault:"false"`
+	// MirrorURL receives JSON summaries of submitted requests and their decisions.
+	MirrorURL string `env:"TG_APPROVER_MIRROR_URL"`
+	// NATSURL enables publishing every decision to a NATS server (nats:// or tls://).
+	NATSURL string `env:"TG_APPROVER_NATS_URL"`
+	// NATSToken authenticates to the NATS server; user and password can be given in NATSURL instead.
+	NATSToken string `env:"TG_APPROVER_NATS_TOKEN"`
+	// NATSSubject is the subject decisions are published to; {tenant} and {decision} are replaced per decision.
+	NATSSubject string `env:"TG_APPROVER_NATS_SUBJECT" envDefault:"telegram-approver.decisions"`
+	// KubeController turns ApprovalRequest custom resources into approvals and writes decisions to their status.
+	KubeController bool `env:"TG_APPROVER_KUBE_CONTROLLER" envDefault:"false"`
+	// KubeNamespace limits the controller to one namespace; empty watches all namespaces.
+	KubeNamespace string `env:"TG_APPROVER_KUBE_NAMESPACE"`
+	// KubeResync is how often the controller reconciles every ApprovalRequest again.
+	KubeResync time.Duration `env:"TG_APPROVER_KUBE_RESYNC" envDefault:"1m"`
+	// GrafanaURL enables Grafana annotations for decisions of GrafanaTools.
+	GrafanaURL string `env:"TG_APPROVER_GRAFANA_URL"`
+	// GrafanaToken is the Grafana service account token.
+	GrafanaToken string `env:"TG_APPROVER_GRAFANA_TOKEN"`
+	// GrafanaTools are tool names or glob patterns whose decisions are annotated.
+	GrafanaTools []string `env:"TG_APPROVER_GRAFANA_TOOLS" envSeparator:","`
+	// GrafanaTags are added to every annotation.
+	GrafanaTags []string `env:"TG_APPROVER_GRAFANA_TAGS" envSeparator:"," envDefault:"telegram-approver"`
+	// APIToken requires client API requests to carry this bearer token (tenant tokens are accepted too).
+	APIToken string `env:"TG_APPROVER_API_TOKEN"`
+	// APIHMACSecret requires client API requests to be signed with HMAC-SHA256.
+	APIHMACSecret string `env:"TG_APPROVER_API_HMAC_SECRET"`
+	// APIHMACSkew is the maximum allowed age of a signed request timestamp.
+	APIHMACSkew time.Duration `env:"TG_APPROVER_API_HMAC_SKEW" envDefault:"5m"`
+	// APIAllowedCIDRs restricts client API requests to these networks or addresses.
+	APIAllowedCIDRs []string `env:"TG_APPROVER_API_ALLOWED_CIDRS" envSeparator:","`
+	// APIAllowedPrefixes holds parsed APIAllowedCIDRs.
+	APIAllowedPrefixes []netip.Prefix `env:"-"`
+	// AdminToken enables admin endpoints protected by this bearer token.
+	AdminToken string `env:"TG_APPROVER_ADMIN_TOKEN"`
+	// Store selects the pending approval store (memory, file, or redis).
+	Store string `env:"TG_APPROVER_STORE" envDefault:"memory"`
+	// StoreFile is the JSON file path used by the file store.
+	StoreFile string `env:"TG_APPROVER_STORE_FILE" envDefault:"/var/lib/telegram-approver/approvals.json"`
+	// RedisURL is the redis:// connection URL used by the redis store.
+	RedisURL string `env:"TG_APPROVER_REDIS_URL"`
+	// RedisPrefix prefixes all Redis keys.
+	RedisPrefix string `env:"TG_APPROVER_REDIS_PREFIX" envDefault:"telegram-approver:approval:"`
+	// DuplicateKeyPrefix prefixes Redis keys indexing pending approvals for duplicate detection.
+	DuplicateKeyPrefix string `env:"TG_APPROVER_DUPLICATE_KEY_PREFIX" envDefault:"telegram-approver:duplicate:"`
+	// StoreSyncInterval controls how often shared stores are polled for approvals created by other replicas.
+	StoreSyncInterval time.Duration `env:"TG_APPROVER_STORE_SYNC_INTERVAL" envDefault:"30s"`
+	// StoreEncryptionKey is a base64-encoded 32-byte key that encrypts approvals in the file and redis stores.
+	StoreEncryptionKey string `env:"TG_APPROVER_STORE_ENCRYPTION_KEY"`
+	// StoreEncryptionKeyBytes is the decoded StoreEncryptionKey.
+	StoreEncryptionKeyBytes []byte `env:"-"`
+	// Standby elects a single active instance through a lease; other instances wait as warm standbys.
+	Standby bool `env:"TG_APPROVER_STANDBY" envDefault:"false"`
+	// StandbyLease selects where the lease is kept: redis or kubernetes.
+	StandbyLease string `env:"TG_APPROVER_STANDBY_LEASE" envDefault:"redis"`
+	// StandbyLeaseTTL is how long the active instance holds the lease without renewing it.
+	StandbyLeaseTTL time.Duration `env:"TG_APPROVER_STANDBY_LEASE_TTL" envDefault:"15s"`
+	// StandbyLeaseKey is the Redis key that holds the lease; keep it outside RedisPrefix.
+	StandbyLeaseKey string `env:"TG_APPROVER_STANDBY_LEASE_KEY" envDefault:"telegram-approver:leader"`
+	// StandbyLeaseName is the name of the Kubernetes Lease object.
+	StandbyLeaseName string `env:"TG_APPROVER_STANDBY_LEASE_NAME" envDefault:"telegram-approver"`
+	// StandbyLeaseNamespace is the namespace of the Lease object; empty means the namespace of the pod.
+	StandbyLeaseNamespace string `env:"TG_APPROVER_STANDBY_LEASE_NAMESPACE"`
+	// InstanceKeyPrefix prefixes Redis keys announcing instances that receive webhook updates.
+	InstanceKeyPrefix string `env:"TG_APPROVER_INSTANCE_KEY_PREFIX" envDefault:"telegram-approver:instance:"`
+	// InstanceTTL is how long an instance stays announced without renewing its key.
+	InstanceTTL time.Duration `env:"TG_APPROVER_INSTANCE_TTL" envDefault:"15s"`
+	// SensitiveTools are tool names or glob patterns whose arguments are never shown in chat.
+	SensitiveTools []string `env:"TG_APPROVER_SENSITIVE_TOOLS" envSeparator:","`
+	// EscalationChatID is the chat unanswered approvals are escalated to; 0 disables escalation.
+	EscalationChatID int64 `env:"TG_APPROVER_ESCALATION_CHAT_ID"`
+	// EscalationAfter is the fraction of the approval timeout after which an approval is escalated.
+	EscalationAfter float64 `env:"TG_APPROVER_ESCALATION_AFTER" envDefault:"0.5"`
+	// EscalationMentions are Telegram usernames mentioned in escalation messages.
+	EscalationMentions []string `env:"TG_APPROVER_ESCALATION_MENTIONS" envSeparator:","`
+	// DigestInterval controls how often a digest of long-pending approvals is posted; 0 disables it.
+	DigestInterval time.Duration `env:"TG_APPROVER_DIGEST_INTERVAL" envDefault:"0"`
+	// DigestMinAge is how long an approval must be pending to appear in the digest.
+	DigestMinAge time.Duration `env:"TG_APPROVER_DIGEST_MIN_AGE" envDefault:"30m"`
+	// BusinessHours are daily working hours such as "09:00-18:00"; approval timeouts only tick inside them.
+	// Empty disables the working-hours calendar.
+	BusinessHours string `env:"TG_APPROVER_BUSINESS_HOURS"`
+	// BusinessDays are working weekdays such as "mon-fri".
+	BusinessDays string `env:"TG_APPROVER_BUSINESS_DAYS" envDefault:"mon-fri"`
+	// BusinessTimezone is the IANA time zone of the working hours.
+	BusinessTimezone string `env:"TG_APPROVER_BUSINESS_TIMEZONE" envDefault:"UTC"`
+	// BusinessHolidays are closed dates as YYYY-MM-DD.
+	BusinessHolidays []string `env:"TG_APPROVER_BUSINESS_HOLIDAYS" envSeparator:","`
+	// BusinessQueuedNote adds a "queued until" line to approvals requested outside working hours.
+	BusinessQueuedNote bool `env:"TG_APPROVER_BUSINESS_QUEUED_NOTE" envDefault:"true"`
+	// Topics groups approvals into forum topics created per tool or workflow; empty disables topics.
+	Topics string `env:"TG_APPROVER_TOPICS"`
+	// ThreadID is the forum topic of TG_APPROVER_CHAT_ID approvals are posted into; 0 means the general topic.
+	ThreadID int `env:"TG_APPROVER_THREAD_ID" envDefault:"0"`
+	// PinApprovals pins approval messages until they are resolved.
+	PinApprovals bool `env:"TG_APPROVER_PIN_APPROVALS" envDefault:"false"`
+	// SnoozeDuration is how long the snooze button postpones the timeout and the reminder; 0 hides the button.
+	SnoozeDuration time.Duration `env:"TG_APPROVER_SNOOZE_DURATION" envDefault:"0"`
+	// SnoozeLimit bounds how many times one approval can be snoozed; 0 means no limit.
+	SnoozeLimit int `env:"TG_APPROVER_SNOOZE_LIMIT" envDefault:"3"`
+	// DeepLinks returns one-time t.me links that open a pending approval in a private chat with the bot.
+	DeepLinks bool `env:"TG_APPROVER_DEEP_LINKS" envDefault:"false"`
+	// LongMessages selects how messages over the Telegram limit are handled (reject, split, or attach).
+	LongMessages string `env:"TG_APPROVER_LONG_MESSAGES" envDefault:"split"`
+	// Keyboard is the button layout of approval messages, e.g. "approve,deny;deny_reason,discuss,snooze,language".
+	Keyboard string `env:"TG_APPROVER_KEYBOARD" envDefault:"approve,deny;deny_reason,discuss,snooze,language"`
+	// Channel is the default approval channel for requests that do not name one.
+	Channel string `env:"TG_APPROVER_CHANNEL" envDefault:"telegram"`
+	// SlackBotToken enables the Slack channel with this bot token.
+	SlackBotToken string `env:"TG_APPROVER_SLACK_BOT_TOKEN"`
+	// SlackSigningSecret verifies Slack interaction requests.
+	SlackSigningSecret string `env:"TG_APPROVER_SLACK_SIGNING_SECRET"`
+	// SlackChannel is the Slack conversation ID approvals are posted to.
+	SlackChannel string `env:"TG_APPROVER_SLACK_CHANNEL"`
+	// SlackAllowedUsers restricts who may press Slack buttons; empty allows every channel member.
+	SlackAllowedUsers []string `env:"TG_APPROVER_SLACK_ALLOWED_USERS" envSeparator:","`
+	// SlackAPIURL overrides the Slack Web API base URL.
+	SlackAPIURL string `env:"TG_APPROVER_SLACK_API_URL" envDefault:"https://slack.com/api"`
+	// MattermostURL is the Mattermost server URL; it enables the Mattermost channel with MattermostToken.
+	MattermostURL string `env:"TG_APPROVER_MATTERMOST_URL"`
+	// MattermostToken is the Mattermost bot access token.
+	MattermostToken string `env:"TG_APPROVER_MATTERMOST_TOKEN"`
+	// MattermostChannelID is the Mattermost channel approvals are posted to.
+	MattermostChannelID string `env:"TG_APPROVER_MATTERMOST_CHANNEL_ID"`
+	// MattermostActionsURL is the public URL of /mattermost/interactions that Mattermost calls on button presses.
+	MattermostActionsURL string `env:"TG_APPROVER_MATTERMOST_ACTIONS_URL"`
+	// MattermostAllowedUsers restricts who may press Mattermost buttons; empty allows every channel member.
+	MattermostAllowedUsers []string `env:"TG_APPROVER_MATTERMOST_ALLOWED_USERS" envSeparator:","`
+	// DiscordBotToken enables the Discord channel with this bot token.
+	DiscordBotToken string `env:"TG_APPROVER_DISCORD_BOT_TOKEN"`
+	// DiscordPublicKey is the hex-encoded application public key that verifies interactions.
+	DiscordPublicKey string `env:"TG_APPROVER_DISCORD_PUBLIC_KEY"`
+	// DiscordPublicKeyBytes is the decoded DiscordPublicKey.
+	DiscordPublicKeyBytes []byte `env:"-"`
+	// DiscordChannelID is the Discord channel approvals are posted to.
+	DiscordChannelID string `env:"TG_APPROVER_DISCORD_CHANNEL_ID"`
+	// DiscordAllowedUsers restricts who may press Discord buttons; empty allows every channel member.
+	DiscordAllowedUsers []string `env:"TG_APPROVER_DISCORD_ALLOWED_USERS" envSeparator:","`
+	// DiscordAPIURL overrides the Discord REST API base URL.
+	DiscordAPIURL string `env:"TG_APPROVER_DISCORD_API_URL" envDefault:"https://discord.com/api/v10"`
+	// MatrixHomeserverURL is the Matrix homeserver URL; it enables the Matrix channel with MatrixAccessToken.
+	MatrixHomeserverURL string `env:"TG_APPROVER_MATRIX_HOMESERVER_URL"`
+	// MatrixAccessToken is the access token of the Matrix bot account.
+	MatrixAccessToken string `env:"TG_APPROVER_MATRIX_ACCESS_TOKEN"`
+	// MatrixRoomID is the Matrix room approvals are posted to.
+	MatrixRoomID string `env:"TG_APPROVER_MATRIX_ROOM_ID"`
+	// MatrixAllowedUsers restricts who may decide in Matrix; empty allows every room member.
+	MatrixAllowedUsers []string `env:"TG_APPROVER_MATRIX_ALLOWED_USERS" envSeparator:","`
+	// EmailSMTPAddr is the SMTP server host:port; it enables the email channel.
+	EmailSMTPAddr string `env:"TG_APPROVER_EMAIL_SMTP_ADDR"`
+	// EmailSMTPUsername authenticates with the SMTP server when set.
+	EmailSMTPUsername string `env:"TG_APPROVER_EMAIL_SMTP_USERNAME"`
+	// EmailSMTPPassword is the SMTP password.
+	EmailSMTPPassword string `env:"TG_APPROVER_EMAIL_SMTP_PASSWORD"`
+	// EmailFrom is the sender address of approval emails.
+	EmailFrom string `env:"TG_APPROVER_EMAIL_FROM"`
+	// EmailTo are the approver addresses approval emails are sent to.
+	EmailTo []string `env:"TG_APPROVER_EMAIL_TO" envSeparator:","`
+	// EmailPublicURL is the public base URL of the service used in decision links.
+	EmailPublicURL string `env:"TG_APPROVER_EMAIL_PUBLIC_URL"`
+	// EmailLinkSecret signs decision links.
+	EmailLinkSecret string `env:"TG_APPROVER_EMAIL_LINK_SECRET"`
+	// VaultAddr is the Vault base URL; it enables reading the bot token and webhook secret from Vault at startup.
+	VaultAddr string `env:"TG_APPROVER_VAULT_ADDR"`
+	// VaultToken authenticates with Vault; when empty the Kubernetes auth method is used with VaultRole.
+	VaultToken string `env:"TG_APPROVER_VAULT_TOKEN"`
+	// VaultRole is the Vault Kubernetes auth role.
+	VaultRole string `env:"TG_APPROVER_VAULT_ROLE"`
+	// VaultAuthMount is the mount path of the Vault Kubernetes auth method.
+	VaultAuthMount string `env:"TG_APPROVER_VAULT_AUTH_MOUNT" envDefault:"kubernetes"`
+	// VaultNamespace is the Vault Enterprise namespace.
+	VaultNamespace string `env:"TG_APPROVER_VAULT_NAMESPACE"`
+	// VaultCAFile is a PEM bundle trusted for the Vault TLS certificate.
+	VaultCAFile string `env:"TG_APPROVER_VAULT_CA_FILE"`
+	// VaultMount is the mount path of the KV version 2 secrets engine.
+	VaultMount string `env:"TG_APPROVER_VAULT_MOUNT" envDefault:"secret"`
+	// VaultPath is the secret path inside VaultMount.
+	VaultPath string `env:"TG_APPROVER_VAULT_PATH"`
+	// VaultTokenKey is the secret key holding the bot token.
+	VaultTokenKey string `env:"TG_APPROVER_VAULT_TOKEN_KEY" envDefault:"token"`
+	// VaultWebhookSecretKey is the secret key holding the webhook secret.
+	VaultWebhookSecretKey string `env:"TG_APPROVER_VAULT_WEBHOOK_SECRET_KEY" envDefault:"webhook_secret"`
+	// ConfigFile is an optional path to the YAML configuration file.
+	ConfigFile string `env:"TG_APPROVER_CONFIG_FILE"`
+
+	// File holds settings loaded from ConfigFile at startup; CurrentFile returns them after a reload.
+	File File `env:"-"`
+
+	// live is shared by every copy of the Config, so a reloaded file reaches all of them.
+	live *liveFile
 }
 
 // Load parses configuration from environment variables.
@@ -49,6 +407,15 @@ func Load() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	if err := loadSecretFiles(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := loadVaultSecrets(&cfg); err != nil {
+		return Config{}, err
+	}
+	if strings.TrimSpace(cfg.Token) == "" {
+		return Config{}, fmt.Errorf("bot token is required: set TG_APPROVER_TOKEN, TG_APPROVER_TOKEN_FILE or a vault path")
+	}
 
 	cfg.Lang = strings.ToLower(strings.TrimSpace(cfg.Lang))
 	if cfg.Lang == "" {
@@ -66,9 +433,343 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("http port must be between 1 and 65535")
 	}
 
+	cfg.APIURL = strings.TrimRight(strings.TrimSpace(cfg.APIURL), "/")
+	if cfg.APIURL != "" {
+		if u, err := url.Parse(cfg.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("api url must be an absolute url")
+		}
+	}
+
+	if cfg.TelegramMaxIdleConns < 0 || cfg.TelegramMaxIdleConnsPerHost < 0 {
+		return Config{}, fmt.Errorf("telegram idle connection limits must not be negative")
+	}
+	if cfg.TelegramIdleConnTimeout < 0 || cfg.TelegramTLSHandshakeTimeout < 0 {
+		return Config{}, fmt.Errorf("telegram transport timeouts must not be negative")
+	}
+	if cfg.TelegramSendRate < 0 || cfg.TelegramChatRate < 0 || cfg.TelegramRetries < 0 {
+		return Config{}, fmt.Errorf("telegram send limits must not be negative")
+	}
+
 	if (cfg.WebhookURL == "") != (cfg.WebhookSecret == "") {
 		return Config{}, fmt.Errorf("webhook url and secret must be set together")
 	}
+	if cfg.WebhookQueue <= 0 {
+		return Config{}, fmt.Errorf("webhook queue must be positive")
+	}
+	if cfg.WebhookQueueWait < 0 {
+		return Config{}, fmt.Errorf("webhook queue wait must not be negative")
+	}
+	if cfg.WebhookMaxConnections < 0 || cfg.WebhookMaxConnections > 100 {
+		return Config{}, fmt.Errorf("webhook max connections must be between 0 and 100")
+	}
+
+	if cfg.HistorySize <= 0 {
+		return Config{}, fmt.Errorf("history size must be positive")
+	}
+	if cfg.OperationTimeout <= 0 {
+		return Config{}, fmt.Errorf("operation timeout must be positive")
+	}
+	if cfg.HandlerWorkers <= 0 {
+		return Config{}, fmt.Errorf("handler workers must be positive")
+	}
+	if cfg.CallbackWorkers <= 0 {
+		return Config{}, fmt.Errorf("callback workers must be positive")
+	}
+	if cfg.CallbackQueue < 0 {
+		return Config{}, fmt.Errorf("callback queue must not be negative")
+	}
+	if cfg.JournalEnabled && cfg.JournalSize <= 0 {
+		return Config{}, fmt.Errorf("journal size must be positive")
+	}
+	if cfg.HistoryRetention < 0 {
+		return Config{}, fmt.Errorf("history retention must not be negative")
+	}
+
+	if cfg.RateLimit < 0 || cfg.SourceRateLimit < 0 {
+		return Config{}, fmt.Errorf("rate limits must not be negative")
+	}
+	if (cfg.RateLimit > 0 && cfg.RateBurst <= 0) || (cfg.SourceRateLimit > 0 && cfg.SourceRateBurst <= 0) {
+		return Config{}, fmt.Errorf("rate burst must be positive")
+	}
+	if cfg.RateQueue < 0 {
+		return Config{}, fmt.Errorf("rate queue must not be negative")
+	}
+	if cfg.HealthCacheTTL < 0 {
+		return Config{}, fmt.Errorf("health cache ttl must not be negative")
+	}
+	if cfg.IdempotencyTTL < 0 {
+		return Config{}, fmt.Errorf("idempotency ttl must not be negative")
+	}
+
+	for _, pattern := range cfg.SensitiveTools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Config{}, fmt.Errorf("invalid sensitive tool pattern %q", pattern)
+		}
+	}
+
+	if cfg.EscalationAfter <= 0 || cfg.EscalationAfter >= 1 {
+		return Config{}, fmt.Errorf("escalation after must be between 0 and 1")
+	}
+	for i, mention := range cfg.EscalationMentions {
+		normalized, ok := NormalizeMention(mention)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid escalation mention %q", mention)
+		}
+		cfg.EscalationMentions[i] = normalized
+	}
+
+	cfg.Topics = strings.ToLower(strings.TrimSpace(cfg.Topics))
+	if cfg.Topics != "" && cfg.Topics != TopicsTool && cfg.Topics != TopicsWorkflow {
+		return Config{}, fmt.Errorf("topics must be tool or workflow")
+	}
+	cfg.ChatsFile = strings.TrimSpace(cfg.ChatsFile)
+	if len(cfg.SuperAdminUserIDs) > 0 && cfg.ChatsFile == "" {
+		return Config{}, fmt.Errorf("chats file is required for super admins")
+	}
+	if cfg.DeepLinks && len(cfg.AllowedUserIDs) == 0 {
+		return Config{}, fmt.Errorf("deep links require allowed user ids")
+	}
+	if cfg.ThreadID < 0 {
+		return Config{}, fmt.Errorf("thread id must not be negative")
+	}
+	if cfg.ThreadID > 0 && cfg.Topics != "" {
+		return Config{}, fmt.Errorf("thread id and topics cannot be used together")
+	}
+
+	cfg.LongMessages = strings.ToLower(strings.TrimSpace(cfg.LongMessages))
+	switch cfg.LongMessages {
+	case "":
+		cfg.LongMessages = LongMessagesSplit
+	case LongMessagesReject, LongMessagesSplit, LongMessagesAttach:
+	default:
+		return Config{}, fmt.Errorf("long messages must be reject, split, or attach")
+	}
+
+	if _, err := ParseKeyboard(cfg.Keyboard); err != nil {
+		return Config{}, err
+	}
+	if cfg.SnoozeDuration < 0 || cfg.SnoozeLimit < 0 {
+		return Config{}, fmt.Errorf("snooze duration and limit must not be negative")
+	}
+
+	if _, err := calendar.New(cfg.CalendarOptions()); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.DigestInterval < 0 || cfg.DigestMinAge < 0 {
+		return Config{}, fmt.Errorf("digest interval and min age must not be negative")
+	}
+
+	if cfg.DecisionCacheTTL < 0 {
+		return Config{}, fmt.Errorf("decision cache ttl must not be negative")
+	}
+
+	cfg.CallbackFormat = strings.ToLower(strings.TrimSpace(cfg.CallbackFormat))
+	switch cfg.CallbackFormat {
+	case "":
+		cfg.CallbackFormat = CallbackFormatJSON
+	case CallbackFormatJSON, CallbackFormatCloudEvents:
+	default:
+		return Config{}, fmt.Errorf("callback format must be json or cloudevents")
+	}
+	if err := validateCallbackFields(cfg.CallbackRedact); err != nil {
+		return Config{}, err
+	}
+
+	cfg.Store = strings.ToLower(strings.TrimSpace(cfg.Store))
+	switch cfg.Store {
+	case "":
+		cfg.Store = StoreMemory
+	case StoreMemory:
+	case StoreFile:
+		if strings.TrimSpace(cfg.StoreFile) == "" {
+			return Config{}, fmt.Errorf("store file is required for file store")
+		}
+	case StoreRedis:
+		if strings.TrimSpace(cfg.RedisURL) == "" {
+			return Config{}, fmt.Errorf("redis url is required for redis store")
+		}
+		if cfg.StoreSyncInterval <= 0 {
+			return Config{}, fmt.Errorf("store sync interval must be positive")
+		}
+		if strings.TrimSpace(cfg.DuplicateKeyPrefix) == "" || strings.HasPrefix(cfg.DuplicateKeyPrefix, cfg.RedisPrefix) {
+			return Config{}, fmt.Errorf("duplicate key prefix must be set and must not start with the redis prefix")
+		}
+	default:
+		return Config{}, fmt.Errorf("store must be memory, file, or redis")
+	}
+	if key := strings.TrimSpace(cfg.StoreEncryptionKey); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(decoded) != 32 {
+			return Config{}, fmt.Errorf("store encryption key must be 32 bytes encoded as base64")
+		}
+		cfg.StoreEncryptionKeyBytes = decoded
+	}
+	if cfg.WebhookHandoff() {
+		if cfg.InstanceTTL < 3*time.Second {
+			return Config{}, fmt.Errorf("instance ttl must be at least 3s")
+		}
+		if strings.TrimSpace(cfg.InstanceKeyPrefix) == "" || strings.HasPrefix(cfg.InstanceKeyPrefix, cfg.RedisPrefix) {
+			return Config{}, fmt.Errorf("instance key prefix must be set and must not start with the redis prefix")
+		}
+	}
+	if cfg.VoiceCommands && strings.TrimSpace(cfg.OpenAIAPIKey) == "" {
+		return Config{}, fmt.Errorf("voice commands require the openai api key")
+	}
+	if strings.TrimSpace(cfg.SummaryModel) != "" {
+		if strings.TrimSpace(cfg.OpenAIAPIKey) == "" {
+			return Config{}, fmt.Errorf("request summaries require the openai api key")
+		}
+		if cfg.SummaryTimeout <= 0 {
+			return Config{}, fmt.Errorf("summary timeout must be positive")
+		}
+	}
+	if cfg.Standby {
+		if cfg.StandbyLeaseTTL < 3*time.Second {
+			return Config{}, fmt.Errorf("standby lease ttl must be at least 3s")
+		}
+		cfg.StandbyLease = strings.ToLower(strings.TrimSpace(cfg.StandbyLease))
+		switch cfg.StandbyLease {
+		case LeaseRedis:
+			if cfg.Store != StoreRedis {
+				return Config{}, fmt.Errorf("redis standby lease requires the redis store")
+			}
+			cfg.StandbyLeaseKey = strings.TrimSpace(cfg.StandbyLeaseKey)
+			if cfg.StandbyLeaseKey == "" {
+				return Config{}, fmt.Errorf("standby lease key must not be empty")
+			}
+			if strings.HasPrefix(cfg.StandbyLeaseKey, cfg.RedisPrefix) {
+				return Config{}, fmt.Errorf("standby lease key must not start with the redis prefix")
+			}
+		case LeaseKubernetes:
+			cfg.StandbyLeaseName = strings.TrimSpace(cfg.StandbyLeaseName)
+			if !kubeNamePattern.MatchString(cfg.StandbyLeaseName) {
+				return Config{}, fmt.Errorf("standby lease name must be a lowercase kubernetes object name")
+			}
+			cfg.StandbyLeaseNamespace = strings.TrimSpace(cfg.StandbyLeaseNamespace)
+		default:
+			return Config{}, fmt.Errorf("standby lease must be redis or kubernetes")
+		}
+	}
+
+	if cfg.SlackEnabled() {
+		if strings.TrimSpace(cfg.SlackSigningSecret) == "" || strings.TrimSpace(cfg.SlackChannel) == "" {
+			return Config{}, fmt.Errorf("slack signing secret and channel are required with the slack bot token")
+		}
+		if u, err := url.Parse(cfg.SlackAPIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("slack api url must be an absolute url")
+		}
+	}
+	if cfg.MattermostEnabled() {
+		if strings.TrimSpace(cfg.MattermostChannelID) == "" {
+			return Config{}, fmt.Errorf("mattermost channel id is required with the mattermost url and token")
+		}
+		for name, value := range map[string]string{"mattermost url": cfg.MattermostURL, "mattermost actions url": cfg.MattermostActionsURL} {
+			if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+				return Config{}, fmt.Errorf("%s must be an absolute url", name)
+			}
+		}
+	}
+	if cfg.DiscordEnabled() {
+		if strings.TrimSpace(cfg.DiscordChannelID) == "" {
+			return Config{}, fmt.Errorf("discord channel id is required with the discord bot token")
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(cfg.DiscordPublicKey))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return Config{}, fmt.Errorf("discord public key must be a hex-encoded ed25519 key")
+		}
+		cfg.DiscordPublicKeyBytes = key
+		if u, err := url.Parse(cfg.DiscordAPIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("discord api url must be an absolute url")
+		}
+	}
+	if cfg.MatrixEnabled() {
+		if !strings.HasPrefix(strings.TrimSpace(cfg.MatrixRoomID), "!") {
+			return Config{}, fmt.Errorf("matrix room id must be a room id like !abc:example.org")
+		}
+		if u, err := url.Parse(cfg.MatrixHomeserverURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("matrix homeserver url must be an absolute url")
+		}
+	}
+	if cfg.EmailEnabled() {
+		if _, _, err := net.SplitHostPort(cfg.EmailSMTPAddr); err != nil {
+			return Config{}, fmt.Errorf("email smtp addr must be host:port")
+		}
+		if strings.TrimSpace(cfg.EmailFrom) == "" || len(cfg.EmailTo) == 0 {
+			return Config{}, fmt.Errorf("email from and to are required with the email smtp addr")
+		}
+		if len(cfg.EmailLinkSecret) < 16 {
+			return Config{}, fmt.Errorf("email link secret must be at least 16 characters")
+		}
+		if u, err := url.Parse(cfg.EmailPublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("email public url must be an absolute url")
+		}
+	}
+	cfg.Channel = strings.ToLower(strings.TrimSpace(cfg.Channel))
+	if cfg.Channel == "" {
+		cfg.Channel = ChannelTelegram
+	}
+	if !slices.Contains(cfg.Channels(), cfg.Channel) {
+		return Config{}, fmt.Errorf("channel %q is not configured", cfg.Channel)
+	}
+
+	if cfg.AuditURL != "" {
+		if u, err := url.Parse(cfg.AuditURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("audit url must be an absolute url")
+		}
+	}
+
+	if cfg.MirrorURL != "" {
+		if u, err := url.Parse(cfg.MirrorURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("mirror url must be an absolute url")
+		}
+	}
+
+	if cfg.NATSURL != "" {
+		if u, err := url.Parse(cfg.NATSURL); err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Hostname() == "" {
+			return Config{}, fmt.Errorf("nats url must be a nats:// or tls:// url")
+		}
+		if cfg.NATSSubject == "" || strings.ContainsAny(cfg.NATSSubject, " \t\r\n*>") || strings.HasPrefix(cfg.NATSSubject, ".") ||
+			strings.HasSuffix(cfg.NATSSubject, ".") || strings.Contains(cfg.NATSSubject, "..") {
+			return Config{}, fmt.Errorf("nats subject must be a valid subject without wildcards")
+		}
+	}
+
+	if cfg.KubeController && cfg.KubeResync <= 0 {
+		return Config{}, fmt.Errorf("kube resync must be positive")
+	}
+
+	if cfg.GrafanaURL != "" {
+		if u, err := url.Parse(cfg.GrafanaURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, fmt.Errorf("grafana url must be an absolute url")
+		}
+		for _, pattern := range cfg.GrafanaTools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return Config{}, fmt.Errorf("invalid grafana tool pattern %q", pattern)
+			}
+		}
+	}
+
+	if cfg.APIHMACSecret != "" && cfg.APIHMACSkew <= 0 {
+		return Config{}, fmt.Errorf("api hmac skew must be positive")
+	}
+	for _, value := range cfg.APIAllowedCIDRs {
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid api allowed cidr %q: %w", value, err)
+		}
+		cfg.APIAllowedPrefixes = append(cfg.APIAllowedPrefixes, prefix)
+	}
+
+	if strings.TrimSpace(cfg.ConfigFile) != "" {
+		file, err := LoadFile(cfg.ConfigFile)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.File = file
+	}
+	cfg.live = &liveFile{}
+	cfg.live.file.Store(&cfg.File)
 
 	return cfg, nil
 }
@@ -78,7 +779,162 @@ func (c Config) HTTPAddr() string {
 	return net.JoinHostPort(strings.TrimSpace(c.HTTPHost), fmt.Sprintf("%d", c.HTTPPort))
 }
 
+// ChatIDs returns the primary chat ID and the escalation chat followed by chats from the config file.
+func (c Config) ChatIDs() []int64 {
+	ids := []int64{c.ChatID}
+	seen := map[int64]struct{}{c.ChatID: {}}
+	if c.EscalationChatID != 0 && c.EscalationChatID != c.ChatID {
+		ids = append(ids, c.EscalationChatID)
+		seen[c.EscalationChatID] = struct{}{}
+	}
+	file := c.CurrentFile()
+	names := make([]string, 0, len(file.Chats))
+	for name := range file.Chats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		id := file.Chats[name]
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// parsePrefix accepts a CIDR or a single IP address.
+func parsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// RouteChat returns the chat ID for a request target or team; an empty route selects the primary chat.
+func (c Config) RouteChat(route string) (int64, bool) {
+	route = strings.TrimSpace(route)
+	if route == "" {
+		return c.ChatID, true
+	}
+	file := c.CurrentFile()
+	chat, ok := file.Routes[route]
+	if !ok {
+		return 0, false
+	}
+	return file.Chats[chat], true
+}
+
+// SensitiveTool reports whether the tool matches TG_APPROVER_SENSITIVE_TOOLS.
+func (c Config) SensitiveTool(tool string) bool {
+	for _, pattern := range c.SensitiveTools {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CalendarOptions returns the working hours approval timeouts are counted in.
+func (c Config) CalendarOptions() calendar.Options {
+	return calendar.Options{
+		Timezone: c.BusinessTimezone,
+		Days:     c.BusinessDays,
+		Hours:    c.BusinessHours,
+		Holidays: c.BusinessHolidays,
+	}
+}
+
+// AdminEnabled reports whether admin endpoints are exposed.
+func (c Config) AdminEnabled() bool {
+	return c.AdminToken != ""
+}
+
 // WebhookEnabled reports whether webhook mode is configured.
 func (c Config) WebhookEnabled() bool {
 	return c.WebhookURL != "" && c.WebhookSecret != ""
 }
+
+// SlackEnabled reports whether the Slack channel is configured.
+func (c Config) SlackEnabled() bool {
+	return c.SlackBotToken != ""
+}
+
+// MattermostEnabled reports whether the Mattermost channel is configured.
+func (c Config) MattermostEnabled() bool {
+	return c.MattermostURL != "" && c.MattermostToken != ""
+}
+
+// DiscordEnabled reports whether the Discord channel is configured.
+func (c Config) DiscordEnabled() bool {
+	return c.DiscordBotToken != ""
+}
+
+// MatrixEnabled reports whether the Matrix channel is configured.
+func (c Config) MatrixEnabled() bool {
+	return c.MatrixHomeserverURL != "" && c.MatrixAccessToken != ""
+}
+
+// EmailEnabled reports whether the email channel is configured.
+func (c Config) EmailEnabled() bool {
+	return c.EmailSMTPAddr != ""
+}
+
+// Channels lists the configured approval channels.
+func (c Config) Channels() []string {
+	channels := []string{ChannelTelegram}
+	if c.SlackEnabled() {
+		channels = append(channels, ChannelSlack)
+	}
+	if c.MattermostEnabled() {
+		channels = append(channels, ChannelMattermost)
+	}
+	if c.DiscordEnabled() {
+		channels = append(channels, ChannelDiscord)
+	}
+	if c.MatrixEnabled() {
+		channels = append(channels, ChannelMatrix)
+	}
+	if c.EmailEnabled() {
+		channels = append(channels, ChannelEmail)
+	}
+	return channels
+}
+
+// WebhookHandoff reports whether instances coordinate webhook ownership through the shared store.
+func (c Config) WebhookHandoff() bool {
+	return c.WebhookEnabled() && c.Store == StoreRedis
+}
+
+// NormalizeMention validates a Telegram username and returns it with a leading "@".
+func NormalizeMention(value string) (string, bool) {
+	name := strings.TrimPrefix(strings.TrimSpace(value), "@")
+	if !usernamePattern.MatchString(name) {
+		return "", false
+	}
+	return "@" + name, true
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+
+// kubeNamePattern matches a DNS subdomain, the name format of Kubernetes objects.
+var kubeNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?$`)
+
+func validateCallbackFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(CallbackFields, field) {
+			return fmt.Errorf("unknown callback field %q, expected one of %s", field, strings.Join(CallbackFields, ", "))
+		}
+	}
+	return nil
+}
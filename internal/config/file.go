@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File describes optional YAML configuration referenced by TG_APPROVER_CONFIG_FILE.
+type File struct {
+	// Tenants maps tenant names to tenant-specific settings.
+	Tenants map[string]Tenant `yaml:"tenants"`
+	// Chats maps chat names to additional Telegram chat IDs the bot serves.
+	Chats map[string]int64 `yaml:"chats"`
+	// Routes maps the target or team of a request to a chat name from Chats.
+	Routes map[string]string `yaml:"routes"`
+	// Tools maps tool names or path.Match patterns to tool profiles.
+	Tools map[string]ToolProfile `yaml:"tools"`
+	// Severities maps the severity classes low, medium, high and critical to their settings.
+	Severities map[string]SeverityClass `yaml:"severities"`
+	// Templates replace the built-in layout of Telegram approval messages.
+	Templates MessageTemplates `yaml:"templates"`
+	// Callback holds the default body template and headers of decision callbacks.
+	Callback CallbackTemplate `yaml:"callback"`
+}
+
+// CallbackTemplate customizes decision callbacks so receivers need no adapter.
+type CallbackTemplate struct {
+	// Template is a Go text/template that renders the callback JSON body when the tenant has none.
+	Template string `yaml:"template"`
+	// Headers are extra callback headers; values are templates over the callback payload.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// MessageTemplates are Go text/template layouts of approval messages per request markup.
+type MessageTemplates struct {
+	// Markdown renders requests with markdown markup (Telegram MarkdownV2).
+	Markdown string `yaml:"markdown"`
+	// HTML renders requests with html markup.
+	HTML string `yaml:"html"`
+}
+
+// Tenant holds per-tenant overrides.
+type Tenant struct {
+	// CallbackTemplate is a Go text/template that renders the callback JSON body.
+	CallbackTemplate string `yaml:"callback_template"`
+	// CallbackHeaders are extra callback headers merged over the global ones.
+	CallbackHeaders map[string]string `yaml:"callback_headers"`
+	// Token is the API token that authenticates requests for this tenant.
+	Token string `yaml:"token"`
+	// Requesters limits requested_by values accepted with the tenant token.
+	Requesters []string `yaml:"requesters"`
+	// CallbackRedact replaces TG_APPROVER_CALLBACK_REDACT for this tenant when set; an empty list redacts nothing.
+	CallbackRedact []string `yaml:"callback_redact"`
+	// Namespaces are the Kubernetes namespaces whose ApprovalRequest resources may name a tenant that has a token.
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// liveFile holds the current config file.
+type liveFile struct {
+	file atomic.Pointer[File]
+}
+
+// CurrentFile returns the config file loaded at startup or by the last SetFile.
+func (c Config) CurrentFile() File {
+	if c.live == nil {
+		return c.File
+	}
+	return *c.live.file.Load()
+}
+
+// SetFile makes file current for every copy of the Config; the File field keeps the startup version.
+func (c Config) SetFile(file File) {
+	if c.live != nil {
+		c.live.file.Store(&file)
+	}
+}
+
+// LoadFile reads and parses the YAML configuration file.
+func LoadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("read config file: %w", err)
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("parse config file: %w", err)
+	}
+	tokens := make(map[string]string)
+	for name, tenant := range file.Tenants {
+		if strings.TrimSpace(name) == "" {
+			return File{}, fmt.Errorf("tenant name must not be empty")
+		}
+		if err := validateCallbackFields(tenant.CallbackRedact); err != nil {
+			return File{}, fmt.Errorf("tenant %q: %w", name, err)
+		}
+		if slices.ContainsFunc(tenant.Namespaces, func(namespace string) bool { return strings.TrimSpace(namespace) == "" }) {
+			return File{}, fmt.Errorf("tenant %q: namespaces must not be empty", name)
+		}
+		if tenant.Token == "" {
+			continue
+		}
+		if other, ok := tokens[tenant.Token]; ok {
+			return File{}, fmt.Errorf("tenants %q and %q share the same token", other, name)
+		}
+		tokens[tenant.Token] = name
+	}
+	for name, id := range file.Chats {
+		if strings.TrimSpace(name) == "" {
+			return File{}, fmt.Errorf("chat name must not be empty")
+		}
+		if id == 0 {
+			return File{}, fmt.Errorf("chat %q must have a non-zero id", name)
+		}
+	}
+	for route, chat := range file.Routes {
+		if strings.TrimSpace(route) == "" {
+			return File{}, fmt.Errorf("route name must not be empty")
+		}
+		if _, ok := file.Chats[chat]; !ok {
+			return File{}, fmt.Errorf("route %q refers to unknown chat %q", route, chat)
+		}
+	}
+	if err := validateProfiles(file); err != nil {
+		return File{}, err
+	}
+	if err := validateSeverities(file); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
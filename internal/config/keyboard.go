@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Keyboard buttons of Telegram approval messages.
+const (
+	ButtonApprove    = "approve"
+	ButtonDeny       = "deny"
+	ButtonDenyReason = "deny_reason"
+	ButtonDiscuss    = "discuss"
+	ButtonDetails    = "details"
+	ButtonDelegate   = "delegate"
+	ButtonAck        = "ack"
+	ButtonLanguage   = "language"
+	ButtonSnooze     = "snooze"
+)
+
+var keyboardButtons = []string{ButtonApprove, ButtonDeny, ButtonDenyReason, ButtonDiscuss, ButtonDetails, ButtonDelegate, ButtonAck, ButtonLanguage, ButtonSnooze}
+
+// ParseKeyboard parses a layout such as "approve,deny;deny_reason,discuss": rows are separated by ";"
+// and buttons by ",". The layout must offer a way to approve and to deny.
+func ParseKeyboard(layout string) ([][]string, error) {
+	var rows [][]string
+	seen := make(map[string]struct{})
+	for _, rawRow := range strings.Split(layout, ";") {
+		var row []string
+		for _, raw := range strings.Split(rawRow, ",") {
+			button := strings.ToLower(strings.TrimSpace(raw))
+			if button == "" {
+				continue
+			}
+			if !slices.Contains(keyboardButtons, button) {
+				return nil, fmt.Errorf("unknown keyboard button %q", button)
+			}
+			if _, ok := seen[button]; ok {
+				return nil, fmt.Errorf("keyboard button %q is listed twice", button)
+			}
+			seen[button] = struct{}{}
+			row = append(row, button)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	_, approve := seen[ButtonApprove]
+	_, deny := seen[ButtonDeny]
+	_, denyReason := seen[ButtonDenyReason]
+	if !approve || (!deny && !denyReason) {
+		return nil, fmt.Errorf("keyboard must contain approve and deny or deny_reason")
+	}
+	return rows, nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// ToolProfile holds per-tool defaults applied by /approve.
+type ToolProfile struct {
+	// Timeout replaces TG_APPROVER_APPROVAL_TIMEOUT when the request sets no timeout_sec.
+	Timeout time.Duration `yaml:"timeout"`
+	// Target is the route used when the request sets neither target nor team.
+	Target string `yaml:"target"`
+	// Approvers limits voting to these Telegram user IDs; approvers sent with the request must be among them.
+	Approvers []int64 `yaml:"approvers"`
+	// Template is a Go text/template rendering the text shown in the message instead of approval_request.
+	Template string `yaml:"template"`
+	// Emoji is prepended to the message title, e.g. a risk marker.
+	Emoji string `yaml:"emoji"`
+	// Severity is the risk class of requests that set none: low, medium, high or critical.
+	Severity string `yaml:"severity"`
+	// OnTimeout is the decision made on timeout for requests that set none: approve, deny or error.
+	OnTimeout string `yaml:"on_timeout"`
+}
+
+// SeverityClass holds settings of requests of one severity.
+type SeverityClass struct {
+	// Timeout replaces TG_APPROVER_APPROVAL_TIMEOUT when neither the request nor its tool profile sets one.
+	Timeout time.Duration `yaml:"timeout"`
+	// OnTimeout is the decision made on timeout when neither the request nor its tool profile sets one.
+	OnTimeout string `yaml:"on_timeout"`
+}
+
+// SeverityTimeout returns the timeout configured for severity, or 0 when there is none.
+func (f File) SeverityTimeout(severity approvals.Severity) time.Duration {
+	return f.Severities[string(severity)].Timeout
+}
+
+// SeverityOnTimeout returns the timeout decision configured for severity, or "" when there is none.
+func (f File) SeverityOnTimeout(severity approvals.Severity) string {
+	return f.Severities[string(severity)].OnTimeout
+}
+
+// ValidOnTimeout reports whether value is a timeout decision: approve, deny, error, or empty for the default.
+func ValidOnTimeout(value string) bool {
+	switch approvals.Decision(value) {
+	case "", approvals.DecisionApprove, approvals.DecisionDeny, approvals.DecisionError:
+		return true
+	}
+	return false
+}
+
+// Profile returns the profile of a tool and the key it is configured under:
+// an exact name match wins, then the longest matching path.Match pattern.
+func (f File) Profile(tool string) (string, ToolProfile, bool) {
+	if profile, ok := f.Tools[tool]; ok {
+		return tool, profile, true
+	}
+	best := ""
+	for pattern := range f.Tools {
+		if ok, _ := path.Match(pattern, tool); !ok {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return "", ToolProfile{}, false
+	}
+	return best, f.Tools[best], true
+}
+
+// ParseTemplate parses the message template of a profile; it returns nil when the profile has none.
+func (p ToolProfile) ParseTemplate(name string) (*template.Template, error) {
+	if strings.TrimSpace(p.Template) == "" {
+		return nil, nil
+	}
+	return template.New(name).Option("missingkey=zero").Parse(p.Template)
+}
+
+func validateProfiles(file File) error {
+	for pattern, profile := range file.Tools {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("tool profile name must not be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("tool profile %q: invalid pattern: %w", pattern, err)
+		}
+		if profile.Timeout < 0 {
+			return fmt.Errorf("tool profile %q: timeout must not be negative", pattern)
+		}
+		if profile.Target != "" {
+			if _, ok := file.Routes[profile.Target]; !ok {
+				return fmt.Errorf("tool profile %q refers to unknown route %q", pattern, profile.Target)
+			}
+		}
+		if _, err := profile.ParseTemplate(pattern); err != nil {
+			return fmt.Errorf("tool profile %q: parse template: %w", pattern, err)
+		}
+		if _, err := approvals.ParseSeverity(profile.Severity); err != nil {
+			return fmt.Errorf("tool profile %q: %w", pattern, err)
+		}
+		if !ValidOnTimeout(profile.OnTimeout) {
+			return fmt.Errorf("tool profile %q: on_timeout must be approve, deny or error", pattern)
+		}
+	}
+	return nil
+}
+
+func validateSeverities(file File) error {
+	for name, class := range file.Severities {
+		if severity, err := approvals.ParseSeverity(name); err != nil || severity == "" || string(severity) != name {
+			return fmt.Errorf("severity %q must be one of low, medium, high or critical", name)
+		}
+		if class.Timeout < 0 {
+			return fmt.Errorf("severity %q: timeout must not be negative", name)
+		}
+		if !ValidOnTimeout(class.OnTimeout) {
+			return fmt.Errorf("severity %q: on_timeout must be approve, deny or error", name)
+		}
+	}
+	return nil
+}
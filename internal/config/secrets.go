@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/vault"
+)
+
+// fileSuffix turns the name of a secret variable into the variable holding the path of a file with the secret.
+const fileSuffix = "_FILE"
+
+// secretField is a variable holding a secret and the field it fills.
+type secretField struct {
+	name  string
+	value *string
+}
+
+// secretFields lists the variables that hold secrets. Each of them can be read from the file named by the variable
+// with the _FILE suffix instead.
+func secretFields(cfg *Config) []secretField {
+	return []secretField{
+		{"TG_APPROVER_TOKEN", &cfg.Token},
+		{"TG_APPROVER_AUDIT_TOKEN", &cfg.AuditToken},
+		{"TG_APPROVER_WEBHOOK_SECRET", &cfg.WebhookSecret},
+		{"TG_APPROVER_OPENAI_API_KEY", &cfg.OpenAIAPIKey},
+		{"TG_APPROVER_CALLBACK_SECRET", &cfg.CallbackSecret},
+		{"TG_APPROVER_NATS_URL", &cfg.NATSURL},
+		{"TG_APPROVER_NATS_TOKEN", &cfg.NATSToken},
+		{"TG_APPROVER_GRAFANA_TOKEN", &cfg.GrafanaToken},
+		{"TG_APPROVER_API_TOKEN", &cfg.APIToken},
+		{"TG_APPROVER_API_HMAC_SECRET", &cfg.APIHMACSecret},
+		{"TG_APPROVER_ADMIN_TOKEN", &cfg.AdminToken},
+		{"TG_APPROVER_REDIS_URL", &cfg.RedisURL},
+		{"TG_APPROVER_STORE_ENCRYPTION_KEY", &cfg.StoreEncryptionKey},
+		{"TG_APPROVER_SLACK_BOT_TOKEN", &cfg.SlackBotToken},
+		{"TG_APPROVER_SLACK_SIGNING_SECRET", &cfg.SlackSigningSecret},
+		{"TG_APPROVER_MATTERMOST_TOKEN", &cfg.MattermostToken},
+		{"TG_APPROVER_DISCORD_BOT_TOKEN", &cfg.DiscordBotToken},
+		{"TG_APPROVER_DISCORD_PUBLIC_KEY", &cfg.DiscordPublicKey},
+		{"TG_APPROVER_MATRIX_ACCESS_TOKEN", &cfg.MatrixAccessToken},
+		{"TG_APPROVER_EMAIL_SMTP_PASSWORD", &cfg.EmailSMTPPassword},
+		{"TG_APPROVER_EMAIL_LINK_SECRET", &cfg.EmailLinkSecret},
+		{"TG_APPROVER_VAULT_TOKEN", &cfg.VaultToken},
+	}
+}
+
+// loadSecretFiles reads the secrets whose _FILE variable is set. Surrounding whitespace, such as the trailing
+// newline of a mounted Kubernetes secret, is dropped.
+func loadSecretFiles(cfg *Config) error {
+	for _, field := range secretFields(cfg) {
+		path := strings.TrimSpace(os.Getenv(field.name + fileSuffix))
+		if path == "" {
+			continue
+		}
+		if *field.value != "" {
+			return fmt.Errorf("%s and %s%s must not be set together", field.name, field.name, fileSuffix)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s%s: %w", field.name, fileSuffix, err)
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return fmt.Errorf("%s%s points to an empty file", field.name, fileSuffix)
+		}
+		*field.value = value
+	}
+	return nil
+}
+
+// loadVaultSecrets fills the bot token and webhook secret that are not set otherwise from the Vault secret at
+// VaultPath. Values set by variables or files take precedence.
+func loadVaultSecrets(cfg *Config) error {
+	if strings.TrimSpace(cfg.VaultAddr) == "" {
+		return nil
+	}
+	if strings.TrimSpace(cfg.VaultPath) == "" {
+		return fmt.Errorf("vault path is required when vault addr is set")
+	}
+	client, err := vault.New(vault.Options{
+		Addr:      cfg.VaultAddr,
+		Token:     cfg.VaultToken,
+		Role:      cfg.VaultRole,
+		AuthMount: cfg.VaultAuthMount,
+		Namespace: cfg.VaultNamespace,
+		CAFile:    cfg.VaultCAFile,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	values, err := client.ReadKV(ctx, cfg.VaultMount, cfg.VaultPath)
+	if err != nil {
+		return err
+	}
+	if cfg.Token == "" {
+		cfg.Token = strings.TrimSpace(values[cfg.VaultTokenKey])
+	}
+	if cfg.WebhookSecret == "" {
+		cfg.WebhookSecret = strings.TrimSpace(values[cfg.VaultWebhookSecretKey])
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+const (
+	// Name is the channel name used in requests and configuration.
+	Name = "discord"
+	// DefaultAPIURL is the Discord REST API base URL.
+	DefaultAPIURL = "https://discord.com/api/v10"
+
+	actionApprove    = "approve"
+	actionDeny       = "deny"
+	actionDenyReason = "denyreason"
+	modalReason      = "reason"
+	// maxCorrelationID keeps "denyreason:<id>" within the 100-character custom_id limit.
+	maxCorrelationID = 88
+	maxReason        = 500
+	maxBodyBytes     = 1 << 20
+	maxDescription   = 4000
+	maxFieldValue    = 1000
+	// Colors of the embed before and after the decision.
+	colorPending  = 0xF1C40F
+	colorApproved = 0x2ECC71
+	colorDenied   = 0xE74C3C
+	colorOther    = 0x95A5A6
+)
+
+// Interaction and response types of the Discord interactions API.
+const (
+	interactionPing        = 1
+	interactionComponent   = 3
+	interactionModalSubmit = 5
+	responsePong           = 1
+	responseMessage        = 4
+	responseDeferredUpdate = 6
+	responseModal          = 9
+	flagEphemeral          = 64
+	componentActionRow     = 1
+	componentButton        = 2
+	componentTextInput     = 4
+	buttonSuccess          = 3
+	buttonDanger           = 4
+	buttonSecondary        = 2
+	textInputParagraph     = 2
+)
+
+// ErrCorrelationIDTooLong is returned when a correlation ID does not fit into Discord component IDs.
+var ErrCorrelationIDTooLong = fmt.Errorf("correlation id is longer than %d characters", maxCorrelationID)
+
+// Options configures the Discord channel.
+type Options struct {
+	// Token is the bot token.
+	Token string
+	// PublicKey is the application public key that verifies interactions.
+	PublicKey ed25519.PublicKey
+	// ChannelID is the channel approvals are posted to.
+	ChannelID string
+	// AllowedUsers are Discord user IDs allowed to decide; empty allows everyone in the channel.
+	AllowedUsers []string
+	// APIURL overrides the REST API base URL.
+	APIURL string
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Channel posts approvals as embeds with Approve, Deny, and Deny with message buttons.
+type Channel struct {
+	client *http.Client
+	opts   Options
+	log    *slog.Logger
+}
+
+// New creates a Discord channel.
+func New(opts Options) *Channel {
+	if opts.APIURL == "" {
+		opts.APIURL = DefaultAPIURL
+	}
+	opts.APIURL = strings.TrimRight(opts.APIURL, "/")
+	return &Channel{client: &http.Client{Timeout: 10 * time.Second}, opts: opts, log: opts.Log}
+}
+
+// Name returns the channel name.
+func (c *Channel) Name() string {
+	return Name
+}
+
+// Post sends the approval to the configured channel.
+func (c *Channel) Post(ctx context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error) {
+	id := approval.Request.CorrelationID
+	if len(id) > maxCorrelationID {
+		return approvals.ChannelRef{}, "", ErrCorrelationIDTooLong
+	}
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	content := summary(msg, approval.Request)
+	var message struct {
+		ID        string `json:"id"`
+		ChannelID string `json:"channel_id"`
+	}
+	err := c.call(ctx, http.MethodPost, "/channels/"+c.opts.ChannelID+"/messages", map[string]any{
+		"content": content,
+		"embeds":  []any{embed(msg, approval.Request, colorPending, "")},
+		"components": []any{map[string]any{
+			"type": componentActionRow,
+			"components": []any{
+				button(msg.ApproveButton, actionApprove, id, buttonSuccess),
+				button(msg.DenyButton, actionDeny, id, buttonDanger),
+				button(msg.DenyWithMessageButton, actionDenyReason, id, buttonSecondary),
+			},
+		}},
+		"allowed_mentions": map[string]any{"parse": []string{}},
+	}, &message)
+	if err != nil {
+		return approvals.ChannelRef{}, "", err
+	}
+	return approvals.ChannelRef{Channel: Name, Conversation: message.ChannelID, Message: message.ID}, content, nil
+}
+
+// Resolve replaces the buttons of the posted message with the decision.
+func (c *Channel) Resolve(ctx context.Context, approval *approvals.Approval, result approvals.Result) error {
+	ref := approval.ChannelRef
+	if ref.Message == "" {
+		return nil
+	}
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	color := colorOther
+	switch result.Decision {
+	case approvals.DecisionApprove:
+		color = colorApproved
+	case approvals.DecisionDeny:
+		color = colorDenied
+	}
+	note := channel.DecisionNote(msg, result, approval.Request.TimeoutMessage)
+	return c.call(ctx, http.MethodPatch, "/channels/"+ref.Conversation+"/messages/"+ref.Message, map[string]any{
+		"embeds":     []any{embed(msg, approval.Request, color, note)},
+		"components": []any{},
+	}, nil)
+}
+
+// Handler returns the interactions endpoint configured as the application's Interactions Endpoint URL.
+func (c *Channel) Handler(decider channel.Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !c.verify(r.Header, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload interaction
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch payload.Type {
+		case interactionPing:
+			writeJSON(w, map[string]any{"type": responsePong})
+		case interactionComponent, interactionModalSubmit:
+			writeJSON(w, c.interact(decider, payload))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+}
+
+// interact handles a button press or modal submission and returns the interaction response.
+func (c *Channel) interact(decider channel.Decider, payload interaction) map[string]any {
+	msg := c.opts.Messages.For(payload.Locale, c.opts.DefaultLang)
+	if !c.allowed(payload.userID()) {
+		return map[string]any{"type": responseMessage, "data": map[string]any{"content": msg.NotAllowed, "flags": flagEphemeral}}
+	}
+	action, correlationID, _ := strings.Cut(payload.Data.CustomID, ":")
+	switch action {
+	case actionApprove:
+		c.decide(decider, correlationID, payload.actor(), approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved})
+	case actionDeny:
+		c.decide(decider, correlationID, payload.actor(), approvals.Result{Decision: approvals.DecisionDeny, ReasonCode: approvals.ReasonDenied})
+	case actionDenyReason:
+		return map[string]any{"type": responseModal, "data": map[string]any{
+			"custom_id": modalReason + ":" + correlationID,
+			"title":     truncate(msg.DenyWithMessageButton, 45),
+			"components": []any{map[string]any{
+				"type": componentActionRow,
+				"components": []any{map[string]any{
+					"type":       componentTextInput,
+					"custom_id":  modalReason,
+					"style":      textInputParagraph,
+					"label":      truncate(msg.DenyPlaceholder, 45),
+					"max_length": maxReason,
+					"required":   false,
+				}},
+			}},
+		}}
+	case modalReason:
+		c.decide(decider, correlationID, payload.actor(), channel.DenyResult(payload.reason()))
+	}
+	return map[string]any{"type": responseDeferredUpdate}
+}
+
+// decide applies a decision in the background; Discord expects a response within three seconds.
+func (c *Channel) decide(decider channel.Decider, correlationID string, actor *approvals.Actor, result approvals.Result) {
+	result.Actor = actor
+	go func() {
+		err := decider.Decide(context.Background(), correlationID, result)
+		if err != nil && !approvals.NotPending(err) {
+			c.log.Error("Failed to apply Discord decision", "error", err, "correlation_id", correlationID)
+		}
+	}()
+}
+
+// verify checks the Ed25519 signature of an interaction request.
+func (c *Channel) verify(header http.Header, body []byte) bool {
+	signature, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize || len(c.opts.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	message := append([]byte(header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(c.opts.PublicKey, message, signature)
+}
+
+func (c *Channel) allowed(userID string) bool {
+	return len(c.opts.AllowedUsers) == 0 || slices.Contains(c.opts.AllowedUsers, userID)
+}
+
+// call invokes a REST API endpoint and decodes its response into out when it is not nil.
+func (c *Channel) call(ctx context.Context, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.APIURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+c.opts.Token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord %s %s: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(&apiErr)
+		return fmt.Errorf("discord %s %s: status %d: %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+	if out != nil {
+		return json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(out)
+	}
+	return nil
+}
+
+type interaction struct {
+	Type   int    `json:"type"`
+	Locale string `json:"locale"`
+	Member *struct {
+		User user `json:"user"`
+	} `json:"member"`
+	User *user `json:"user"`
+	Data struct {
+		CustomID   string `json:"custom_id"`
+		Components []struct {
+			Components []struct {
+				CustomID string `json:"custom_id"`
+				Value    string `json:"value"`
+			} `json:"components"`
+		} `json:"components"`
+	} `json:"data"`
+}
+
+type user struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// userID returns the presser; guild interactions carry it in member, DMs in user.
+func (i interaction) userID() string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// actor identifies the presser in decision results.
+func (i interaction) actor() *approvals.Actor {
+	switch {
+	case i.Member != nil:
+		return &approvals.Actor{ID: i.Member.User.ID, Username: i.Member.User.Username}
+	case i.User != nil:
+		return &approvals.Actor{ID: i.User.ID, Username: i.User.Username}
+	}
+	return nil
+}
+
+// reason returns the deny reason typed into the modal.
+func (i interaction) reason() string {
+	for _, row := range i.Data.Components {
+		for _, input := range row.Components {
+			if input.CustomID == modalReason {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// summary is the plain content line shown in notifications.
+func summary(msg i18n.Messages, req approvals.Request) string {
+	return fmt.Sprintf("%s: `%s`", msg.ApprovalTitle, code(req.Tool))
+}
+
+func embed(msg i18n.Messages, req approvals.Request, color int, note string) map[string]any {
+	fields := []any{
+		field(msg.ApprovalTool, "`"+code(req.Tool)+"`", true),
+		field(msg.ApprovalCorrelation, "`"+code(req.CorrelationID)+"`", true),
+	}
+	if value := strings.TrimSpace(req.RequestedBy); value != "" {
+		fields = append(fields, field(msg.RequestedByLabel, value, true))
+	}
+	if value := strings.TrimSpace(req.SessionID); value != "" {
+		fields = append(fields, field(msg.SessionLabel, value, true))
+	}
+	description := &strings.Builder{}
+	if note := channel.ExecuteAfterNote(msg, req); note != "" {
+		fmt.Fprintf(description, "%s\n", note)
+	}
+	if note := channel.QueuedNote(msg, req); note != "" {
+		fmt.Fprintf(description, "%s\n", note)
+	}
+	if value := strings.TrimSpace(req.Justification); value != "" {
+		fmt.Fprintf(description, "**%s:** %s\n", msg.JustificationLabel, value)
+	}
+	if req.Sensitive {
+		fields = append(fields, field(msg.FingerprintLabel, "`"+code(req.Fingerprint)+"`", false))
+		fmt.Fprintf(description, "\n%s\n", msg.SensitiveNote)
+	} else {
+		if value := strings.TrimSpace(req.ApprovalRequest); value != "" {
+			fields = append(fields, field(msg.SectionAction, value, false))
+		}
+		if value := strings.TrimSpace(req.RiskAssessment); value != "" {
+			fields = append(fields, field(msg.SectionRisks, value, false))
+		}
+		if len(req.Arguments) > 0 {
+			if data, err := json.MarshalIndent(req.Arguments, "", "  "); err == nil {
+				fmt.Fprintf(description, "\n**%s**\n```json\n%s\n```\n", msg.SectionParams, strings.ReplaceAll(truncate(string(data), maxDescription-500), "```", "` ` `"))
+			}
+		}
+		if len(req.LinksToCode) > 0 {
+			lines := make([]string, 0, len(req.LinksToCode))
+			for _, link := range req.LinksToCode {
+				lines = append(lines, fmt.Sprintf("[%s](%s)", strings.NewReplacer("[", "", "]", "").Replace(link.Text), link.URL))
+			}
+			fields = append(fields, field(msg.LinksLabel, strings.Join(lines, "\n"), false))
+		}
+	}
+	if note != "" {
+		fmt.Fprintf(description, "\n%s\n", note)
+	}
+	return map[string]any{
+		"title":       truncate(msg.ApprovalTitle, 256),
+		"description": truncate(description.String(), maxDescription),
+		"color":       color,
+		"fields":      fields,
+	}
+}
+
+func field(name, value string, inline bool) map[string]any {
+	return map[string]any{"name": truncate(name, 256), "value": truncate(value, maxFieldValue), "inline": inline}
+}
+
+func button(label, action, correlationID string, style int) map[string]any {
+	return map[string]any{"type": componentButton, "style": style, "label": truncate(label, 80), "custom_id": action + ":" + correlationID}
+}
+
+// code keeps inline code spans intact.
+func code(value string) string {
+	return strings.ReplaceAll(value, "`", "'")
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,2 @@
+// Package discord posts approval requests to Discord with buttons and a deny reason modal.
+package discord
@@ -0,0 +1,2 @@
+// Package email sends approval requests by SMTP with signed approve and deny links.
+package email
@@ -0,0 +1,300 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+const (
+	// Name is the channel name used in requests and configuration.
+	Name = "email"
+	// Path is where decision links point to.
+	Path = "/email/interactions"
+
+	maxReason = 500
+)
+
+// Options configures the email channel.
+type Options struct {
+	// SMTPAddr is the SMTP server host:port.
+	SMTPAddr string
+	// Username and Password authenticate with PLAIN auth when Username is set.
+	Username string
+	Password string
+	// From is the sender address.
+	From string
+	// To are the approver addresses.
+	To []string
+	// PublicURL is the externally reachable base URL of the service used in links.
+	PublicURL string
+	// Secret signs decision links.
+	Secret []byte
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Channel emails approvals with signed links that expire with the approval.
+// A link opens a confirmation page and the decision is applied by the form it submits,
+// so mail scanners that prefetch links cannot decide. Links work once: a resolved approval rejects them.
+type Channel struct {
+	opts Options
+	log  *slog.Logger
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// New creates an email channel.
+func New(opts Options) *Channel {
+	opts.PublicURL = strings.TrimRight(opts.PublicURL, "/")
+	return &Channel{opts: opts, log: opts.Log, send: smtp.SendMail}
+}
+
+// Name returns the channel name.
+func (c *Channel) Name() string {
+	return Name
+}
+
+// Post emails the approval to the configured approvers.
+func (c *Channel) Post(_ context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error) {
+	req := approval.Request
+	msg := c.opts.Messages.For(req.Lang, c.opts.DefaultLang)
+	text := render(msg, req)
+	body := &strings.Builder{}
+	body.WriteString(text)
+	fmt.Fprintf(body, "\n%s: %s\n", msg.ApproveButton, c.link(req.CorrelationID, approvals.DecisionApprove, approval.Deadline))
+	fmt.Fprintf(body, "%s: %s\n", msg.DenyButton, c.link(req.CorrelationID, approvals.DecisionDeny, approval.Deadline))
+	messageID := newMessageID(c.opts.From)
+	subject := fmt.Sprintf("%s: %s (%s)", msg.ApprovalTitle, req.Tool, req.CorrelationID)
+	if err := c.mail(subject, messageID, "", body.String()); err != nil {
+		return approvals.ChannelRef{}, "", err
+	}
+	return approvals.ChannelRef{Channel: Name, Conversation: strings.Join(c.opts.To, ","), Message: messageID}, text, nil
+}
+
+// Resolve replies to the request email with the decision.
+func (c *Channel) Resolve(_ context.Context, approval *approvals.Approval, result approvals.Result) error {
+	ref := approval.ChannelRef
+	if ref.Message == "" {
+		return nil
+	}
+	req := approval.Request
+	msg := c.opts.Messages.For(req.Lang, c.opts.DefaultLang)
+	note := channel.DecisionNote(msg, result, req.TimeoutMessage)
+	subject := fmt.Sprintf("Re: %s: %s (%s)", msg.ApprovalTitle, req.Tool, req.CorrelationID)
+	return c.mail(subject, newMessageID(c.opts.From), ref.Message, note+"\n\n"+approval.MessageText)
+}
+
+// Handler serves the confirmation page of decision links and applies the submitted decision.
+func (c *Channel) Handler(decider channel.Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+		correlationID := r.Form.Get("id")
+		decision := approvals.Decision(r.Form.Get("decision"))
+		expires, _ := strconv.ParseInt(r.Form.Get("expires"), 10, 64)
+		if !c.valid(correlationID, decision, expires, r.Form.Get("sig")) {
+			http.Error(w, "invalid or expired link", http.StatusForbidden)
+			return
+		}
+		msg := c.opts.Messages.For("", c.opts.DefaultLang)
+		switch r.Method {
+		case http.MethodGet:
+			link := url.Values{}
+			for _, key := range []string{"id", "decision", "expires", "sig"} {
+				link.Set(key, r.Form.Get(key))
+			}
+			c.page(w, http.StatusOK, pageData{Messages: msg, Form: link, Decision: decision, Confirm: true})
+		case http.MethodPost:
+			result := approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved}
+			if decision == approvals.DecisionDeny {
+				result = channel.DenyResult(truncate(r.Form.Get("reason"), maxReason))
+			}
+			err := decider.Decide(r.Context(), correlationID, result)
+			switch {
+			case err == nil:
+				c.page(w, http.StatusOK, pageData{Messages: msg, Note: channel.DecisionNote(msg, result, "")})
+			case approvals.NotPending(err):
+				c.page(w, http.StatusConflict, pageData{Messages: msg, Note: msg.AlreadyResolved})
+			default:
+				c.log.Error("Failed to apply email decision", "error", err, "correlation_id", correlationID)
+				c.page(w, http.StatusServiceUnavailable, pageData{Messages: msg, Note: "⚠️ " + msg.ErrorNote})
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// link returns a signed decision link valid until the approval deadline.
+func (c *Channel) link(correlationID string, decision approvals.Decision, deadline time.Time) string {
+	expires := deadline.Unix()
+	query := url.Values{
+		"id":       {correlationID},
+		"decision": {string(decision)},
+		"expires":  {strconv.FormatInt(expires, 10)},
+		"sig":      {c.sign(correlationID, decision, expires)},
+	}
+	return c.opts.PublicURL + Path + "?" + query.Encode()
+}
+
+func (c *Channel) sign(correlationID string, decision approvals.Decision, expires int64) string {
+	mac := hmac.New(sha256.New, c.opts.Secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d", correlationID, decision, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Channel) valid(correlationID string, decision approvals.Decision, expires int64, signature string) bool {
+	if correlationID == "" || (decision != approvals.DecisionApprove && decision != approvals.DecisionDeny) {
+		return false
+	}
+	if time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(c.sign(correlationID, decision, expires)), []byte(signature))
+}
+
+// mail sends a plain-text message to all approvers.
+func (c *Channel) mail(subject, messageID, inReplyTo, body string) error {
+	var auth smtp.Auth
+	if c.opts.Username != "" {
+		host, _, _ := net.SplitHostPort(c.opts.SMTPAddr)
+		auth = smtp.PlainAuth("", c.opts.Username, c.opts.Password, host)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "From: %s\r\n", c.opts.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(c.opts.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(buf, "Message-ID: %s\r\n", messageID)
+	if inReplyTo != "" {
+		fmt.Fprintf(buf, "In-Reply-To: %s\r\nReferences: %s\r\n", inReplyTo, inReplyTo)
+	}
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	if err := c.send(c.opts.SMTPAddr, auth, c.opts.From, c.opts.To, buf.Bytes()); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+type pageData struct {
+	Messages i18n.Messages
+	Form     url.Values
+	Decision approvals.Decision
+	Confirm  bool
+	Note     string
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>{{ .Messages.ApprovalTitle }}</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto">
+<h2>{{ .Messages.ApprovalTitle }}</h2>
+{{ if .Confirm }}<p><code>{{ .Form.Get "id" }}</code></p>
+<form method="post">
+{{ range $key, $values := .Form }}{{ range $values }}<input type="hidden" name="{{ $key }}" value="{{ . }}">{{ end }}{{ end }}
+{{ if eq .Decision "deny" }}<p><textarea name="reason" rows="4" cols="50" maxlength="500" placeholder="{{ .Messages.DenyPlaceholder }}"></textarea></p>
+<button type="submit">{{ .Messages.DenyButton }}</button>{{ else }}<button type="submit">{{ .Messages.ApproveButton }}</button>{{ end }}
+</form>{{ else }}<p>{{ .Note }}</p>{{ end }}
+</body></html>
+`))
+
+func (c *Channel) page(w http.ResponseWriter, status int, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	if err := pageTemplate.Execute(w, data); err != nil {
+		c.log.Error("Failed to render email decision page", "error", err)
+	}
+}
+
+// render formats the approval as plain text.
+func render(msg i18n.Messages, req approvals.Request) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "%s\n\n", msg.ApprovalTitle)
+	fmt.Fprintf(builder, "%s: %s\n", msg.ApprovalTool, req.Tool)
+	fmt.Fprintf(builder, "%s: %s\n", msg.ApprovalCorrelation, req.CorrelationID)
+	if value := strings.TrimSpace(req.RequestedBy); value != "" {
+		fmt.Fprintf(builder, "%s: %s\n", msg.RequestedByLabel, value)
+	}
+	if value := strings.TrimSpace(req.SessionID); value != "" {
+		fmt.Fprintf(builder, "%s: %s\n", msg.SessionLabel, value)
+	}
+	if note := channel.ExecuteAfterNote(msg, req); note != "" {
+		fmt.Fprintf(builder, "\n%s\n", note)
+	}
+	if value := strings.TrimSpace(req.Justification); value != "" {
+		fmt.Fprintf(builder, "\n%s: %s\n", msg.JustificationLabel, value)
+	}
+	if req.Sensitive {
+		fmt.Fprintf(builder, "%s: %s\n\n%s\n", msg.FingerprintLabel, req.Fingerprint, msg.SensitiveNote)
+		return builder.String()
+	}
+	if value := strings.TrimSpace(req.ApprovalRequest); value != "" {
+		fmt.Fprintf(builder, "\n%s\n%s\n", msg.SectionAction, value)
+	}
+	if value := strings.TrimSpace(req.RiskAssessment); value != "" {
+		fmt.Fprintf(builder, "\n%s\n%s\n", msg.SectionRisks, value)
+	}
+	if len(req.Arguments) > 0 {
+		if data, err := json.MarshalIndent(req.Arguments, "", "  "); err == nil {
+			fmt.Fprintf(builder, "\n%s\n%s\n", msg.SectionParams, data)
+		}
+	}
+	if len(req.LinksToCode) > 0 {
+		fmt.Fprintf(builder, "\n%s:\n", msg.LinksLabel)
+		for _, link := range req.LinksToCode {
+			fmt.Fprintf(builder, "- %s: %s\n", link.Text, link.URL)
+		}
+	}
+	return builder.String()
+}
+
+func newMessageID(from string) string {
+	domain := "telegram-approver"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain)
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,112 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// Options configures the annotator.
+type Options struct {
+	// URL is the Grafana base URL.
+	URL string
+	// Token is a Grafana service account token.
+	Token string
+	// Tools are tool names or glob patterns whose decisions are annotated.
+	Tools []string
+	// Tags are added to every annotation.
+	Tags []string
+}
+
+// Annotator pushes approve/deny decisions to the Grafana annotations API.
+// A nil Annotator does nothing.
+type Annotator struct {
+	client *http.Client
+	url    string
+	token  string
+	tools  []string
+	tags   []string
+	log    *slog.Logger
+}
+
+// annotation is the Grafana POST /api/annotations payload.
+type annotation struct {
+	Time int64    `json:"time"`
+	Tags []string `json:"tags"`
+	Text string   `json:"text"`
+}
+
+// New creates an annotator; it returns nil when no Grafana URL is configured.
+func New(opts Options, log *slog.Logger) *Annotator {
+	if strings.TrimSpace(opts.URL) == "" {
+		return nil
+	}
+	return &Annotator{
+		client: &http.Client{Timeout: 5 * time.Second},
+		url:    strings.TrimRight(opts.URL, "/") + "/api/annotations",
+		token:  opts.Token,
+		tools:  opts.Tools,
+		tags:   opts.Tags,
+		log:    log,
+	}
+}
+
+// Annotate records the decision if it is approve/deny for a matching tool.
+func (a *Annotator) Annotate(ctx context.Context, approval *approvals.Approval, result approvals.Result) {
+	if a == nil || approval == nil {
+		return
+	}
+	if result.Decision != approvals.DecisionApprove && result.Decision != approvals.DecisionDeny {
+		return
+	}
+	if !a.matches(approval.Request.Tool) {
+		return
+	}
+	tags := append([]string{}, a.tags...)
+	tags = append(tags, "tool:"+approval.Request.Tool, "decision:"+string(result.Decision))
+	if approval.Request.Tenant != "" {
+		tags = append(tags, "tenant:"+approval.Request.Tenant)
+	}
+	text := fmt.Sprintf("%s %s (%s)", approval.Request.Tool, result.Decision, approval.Request.CorrelationID)
+	if result.Reason != "" {
+		text += ": " + result.Reason
+	}
+	body, err := json.Marshal(annotation{Time: time.Now().UnixMilli(), Tags: tags, Text: text})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.log.Error("Grafana annotation failed", "error", err, "correlation_id", approval.Request.CorrelationID)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		a.log.Error("Grafana annotation rejected", "status", resp.StatusCode, "correlation_id", approval.Request.CorrelationID)
+	}
+}
+
+func (a *Annotator) matches(tool string) bool {
+	for _, pattern := range a.tools {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+	}
+	return false
+}
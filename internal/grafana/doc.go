@@ -0,0 +1,2 @@
+// Package grafana pushes approval decisions to Grafana as annotations.
+package grafana
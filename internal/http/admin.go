@@ -0,0 +1,414 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/audit"
+	"github.com/codex-k8s/telegram-approver/internal/journal"
+	"github.com/codex-k8s/telegram-approver/internal/telegram"
+)
+
+// DecisionCacheHandler exposes the decision cache for inspection and flushing.
+type DecisionCacheHandler struct {
+	cache *approvals.DecisionCache
+}
+
+// NewDecisionCacheHandler creates a decision cache admin handler.
+func NewDecisionCacheHandler(cache *approvals.DecisionCache) *DecisionCacheHandler {
+	return &DecisionCacheHandler{cache: cache}
+}
+
+// ServeHTTP handles /admin/decision-cache requests.
+func (h *DecisionCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries := h.cache.List()
+		if entries == nil {
+			entries = []approvals.CachedDecision{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	case http.MethodDelete:
+		fingerprint := strings.TrimSpace(r.URL.Query().Get("fingerprint"))
+		if fingerprint == "" {
+			h.cache.Clear()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !h.cache.Forget(fingerprint) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ApprovalHandler returns the full payload of a pending approval, including masked arguments.
+type ApprovalHandler struct {
+	svc *telegram.Service
+}
+
+// NewApprovalHandler creates a pending approval admin handler.
+func NewApprovalHandler(svc *telegram.Service) *ApprovalHandler {
+	return &ApprovalHandler{svc: svc}
+}
+
+// ServeHTTP handles GET /admin/approvals/{correlation_id} requests.
+func (h *ApprovalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	approval := h.svc.Approval(r.PathValue("correlation_id"))
+	if approval == nil {
+		writeError(w, http.StatusNotFound, "approval not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, approval)
+}
+
+// TransferHandler moves a pending approval to another configured chat.
+type TransferHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewTransferHandler creates a transfer admin handler.
+func NewTransferHandler(svc *telegram.Service, log *slog.Logger) *TransferHandler {
+	return &TransferHandler{svc: svc, log: log}
+}
+
+// TransferRequest defines input payload for approval transfer.
+type TransferRequest struct {
+	// Chat is a chat name from the config file or a numeric chat ID.
+	Chat string `json:"chat"`
+}
+
+// ServeHTTP handles POST /admin/approvals/{correlation_id}/transfer requests.
+func (h *TransferHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	correlationID := r.PathValue("correlation_id")
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	chatID, err := h.svc.ResolveChat(req.Chat)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "chat must be a configured chat name or id")
+		return
+	}
+	err = h.svc.TransferApproval(r.Context(), correlationID, chatID)
+	switch status := errorStatus(err, 0); {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]any{"correlation_id": correlationID, "chat_id": chatID})
+	case status != 0:
+		writeError(w, status, err.Error())
+	default:
+		h.log.Error("Approval transfer failed", "error", err, "correlation_id", correlationID)
+		writeError(w, http.StatusBadGateway, "failed to repost approval")
+	}
+}
+
+// ForceHandler resolves a stuck approval through the admin API.
+type ForceHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewForceHandler creates a force-resolve admin handler.
+func NewForceHandler(svc *telegram.Service, log *slog.Logger) *ForceHandler {
+	return &ForceHandler{svc: svc, log: log}
+}
+
+// ForceRequest defines input payload for force-resolving an approval.
+type ForceRequest struct {
+	// Decision is approve or deny.
+	Decision string `json:"decision"`
+	// Reason is sent in the callback.
+	Reason string `json:"reason"`
+	// Actor names the administrator; it is shown in the chat and kept in history.
+	Actor string `json:"actor"`
+}
+
+// ServeHTTP handles POST /admin/approvals/{correlation_id}/force requests.
+func (h *ForceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	correlationID := r.PathValue("correlation_id")
+	var req ForceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	decision := approvals.Decision(strings.ToLower(strings.TrimSpace(req.Decision)))
+	if decision != approvals.DecisionApprove && decision != approvals.DecisionDeny {
+		writeError(w, http.StatusBadRequest, "decision must be approve or deny")
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		actor = "admin"
+	}
+	err := h.svc.ForceResolve(r.Context(), correlationID, approvals.Result{
+		Decision: decision,
+		Reason:   strings.TrimSpace(req.Reason),
+		ForcedBy: actor,
+	})
+	if err != nil {
+		writeError(w, errorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"correlation_id": correlationID, "decision": decision})
+}
+
+// CleanupHandler deletes resolved approval messages from the chat.
+type CleanupHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewCleanupHandler creates a cleanup admin handler.
+func NewCleanupHandler(svc *telegram.Service, log *slog.Logger) *CleanupHandler {
+	return &CleanupHandler{svc: svc, log: log}
+}
+
+// ServeHTTP handles POST /admin/cleanup?older_than=24h requests.
+func (h *CleanupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	olderThan := 24 * time.Hour
+	if raw := strings.TrimSpace(r.URL.Query().Get("older_than")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, "older_than must be a non-negative duration")
+			return
+		}
+		olderThan = parsed
+	}
+	result, err := h.svc.Cleanup(r.Context(), olderThan)
+	if err != nil {
+		h.log.Error("Cleanup interrupted", "error", err)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// PurgeHandler removes stored data of resolved approvals on request.
+type PurgeHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewPurgeHandler creates a purge admin handler.
+func NewPurgeHandler(svc *telegram.Service, log *slog.Logger) *PurgeHandler {
+	return &PurgeHandler{svc: svc, log: log}
+}
+
+// PurgeRequest selects resolved approvals to purge; set fields are combined.
+type PurgeRequest struct {
+	// CorrelationID selects a single approval.
+	CorrelationID string `json:"correlation_id"`
+	// SessionID selects approvals of an agent run.
+	SessionID string `json:"session_id"`
+	// From selects approvals resolved at or after this time.
+	From time.Time `json:"from"`
+	// To selects approvals resolved before this time.
+	To time.Time `json:"to"`
+	// DeleteMessages also removes the approval messages from Telegram.
+	DeleteMessages bool `json:"delete_messages"`
+}
+
+// ServeHTTP handles POST /admin/purge requests.
+func (h *PurgeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	filter := approvals.PurgeFilter{
+		CorrelationID: strings.TrimSpace(req.CorrelationID),
+		SessionID:     strings.TrimSpace(req.SessionID),
+		From:          req.From,
+		To:            req.To,
+	}
+	if filter.Empty() {
+		writeError(w, http.StatusBadRequest, "correlation_id, session_id, from, or to is required")
+		return
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	result := h.svc.Purge(r.Context(), filter, req.DeleteMessages)
+	h.log.Info("Purged resolved approvals", "count", result.Purged, "messages_deleted", result.MessagesDeleted)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// maxSnapshotSize bounds the body of a snapshot import.
+const maxSnapshotSize = 64 << 20
+
+// SnapshotHandler exports pending approvals and imports them into another instance.
+type SnapshotHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewSnapshotHandler creates a snapshot admin handler.
+func NewSnapshotHandler(svc *telegram.Service, log *slog.Logger) *SnapshotHandler {
+	return &SnapshotHandler{svc: svc, log: log}
+}
+
+// ServeHTTP handles GET and POST /admin/snapshot requests.
+func (h *SnapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.svc.ExportSnapshot())
+	case http.MethodPost:
+		var snapshot approvals.Snapshot
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSnapshotSize)).Decode(&snapshot); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json payload")
+			return
+		}
+		result, err := h.svc.ImportSnapshot(r.Context(), snapshot)
+		if errors.Is(err, approvals.ErrInvalidSnapshot) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			h.log.Error("Snapshot import failed", "error", err, "imported", len(result.Imported))
+			writeError(w, errorStatus(err, http.StatusInternalServerError), "failed to import snapshot")
+			return
+		}
+		if result.Skipped == nil {
+			result.Skipped = []string{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"imported": len(result.Imported), "skipped": result.Skipped})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// PromoteHandler activates a standby instance, e.g. to hand over before an upgrade.
+type PromoteHandler struct {
+	svc *telegram.Service
+	log *slog.Logger
+}
+
+// NewPromoteHandler creates a standby promotion admin handler.
+func NewPromoteHandler(svc *telegram.Service, log *slog.Logger) *PromoteHandler {
+	return &PromoteHandler{svc: svc, log: log}
+}
+
+// ServeHTTP handles POST /admin/promote requests.
+func (h *PromoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	promoted, err := h.svc.Promote(r.Context())
+	if err != nil {
+		h.log.Error("Standby promotion failed", "error", err)
+		writeError(w, http.StatusBadGateway, "failed to promote instance")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"active": true, "promoted": promoted})
+}
+
+// JournalHandler exposes recorded Telegram updates and Bot API calls.
+type JournalHandler struct {
+	journal *journal.Journal
+}
+
+// NewJournalHandler creates a journal admin handler.
+func NewJournalHandler(journal *journal.Journal) *JournalHandler {
+	return &JournalHandler{journal: journal}
+}
+
+// ServeHTTP handles GET /admin/journal?since=0&correlation_id=req-123&limit=100 requests.
+func (h *JournalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	var since uint64
+	if raw := strings.TrimSpace(query.Get("since")); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be a sequence number")
+			return
+		}
+		since = parsed
+	}
+	limit := 0
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = parsed
+	}
+	entries := h.journal.List(since, strings.TrimSpace(query.Get("correlation_id")), limit)
+	if entries == nil {
+		entries = []journal.Entry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// AuditVerifyHandler checks the hash chain of the audit file.
+type AuditVerifyHandler struct {
+	audit *audit.Log
+}
+
+// NewAuditVerifyHandler creates an audit verification handler.
+func NewAuditVerifyHandler(trail *audit.Log) *AuditVerifyHandler {
+	return &AuditVerifyHandler{audit: trail}
+}
+
+// ServeHTTP handles GET /admin/audit/verify requests.
+func (h *AuditVerifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	records, err := h.audit.Verify()
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"valid": false, "records": records, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"valid": true, "records": records})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/telegram"
+)
+
+// sessionCancelledReason is reported in callbacks of approvals cancelled with their session.
+const sessionCancelledReason = "session cancelled"
+
+// ApprovalsHandler lists pending approvals.
+type ApprovalsHandler struct {
+	registry *approvals.Registry
+	cfg      config.Config
+}
+
+// NewApprovalsHandler creates a pending approvals handler.
+func NewApprovalsHandler(registry *approvals.Registry, cfg config.Config) *ApprovalsHandler {
+	return &ApprovalsHandler{registry: registry, cfg: cfg}
+}
+
+// PendingApproval describes a pending approval in list responses.
+type PendingApproval struct {
+	CorrelationID string    `json:"correlation_id"`
+	Tool          string    `json:"tool"`
+	SessionID     string    `json:"session_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	Deadline      time.Time `json:"deadline"`
+	RemainingSec  int64     `json:"remaining_sec"`
+}
+
+// ServeHTTP handles GET /approvals requests.
+// The optional session_id query parameter limits the list to one agent session. Approvals of tenants with an API
+// token are listed only when the same bearer token is presented.
+func (h *ApprovalsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	session := strings.TrimSpace(r.URL.Query().Get("session_id"))
+	now := time.Now()
+	pending := h.registry.List()
+	tenants := h.cfg.CurrentFile().Tenants
+	items := make([]PendingApproval, 0, len(pending))
+	for _, approval := range pending {
+		if session != "" && approval.Request.SessionID != session {
+			continue
+		}
+		if !tenantAllows(r, tenants, approval.Request.Tenant) {
+			continue
+		}
+		remaining := approval.Deadline.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		items = append(items, PendingApproval{
+			CorrelationID: approval.Request.CorrelationID,
+			Tool:          approval.Request.Tool,
+			SessionID:     approval.Request.SessionID,
+			CreatedAt:     approval.CreatedAt,
+			Deadline:      approval.Deadline,
+			RemainingSec:  int64(remaining / time.Second),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"approvals": items})
+}
+
+// CancelHandler withdraws pending approvals on behalf of the requester.
+type CancelHandler struct {
+	svc *telegram.Service
+	cfg config.Config
+}
+
+// NewCancelHandler creates an approval cancel handler.
+func NewCancelHandler(svc *telegram.Service, cfg config.Config) *CancelHandler {
+	return &CancelHandler{svc: svc, cfg: cfg}
+}
+
+// ServeHTTP handles DELETE /approvals/{correlation_id} requests.
+func (h *CancelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	correlationID := r.PathValue("correlation_id")
+	approval := h.svc.Approval(correlationID)
+	if approval == nil {
+		err := h.svc.NotPending(correlationID)
+		writeError(w, errorStatus(err, http.StatusNotFound), err.Error())
+		return
+	}
+	if tenant, ok := h.cfg.CurrentFile().Tenants[approval.Request.Tenant]; ok && tenant.Token != "" && !bearerMatches(r, tenant.Token) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.CancelApproval(r.Context(), correlationID); err != nil {
+		if status := errorStatus(err, 0); status != 0 {
+			writeError(w, status, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "cancel failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SessionCancelHandler cancels every pending approval of an agent session.
+type SessionCancelHandler struct {
+	svc *telegram.Service
+	cfg config.Config
+}
+
+// NewSessionCancelHandler creates a session cancel handler.
+func NewSessionCancelHandler(svc *telegram.Service, cfg config.Config) *SessionCancelHandler {
+	return &SessionCancelHandler{svc: svc, cfg: cfg}
+}
+
+// ServeHTTP handles POST /sessions/{session_id}/cancel requests.
+// Approvals of tenants with an API token are cancelled only when the same bearer token is presented.
+func (h *SessionCancelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID := strings.TrimSpace(r.PathValue("session_id"))
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session_id is required")
+		return
+	}
+	tenants := h.cfg.CurrentFile().Tenants
+	cancelled := h.svc.CancelSession(r.Context(), sessionID, sessionCancelledReason, func(req approvals.Request) bool {
+		return tenantAllows(r, tenants, req.Tenant)
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"session_id": sessionID, "cancelled": cancelled})
+}
+
+// tenantAllows reports whether r may see or change approvals of the tenant: tenants with an API token are limited
+// to callers presenting the same bearer token.
+func tenantAllows(r *http.Request, tenants map[string]config.Tenant, name string) bool {
+	tenant, ok := tenants[name]
+	return !ok || tenant.Token == "" || bearerMatches(r, tenant.Token)
+}
@@ -2,49 +2,150 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/callback"
 	"github.com/codex-k8s/telegram-approver/internal/config"
 	"github.com/codex-k8s/telegram-approver/internal/telegram"
+	"github.com/codex-k8s/telegram-approver/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// maxRequiredApprovals bounds quorum size.
+	maxRequiredApprovals = 10
+	// maxNoteLength bounds per-request timeout and reminder texts.
+	maxNoteLength = 300
+	// maxHeadline bounds the text rendered from a tool profile template.
+	maxHeadline = 500
+	// maxAttachments bounds files attached to one request.
+	maxAttachments = 5
+	// maxAttachmentSize bounds inline attachments; Telegram accepts bot uploads up to 50 MB.
+	maxAttachmentSize = 10 << 20
+	// maxDiffSize bounds the diff of a request; the part that does not fit the message is attached as a document.
+	maxDiffSize = 1 << 20
+	// maxAttachmentCaption is the Telegram caption limit.
+	maxAttachmentCaption = 1024
+	// maxFieldsBody bounds the part of the /approve body other than diffs and attachments.
+	maxFieldsBody = 1 << 20
+	// maxAttachmentsBody is the size of the largest attachments, each sent inline as base64.
+	maxAttachmentsBody = maxAttachments * ((maxAttachmentSize + 2) / 3 * 4)
+	// maxDiffBody is the size of the largest diff with every byte escaped as \u00XX in JSON.
+	maxDiffBody = 6 * maxDiffSize
+	// maxApproveBody is the largest /approve body accepted.
+	maxApproveBody = maxFieldsBody + maxAttachmentsBody + maxDiffBody
+	// modeAsync returns right after the message is posted; the decision goes to the callback.
+	modeAsync = "async"
+	// modeSync holds the request until the decision is made.
+	modeSync = "sync"
+	// syncGrace covers the time the timeout handler needs to finalize an expired approval.
+	syncGrace = 5 * time.Second
+)
+
+// codeLanguagePattern limits language hints to tags that are safe inside a code fence.
+var codeLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+
 // ApproveHandler handles approval requests from yaml-mcp-server.
 type ApproveHandler struct {
-	svc *telegram.Service
-	cfg config.Config
-	log *slog.Logger
+	svc     *telegram.Service
+	cfg     config.Config
+	limiter *rateLimiter
+	log     *slog.Logger
 }
 
 // NewApproveHandler creates a new approval handler.
 func NewApproveHandler(svc *telegram.Service, cfg config.Config, log *slog.Logger) *ApproveHandler {
-	return &ApproveHandler{svc: svc, cfg: cfg, log: log}
+	return &ApproveHandler{svc: svc, cfg: cfg, limiter: newRateLimiter(cfg), log: log}
 }
 
 // ApproveRequest defines input payload for /approve.
 type ApproveRequest struct {
-	CorrelationID   string              `json:"correlation_id"`
-	Tool            string              `json:"tool"`
-	Arguments       map[string]any      `json:"arguments"`
-	Justification   string              `json:"justification,omitempty"`
-	ApprovalRequest string              `json:"approval_request,omitempty"`
-	RiskAssessment  string              `json:"risk_assessment,omitempty"`
-	LinksToCode     []approvals.Link    `json:"links_to_code,omitempty"`
-	Lang            string              `json:"lang,omitempty"`
-	Markup          string              `json:"markup,omitempty"`
-	Callback        *approvals.Callback `json:"callback,omitempty"`
-	TimeoutSec      int                 `json:"timeout_sec,omitempty"`
+	CorrelationID     string              `json:"correlation_id"`
+	Tool              string              `json:"tool"`
+	Arguments         map[string]any      `json:"arguments"`
+	Justification     string              `json:"justification,omitempty"`
+	ApprovalRequest   string              `json:"approval_request,omitempty"`
+	RiskAssessment    string              `json:"risk_assessment,omitempty"`
+	LinksToCode       []approvals.Link    `json:"links_to_code,omitempty"`
+	Attachments       []AttachmentRequest `json:"attachments,omitempty"`
+	Diff              string              `json:"diff,omitempty"`
+	MessageThreadID   int                 `json:"message_thread_id,omitempty"`
+	Lang              string              `json:"lang,omitempty"`
+	Markup            string              `json:"markup,omitempty"`
+	Callback          *approvals.Callback `json:"callback,omitempty"`
+	TimeoutSec        int                 `json:"timeout_sec,omitempty"`
+	Tenant            string              `json:"tenant,omitempty"`
+	RequestedBy       string              `json:"requested_by,omitempty"`
+	Target            string              `json:"target,omitempty"`
+	ArgumentLanguages map[string]string   `json:"argument_languages,omitempty"`
+	Team              string              `json:"team,omitempty"`
+	SessionID         string              `json:"session_id,omitempty"`
+	WorkflowID        string              `json:"workflow_id,omitempty"`
+	ExecuteAfter      string              `json:"execute_after,omitempty"`
+	TaskSummary       string              `json:"task_summary,omitempty"`
+	RequiredApprovals int                 `json:"required_approvals,omitempty"`
+	Approvers         []int64             `json:"approvers,omitempty"`
+	Critical          bool                `json:"critical,omitempty"`
+	Severity          string              `json:"severity,omitempty"`
+	RiskLevel         string              `json:"risk_level,omitempty"`
+	Keyboard          string              `json:"keyboard,omitempty"`
+	Escalation        *EscalationRequest  `json:"escalation,omitempty"`
+	NotifyURL         string              `json:"notify_url,omitempty"`
+	Mode              string              `json:"mode,omitempty"`
+	DryRun            bool                `json:"dry_run,omitempty"`
+	Channel           string              `json:"channel,omitempty"`
+	TimeoutMessage    string              `json:"timeout_message,omitempty"`
+	OnTimeout         string              `json:"on_timeout,omitempty"`
+	ReminderMessage   string              `json:"reminder_message,omitempty"`
+}
+
+// EscalationRequest overrides escalation settings for a single approval.
+type EscalationRequest struct {
+	// Chat is a chat name from the config file or a numeric chat ID.
+	Chat string `json:"chat,omitempty"`
+	// AfterSec is how many seconds after submission the approval is escalated.
+	AfterSec int `json:"after_sec,omitempty"`
+	// Mentions are Telegram usernames to mention.
+	Mentions []string `json:"mentions,omitempty"`
+	// Disabled turns escalation off.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// AttachmentRequest is a file attached to the approval message, given inline as base64 or by URL.
+type AttachmentRequest struct {
+	Name    string `json:"name"`
+	Data    []byte `json:"data,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Caption string `json:"caption,omitempty"`
 }
 
 // ApproveResponse defines output payload for /approve.
 type ApproveResponse struct {
-	Decision      string `json:"decision"`
-	Reason        string `json:"reason,omitempty"`
-	CorrelationID string `json:"correlation_id,omitempty"`
+	Decision      string             `json:"decision"`
+	Reason        string             `json:"reason,omitempty"`
+	ReasonCode    string             `json:"reason_code,omitempty"`
+	Error         *approvals.Failure `json:"error,omitempty"`
+	CorrelationID string             `json:"correlation_id,omitempty"`
+	Fingerprint   string             `json:"fingerprint,omitempty"`
+	// TooLong lists section sizes when the rendered message exceeds the Telegram limit.
+	TooLong *telegram.MessageTooLongError `json:"message_too_long,omitempty"`
+	// DeepLink opens the pending approval in a private chat with the bot; it works once.
+	DeepLink string `json:"deep_link,omitempty"`
+	// AttachedTo is the pending approval an identical request was attached to instead of being posted.
+	AttachedTo string `json:"attached_to,omitempty"`
 }
 
 // ServeHTTP handles /approve requests.
@@ -53,9 +154,15 @@ func (h *ApproveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "approve", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
 	var req ApproveRequest
-	decoder := json.NewDecoder(r.Body)
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApproveBody))
 	if err := decoder.Decode(&req); err != nil {
+		if bodyTooLarge(err) {
+			h.respond(w, http.StatusRequestEntityTooLarge, approvals.DecisionError, fmt.Sprintf("request body must be at most %d bytes", maxApproveBody))
+			return
+		}
 		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "invalid json payload")
 		return
 	}
@@ -94,6 +201,62 @@ func (h *ApproveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error())
 		return
 	}
+	if len([]rune(req.TaskSummary)) > 200 {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "task_summary must be at most 200 characters")
+		return
+	}
+	for name, language := range req.ArgumentLanguages {
+		if !codeLanguagePattern.MatchString(language) {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, fmt.Sprintf("argument_languages.%s must be a short language tag", name))
+			return
+		}
+	}
+	severity, reason := requestSeverity(req)
+	if reason != "" {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, reason, req.CorrelationID)
+		return
+	}
+	req.Severity = string(severity)
+	req.OnTimeout = strings.ToLower(strings.TrimSpace(req.OnTimeout))
+	if !config.ValidOnTimeout(req.OnTimeout) {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "on_timeout must be approve, deny or error", req.CorrelationID)
+		return
+	}
+	file := h.cfg.CurrentFile()
+	profileName, profile, hasProfile := file.Profile(req.Tool)
+	if hasProfile {
+		if reason := applyProfile(&req, profile); reason != "" {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, reason, req.CorrelationID)
+			return
+		}
+	}
+	if req.RequiredApprovals < 0 || req.RequiredApprovals > maxRequiredApprovals {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, fmt.Sprintf("required_approvals must be between 0 and %d", maxRequiredApprovals))
+		return
+	}
+	if len(req.Approvers) > 0 && len(req.Approvers) < req.RequiredApprovals {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "approvers must include at least required_approvals users")
+		return
+	}
+	req.TimeoutMessage = strings.TrimSpace(req.TimeoutMessage)
+	req.ReminderMessage = strings.TrimSpace(req.ReminderMessage)
+	if err := validateNoteLength("timeout_message", req.TimeoutMessage); err != nil {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error())
+		return
+	}
+	if err := validateNoteLength("reminder_message", req.ReminderMessage); err != nil {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error())
+		return
+	}
+	var executeAfter time.Time
+	if raw := strings.TrimSpace(req.ExecuteAfter); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "execute_after must be an RFC 3339 timestamp")
+			return
+		}
+		executeAfter = parsed
+	}
 	if len(req.LinksToCode) > 5 {
 		req.LinksToCode = req.LinksToCode[:5]
 	}
@@ -103,6 +266,19 @@ func (h *ApproveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if len(req.Diff) > maxDiffSize {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, fmt.Sprintf("diff must be at most %d bytes", maxDiffSize))
+		return
+	}
+	if req.MessageThreadID < 0 {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "message_thread_id must not be negative")
+		return
+	}
+	attachments, err := validateAttachments(req.Attachments)
+	if err != nil {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error())
+		return
+	}
 	if strings.TrimSpace(req.Markup) == "" {
 		req.Markup = "markdown"
 	}
@@ -112,55 +288,452 @@ func (h *ApproveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "markup must be markdown or html")
 		return
 	}
+	req.Tenant = strings.TrimSpace(req.Tenant)
+	req.RequestedBy = strings.TrimSpace(req.RequestedBy)
+	if status, reason := h.authorizeTenant(r, &req); status != 0 {
+		h.respond(w, status, approvals.DecisionError, reason, req.CorrelationID)
+		return
+	}
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		target = strings.TrimSpace(req.Team)
+	}
+	req.Channel = strings.ToLower(strings.TrimSpace(req.Channel))
+	if req.Channel == "" {
+		req.Channel = h.cfg.Channel
+	}
+	if !h.svc.HasChannel(req.Channel) {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "unknown channel", req.CorrelationID)
+		return
+	}
+	if strings.TrimSpace(req.Keyboard) != "" {
+		if _, err := config.ParseKeyboard(req.Keyboard); err != nil {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error(), req.CorrelationID)
+			return
+		}
+	}
+	channelName := ""
+	if req.Channel != config.ChannelTelegram {
+		// Routing, quorum, and escalation are Telegram features.
+		channelName = req.Channel
+		if req.RequiredApprovals > 1 {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "required_approvals is supported only in telegram", req.CorrelationID)
+			return
+		}
+		if req.Critical {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "critical is supported only in telegram", req.CorrelationID)
+			return
+		}
+		if strings.TrimSpace(req.Keyboard) != "" {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "keyboard is supported only in telegram", req.CorrelationID)
+			return
+		}
+		if len(attachments) > 0 {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "attachments are supported only in telegram", req.CorrelationID)
+			return
+		}
+		if strings.TrimSpace(req.Diff) != "" {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "diff is supported only in telegram", req.CorrelationID)
+			return
+		}
+		if req.MessageThreadID > 0 {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "message_thread_id is supported only in telegram", req.CorrelationID)
+			return
+		}
+	} else if _, ok := h.svc.RouteChat(target); !ok {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "unknown target", req.CorrelationID)
+		return
+	}
 	if strings.TrimSpace(req.Lang) == "" {
 		req.Lang = h.cfg.Lang
+		if channelName == "" {
+			req.Lang = h.svc.RouteLang(target)
+		}
+	}
+	req.Mode = strings.ToLower(strings.TrimSpace(req.Mode))
+	switch req.Mode {
+	case "":
+		req.Mode = modeAsync
+	case modeAsync, modeSync:
+	default:
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "mode must be async or sync", req.CorrelationID)
+		return
+	}
+	if req.Callback == nil {
+		// Without a callback the decision is fetched from GET /approvals/{correlation_id}/wait.
+		req.Callback = &approvals.Callback{}
+	}
+	req.Callback.Format = strings.ToLower(strings.TrimSpace(req.Callback.Format))
+	switch req.Callback.Format {
+	case "", config.CallbackFormatJSON, config.CallbackFormatCloudEvents:
+	default:
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "callback.format must be json or cloudevents", req.CorrelationID)
+		return
 	}
-	if req.Callback == nil || strings.TrimSpace(req.Callback.URL) == "" {
-		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "callback.url is required for async approval")
+	if strings.TrimSpace(req.Callback.Template) != "" {
+		if _, err := callback.ParseTemplate("request", req.Callback.Template); err != nil {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "invalid callback.template: "+err.Error(), req.CorrelationID)
+			return
+		}
+	}
+	if _, err := callback.ParseHeaders(req.Callback.Headers); err != nil {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, err.Error(), req.CorrelationID)
 		return
 	}
+	req.NotifyURL = strings.TrimSpace(req.NotifyURL)
+	if req.NotifyURL != "" {
+		if u, err := url.Parse(req.NotifyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			h.respond(w, http.StatusBadRequest, approvals.DecisionError, "notify_url must be an absolute http(s) url")
+			return
+		}
+	}
 
+	severity = approvals.Severity(req.Severity)
+	if req.OnTimeout == "" {
+		req.OnTimeout = file.SeverityOnTimeout(severity)
+	}
 	timeout := h.cfg.ApprovalTimeout
 	if req.TimeoutSec > 0 {
 		timeout = time.Duration(req.TimeoutSec) * time.Second
+	} else if byClass := file.SeverityTimeout(severity); byClass > 0 {
+		timeout = byClass
+	}
+	escalation, reason := h.escalation(req.Escalation)
+	if reason != "" {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, reason, req.CorrelationID)
+		return
 	}
 
-	ctx := r.Context()
-	res, err := h.svc.SubmitApproval(ctx, approvals.Request{
-		CorrelationID:   req.CorrelationID,
-		Tool:            req.Tool,
-		Arguments:       req.Arguments,
-		Justification:   req.Justification,
-		ApprovalRequest: req.ApprovalRequest,
-		RiskAssessment:  req.RiskAssessment,
-		LinksToCode:     req.LinksToCode,
-		Lang:            req.Lang,
-		Markup:          req.Markup,
-		Callback:        *req.Callback,
-	}, timeout, h.cfg.TimeoutMessage)
+	fingerprint := approvals.Fingerprint(req.Tool, req.Arguments)
+	span.SetAttributes(
+		attribute.String("approval.correlation_id", req.CorrelationID),
+		attribute.String("approval.tool", req.Tool),
+		attribute.String("approval.tenant", req.Tenant),
+	)
+	request := approvals.Request{
+		CorrelationID:     req.CorrelationID,
+		Tool:              req.Tool,
+		Arguments:         req.Arguments,
+		Justification:     req.Justification,
+		ApprovalRequest:   req.ApprovalRequest,
+		RiskAssessment:    req.RiskAssessment,
+		LinksToCode:       req.LinksToCode,
+		Attachments:       attachments,
+		Diff:              req.Diff,
+		MessageThreadID:   req.MessageThreadID,
+		Lang:              req.Lang,
+		Markup:            req.Markup,
+		Callback:          *req.Callback,
+		NotifyURL:         req.NotifyURL,
+		Tenant:            req.Tenant,
+		RequestedBy:       req.RequestedBy,
+		Target:            target,
+		ArgumentLanguages: req.ArgumentLanguages,
+		SessionID:         strings.TrimSpace(req.SessionID),
+		WorkflowID:        strings.TrimSpace(req.WorkflowID),
+		ExecuteAfter:      executeAfter,
+		TaskSummary:       strings.TrimSpace(req.TaskSummary),
+		Fingerprint:       fingerprint,
+		TraceContext:      tracing.Capture(ctx),
+		RequiredApprovals: req.RequiredApprovals,
+		Approvers:         req.Approvers,
+		Critical:          req.Critical,
+		Severity:          severity,
+		Keyboard:          strings.TrimSpace(req.Keyboard),
+		Escalation:        escalation,
+		Channel:           channelName,
+		TimeoutMessage:    req.TimeoutMessage,
+		OnTimeout:         approvals.Decision(req.OnTimeout),
+		ReminderMessage:   req.ReminderMessage,
+	}
+	if hasProfile {
+		request.Emoji = profile.Emoji
+		request.Headline = h.headline(profileName, profile, request)
+	}
+	if req.DryRun {
+		h.dryRun(w, request, timeout)
+		return
+	}
+	if ok, retry := h.limiter.wait(r.Context(), rateSource(r, h.cfg.CurrentFile().Tenants)); !ok {
+		if retry > 0 {
+			h.log.Warn("Approval request rate limited", "correlation_id", req.CorrelationID, "tenant", req.Tenant, "requested_by", req.RequestedBy)
+			h.writeResponse(w, http.StatusTooManyRequests, ApproveResponse{
+				Decision:      string(approvals.DecisionError),
+				Reason:        "too many approval requests",
+				Error:         &approvals.Failure{Class: approvals.FailureRateLimited, Retryable: true, RetryAfterSec: int(math.Ceil(retry.Seconds()))},
+				CorrelationID: req.CorrelationID,
+			})
+		}
+		return
+	}
+	var decisions <-chan approvals.Result
+	if req.Mode == modeSync {
+		// Subscribe before submitting so an instant decision is not missed.
+		var stop func()
+		decisions, stop = h.svc.Wait(req.CorrelationID)
+		defer stop()
+	}
+	res, err := h.svc.SubmitApproval(ctx, request, timeout, h.cfg.TimeoutMessage)
+	if errors.Is(err, telegram.ErrStandby) || errors.Is(err, approvals.ErrAlreadyExists) {
+		h.respond(w, errorStatus(err, http.StatusInternalServerError), approvals.DecisionError, err.Error(), req.CorrelationID)
+		return
+	}
+	var tooLong *telegram.MessageTooLongError
+	if errors.As(err, &tooLong) {
+		h.writeResponse(w, http.StatusBadRequest, ApproveResponse{
+			Decision:      string(approvals.DecisionError),
+			Reason:        "approval message is too long",
+			ReasonCode:    res.ReasonCode,
+			CorrelationID: req.CorrelationID,
+			Fingerprint:   fingerprint,
+			TooLong:       tooLong,
+		})
+		return
+	}
 	if err != nil {
 		h.log.Error("Approval request failed", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "approval failed")
 		if res.Decision == "" {
 			h.respond(w, http.StatusInternalServerError, approvals.DecisionError, "approval failed")
 			return
 		}
 	}
 
-	h.respond(w, http.StatusAccepted, res.Decision, res.Reason, req.CorrelationID)
+	if req.Mode == modeSync && err == nil && res.Decision == approvals.DecisionPending {
+		select {
+		case result := <-decisions:
+			res = result
+		case <-time.After(timeout + syncGrace):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := http.StatusAccepted
+	if err != nil {
+		// Send failures keep the decision body with the failure class; the status tells whether to retry.
+		status = errorStatus(err, http.StatusBadGateway)
+	} else if res.Cached || (req.Mode == modeSync && res.Decision != approvals.DecisionPending) {
+		status = http.StatusOK
+	}
+	var link string
+	if err == nil && channelName == "" && res.Decision == approvals.DecisionPending {
+		// A duplicate request shares the message of the approval it is attached to.
+		linked := req.CorrelationID
+		if res.AttachedTo != "" {
+			linked = res.AttachedTo
+		}
+		if link, err = h.svc.DeepLink(ctx, linked); err != nil {
+			h.log.Warn("Failed to issue approval deep link", "error", err, "correlation_id", req.CorrelationID)
+		}
+	}
+	h.writeResponse(w, status, ApproveResponse{
+		Decision:      string(res.Decision),
+		Reason:        res.Reason,
+		ReasonCode:    res.ReasonCode,
+		Error:         res.Failure,
+		CorrelationID: req.CorrelationID,
+		Fingerprint:   fingerprint,
+		DeepLink:      link,
+		AttachedTo:    res.AttachedTo,
+	})
+}
+
+// dryRun reports where the approval would be posted without posting or registering it.
+func (h *ApproveHandler) dryRun(w http.ResponseWriter, req approvals.Request, timeout time.Duration) {
+	preview, err := h.svc.Preview(req, timeout)
+	if err != nil {
+		h.respond(w, http.StatusBadRequest, approvals.DecisionError, "unknown target", req.CorrelationID)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"dry_run":        true,
+		"correlation_id": req.CorrelationID,
+		"fingerprint":    req.Fingerprint,
+		"preview":        preview,
+	})
+}
+
+// headline renders the profile template of a request; a failing template falls back to approval_request.
+// The template is parsed per request so a reloaded profile applies at once; it was validated with the config file.
+func (h *ApproveHandler) headline(name string, profile config.ToolProfile, req approvals.Request) string {
+	tmpl, err := profile.ParseTemplate(name)
+	if err != nil || tmpl == nil {
+		return ""
+	}
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, req); err != nil {
+		h.log.Warn("Failed to render tool profile template", "error", err, "tool", req.Tool, "profile", name)
+		return ""
+	}
+	headline := strings.TrimSpace(builder.String())
+	if runes := []rune(headline); len(runes) > maxHeadline {
+		headline = string(runes[:maxHeadline-1]) + "…"
+	}
+	return headline
+}
+
+// applyProfile fills request defaults from a tool profile; a non-empty reason reports a request the profile forbids.
+func applyProfile(req *ApproveRequest, profile config.ToolProfile) string {
+	if req.TimeoutSec <= 0 && profile.Timeout > 0 {
+		req.TimeoutSec = int(profile.Timeout / time.Second)
+	}
+	if strings.TrimSpace(req.Target) == "" && strings.TrimSpace(req.Team) == "" {
+		req.Target = profile.Target
+	}
+	if req.OnTimeout == "" {
+		req.OnTimeout = profile.OnTimeout
+	}
+	if req.Severity == "" {
+		// Validated when the config file is loaded.
+		severity, _ := approvals.ParseSeverity(profile.Severity)
+		req.Severity = string(severity)
+	}
+	if len(profile.Approvers) == 0 {
+		return ""
+	}
+	if len(req.Approvers) == 0 {
+		req.Approvers = profile.Approvers
+		return ""
+	}
+	for _, id := range req.Approvers {
+		if !slices.Contains(profile.Approvers, id) {
+			return "approvers must be listed in the tool profile"
+		}
+	}
+	return ""
+}
+
+// requestSeverity parses severity or its alias risk_level; a non-empty reason reports invalid input.
+func requestSeverity(req ApproveRequest) (approvals.Severity, string) {
+	severity, err := approvals.ParseSeverity(req.Severity)
+	if err != nil {
+		return "", "severity must be low, medium, high or critical"
+	}
+	riskLevel, err := approvals.ParseSeverity(req.RiskLevel)
+	if err != nil {
+		return "", "risk_level must be low, medium, high or critical"
+	}
+	if severity != "" && riskLevel != "" && severity != riskLevel {
+		return "", "severity and risk_level must match when both are set"
+	}
+	if severity == "" {
+		severity = riskLevel
+	}
+	return severity, ""
+}
+
+// escalation converts per-request escalation overrides; a non-empty reason reports invalid input.
+func (h *ApproveHandler) escalation(req *EscalationRequest) (approvals.Escalation, string) {
+	var escalation approvals.Escalation
+	if req == nil {
+		return escalation, ""
+	}
+	escalation.Disabled = req.Disabled
+	if req.AfterSec < 0 {
+		return escalation, "escalation.after_sec must not be negative"
+	}
+	escalation.After = time.Duration(req.AfterSec) * time.Second
+	if strings.TrimSpace(req.Chat) != "" {
+		chatID, err := h.svc.ResolveChat(req.Chat)
+		if err != nil {
+			return escalation, "escalation.chat must be a configured chat name or id"
+		}
+		escalation.ChatID = chatID
+	}
+	for _, mention := range req.Mentions {
+		normalized, ok := config.NormalizeMention(mention)
+		if !ok {
+			return escalation, "escalation.mentions must be telegram usernames"
+		}
+		escalation.Mentions = append(escalation.Mentions, normalized)
+	}
+	return escalation, ""
+}
+
+// authorizeTenant checks the request against tenant API tokens.
+// A token binds the request to its tenant and, when configured, to an allowed set of requesters.
+func (h *ApproveHandler) authorizeTenant(r *http.Request, req *ApproveRequest) (int, string) {
+	tenants := h.cfg.CurrentFile().Tenants
+	name, authenticated := tenantForBearer(r, tenants)
+	if !authenticated {
+		if tenant, ok := tenants[req.Tenant]; ok && tenant.Token != "" {
+			return http.StatusUnauthorized, "tenant requires a valid api token"
+		}
+		return 0, ""
+	}
+	if req.Tenant == "" {
+		req.Tenant = name
+	}
+	if req.Tenant != name {
+		return http.StatusForbidden, "tenant does not match api token"
+	}
+	requesters := tenants[name].Requesters
+	if len(requesters) > 0 && !slices.Contains(requesters, req.RequestedBy) {
+		return http.StatusForbidden, "requested_by is not allowed for this api token"
+	}
+	return 0, ""
 }
 
 func (h *ApproveHandler) respond(w http.ResponseWriter, status int, decision approvals.Decision, reason string, correlationID ...string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
 	resp := ApproveResponse{Decision: string(decision), Reason: reason}
 	if len(correlationID) > 0 {
 		resp.CorrelationID = correlationID[0]
 	}
+	h.writeResponse(w, status, resp)
+}
+
+func (h *ApproveHandler) writeResponse(w http.ResponseWriter, status int, resp ApproveResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Error != nil && resp.Error.RetryAfterSec > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(resp.Error.RetryAfterSec))
+	}
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		return
 	}
 }
 
+// validateAttachments checks attached files and converts them for the service.
+func validateAttachments(items []AttachmentRequest) ([]approvals.Attachment, error) {
+	if len(items) > maxAttachments {
+		return nil, fmt.Errorf("attachments must contain at most %d files", maxAttachments)
+	}
+	attachments := make([]approvals.Attachment, 0, len(items))
+	for _, item := range items {
+		name := strings.TrimSpace(item.Name)
+		if name == "" || strings.ContainsAny(name, "/\\") {
+			return nil, fmt.Errorf("attachments items must include a file name without slashes")
+		}
+		item.URL = strings.TrimSpace(item.URL)
+		if (len(item.Data) == 0) == (item.URL == "") {
+			return nil, fmt.Errorf("attachment %q must include either data or url", name)
+		}
+		if len(item.Data) > maxAttachmentSize {
+			return nil, fmt.Errorf("attachment %q must be at most %d bytes", name, maxAttachmentSize)
+		}
+		if item.URL != "" {
+			if u, err := url.Parse(item.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("attachment %q url must be an absolute http(s) url", name)
+			}
+		}
+		if len([]rune(item.Caption)) > maxAttachmentCaption {
+			return nil, fmt.Errorf("attachment %q caption must be at most %d characters", name, maxAttachmentCaption)
+		}
+		attachments = append(attachments, approvals.Attachment{Name: name, Data: item.Data, URL: item.URL, Caption: strings.TrimSpace(item.Caption)})
+	}
+	return attachments, nil
+}
+
+// validateNoteLength limits optional texts that replace notes appended to the message.
+func validateNoteLength(field, value string) error {
+	if len([]rune(value)) > maxNoteLength {
+		return fmt.Errorf("%s must be at most %d characters", field, maxNoteLength)
+	}
+	return nil
+}
+
 func validateReasonLength(field, value string) error {
 	length := len([]rune(strings.TrimSpace(value)))
 	if length < 10 || length > 500 {
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/audit"
+)
+
+const (
+	// defaultAuditLimit is the page size of audit exports when no limit is given.
+	defaultAuditLimit = 100
+	// maxAuditLimit bounds the page size of audit exports.
+	maxAuditLimit = 1000
+)
+
+// auditColumns are the CSV export columns.
+var auditColumns = []string{
+	"seq", "at", "event", "correlation_id", "tool", "tenant", "requested_by", "channel", "decision", "reason",
+	"reason_code", "actor_id", "actor_username", "forced_by", "votes", "callback_url", "status_code", "error", "hash",
+}
+
+// AuditHandler exports audit records for compliance reports.
+type AuditHandler struct {
+	audit *audit.Log
+}
+
+// NewAuditHandler creates an audit export handler.
+func NewAuditHandler(trail *audit.Log) *AuditHandler {
+	return &AuditHandler{audit: trail}
+}
+
+// AuditResponse is a page of audit records.
+type AuditResponse struct {
+	Records []audit.Record `json:"records"`
+	// NextAfter is the after value of the next page; zero when this is the last page.
+	NextAfter uint64 `json:"next_after,omitempty"`
+}
+
+// ServeHTTP handles GET /audit?from=&to=&event=&decision=&tool=&approver=&after=&limit=&format=csv requests.
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Event:    strings.TrimSpace(query.Get("event")),
+		Decision: strings.TrimSpace(query.Get("decision")),
+		Tool:     strings.TrimSpace(query.Get("tool")),
+		Approver: strings.TrimSpace(query.Get("approver")),
+		Limit:    defaultAuditLimit,
+	}
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := strings.TrimSpace(query.Get(name)); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be an RFC3339 time")
+				return
+			}
+			*target = parsed
+		}
+	}
+	if raw := strings.TrimSpace(query.Get("after")); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "after must be a sequence number")
+			return
+		}
+		filter.After = parsed
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxAuditLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditLimit))
+			return
+		}
+		filter.Limit = parsed
+	}
+	records, more, err := h.audit.Query(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var next uint64
+	if more {
+		next = records[len(records)-1].Seq
+		w.Header().Set("X-Next-After", strconv.FormatUint(next, 10))
+	}
+	if query.Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		writeAuditCSV(w, records)
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+	writeJSON(w, http.StatusOK, AuditResponse{Records: records, NextAfter: next})
+}
+
+func writeAuditCSV(w http.ResponseWriter, records []audit.Record) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+	w.WriteHeader(http.StatusOK)
+	out := csv.NewWriter(w)
+	_ = out.Write(auditColumns)
+	for _, record := range records {
+		var actorID, actorName string
+		if record.Actor != nil {
+			actorID, actorName = record.Actor.ID, record.Actor.Username
+		}
+		voters := make([]string, 0, len(record.Votes))
+		for _, vote := range record.Votes {
+			name := vote.Username
+			if name == "" {
+				name = strconv.FormatInt(vote.UserID, 10)
+			}
+			voters = append(voters, name)
+		}
+		status := ""
+		if record.StatusCode != 0 {
+			status = strconv.Itoa(record.StatusCode)
+		}
+		_ = out.Write([]string{
+			strconv.FormatUint(record.Seq, 10), record.At.Format(time.RFC3339), record.Event, plainCell(record.CorrelationID),
+			plainCell(record.Tool), plainCell(record.Tenant), plainCell(record.RequestedBy), record.Channel, record.Decision,
+			plainCell(record.Reason), record.ReasonCode, actorID, actorName, record.ForcedBy, strings.Join(voters, " "),
+			record.CallbackURL, status, plainCell(record.Error), record.Hash,
+		})
+	}
+	out.Flush()
+}
+
+// plainCell keeps spreadsheets from evaluating caller-supplied text as a formula.
+func plainCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
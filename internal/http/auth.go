@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/callback"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+)
+
+// RequireBearer rejects requests that don't carry the expected bearer token.
+func RequireBearer(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bearerMatches(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerMatches(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	header := r.Header.Get("Authorization")
+	presented, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(token)) == 1
+}
+
+// tenantForBearer returns the tenant whose token matches the request bearer token.
+func tenantForBearer(r *http.Request, tenants map[string]config.Tenant) (string, bool) {
+	for name, tenant := range tenants {
+		if bearerMatches(r, tenant.Token) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+const (
+	// TimestampHeader carries the unix time a signed API request was created at.
+	TimestampHeader = "X-Approver-Timestamp"
+	// maxAPIBody bounds client API request bodies; /approve takes the largest ones.
+	maxAPIBody = maxApproveBody
+)
+
+// RequireAPIAuth protects client API endpoints with the configured IP allowlist, bearer token, and HMAC signature.
+// Checks that are not configured are skipped; with nothing configured requests pass through unchanged.
+func RequireAPIAuth(cfg config.Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.APIAllowedPrefixes) > 0 && !addrAllowed(r.RemoteAddr, cfg.APIAllowedPrefixes) {
+			writeError(w, http.StatusForbidden, "address not allowed")
+			return
+		}
+		if cfg.APIToken != "" && !bearerMatches(r, cfg.APIToken) {
+			if _, ok := tenantForBearer(r, cfg.CurrentFile().Tenants); !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "invalid api token")
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAPIBody)
+		if cfg.APIHMACSecret != "" {
+			body, err := io.ReadAll(r.Body)
+			if bodyTooLarge(err) {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "failed to read body")
+				return
+			}
+			if err := verifySignature(r, body, cfg.APIHMACSecret, cfg.APIHMACSkew); err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyTooLarge reports whether err comes from a body cut off by http.MaxBytesReader.
+func bodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// verifySignature checks X-Approver-Signature against HMAC-SHA256 of "<timestamp>.<body>".
+func verifySignature(r *http.Request, body []byte, secret string, skew time.Duration) error {
+	timestamp := strings.TrimSpace(r.Header.Get(TimestampHeader))
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid request timestamp")
+	}
+	age := time.Since(time.Unix(seconds, 0))
+	if age > skew || age < -skew {
+		return errors.New("request timestamp outside allowed window")
+	}
+	signed := append([]byte(timestamp+"."), body...)
+	expected := callback.Sign(secret, signed)
+	if !hmac.Equal([]byte(r.Header.Get(callback.SignatureHeader)), []byte(expected)) {
+		return errors.New("invalid request signature")
+	}
+	return nil
+}
+
+func addrAllowed(remoteAddr string, prefixes []netip.Prefix) bool {
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/telegram"
+)
+
+// errorStatus maps service errors to HTTP statuses; errors outside the taxonomy get fallback.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, approvals.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, approvals.ErrAlreadyExists), errors.Is(err, approvals.ErrAlreadyResolved),
+		errors.Is(err, telegram.ErrSameChat), errors.Is(err, telegram.ErrChannelApproval):
+		return http.StatusConflict
+	case errors.Is(err, telegram.ErrUnknownChat), errors.Is(err, telegram.ErrUnknownChannel):
+		return http.StatusBadRequest
+	case errors.Is(err, telegram.ErrStandby), errors.Is(err, approvals.ErrTelegramUnavailable),
+		errors.Is(err, approvals.ErrStoreUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return fallback
+	}
+}
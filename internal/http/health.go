@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single dependency check of a health probe.
+const checkTimeout = 5 * time.Second
+
+// Check is a dependency verified by /healthz and /readyz.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// healthReport is the response body of the health endpoints.
+type healthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// health runs the dependency checks and caches their result for ttl.
+type health struct {
+	mu      sync.Mutex
+	checks  []Check
+	ttl     time.Duration
+	checked time.Time
+	results map[string]string
+	healthy bool
+}
+
+// SetChecks registers the dependency checks of the health endpoints; results are reused for ttl.
+func (s *Server) SetChecks(ttl time.Duration, checks ...Check) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	s.health.checks = checks
+	s.health.ttl = ttl
+	s.health.checked = time.Time{}
+}
+
+// run returns the check results, running the checks again once the cached ones expire.
+// Concurrent probes wait for a single run instead of hitting the dependencies in parallel.
+func (h *health) run(ctx context.Context) (map[string]string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.checks) == 0 {
+		return nil, true
+	}
+	if !h.checked.IsZero() && time.Since(h.checked) < h.ttl {
+		return h.results, h.healthy
+	}
+	results := make(map[string]string, len(h.checks))
+	healthy := true
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Go(func() {
+			checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkTimeout)
+			defer cancel()
+			result := "ok"
+			if err := check.Run(checkCtx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			healthy = healthy && result == "ok"
+		})
+	}
+	wg.Wait()
+	h.results, h.healthy, h.checked = results, healthy, time.Now()
+	return results, healthy
+}
+
+func (s *Server) registerHealth() {
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		results, healthy := s.health.run(r.Context())
+		writeHealth(w, healthy, "unhealthy", results)
+	})
+	s.mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready.Load() {
+			writeHealth(w, false, "not ready", nil)
+			return
+		}
+		results, healthy := s.health.run(r.Context())
+		writeHealth(w, healthy, "not ready", results)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, healthy bool, failure string, results map[string]string) {
+	if !healthy {
+		writeJSON(w, http.StatusServiceUnavailable, healthReport{Status: failure, Checks: results})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthReport{Status: "ok", Checks: results})
+}
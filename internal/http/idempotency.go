@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader identifies retries of the same request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from the idempotency cache.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKey bounds the accepted key length.
+	maxIdempotencyKey = 255
+)
+
+// IdempotencyCache stores the first response per Idempotency-Key for a limited window.
+type IdempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	bodyHash    [sha256.Size]byte
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// NewIdempotencyCache creates a cache; it returns nil when ttl is not positive.
+func NewIdempotencyCache(ttl time.Duration) *IdempotencyCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &IdempotencyCache{ttl: ttl, entries: make(map[string]*idempotencyEntry)}
+}
+
+// WithIdempotency replays stored responses for requests that repeat an Idempotency-Key.
+// A retry with a different body is rejected with 422; a retry while the first request is in flight gets 409.
+// Server errors and rate-limit rejections are not stored so the request can be retried.
+func WithIdempotency(cache *IdempotencyCache, next http.Handler) http.Handler {
+	if cache == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeError(w, http.StatusBadRequest, "idempotency key is too long")
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if bodyTooLarge(err) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		scope := r.Method + " " + r.URL.Path + " " + key
+		entry, fresh := cache.begin(scope, sha256.Sum256(body))
+		if !fresh {
+			switch {
+			case entry == nil:
+				writeError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different payload")
+			case !entry.done:
+				writeError(w, http.StatusConflict, "request with this idempotency key is in progress")
+			default:
+				w.Header().Set("Content-Type", entry.contentType)
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(entry.status)
+				_, _ = w.Write(entry.body)
+			}
+			return
+		}
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		cache.finish(scope, recorder)
+	})
+}
+
+// begin reserves scope for a new request or returns the existing entry.
+// A nil entry with fresh=false means the key was used with a different body.
+func (c *IdempotencyCache) begin(scope string, bodyHash [sha256.Size]byte) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, entry := range c.entries {
+		if entry.done && now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if entry, ok := c.entries[scope]; ok {
+		if entry.bodyHash != bodyHash {
+			return nil, false
+		}
+		copied := *entry
+		return &copied, false
+	}
+	c.entries[scope] = &idempotencyEntry{bodyHash: bodyHash}
+	return nil, true
+}
+
+func (c *IdempotencyCache) finish(scope string, recorder *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if recorder.status >= http.StatusInternalServerError || recorder.status == http.StatusTooManyRequests {
+		delete(c.entries, scope)
+		return
+	}
+	entry := c.entries[scope]
+	entry.done = true
+	entry.status = recorder.status
+	entry.contentType = recorder.Header().Get("Content-Type")
+	entry.body = recorder.body.Bytes()
+	entry.expiresAt = time.Now().Add(c.ttl)
+}
+
+// responseRecorder captures the status and body while writing them through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
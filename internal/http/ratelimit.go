@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/ratelimit"
+)
+
+// idleSweep is how often buckets of sources that went quiet are dropped.
+const idleSweep = time.Minute
+
+// rateLimiter throttles /approve with a global and a per-source token bucket.
+// Requests over the limit wait in a bounded queue until their token is due; a full queue rejects them.
+type rateLimiter struct {
+	mu          sync.Mutex
+	global      *ratelimit.Bucket
+	sourceRate  int
+	sourceBurst int
+	sources     map[string]*ratelimit.Bucket
+	queue       int
+	waiting     int
+	swept       time.Time
+}
+
+// newRateLimiter creates the /approve limiter; it returns nil when both limits are disabled.
+func newRateLimiter(cfg config.Config) *rateLimiter {
+	if cfg.RateLimit <= 0 && cfg.SourceRateLimit <= 0 {
+		return nil
+	}
+	now := time.Now()
+	limiter := &rateLimiter{
+		sourceRate:  cfg.SourceRateLimit,
+		sourceBurst: cfg.SourceRateBurst,
+		sources:     make(map[string]*ratelimit.Bucket),
+		queue:       cfg.RateQueue,
+		swept:       now,
+	}
+	if cfg.RateLimit > 0 {
+		limiter.global = ratelimit.NewBucket(ratelimit.PerMinute(cfg.RateLimit), cfg.RateBurst, now)
+	}
+	return limiter
+}
+
+// wait takes a token for source, queueing the request until the token is due.
+// It returns false with the time to wait before retrying when the queue is full, and false with zero when ctx ends.
+func (l *rateLimiter) wait(ctx context.Context, source string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	delay, ok := l.reserve(source)
+	if !ok {
+		return false, delay
+	}
+	if delay <= 0 {
+		return true, 0
+	}
+	defer l.dequeue()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true, 0
+	case <-ctx.Done():
+		return false, 0
+	}
+}
+
+// reserve takes a token from the global and source buckets when the request is admitted, directly or into the queue.
+func (l *rateLimiter) reserve(source string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.sweep(now)
+	buckets := make([]*ratelimit.Bucket, 0, 2)
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	if l.sourceRate > 0 {
+		bucket, ok := l.sources[source]
+		if !ok {
+			bucket = ratelimit.NewBucket(ratelimit.PerMinute(l.sourceRate), l.sourceBurst, now)
+			l.sources[source] = bucket
+		}
+		buckets = append(buckets, bucket)
+	}
+	var delay time.Duration
+	for _, bucket := range buckets {
+		delay = max(delay, bucket.Delay(now))
+	}
+	if delay > 0 && l.waiting >= l.queue {
+		return delay, false
+	}
+	for _, bucket := range buckets {
+		bucket.Take()
+	}
+	if delay > 0 {
+		l.waiting++
+	}
+	return delay, true
+}
+
+func (l *rateLimiter) dequeue() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting--
+}
+
+// sweep drops the buckets of sources that refilled completely, keeping the map bounded by active sources.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < idleSweep {
+		return
+	}
+	l.swept = now
+	for source, bucket := range l.sources {
+		if bucket.Full(now) {
+			delete(l.sources, source)
+		}
+	}
+}
+
+// rateSource identifies the caller for the per-source limit: the tenant of the API token, else the client address.
+// Body fields are chosen by the caller, so they never pick the bucket.
+func rateSource(r *http.Request, tenants map[string]config.Tenant) string {
+	if name, ok := tenantForBearer(r, tenants); ok {
+		return "tenant:" + name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
@@ -8,11 +8,12 @@ import (
 	"time"
 )
 
-// Server wraps HTTP server with readiness checks.
+// Server wraps HTTP server with health and readiness checks.
 type Server struct {
 	server *http.Server
 	mux    *http.ServeMux
 	ready  atomic.Bool
+	health health
 	log    *slog.Logger
 }
 
@@ -52,19 +53,3 @@ func (s *Server) ListenAndServe() error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
-
-func (s *Server) registerHealth() {
-	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-	s.mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		if !s.ready.Load() {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write([]byte("not ready"))
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/telegram"
+)
+
+const (
+	// defaultWait is how long a long-poll request blocks when no timeout is given.
+	defaultWait = 30 * time.Second
+	// maxWait bounds long-poll requests.
+	maxWait = 5 * time.Minute
+	// sseKeepAlive is the interval of SSE comments that keep proxies from closing idle streams.
+	sseKeepAlive = 15 * time.Second
+)
+
+// WaitHandler lets callers that cannot receive callbacks wait for a decision.
+type WaitHandler struct {
+	svc     *telegram.Service
+	history *approvals.History
+	cfg     config.Config
+}
+
+// NewWaitHandler creates a decision wait handler.
+func NewWaitHandler(svc *telegram.Service, history *approvals.History, cfg config.Config) *WaitHandler {
+	return &WaitHandler{svc: svc, history: history, cfg: cfg}
+}
+
+// WaitResponse defines output payload for decision waits.
+type WaitResponse struct {
+	CorrelationID string             `json:"correlation_id"`
+	Decision      string             `json:"decision"`
+	Reason        string             `json:"reason,omitempty"`
+	ReasonCode    string             `json:"reason_code,omitempty"`
+	Error         *approvals.Failure `json:"error,omitempty"`
+}
+
+// ServeHTTP handles GET /approvals/{correlation_id}/wait requests.
+// Requests accepting text/event-stream get an SSE stream that ends with the decision;
+// others block for up to the timeout query parameter and return pending when it passes.
+func (h *WaitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	correlationID := r.PathValue("correlation_id")
+	timeout := defaultWait
+	if raw := strings.TrimSpace(r.URL.Query().Get("timeout")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxWait {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("timeout must be a duration up to %s", maxWait))
+			return
+		}
+		timeout = parsed
+	}
+
+	// Subscribe before checking state so a decision made in between is not missed.
+	decisions, stop := h.svc.Wait(correlationID)
+	defer stop()
+	tenant := ""
+	var deadline time.Time
+	var resolved *WaitResponse
+	if approval := h.svc.Approval(correlationID); approval != nil {
+		tenant = approval.Request.Tenant
+		deadline = approval.Deadline
+	} else if entry, ok := h.history.Lookup(correlationID); ok {
+		tenant = entry.Tenant
+		resolved = &WaitResponse{CorrelationID: correlationID, Decision: string(entry.Decision), Reason: entry.Reason}
+	} else {
+		writeError(w, http.StatusNotFound, approvals.ErrNotFound.Error())
+		return
+	}
+	if settings, ok := h.cfg.CurrentFile().Tenants[tenant]; ok && settings.Token != "" && !bearerMatches(r, settings.Token) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.stream(w, r, correlationID, deadline, decisions, resolved)
+		return
+	}
+	if resolved != nil {
+		writeJSON(w, http.StatusOK, resolved)
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-decisions:
+		writeJSON(w, http.StatusOK, WaitResponse{CorrelationID: correlationID, Decision: string(result.Decision), Reason: result.Reason, ReasonCode: result.ReasonCode, Error: result.Failure})
+	case <-timer.C:
+		writeJSON(w, http.StatusAccepted, WaitResponse{CorrelationID: correlationID, Decision: string(approvals.DecisionPending)})
+	case <-r.Context().Done():
+	}
+}
+
+// stream sends the decision as an SSE event once it is known; the stream ends shortly after the approval deadline.
+func (h *WaitHandler) stream(w http.ResponseWriter, r *http.Request, correlationID string, deadline time.Time, decisions <-chan approvals.Result, resolved *WaitResponse) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if resolved != nil {
+		writeEvent(w, "decision", resolved)
+		flusher.Flush()
+		return
+	}
+	writeEvent(w, "pending", WaitResponse{CorrelationID: correlationID, Decision: string(approvals.DecisionPending)})
+	flusher.Flush()
+
+	// The timeout handler resolves the approval at its deadline; the grace covers a slow finalize.
+	expired := time.NewTimer(time.Until(deadline) + time.Minute)
+	defer expired.Stop()
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case result := <-decisions:
+			writeEvent(w, "decision", WaitResponse{CorrelationID: correlationID, Decision: string(result.Decision), Reason: result.Reason, ReasonCode: result.ReasonCode, Error: result.Failure})
+			flusher.Flush()
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-expired.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
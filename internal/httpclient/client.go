@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options configures an outbound HTTP client.
+type Options struct {
+	// ProxyURL routes requests through an HTTP, HTTPS, or SOCKS5 proxy when set.
+	ProxyURL string
+	// Timeout limits the whole request; zero means no limit.
+	Timeout time.Duration
+	// MaxIdleConns limits idle connections across all hosts; zero keeps the default.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits idle connections per host; zero keeps the default.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this duration; zero keeps the default.
+	IdleConnTimeout time.Duration
+	// TLSHandshakeTimeout limits the TLS handshake; zero keeps the default.
+	TLSHandshakeTimeout time.Duration
+}
+
+// New creates an HTTP client with its own transport.
+func New(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.TrimSpace(opts.ProxyURL) != "" {
+		proxyURL, err := ParseProxyURL(opts.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	return &http.Client{Transport: transport, Timeout: opts.Timeout}, nil
+}
+
+// ParseProxyURL validates a proxy URL with http, https, socks5, or socks5h scheme.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy url scheme must be http, https, socks5, or socks5h")
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy url must include a host")
+	}
+	return proxyURL, nil
+}
@@ -0,0 +1,2 @@
+// Package httpclient builds outbound HTTP clients with proxy and transport settings.
+package httpclient
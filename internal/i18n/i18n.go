@@ -18,23 +18,104 @@ type Messages struct {
 	SectionAction         string `yaml:"section_action"`
 	SectionRisks          string `yaml:"section_risks"`
 	SectionParams         string `yaml:"section_params"`
+	SectionDiff           string `yaml:"section_diff"`
+	SectionSummary        string `yaml:"section_summary"`
+	DiffTruncated         string `yaml:"diff_truncated"`
 	JustificationLabel    string `yaml:"justification_label"`
 	LinksLabel            string `yaml:"links_label"`
+	AttachmentsLabel      string `yaml:"attachments_label"`
+	RequestedByLabel      string `yaml:"requested_by_label"`
+	SeverityLabel         string `yaml:"severity_label"`
+	SeverityLow           string `yaml:"severity_low"`
+	SeverityMedium        string `yaml:"severity_medium"`
+	SeverityHigh          string `yaml:"severity_high"`
+	SeverityCritical      string `yaml:"severity_critical"`
+	SessionLabel          string `yaml:"session_label"`
 	ApproveButton         string `yaml:"approve_button"`
 	DenyButton            string `yaml:"deny_button"`
 	DenyWithMessageButton string `yaml:"deny_with_message_button"`
-	CancelDenyButton      string `yaml:"cancel_deny_button"`
+	DenyPlaceholder       string `yaml:"deny_placeholder"`
 	DeleteButton          string `yaml:"delete_button"`
+	DiscussButton         string `yaml:"discuss_button"`
+	DiscussionPrompt      string `yaml:"discussion_prompt"`
 	DenyPrompt            string `yaml:"deny_prompt"`
 	ApprovedNote          string `yaml:"approved_note"`
 	DeniedNote            string `yaml:"denied_note"`
+	DefaultDenyReason     string `yaml:"default_deny_reason"`
 	TimeoutNote           string `yaml:"timeout_note"`
+	TimeoutApprovedNote   string `yaml:"timeout_approved_note"`
+	TimeoutDeniedNote     string `yaml:"timeout_denied_note"`
+	CancelledNote         string `yaml:"cancelled_note"`
+	ForcedNote            string `yaml:"forced_note"`
 	ErrorNote             string `yaml:"error_note"`
 	InvalidAction         string `yaml:"invalid_action"`
 	AlreadyResolved       string `yaml:"already_resolved"`
+	DecisionNotSaved      string `yaml:"decision_not_saved"`
+	AlreadyVoted          string `yaml:"already_voted"`
+	VoteRecorded          string `yaml:"vote_recorded"`
+	VotesProgress         string `yaml:"votes_progress"`
+	AckButton             string `yaml:"ack_button"`
+	DetailsButton         string `yaml:"details_button"`
+	DelegateButton        string `yaml:"delegate_button"`
+	BackButton            string `yaml:"back_button"`
+	DelegateDone          string `yaml:"delegate_done"`
+	DelegateFailed        string `yaml:"delegate_failed"`
+	TenantLabel           string `yaml:"tenant_label"`
+	AckRecorded           string `yaml:"ack_recorded"`
+	AlreadyAcked          string `yaml:"already_acked"`
+	AcksProgress          string `yaml:"acks_progress"`
+	QuorumLabel           string `yaml:"quorum_label"`
+	FingerprintLabel      string `yaml:"fingerprint_label"`
+	SensitiveNote         string `yaml:"sensitive_note"`
 	InvalidChat           string `yaml:"invalid_chat"`
+	NotAllowed            string `yaml:"not_allowed"`
 	VoiceDisabled         string `yaml:"voice_disabled"`
 	TranscriptionFailed   string `yaml:"transcription_failed"`
+	VoiceNotUnderstood    string `yaml:"voice_not_understood"`
+	VoiceAmbiguous        string `yaml:"voice_ambiguous"`
+	AdminOnly             string `yaml:"admin_only"`
+	CleanupDone           string `yaml:"cleanup_done"`
+	CleanupUsage          string `yaml:"cleanup_usage"`
+	MuteDone              string `yaml:"mute_done"`
+	MuteOff               string `yaml:"mute_off"`
+	MuteUsage             string `yaml:"mute_usage"`
+	DigestTitle           string `yaml:"digest_title"`
+	StatusTitle           string `yaml:"status_title"`
+	StatusEmpty           string `yaml:"status_empty"`
+	StatusRemaining       string `yaml:"status_remaining"`
+	StatusMore            string `yaml:"status_more"`
+	DecideUsage           string `yaml:"decide_usage"`
+	DecideNotFound        string `yaml:"decide_not_found"`
+	DecideApproved        string `yaml:"decide_approved"`
+	DecideDenied          string `yaml:"decide_denied"`
+	ApproveAllConfirm     string `yaml:"approve_all_confirm"`
+	ApproveAllButton      string `yaml:"approve_all_button"`
+	ApproveAllCancel      string `yaml:"approve_all_cancel"`
+	ApproveAllCancelled   string `yaml:"approve_all_cancelled"`
+	ApproveAllDone        string `yaml:"approve_all_done"`
+	ApproveAllExpired     string `yaml:"approve_all_expired"`
+	EscalationNote        string `yaml:"escalation_note"`
+	ReactionHint          string `yaml:"reaction_hint"`
+	ExecuteAfterNote      string `yaml:"execute_after_note"`
+	QueuedNote            string `yaml:"queued_note"`
+	SnoozeButton          string `yaml:"snooze_button"`
+	SnoozeDone            string `yaml:"snooze_done"`
+	SnoozeLimit           string `yaml:"snooze_limit"`
+	SetupIntro            string `yaml:"setup_intro"`
+	SetupConfirmButton    string `yaml:"setup_confirm_button"`
+	SetupCancelButton     string `yaml:"setup_cancel_button"`
+	SetupCancelled        string `yaml:"setup_cancelled"`
+	SetupLanguage         string `yaml:"setup_language"`
+	SetupLanguageDone     string `yaml:"setup_language_done"`
+	SetupLabel            string `yaml:"setup_label"`
+	SetupLabelPlaceholder string `yaml:"setup_label_placeholder"`
+	SetupLabelInvalid     string `yaml:"setup_label_invalid"`
+	SetupLabelTaken       string `yaml:"setup_label_taken"`
+	SetupDone             string `yaml:"setup_done"`
+	SetupKnown            string `yaml:"setup_known"`
+	SetupFailed           string `yaml:"setup_failed"`
+	SetupExpired          string `yaml:"setup_expired"`
+	LinkInvalid           string `yaml:"link_invalid"`
 }
 
 // Bundle combines language code and messages.
@@ -69,6 +150,38 @@ func Load(lang string) (Bundle, error) {
 	return Bundle{Lang: lang, Messages: messages}, nil
 }
 
+// Catalog maps language codes to messages.
+type Catalog map[string]Messages
+
+// LoadCatalog returns the bundle messages together with the other bundled languages.
+func LoadCatalog(bundle Bundle) Catalog {
+	catalog := Catalog{bundle.Lang: bundle.Messages}
+	for _, lang := range []string{"en", "ru"} {
+		if _, ok := catalog[lang]; ok {
+			continue
+		}
+		if extra, err := Load(lang); err == nil {
+			catalog[extra.Lang] = extra.Messages
+		}
+	}
+	return catalog
+}
+
+// For resolves messages with fallback to fallbackLang and then English.
+func (c Catalog) For(lang, fallbackLang string) Messages {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = strings.ToLower(strings.TrimSpace(fallbackLang))
+	}
+	if msg, ok := c[lang]; ok {
+		return msg
+	}
+	if msg, ok := c["en"]; ok {
+		return msg
+	}
+	return Messages{}
+}
+
 func loadMessages(lang string) (Messages, error) {
 	data, err := files.ReadFile(fmt.Sprintf("%s.yaml", lang))
 	if err != nil {
@@ -0,0 +1,97 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Languages returns the language codes of the catalog in alphabetical order.
+func (c Catalog) Languages() []string {
+	langs := make([]string, 0, len(c))
+	for lang := range c {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Live is the message catalog shared by everything that renders messages. Reload replaces it without a restart,
+// so messages rendered afterwards use the new texts. A nil Live serves empty messages.
+type Live struct {
+	lang    string
+	dir     string
+	catalog atomic.Pointer[Catalog]
+}
+
+// NewLive loads the bundled languages and the <lang>.yaml files of dir. A file overrides the bundled texts of its
+// language, keys it leaves out keep their bundled text, and a language without a bundled file starts from English.
+// An empty dir uses the bundled files only.
+func NewLive(bundle Bundle, dir string) (*Live, error) {
+	l := &Live{lang: bundle.Lang, dir: strings.TrimSpace(dir)}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reload re-reads the message files. On error the current catalog is kept.
+func (l *Live) Reload() error {
+	bundle, err := Load(l.lang)
+	if err != nil {
+		return err
+	}
+	catalog := LoadCatalog(bundle)
+	if l.dir != "" {
+		if err := overlay(catalog, l.dir); err != nil {
+			return err
+		}
+	}
+	l.catalog.Store(&catalog)
+	return nil
+}
+
+// Catalog returns the current catalog; callers must not modify it.
+func (l *Live) Catalog() Catalog {
+	if l == nil {
+		return nil
+	}
+	if catalog := l.catalog.Load(); catalog != nil {
+		return *catalog
+	}
+	return nil
+}
+
+// For resolves messages of the current catalog like Catalog.For.
+func (l *Live) For(lang, fallbackLang string) Messages {
+	return l.Catalog().For(lang, fallbackLang)
+}
+
+// overlay merges the <lang>.yaml files of dir into catalog.
+func overlay(catalog Catalog, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("list i18n files: %w", err)
+	}
+	for _, path := range paths {
+		lang := strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".yaml"))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read i18n file: %w", err)
+		}
+		msg, ok := catalog[lang]
+		if !ok {
+			msg = catalog["en"]
+		}
+		if err := yaml.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("parse i18n file %s: %w", filepath.Base(path), err)
+		}
+		catalog[lang] = msg
+	}
+	return nil
+}
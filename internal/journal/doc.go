@@ -0,0 +1,2 @@
+// Package journal records Telegram updates and Bot API calls in a bounded in-memory buffer for debugging.
+package journal
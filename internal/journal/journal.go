@@ -0,0 +1,207 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+const (
+	// KindUpdate marks an incoming Telegram update.
+	KindUpdate = "update"
+	// KindCall marks an outgoing Bot API call.
+	KindCall = "call"
+)
+
+// scrubbedKeys are JSON keys whose values never reach the journal.
+var scrubbedKeys = map[string]struct{}{
+	"token":         {},
+	"secret":        {},
+	"secret_token":  {},
+	"password":      {},
+	"api_key":       {},
+	"authorization": {},
+}
+
+// Entry is a single journal record.
+type Entry struct {
+	// Seq increases by one per record.
+	Seq uint64 `json:"seq"`
+	// At is the time the record was made.
+	At time.Time `json:"at"`
+	// Kind is update or call.
+	Kind string `json:"kind"`
+	// Method is the Bot API method of a call.
+	Method string `json:"method,omitempty"`
+	// Request is the scrubbed update or call parameters.
+	Request json.RawMessage `json:"request,omitempty"`
+	// Response is the scrubbed call result.
+	Response json.RawMessage `json:"response,omitempty"`
+	// Error describes a failed call.
+	Error string `json:"error,omitempty"`
+	// DurationMS is the call duration in milliseconds.
+	DurationMS int64 `json:"duration_ms,omitempty"`
+}
+
+// Journal is a ring buffer of the most recent entries; a nil Journal records nothing.
+type Journal struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	seq     uint64
+}
+
+// New creates a journal holding up to size entries.
+func New(size int) *Journal {
+	if size <= 0 {
+		size = 500
+	}
+	return &Journal{entries: make([]Entry, size)}
+}
+
+// Update records an incoming update.
+func (j *Journal) Update(update telego.Update) {
+	if j == nil {
+		return
+	}
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+	j.add(Entry{Kind: KindUpdate, Request: scrub(data)})
+}
+
+// List returns entries with a sequence number above since, oldest first, optionally limited to those mentioning contains.
+func (j *Journal) List(since uint64, contains string, limit int) []Entry {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ordered := make([]Entry, 0, len(j.entries))
+	if j.full {
+		ordered = append(ordered, j.entries[j.next:]...)
+	}
+	ordered = append(ordered, j.entries[:j.next]...)
+	var list []Entry
+	for _, entry := range ordered {
+		if entry.Seq <= since {
+			continue
+		}
+		if contains != "" && !bytes.Contains(entry.Request, []byte(contains)) && !bytes.Contains(entry.Response, []byte(contains)) {
+			continue
+		}
+		list = append(list, entry)
+	}
+	if limit > 0 && len(list) > limit {
+		list = list[len(list)-limit:]
+	}
+	return list
+}
+
+func (j *Journal) add(entry Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	entry.Seq = j.seq
+	entry.At = time.Now()
+	j.entries[j.next] = entry
+	j.next++
+	if j.next == len(j.entries) {
+		j.next = 0
+		j.full = true
+	}
+}
+
+// Caller records Bot API calls made through the wrapped caller.
+// The request URL carries the bot token and is never recorded; only the method name is kept.
+type Caller struct {
+	// Next performs the actual call.
+	Next ta.Caller
+	// Journal receives the records.
+	Journal *Journal
+}
+
+// Call implements ta.Caller.
+func (c Caller) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	started := time.Now()
+	resp, err := c.Next.Call(ctx, url, data)
+	if c.Journal == nil {
+		return resp, err
+	}
+	entry := Entry{
+		Kind:       KindCall,
+		Method:     url[strings.LastIndex(url, "/")+1:],
+		Request:    requestBody(data),
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	switch {
+	case err != nil:
+		entry.Error = scrubURL(err.Error(), url)
+	case resp != nil && !resp.Ok && resp.Error != nil:
+		entry.Error = resp.Error.Error()
+	case resp != nil:
+		entry.Response = scrub(resp.Result)
+	}
+	c.Journal.add(entry)
+	return resp, err
+}
+
+// requestBody returns scrubbed JSON parameters; multipart uploads are summarized.
+func requestBody(data *ta.RequestData) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	if data.BodyRaw == nil || !strings.HasPrefix(data.ContentType, "application/json") {
+		summary, _ := json.Marshal(fmt.Sprintf("<%s body omitted>", data.ContentType))
+		return summary
+	}
+	return scrub(data.BodyRaw)
+}
+
+// scrub replaces values of secret-looking keys; invalid JSON is dropped.
+func scrub(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(scrubValue(value))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func scrubValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			if _, ok := scrubbedKeys[strings.ToLower(key)]; ok {
+				typed[key] = "***"
+				continue
+			}
+			typed[key] = scrubValue(item)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = scrubValue(item)
+		}
+	}
+	return value
+}
+
+// scrubURL removes the request URL, which contains the bot token, from transport errors.
+func scrubURL(message, url string) string {
+	return strings.ReplaceAll(message, url, "<bot api url>")
+}
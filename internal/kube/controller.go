@@ -0,0 +1,464 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// Group is the API group of the ApprovalRequest resource.
+	Group = "telegram-approver.codex-k8s.io"
+	// Version is the served version of the ApprovalRequest resource.
+	Version = "v1alpha1"
+	// Resource is the plural name of the ApprovalRequest resource.
+	Resource = "approvalrequests"
+)
+
+// Phases of an ApprovalRequest written to status.phase.
+const (
+	// PhasePending means the approval message is posted and waits for a decision.
+	PhasePending = "Pending"
+	// PhaseApproved means the request was approved.
+	PhaseApproved = "Approved"
+	// PhaseDenied means the request was denied.
+	PhaseDenied = "Denied"
+	// PhaseExpired means nobody decided before the approval timed out.
+	PhaseExpired = "Expired"
+	// PhaseCancelled means the approval was cancelled.
+	PhaseCancelled = "Cancelled"
+	// PhaseFailed means the request was rejected or could not be posted.
+	PhaseFailed = "Failed"
+)
+
+const (
+	// idPrefix starts correlation IDs derived from resource UIDs.
+	idPrefix = "k8s-"
+	// workers is how many resources are reconciled concurrently.
+	workers = 2
+)
+
+var resource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: Resource}
+
+// Approvals is the part of the approval service the controller relies on.
+type Approvals interface {
+	// Active reports whether this instance serves approvals; standby instances leave resources alone.
+	Active() bool
+	// Wait subscribes to the decision of an approval.
+	Wait(correlationID string) (<-chan approvals.Result, func())
+	// Approval returns a pending approval.
+	Approval(correlationID string) *approvals.Approval
+	// CancelApproval cancels a pending approval.
+	CancelApproval(ctx context.Context, correlationID string) error
+}
+
+// Options configure the controller.
+type Options struct {
+	// Namespace limits the watched resources; empty watches all namespaces.
+	Namespace string
+	// Resync is how often every resource is reconciled again, e.g. after a standby took over.
+	Resync time.Duration
+	// Approve handles POST /approve; resource specs go through it unchanged.
+	Approve http.Handler
+	// Approvals tracks the posted approvals.
+	Approvals Approvals
+	// History finds decisions made while the controller was not watching.
+	History *approvals.History
+	// Tenants returns the tenants that authorize resources naming a tenant with an API token; a resource may only
+	// name such a tenant from a namespace bound to it.
+	Tenants func() map[string]config.Tenant
+	// Log receives controller errors.
+	Log *slog.Logger
+}
+
+// Status is the status subresource of an ApprovalRequest.
+type Status struct {
+	// Phase is one of the Phase constants.
+	Phase string `json:"phase"`
+	// CorrelationID identifies the approval in the service API.
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Decision is the decision as reported by the API.
+	Decision string `json:"decision,omitempty"`
+	// Reason contains human-readable details.
+	Reason string `json:"reason,omitempty"`
+	// ReasonCode classifies the reason, e.g. denied or timeout.
+	ReasonCode string `json:"reasonCode,omitempty"`
+	// DecidedBy names the approver or the administrator who forced the decision.
+	DecidedBy string `json:"decidedBy,omitempty"`
+	// DeepLink opens the pending approval in a private chat with the bot.
+	DeepLink string `json:"deepLink,omitempty"`
+	// DecidedAt is when the decision was written.
+	DecidedAt string `json:"decidedAt,omitempty"`
+}
+
+// Controller turns ApprovalRequest resources into approvals and writes decisions to their status.
+type Controller struct {
+	client    dynamic.Interface
+	informer  cache.SharedIndexInformer
+	queue     workqueue.TypedRateLimitingInterface[string]
+	approve   http.Handler
+	approvals Approvals
+	history   *approvals.History
+	tenants   func() map[string]config.Tenant
+	log       *slog.Logger
+
+	mu       sync.Mutex
+	watching map[string]struct{}
+}
+
+// approveResponse is the part of the POST /approve response the controller reads.
+type approveResponse struct {
+	Decision   string             `json:"decision"`
+	Reason     string             `json:"reason"`
+	ReasonCode string             `json:"reason_code"`
+	Error      *approvals.Failure `json:"error"`
+	DeepLink   string             `json:"deep_link"`
+}
+
+// New creates a controller using the in-cluster service account, or the kubeconfig from KUBECONFIG or
+// ~/.kube/config when the service runs outside a cluster.
+func New(opts Options) (*Controller, error) {
+	restConfig, err := clientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubernetes config: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create kubernetes client: %w", err)
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, opts.Resync, opts.Namespace, nil)
+	c := &Controller{
+		client:    client,
+		informer:  factory.ForResource(resource).Informer(),
+		queue:     workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]()),
+		approve:   opts.Approve,
+		approvals: opts.Approvals,
+		history:   opts.History,
+		tenants:   opts.Tenants,
+		log:       opts.Log,
+		watching:  make(map[string]struct{}),
+	}
+	_, err = c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj any) { c.enqueue(obj) },
+		DeleteFunc: c.deleted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watch approval requests: %w", err)
+	}
+	return c, nil
+}
+
+// clientConfig loads the in-cluster service account, or the kubeconfig from KUBECONFIG or ~/.kube/config.
+func clientConfig() clientcmd.ClientConfig {
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{},
+	)
+}
+
+// Run watches resources until ctx is done.
+func (c *Controller) Run(ctx context.Context) {
+	defer c.queue.ShutDown()
+	go c.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return
+	}
+	c.log.Info("Kubernetes controller started", "resource", resource.String())
+	for range workers {
+		go c.work(ctx)
+	}
+	<-ctx.Done()
+}
+
+func (c *Controller) enqueue(obj any) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.queue.Add(key)
+}
+
+// deleted cancels the pending approval of a deleted resource.
+func (c *Controller) deleted(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || !c.approvals.Active() {
+		return
+	}
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	id, _, _ := unstructured.NestedString(u.Object, "status", "correlationId")
+	if phase != PhasePending || id == "" {
+		return
+	}
+	if err := c.approvals.CancelApproval(context.Background(), id); err != nil && !approvals.NotPending(err) {
+		c.log.Warn("Failed to cancel approval of deleted resource", "error", err, "correlation_id", id)
+	}
+}
+
+func (c *Controller) work(ctx context.Context) {
+	for {
+		key, shutdown := c.queue.Get()
+		if shutdown {
+			return
+		}
+		if err := c.reconcile(ctx, key); err != nil {
+			c.log.Warn("Failed to reconcile approval request", "error", err, "resource", key)
+			c.queue.AddRateLimited(key)
+		} else {
+			c.queue.Forget(key)
+		}
+		c.queue.Done(key)
+	}
+}
+
+// reconcile posts new resources and makes sure pending ones are tracked. Resolved resources are left alone.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	obj, exists, err := c.informer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return err
+	}
+	if !c.approvals.Active() {
+		// The active instance handles the resource; the resync brings it back after a takeover.
+		return nil
+	}
+	u := obj.(*unstructured.Unstructured)
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	switch phase {
+	case "":
+		return c.submit(ctx, u)
+	case PhasePending:
+		id, _, _ := unstructured.NestedString(u.Object, "status", "correlationId")
+		return c.track(ctx, u, id)
+	}
+	return nil
+}
+
+// submit posts the resource spec as a POST /approve body in async mode.
+func (c *Controller) submit(ctx context.Context, u *unstructured.Unstructured) error {
+	spec, ok, _ := unstructured.NestedMap(u.Object, "spec")
+	if !ok {
+		return c.patch(ctx, u, Status{Phase: PhaseFailed, Reason: "spec is required"})
+	}
+	id, _ := spec["correlation_id"].(string)
+	if strings.TrimSpace(id) == "" {
+		id = idPrefix + string(u.GetUID())
+		spec["correlation_id"] = id
+	}
+	spec["mode"] = "async"
+	delete(spec, "dry_run")
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return c.patch(ctx, u, Status{Phase: PhaseFailed, Reason: err.Error()})
+	}
+	tenant, _ := spec["tenant"].(string)
+	tenant = strings.TrimSpace(tenant)
+	token, ok := c.tenantToken(tenant, u.GetNamespace())
+	if !ok {
+		return c.patch(ctx, u, Status{Phase: PhaseFailed, Reason: fmt.Sprintf("tenant %q is not bound to namespace %q", tenant, u.GetNamespace())})
+	}
+
+	// Subscribe before submitting so an instant decision is not missed.
+	decisions, stop := c.approvals.Wait(id)
+	status, resp := c.post(ctx, body, token)
+	switch {
+	case status == http.StatusAccepted && resp.Decision == string(approvals.DecisionPending):
+		if !c.startWatch(id) {
+			stop()
+			return nil
+		}
+		if err := c.patch(ctx, u, Status{Phase: PhasePending, CorrelationID: id, DeepLink: resp.DeepLink}); err != nil {
+			stop()
+			c.stopWatch(id)
+			return err
+		}
+		go c.watch(ctx, u.GetNamespace(), u.GetName(), id, decisions, stop)
+		return nil
+	case status == http.StatusConflict:
+		// The approval is already posted, e.g. the status update of an earlier attempt failed.
+		stop()
+		return c.patch(ctx, u, Status{Phase: PhasePending, CorrelationID: id})
+	}
+	stop()
+	if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError && (resp.Error == nil || resp.Error.Retryable) {
+		return fmt.Errorf("approve returned status %d: %s", status, resp.Reason)
+	}
+	result := approvals.Result{Decision: approvals.Decision(resp.Decision), Reason: resp.Reason, ReasonCode: resp.ReasonCode}
+	return c.patch(ctx, u, resolvedStatus(id, result))
+}
+
+// track watches the decision of a pending resource, e.g. after a restart or a standby takeover.
+func (c *Controller) track(ctx context.Context, u *unstructured.Unstructured, id string) error {
+	if id == "" || !c.startWatch(id) {
+		return nil
+	}
+	decisions, stop := c.approvals.Wait(id)
+	if c.approvals.Approval(id) != nil {
+		go c.watch(ctx, u.GetNamespace(), u.GetName(), id, decisions, stop)
+		return nil
+	}
+	stop()
+	c.stopWatch(id)
+	if entry, ok := c.history.Lookup(id); ok {
+		return c.patch(ctx, u, resolvedStatus(id, approvals.Result{Decision: entry.Decision, Reason: entry.Reason, ForcedBy: entry.ForcedBy}))
+	}
+	return c.patch(ctx, u, Status{Phase: PhaseFailed, CorrelationID: id, Reason: "approval is no longer known to the service"})
+}
+
+// watch writes the decision of the approval to the resource status.
+func (c *Controller) watch(ctx context.Context, namespace, name, id string, decisions <-chan approvals.Result, stop func()) {
+	defer c.stopWatch(id)
+	defer stop()
+	select {
+	case result := <-decisions:
+		u := &unstructured.Unstructured{}
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		if err := c.patch(ctx, u, resolvedStatus(id, result)); err != nil {
+			c.log.Error("Failed to write decision to approval request", "error", err, "resource", namespace+"/"+name, "correlation_id", id)
+			c.queue.AddRateLimited(namespace + "/" + name)
+		}
+	case <-ctx.Done():
+	}
+}
+
+// startWatch reserves the decision watch of an approval and reports false when it is watched already.
+func (c *Controller) startWatch(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.watching[id]; ok {
+		return false
+	}
+	c.watching[id] = struct{}{}
+	return true
+}
+
+func (c *Controller) stopWatch(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.watching, id)
+}
+
+// tenantToken returns the API token supplied on behalf of a resource naming tenant in namespace. Kubernetes RBAC
+// decides who may create resources in a namespace, so only namespaces the tenant lists may use its token; ok is
+// false for any other.
+func (c *Controller) tenantToken(tenant, namespace string) (token string, ok bool) {
+	settings, found := c.tenants()[tenant]
+	if !found || settings.Token == "" {
+		return "", true
+	}
+	return settings.Token, slices.Contains(settings.Namespaces, namespace)
+}
+
+// post runs body through the POST /approve handler in-process, authenticated with token when it is set.
+func (c *Controller) post(ctx context.Context, body []byte, token string) (int, approveResponse) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/approve", bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, approveResponse{Reason: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "kubernetes"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := &recorder{header: make(http.Header)}
+	c.approve.ServeHTTP(rec, req)
+	var resp approveResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+		resp.Reason = fmt.Sprintf("invalid approve response: %v", err)
+	}
+	return rec.status, resp
+}
+
+// patch merges status into the status subresource. A deleted resource is not an error.
+func (c *Controller) patch(ctx context.Context, u *unstructured.Unstructured, status Status) error {
+	data, err := json.Marshal(map[string]any{"status": status})
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Resource(resource).Namespace(u.GetNamespace()).Patch(ctx, u.GetName(), types.MergePatchType, data, metav1.PatchOptions{}, "status")
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// resolvedStatus describes a final decision.
+func resolvedStatus(id string, result approvals.Result) Status {
+	status := Status{
+		Phase:         phaseOf(result),
+		CorrelationID: id,
+		Decision:      string(result.Decision),
+		Reason:        result.Reason,
+		ReasonCode:    result.ReasonCode,
+		DecidedBy:     result.ForcedBy,
+		DecidedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if result.Actor != nil && status.DecidedBy == "" {
+		status.DecidedBy = result.Actor.Username
+		if status.DecidedBy == "" {
+			status.DecidedBy = result.Actor.ID
+		}
+	}
+	return status
+}
+
+func phaseOf(result approvals.Result) string {
+	switch result.Decision {
+	case approvals.DecisionApprove:
+		return PhaseApproved
+	case approvals.DecisionDeny:
+		return PhaseDenied
+	case approvals.DecisionCancelled:
+		return PhaseCancelled
+	}
+	if result.ReasonCode == approvals.ReasonTimeout {
+		return PhaseExpired
+	}
+	return PhaseFailed
+}
+
+// recorder captures the response of the in-process POST /approve call.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
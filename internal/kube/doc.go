@@ -0,0 +1,4 @@
+// Package kube runs the optional Kubernetes controller that turns ApprovalRequest custom resources into approval
+// requests and writes their decisions back to the resource status, and keeps the standby lease in a Kubernetes
+// Lease object.
+package kube
@@ -0,0 +1,162 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// leaseTimeout bounds one round trip to the API server.
+const leaseTimeout = 5 * time.Second
+
+// Lease is a standby lease stored in a coordination.k8s.io/v1 Lease object. Updates use the resource version, so
+// two replicas racing for a free lease cannot both win.
+type Lease struct {
+	client    coordinationclient.LeaseInterface
+	namespace string
+	name      string
+}
+
+// NewLease returns a lease on the Lease object name. An empty namespace means the namespace of the pod, or of
+// the current kubeconfig context outside a cluster.
+func NewLease(namespace, name string) (*Lease, error) {
+	loader := clientConfig()
+	restConfig, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubernetes config: %w", err)
+	}
+	if strings.TrimSpace(namespace) == "" {
+		namespace, _, err = loader.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("detect kubernetes namespace: %w", err)
+		}
+	}
+	client, err := coordinationclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create kubernetes client: %w", err)
+	}
+	return &Lease{client: client.Leases(namespace), namespace: namespace, name: name}, nil
+}
+
+// Acquire takes a free or expired lease or renews one held by holder and reports whether holder owns it.
+// Losing a race for the lease is not an error.
+func (l *Lease) Acquire(holder string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseTimeout)
+	defer cancel()
+	lease, err := l.client.Get(ctx, l.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = l.client.Create(ctx, l.claim(nil, holder, ttl), metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	if owner := holderOf(lease); owner != "" && owner != holder && !expired(lease) {
+		return false, nil
+	}
+	_, err = l.client.Update(ctx, l.claim(lease, holder, ttl), metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Force hands the lease to holder regardless of the current owner.
+func (l *Lease) Force(holder string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseTimeout)
+	defer cancel()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := l.client.Get(ctx, l.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = l.client.Create(ctx, l.claim(nil, holder, ttl), metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Retried as a conflict with the replica that created it first.
+				return apierrors.NewConflict(coordinationv1.Resource("leases"), l.name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		_, err = l.client.Update(ctx, l.claim(lease, holder, ttl), metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Release frees the lease if holder owns it. The object is kept so its transition count survives.
+func (l *Lease) Release(holder string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseTimeout)
+	defer cancel()
+	lease, err := l.client.Get(ctx, l.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if holderOf(lease) != holder {
+		return nil
+	}
+	lease = lease.DeepCopy()
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	_, err = l.client.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		// Somebody else changed the lease in between, so it is no longer ours to release.
+		return nil
+	}
+	return err
+}
+
+// claim returns lease, or a new Lease object when lease is nil, held by holder for ttl from now.
+func (l *Lease) claim(lease *coordinationv1.Lease, holder string, ttl time.Duration) *coordinationv1.Lease {
+	if lease == nil {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: l.name, Namespace: l.namespace}}
+	} else {
+		lease = lease.DeepCopy()
+	}
+	now := metav1.NewMicroTime(time.Now())
+	if holderOf(lease) != holder {
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		if holderOf(lease) != "" {
+			transitions++
+		}
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	seconds := int32((ttl + time.Second - 1) / time.Second)
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+	return lease
+}
+
+// holderOf returns the current holder of lease, or "" when it is free.
+func holderOf(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// expired reports whether the holder of lease stopped renewing it for longer than its duration.
+func expired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return time.Since(lease.Spec.RenewTime.Time) > duration
+}
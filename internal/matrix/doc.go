@@ -0,0 +1,2 @@
+// Package matrix posts approval requests to a Matrix room and takes decisions from reactions and replies.
+package matrix
@@ -0,0 +1,505 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+const (
+	// Name is the channel name used in requests and configuration.
+	Name = "matrix"
+
+	// correlationKey stores the correlation ID in the content of approval messages.
+	correlationKey  = "com.github.codex-k8s.correlation_id"
+	reactionApprove = "✅"
+	reactionDeny    = "❌"
+	maxReason       = 500
+	maxBodyBytes    = 8 << 20
+	maxArguments    = 3000
+	// maxCachedEvents bounds the event to correlation ID cache.
+	maxCachedEvents = 10000
+	syncTimeout     = 30 * time.Second
+	retryDelay      = 5 * time.Second
+)
+
+// Reaction keys and reply words accepted as decisions besides the localized button labels.
+var (
+	approveReactions = []string{"✅", "👍", "✔"}
+	denyReactions    = []string{"❌", "👎", "🚫"}
+	approveWords     = []string{"approve", "approved"}
+	denyWords        = []string{"deny", "denied"}
+)
+
+// Options configures the Matrix channel.
+type Options struct {
+	// HomeserverURL is the base URL of the homeserver client API.
+	HomeserverURL string
+	// Token is the access token of the bot account.
+	Token string
+	// RoomID is the room approvals are posted to; the bot must have joined it.
+	RoomID string
+	// AllowedUsers are Matrix user IDs allowed to decide; empty allows every room member.
+	AllowedUsers []string
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Channel posts approvals as formatted messages and reads reactions and replies through the sync API.
+type Channel struct {
+	client *http.Client
+	opts   Options
+	log    *slog.Logger
+	txn    atomic.Int64
+	userID string
+
+	mu     sync.Mutex
+	events map[string]string
+}
+
+// New creates a Matrix channel.
+func New(opts Options) *Channel {
+	opts.HomeserverURL = strings.TrimRight(opts.HomeserverURL, "/")
+	c := &Channel{
+		client: &http.Client{Timeout: syncTimeout + 15*time.Second},
+		opts:   opts,
+		log:    opts.Log,
+		events: make(map[string]string),
+	}
+	c.txn.Store(time.Now().UnixNano())
+	return c
+}
+
+// Name returns the channel name.
+func (c *Channel) Name() string {
+	return Name
+}
+
+// Post sends the approval to the room and adds the approve and deny reactions for approvers to click.
+func (c *Channel) Post(ctx context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error) {
+	req := approval.Request
+	msg := c.opts.Messages.For(req.Lang, c.opts.DefaultLang)
+	plain, formatted := render(msg, req)
+	content := map[string]any{
+		"msgtype":        "m.text",
+		"body":           plain + "\n\n" + msg.ReactionHint,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted + "<p><em>" + html.EscapeString(msg.ReactionHint) + "</em></p>",
+		correlationKey:   req.CorrelationID,
+	}
+	eventID, err := c.send(ctx, "m.room.message", content)
+	if err != nil {
+		return approvals.ChannelRef{}, "", err
+	}
+	c.remember(eventID, req.CorrelationID)
+	for _, key := range []string{reactionApprove, reactionDeny} {
+		if _, err := c.send(ctx, "m.reaction", map[string]any{
+			"m.relates_to": map[string]any{"rel_type": "m.annotation", "event_id": eventID, "key": key},
+		}); err != nil {
+			c.log.Warn("Failed to add Matrix reaction", "error", err, "correlation_id", req.CorrelationID)
+		}
+	}
+	return approvals.ChannelRef{Channel: Name, Conversation: c.opts.RoomID, Message: eventID}, plain, nil
+}
+
+// Resolve edits the posted message to show the decision.
+func (c *Channel) Resolve(ctx context.Context, approval *approvals.Approval, result approvals.Result) error {
+	ref := approval.ChannelRef
+	if ref.Message == "" {
+		return nil
+	}
+	req := approval.Request
+	msg := c.opts.Messages.For(req.Lang, c.opts.DefaultLang)
+	plain, formatted := render(msg, req)
+	note := channel.DecisionNote(msg, result, req.TimeoutMessage)
+	updated := map[string]any{
+		"msgtype":        "m.text",
+		"body":           plain + "\n\n" + note,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted + "<p><strong>" + html.EscapeString(note) + "</strong></p>",
+	}
+	_, err := c.send(ctx, "m.room.message", map[string]any{
+		"msgtype":       "m.text",
+		"body":          "* " + note,
+		"m.new_content": updated,
+		"m.relates_to":  map[string]any{"rel_type": "m.replace", "event_id": ref.Message},
+	})
+	return err
+}
+
+// Handler returns nil: decisions arrive through the sync API, see Poll.
+func (c *Channel) Handler(channel.Decider) http.Handler {
+	return nil
+}
+
+// Poll follows the room timeline and passes decisions made by reactions and replies to decider until ctx is done.
+// The first sync returns recent events, so decisions made while the service was down are applied too.
+func (c *Channel) Poll(ctx context.Context, decider channel.Decider) {
+	for c.userID == "" {
+		var whoami struct {
+			UserID string `json:"user_id"`
+		}
+		if err := c.call(ctx, http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &whoami); err != nil {
+			c.log.Error("Failed to identify Matrix bot user", "error", err)
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+		c.userID = whoami.UserID
+	}
+	filter, _ := json.Marshal(map[string]any{
+		"presence":     map[string]any{"types": []string{}},
+		"account_data": map[string]any{"types": []string{}},
+		"room": map[string]any{
+			"rooms":        []string{c.opts.RoomID},
+			"state":        map[string]any{"types": []string{}},
+			"ephemeral":    map[string]any{"types": []string{}},
+			"account_data": map[string]any{"types": []string{}},
+			"timeline":     map[string]any{"types": []string{"m.reaction", "m.room.message"}, "limit": 50},
+		},
+	})
+	since := ""
+	for ctx.Err() == nil {
+		query := url.Values{"filter": {string(filter)}, "timeout": {strconv.FormatInt(syncTimeout.Milliseconds(), 10)}}
+		if since != "" {
+			query.Set("since", since)
+		}
+		var resp syncResponse
+		if err := c.call(ctx, http.MethodGet, "/_matrix/client/v3/sync?"+query.Encode(), nil, &resp); err != nil {
+			if ctx.Err() == nil {
+				c.log.Error("Matrix sync failed", "error", err)
+				sleep(ctx, retryDelay)
+			}
+			continue
+		}
+		since = resp.NextBatch
+		for _, event := range resp.Rooms.Join[c.opts.RoomID].Timeline.Events {
+			c.handle(ctx, decider, event)
+		}
+	}
+}
+
+// handle applies the decision carried by a reaction or reply to an approval message.
+func (c *Channel) handle(ctx context.Context, decider channel.Decider, event event) {
+	if event.Sender == c.userID || !c.allowed(event.Sender) {
+		return
+	}
+	relates := event.Content.RelatesTo
+	if relates == nil {
+		return
+	}
+	var target string
+	var result approvals.Result
+	switch event.Type {
+	case "m.reaction":
+		if relates.RelType != "m.annotation" {
+			return
+		}
+		decision, ok := reactionDecision(relates.Key)
+		if !ok {
+			return
+		}
+		target, result = relates.EventID, decision
+	case "m.room.message":
+		switch {
+		case relates.RelType == "m.thread":
+			target = relates.EventID
+		case relates.InReplyTo != nil:
+			target = relates.InReplyTo.EventID
+		default:
+			return
+		}
+		decision, ok := c.replyDecision(stripFallback(event.Content.Body))
+		if !ok {
+			return
+		}
+		result = decision
+	default:
+		return
+	}
+	correlationID := c.correlationID(ctx, target)
+	if correlationID == "" {
+		return
+	}
+	result.Actor = &approvals.Actor{ID: event.Sender}
+	err := decider.Decide(ctx, correlationID, result)
+	switch {
+	case err == nil:
+		c.log.Info("Matrix decision applied", "correlation_id", correlationID, "user", event.Sender, "decision", result.Decision)
+	case approvals.NotPending(err):
+	default:
+		c.log.Error("Failed to apply Matrix decision", "error", err, "correlation_id", correlationID)
+	}
+}
+
+// correlationID returns the approval posted as eventID, fetching the event when it is not cached.
+// Events that are not approval messages map to an empty ID.
+func (c *Channel) correlationID(ctx context.Context, eventID string) string {
+	c.mu.Lock()
+	id, ok := c.events[eventID]
+	c.mu.Unlock()
+	if ok {
+		return id
+	}
+	var fetched struct {
+		Content map[string]any `json:"content"`
+	}
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(c.opts.RoomID) + "/event/" + url.PathEscape(eventID)
+	if err := c.call(ctx, http.MethodGet, path, nil, &fetched); err != nil {
+		c.log.Warn("Failed to fetch Matrix event", "error", err, "event_id", eventID)
+		return ""
+	}
+	id, _ = fetched.Content[correlationKey].(string)
+	c.remember(eventID, id)
+	return id
+}
+
+func (c *Channel) remember(eventID, correlationID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.events) >= maxCachedEvents {
+		c.events = make(map[string]string)
+	}
+	c.events[eventID] = correlationID
+}
+
+// replyDecision parses "approve" or "deny <reason>" replies; localized button labels work as commands too.
+func (c *Channel) replyDecision(text string) (approvals.Result, bool) {
+	command, reason, _ := strings.Cut(strings.TrimSpace(text), " ")
+	command = strings.ToLower(strings.TrimRight(command, ".!:,"))
+	approve, deny := slices.Clone(approveWords), slices.Clone(denyWords)
+	for _, msg := range c.opts.Messages.Catalog() {
+		approve = append(approve, labelWord(msg.ApproveButton))
+		deny = append(deny, labelWord(msg.DenyButton))
+	}
+	switch {
+	case command == "":
+		return approvals.Result{}, false
+	case slices.Contains(approve, command):
+		return approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved}, true
+	case slices.Contains(deny, command):
+		return channel.DenyResult(truncate(reason, maxReason)), true
+	default:
+		return approvals.Result{}, false
+	}
+}
+
+func reactionDecision(key string) (approvals.Result, bool) {
+	key = strings.TrimSuffix(key, "\ufe0f")
+	switch {
+	case slices.Contains(approveReactions, key):
+		return approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved}, true
+	case slices.Contains(denyReactions, key):
+		return channel.DenyResult(""), true
+	default:
+		return approvals.Result{}, false
+	}
+}
+
+func (c *Channel) allowed(userID string) bool {
+	return len(c.opts.AllowedUsers) == 0 || slices.Contains(c.opts.AllowedUsers, userID)
+}
+
+// send puts an event into the room and returns its ID.
+func (c *Channel) send(ctx context.Context, eventType string, content any) (string, error) {
+	txn := strconv.FormatInt(c.txn.Add(1), 10)
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(c.opts.RoomID) + "/send/" + eventType + "/" + txn
+	var resp struct {
+		EventID string `json:"event_id"`
+	}
+	if err := c.call(ctx, http.MethodPut, path, content, &resp); err != nil {
+		return "", err
+	}
+	return resp.EventID, nil
+}
+
+// call invokes a client API endpoint and decodes its response into out when it is not nil.
+func (c *Channel) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.HomeserverURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	resp, err := c.client.Do(req)
+	endpoint, _, _ := strings.Cut(path, "?")
+	if err != nil {
+		return fmt.Errorf("matrix %s %s: %w", method, endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Code    string `json:"errcode"`
+			Message string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(&apiErr)
+		return fmt.Errorf("matrix %s %s: status %d: %s %s", method, endpoint, resp.StatusCode, apiErr.Code, apiErr.Message)
+	}
+	if out != nil {
+		return json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(out)
+	}
+	return nil
+}
+
+type syncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+type event struct {
+	Type    string `json:"type"`
+	Sender  string `json:"sender"`
+	Content struct {
+		Body      string `json:"body"`
+		RelatesTo *struct {
+			RelType   string `json:"rel_type"`
+			EventID   string `json:"event_id"`
+			Key       string `json:"key"`
+			InReplyTo *struct {
+				EventID string `json:"event_id"`
+			} `json:"m.in_reply_to"`
+		} `json:"m.relates_to"`
+	} `json:"content"`
+}
+
+// render returns the plain-text and HTML bodies of the approval message.
+func render(msg i18n.Messages, req approvals.Request) (string, string) {
+	plain := &strings.Builder{}
+	formatted := &strings.Builder{}
+	line := func(label, value string, code bool) {
+		fmt.Fprintf(plain, "%s: %s\n", label, value)
+		value = html.EscapeString(value)
+		if code {
+			value = "<code>" + value + "</code>"
+		}
+		fmt.Fprintf(formatted, "<strong>%s:</strong> %s<br>", html.EscapeString(label), value)
+	}
+	section := func(title, value string) {
+		fmt.Fprintf(plain, "\n%s\n%s\n", title, value)
+		fmt.Fprintf(formatted, "<p><strong>%s</strong><br>%s</p>", html.EscapeString(title), strings.ReplaceAll(html.EscapeString(value), "\n", "<br>"))
+	}
+	fmt.Fprintf(plain, "%s\n\n", msg.ApprovalTitle)
+	fmt.Fprintf(formatted, "<h4>%s</h4><p>", html.EscapeString(msg.ApprovalTitle))
+	line(msg.ApprovalTool, req.Tool, true)
+	line(msg.ApprovalCorrelation, req.CorrelationID, true)
+	if value := strings.TrimSpace(req.RequestedBy); value != "" {
+		line(msg.RequestedByLabel, value, false)
+	}
+	if value := strings.TrimSpace(req.SessionID); value != "" {
+		line(msg.SessionLabel, value, false)
+	}
+	formatted.WriteString("</p>")
+	if note := channel.ExecuteAfterNote(msg, req); note != "" {
+		fmt.Fprintf(plain, "\n%s\n", note)
+		fmt.Fprintf(formatted, "<p>%s</p>", html.EscapeString(note))
+	}
+	if value := strings.TrimSpace(req.Justification); value != "" {
+		section(msg.JustificationLabel, value)
+	}
+	if req.Sensitive {
+		formatted.WriteString("<p>")
+		line(msg.FingerprintLabel, req.Fingerprint, true)
+		formatted.WriteString("</p>")
+		fmt.Fprintf(plain, "\n%s\n", msg.SensitiveNote)
+		fmt.Fprintf(formatted, "<p>%s</p>", html.EscapeString(msg.SensitiveNote))
+		return strings.TrimSpace(plain.String()), formatted.String()
+	}
+	if value := strings.TrimSpace(req.ApprovalRequest); value != "" {
+		section(msg.SectionAction, value)
+	}
+	if value := strings.TrimSpace(req.RiskAssessment); value != "" {
+		section(msg.SectionRisks, value)
+	}
+	if len(req.Arguments) > 0 {
+		if data, err := json.MarshalIndent(req.Arguments, "", "  "); err == nil {
+			args := truncate(string(data), maxArguments)
+			fmt.Fprintf(plain, "\n%s\n%s\n", msg.SectionParams, args)
+			fmt.Fprintf(formatted, "<p><strong>%s</strong></p><pre><code class=\"language-json\">%s</code></pre>", html.EscapeString(msg.SectionParams), html.EscapeString(args))
+		}
+	}
+	if len(req.LinksToCode) > 0 {
+		fmt.Fprintf(plain, "\n%s:\n", msg.LinksLabel)
+		fmt.Fprintf(formatted, "<p><strong>%s:</strong></p><ul>", html.EscapeString(msg.LinksLabel))
+		for _, link := range req.LinksToCode {
+			fmt.Fprintf(plain, "- %s: %s\n", link.Text, link.URL)
+			fmt.Fprintf(formatted, "<li><a href=\"%s\">%s</a></li>", html.EscapeString(link.URL), html.EscapeString(link.Text))
+		}
+		formatted.WriteString("</ul>")
+	}
+	return strings.TrimSpace(plain.String()), formatted.String()
+}
+
+// stripFallback removes the quoted original that clients prepend to reply bodies.
+func stripFallback(body string) string {
+	lines := strings.Split(body, "\n")
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], ">") {
+		i++
+	}
+	return strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
+
+// labelWord returns the word of a button label without its emoji, e.g. "approve" for "✅ Approve".
+func labelWord(label string) string {
+	fields := strings.Fields(label)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[len(fields)-1])
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,2 @@
+// Package mattermost posts approval requests to Mattermost with interactive buttons and a deny reason dialog.
+package mattermost
@@ -0,0 +1,357 @@
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+const (
+	// Name is the channel name used in requests and configuration.
+	Name = "mattermost"
+
+	actionApprove    = "approve"
+	actionDeny       = "deny"
+	actionDenyReason = "denyreason"
+	// maxReason bounds the deny reason typed into the dialog.
+	maxReason    = 500
+	maxBodyBytes = 1 << 20
+	// maxArguments keeps the post well below the Mattermost message limit.
+	maxArguments = 4000
+)
+
+// Options configures the Mattermost channel.
+type Options struct {
+	// URL is the Mattermost server URL.
+	URL string
+	// Token is the bot access token.
+	Token string
+	// ChannelID is the channel approvals are posted to.
+	ChannelID string
+	// ActionsURL is the public URL of the interactions endpoint that Mattermost calls on button presses.
+	ActionsURL string
+	// AllowedUsers are Mattermost user IDs allowed to decide; empty allows everyone in the channel.
+	AllowedUsers []string
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Channel posts approvals as messages with interactive buttons.
+// Button contexts are signed with the bot token because Mattermost does not sign integration requests.
+type Channel struct {
+	client *http.Client
+	opts   Options
+	log    *slog.Logger
+}
+
+// New creates a Mattermost channel.
+func New(opts Options) *Channel {
+	opts.URL = strings.TrimRight(opts.URL, "/")
+	return &Channel{client: &http.Client{Timeout: 10 * time.Second}, opts: opts, log: opts.Log}
+}
+
+// Name returns the channel name.
+func (c *Channel) Name() string {
+	return Name
+}
+
+// Post sends the approval to the configured channel.
+func (c *Channel) Post(ctx context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error) {
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	text := render(msg, approval.Request)
+	id := approval.Request.CorrelationID
+	var post struct {
+		ID        string `json:"id"`
+		ChannelID string `json:"channel_id"`
+	}
+	err := c.call(ctx, http.MethodPost, "/api/v4/posts", map[string]any{
+		"channel_id": c.opts.ChannelID,
+		"message":    text,
+		"props": map[string]any{
+			"attachments": []any{map[string]any{
+				"actions": []any{
+					c.button(actionApprove, msg.ApproveButton, id, "success"),
+					c.button(actionDeny, msg.DenyButton, id, "danger"),
+					c.button(actionDenyReason, msg.DenyWithMessageButton, id, "default"),
+				},
+			}},
+		},
+	}, &post)
+	if err != nil {
+		return approvals.ChannelRef{}, "", err
+	}
+	return approvals.ChannelRef{Channel: Name, Conversation: post.ChannelID, Message: post.ID}, text, nil
+}
+
+// Resolve replaces the buttons of the posted message with the decision.
+func (c *Channel) Resolve(ctx context.Context, approval *approvals.Approval, result approvals.Result) error {
+	ref := approval.ChannelRef
+	if ref.Message == "" {
+		return nil
+	}
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	return c.call(ctx, http.MethodPut, "/api/v4/posts/"+ref.Message+"/patch", map[string]any{
+		"message": approval.MessageText + "\n\n" + channel.DecisionNote(msg, result, approval.Request.TimeoutMessage),
+		"props":   map[string]any{"attachments": []any{}},
+	}, nil)
+}
+
+// Handler returns the endpoint Mattermost calls for button presses and dialog submissions.
+func (c *Channel) Handler(decider channel.Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var payload interaction
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes)).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if payload.Type == "dialog_submission" {
+			c.handleDialog(w, decider, payload)
+			return
+		}
+		c.handleAction(r.Context(), w, decider, payload)
+	})
+}
+
+func (c *Channel) handleAction(ctx context.Context, w http.ResponseWriter, decider channel.Decider, payload interaction) {
+	action, correlationID := payload.Context.Action, payload.Context.CorrelationID
+	if !c.valid(action, correlationID, payload.Context.Token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !c.allowed(payload.UserID) {
+		writeJSON(w, map[string]any{"ephemeral_text": c.opts.Messages.For("", c.opts.DefaultLang).NotAllowed})
+		return
+	}
+	switch action {
+	case actionApprove:
+		c.decide(decider, correlationID, payload.actor(), approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved})
+	case actionDeny:
+		c.decide(decider, correlationID, payload.actor(), approvals.Result{Decision: approvals.DecisionDeny, ReasonCode: approvals.ReasonDenied})
+	case actionDenyReason:
+		// The trigger ID expires within seconds, so the dialog is opened before responding.
+		if err := c.openDialog(ctx, payload.TriggerID, correlationID); err != nil {
+			c.log.Error("Failed to open Mattermost deny dialog", "error", err, "correlation_id", correlationID)
+		}
+	}
+	writeJSON(w, map[string]any{})
+}
+
+func (c *Channel) handleDialog(w http.ResponseWriter, decider channel.Decider, payload interaction) {
+	correlationID, token, _ := strings.Cut(payload.State, ":")
+	if !c.valid(actionDenyReason, correlationID, token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if payload.Cancelled {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !c.allowed(payload.UserID) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	c.decide(decider, correlationID, payload.actor(), channel.DenyResult(truncate(payload.Submission.Reason, maxReason)))
+	w.WriteHeader(http.StatusOK)
+}
+
+// decide applies a decision in the background; Mattermost expects a quick response.
+func (c *Channel) decide(decider channel.Decider, correlationID string, actor *approvals.Actor, result approvals.Result) {
+	result.Actor = actor
+	go func() {
+		err := decider.Decide(context.Background(), correlationID, result)
+		if err != nil && !approvals.NotPending(err) {
+			c.log.Error("Failed to apply Mattermost decision", "error", err, "correlation_id", correlationID)
+		}
+	}()
+}
+
+func (c *Channel) openDialog(ctx context.Context, triggerID, correlationID string) error {
+	msg := c.opts.Messages.For("", c.opts.DefaultLang)
+	return c.call(ctx, http.MethodPost, "/api/v4/actions/dialogs/open", map[string]any{
+		"trigger_id": triggerID,
+		"url":        c.opts.ActionsURL,
+		"dialog": map[string]any{
+			"callback_id":  correlationID,
+			"title":        msg.DenyWithMessageButton,
+			"submit_label": msg.DenyButton,
+			"state":        correlationID + ":" + c.sign(actionDenyReason, correlationID),
+			"elements": []any{map[string]any{
+				"display_name": msg.DenyPlaceholder,
+				"name":         "reason",
+				"type":         "textarea",
+				"max_length":   maxReason,
+			}},
+		},
+	}, nil)
+}
+
+func (c *Channel) button(action, text, correlationID, style string) any {
+	return map[string]any{
+		"id":    action,
+		"name":  text,
+		"style": style,
+		"integration": map[string]any{
+			"url": c.opts.ActionsURL,
+			"context": map[string]any{
+				"action":         action,
+				"correlation_id": correlationID,
+				"token":          c.sign(action, correlationID),
+			},
+		},
+	}
+}
+
+// sign authenticates button contexts and dialog state.
+func (c *Channel) sign(action, correlationID string) string {
+	mac := hmac.New(sha256.New, []byte(c.opts.Token))
+	_, _ = fmt.Fprintf(mac, "%s:%s", action, correlationID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Channel) valid(action, correlationID, token string) bool {
+	if correlationID == "" || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(c.sign(action, correlationID)), []byte(token))
+}
+
+func (c *Channel) allowed(userID string) bool {
+	return len(c.opts.AllowedUsers) == 0 || slices.Contains(c.opts.AllowedUsers, userID)
+}
+
+// call invokes a REST API endpoint and decodes its response into out when it is not nil.
+func (c *Channel) call(ctx context.Context, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mattermost %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(&apiErr)
+		return fmt.Errorf("mattermost %s: status %d: %s", path, resp.StatusCode, apiErr.Message)
+	}
+	if out != nil {
+		return json.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(out)
+	}
+	return nil
+}
+
+type interaction struct {
+	Type      string `json:"type"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	TriggerID string `json:"trigger_id"`
+	Context   struct {
+		Action        string `json:"action"`
+		CorrelationID string `json:"correlation_id"`
+		Token         string `json:"token"`
+	} `json:"context"`
+	State      string `json:"state"`
+	Cancelled  bool   `json:"cancelled"`
+	Submission struct {
+		Reason string `json:"reason"`
+	} `json:"submission"`
+}
+
+// render formats the approval as Mattermost markdown.
+func render(msg i18n.Messages, req approvals.Request) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "#### %s\n", msg.ApprovalTitle)
+	fmt.Fprintf(builder, "**%s:** `%s`\n", msg.ApprovalTool, code(req.Tool))
+	fmt.Fprintf(builder, "**%s:** `%s`\n", msg.ApprovalCorrelation, code(req.CorrelationID))
+	if value := strings.TrimSpace(req.RequestedBy); value != "" {
+		fmt.Fprintf(builder, "**%s:** %s\n", msg.RequestedByLabel, value)
+	}
+	if value := strings.TrimSpace(req.SessionID); value != "" {
+		fmt.Fprintf(builder, "**%s:** %s\n", msg.SessionLabel, value)
+	}
+	if note := channel.ExecuteAfterNote(msg, req); note != "" {
+		fmt.Fprintf(builder, "\n%s\n", note)
+	}
+	if note := channel.QueuedNote(msg, req); note != "" {
+		fmt.Fprintf(builder, "\n%s\n", note)
+	}
+	if value := strings.TrimSpace(req.Justification); value != "" {
+		fmt.Fprintf(builder, "\n**%s:** %s\n", msg.JustificationLabel, value)
+	}
+	if req.Sensitive {
+		fmt.Fprintf(builder, "**%s:** `%s`\n\n%s\n", msg.FingerprintLabel, code(req.Fingerprint), msg.SensitiveNote)
+		return builder.String()
+	}
+	if value := strings.TrimSpace(req.ApprovalRequest); value != "" {
+		fmt.Fprintf(builder, "\n**%s**\n%s\n", msg.SectionAction, value)
+	}
+	if value := strings.TrimSpace(req.RiskAssessment); value != "" {
+		fmt.Fprintf(builder, "\n**%s**\n%s\n", msg.SectionRisks, value)
+	}
+	if len(req.Arguments) > 0 {
+		if data, err := json.MarshalIndent(req.Arguments, "", "  "); err == nil {
+			fmt.Fprintf(builder, "\n**%s**\n```json\n%s\n```\n", msg.SectionParams, strings.ReplaceAll(truncate(string(data), maxArguments), "```", "` ` `"))
+		}
+	}
+	if len(req.LinksToCode) > 0 {
+		fmt.Fprintf(builder, "\n**%s:**\n", msg.LinksLabel)
+		for _, link := range req.LinksToCode {
+			fmt.Fprintf(builder, "- [%s](%s)\n", strings.NewReplacer("[", "\\[", "]", "\\]").Replace(link.Text), link.URL)
+		}
+	}
+	return builder.String()
+}
+
+// code keeps inline code spans intact.
+func code(value string) string {
+	return strings.ReplaceAll(value, "`", "'")
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit]) + "…"
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// actor identifies the user who pressed a button or submitted a dialog.
+func (i interaction) actor() *approvals.Actor {
+	return &approvals.Actor{ID: i.UserID, Username: i.UserName}
+}
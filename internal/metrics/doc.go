@@ -0,0 +1,2 @@
+// Package metrics exposes Prometheus metrics for the approval flow.
+package metrics
@@ -0,0 +1,258 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Webhook update outcomes.
+const (
+	// WebhookQueued means the update was queued for the handler.
+	WebhookQueued = "queued"
+	// WebhookDuplicate means the update was a redelivery of a queued one and was skipped.
+	WebhookDuplicate = "duplicate"
+	// WebhookDropped means the queue stayed full and Telegram was asked to retry.
+	WebhookDropped = "dropped"
+	// WebhookRejected means the instance stopped accepting updates.
+	WebhookRejected = "rejected"
+)
+
+// Telegram send queue outcomes.
+const (
+	// SendThrottled means a call waited in the queue for the Telegram rate limit.
+	SendThrottled = "throttled"
+	// SendRetried means a call was repeated after Telegram flood control rejected it.
+	SendRetried = "retried"
+	// SendCoalesced means an edit waiting in the queue was replaced by a newer edit of the same message.
+	SendCoalesced = "coalesced"
+)
+
+// Bus publish outcomes.
+const (
+	// BusPublished means the server confirmed a decision message.
+	BusPublished = "published"
+	// BusRetried means a publish failed and was repeated.
+	BusRetried = "retried"
+	// BusDropped means a decision message was given up: the queue was full, the message was too large, or the
+	// service stopped before the server could be reached.
+	BusDropped = "dropped"
+)
+
+const (
+	// otherLabel replaces tool and tenant values outside the bounded label set.
+	otherLabel = "other"
+	// noneLabel is used when a request has no tenant.
+	noneLabel = "none"
+)
+
+// Options configures label cardinality.
+type Options struct {
+	// Tools is an allowlist of tool names used as label values; empty admits tools up to MaxTools.
+	Tools []string
+	// MaxTools bounds distinct tool labels when Tools is empty.
+	MaxTools int
+	// Tenants are the known tenant names; unknown tenants are reported as "other".
+	Tenants []string
+}
+
+// Metrics records approval flow metrics. A nil Metrics records nothing.
+type Metrics struct {
+	registry  *prometheus.Registry
+	requests  *prometheus.CounterVec
+	decisions *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	acks      *prometheus.HistogramVec
+	failures  *prometheus.CounterVec
+	webhook   *prometheus.CounterVec
+	sends     *prometheus.CounterVec
+	bus       *prometheus.CounterVec
+
+	mu       sync.Mutex
+	allowed  map[string]struct{}
+	fixed    bool
+	maxTools int
+	tenants  map[string]struct{}
+}
+
+// New creates metrics registered in a dedicated registry.
+func New(opts Options) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_requests_total",
+			Help: "Approval requests sent to Telegram.",
+		}, []string{"tool", "tenant"}),
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_decisions_total",
+			Help: "Resolved approvals by decision.",
+		}, []string{"tool", "tenant", "decision"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "telegram_approver_decision_duration_seconds",
+			Help:    "Time from request to decision.",
+			Buckets: []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200},
+		}, []string{"tool", "tenant", "decision"}),
+		acks: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "telegram_approver_ack_duration_seconds",
+			Help:    "Time from request to each acknowledgement of a critical approval.",
+			Buckets: []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200},
+		}, []string{"tool", "tenant"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_errors_total",
+			Help: "Failed approval operations by error kind.",
+		}, []string{"kind"}),
+		webhook: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_webhook_updates_total",
+			Help: "Telegram webhook updates by queue outcome.",
+		}, []string{"outcome"}),
+		sends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_send_queue_total",
+			Help: "Telegram message calls held back by the send queue, by outcome.",
+		}, []string{"outcome"}),
+		bus: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "telegram_approver_bus_messages_total",
+			Help: "Decision messages published to the bus, by outcome.",
+		}, []string{"outcome"}),
+		allowed:  make(map[string]struct{}),
+		fixed:    len(opts.Tools) > 0,
+		maxTools: opts.MaxTools,
+	}
+	for _, tool := range opts.Tools {
+		m.allowed[tool] = struct{}{}
+	}
+	m.SetTenants(opts.Tenants)
+	m.registry.MustRegister(
+		m.requests,
+		m.decisions,
+		m.latency,
+		m.acks,
+		m.failures,
+		m.webhook,
+		m.sends,
+		m.bus,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// SetTenants replaces the known tenant names, e.g. after the config file is reloaded.
+func (m *Metrics) SetTenants(tenants []string) {
+	if m == nil {
+		return
+	}
+	known := make(map[string]struct{}, len(tenants))
+	for _, tenant := range tenants {
+		known[tenant] = struct{}{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tenants = known
+}
+
+// Handler returns the Prometheus scrape handler.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Requested records an approval request sent to Telegram.
+func (m *Metrics) Requested(req approvals.Request) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(m.toolLabel(req.Tool), m.tenantLabel(req.Tenant)).Inc()
+}
+
+// Resolved records the decision of an approval and how long it took.
+func (m *Metrics) Resolved(approval *approvals.Approval, decision approvals.Decision) {
+	if m == nil || approval == nil {
+		return
+	}
+	tool := m.toolLabel(approval.Request.Tool)
+	tenant := m.tenantLabel(approval.Request.Tenant)
+	m.decisions.WithLabelValues(tool, tenant, string(decision)).Inc()
+	if !approval.CreatedAt.IsZero() {
+		m.latency.WithLabelValues(tool, tenant, string(decision)).Observe(time.Since(approval.CreatedAt).Seconds())
+	}
+}
+
+// Acknowledged records how long it took an approver to acknowledge a critical approval.
+func (m *Metrics) Acknowledged(approval *approvals.Approval, at time.Time) {
+	if m == nil || approval == nil || approval.CreatedAt.IsZero() {
+		return
+	}
+	m.acks.WithLabelValues(m.toolLabel(approval.Request.Tool), m.tenantLabel(approval.Request.Tenant)).Observe(at.Sub(approval.CreatedAt).Seconds())
+}
+
+// Failed records an error of the approval flow by its kind.
+func (m *Metrics) Failed(err error) {
+	if m == nil || err == nil {
+		return
+	}
+	m.failures.WithLabelValues(approvals.ErrorKind(err)).Inc()
+}
+
+// WebhookUpdate records the queue outcome of a Telegram webhook update.
+func (m *Metrics) WebhookUpdate(outcome string) {
+	if m == nil {
+		return
+	}
+	m.webhook.WithLabelValues(outcome).Inc()
+}
+
+// WebhookQueue exposes the number of webhook updates waiting for the handler.
+func (m *Metrics) WebhookQueue(length func() int) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "telegram_approver_webhook_queue_length",
+		Help: "Telegram webhook updates waiting for the handler.",
+	}, func() float64 { return float64(length()) }))
+}
+
+// SendQueue records an outcome of the Telegram send queue.
+func (m *Metrics) SendQueue(outcome string) {
+	if m == nil {
+		return
+	}
+	m.sends.WithLabelValues(outcome).Inc()
+}
+
+// BusMessage records an outcome of publishing a decision to the bus.
+func (m *Metrics) BusMessage(outcome string) {
+	if m == nil {
+		return
+	}
+	m.bus.WithLabelValues(outcome).Inc()
+}
+
+func (m *Metrics) toolLabel(tool string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.allowed[tool]; ok {
+		return tool
+	}
+	if m.fixed || len(m.allowed) >= m.maxTools {
+		return otherLabel
+	}
+	m.allowed[tool] = struct{}{}
+	return tool
+}
+
+func (m *Metrics) tenantLabel(tenant string) string {
+	if tenant == "" {
+		return noneLabel
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tenants[tenant]; ok {
+		return tenant
+	}
+	return otherLabel
+}
@@ -0,0 +1,2 @@
+// Package mirror posts summaries of approval requests and decisions to an outbound webhook.
+package mirror
@@ -0,0 +1,132 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+const (
+	// EventSubmitted is sent when a request is posted to Telegram.
+	EventSubmitted = "submitted"
+	// EventResolved is sent when a request receives its final decision.
+	EventResolved = "resolved"
+)
+
+// Event is the JSON summary posted to the mirror URL.
+type Event struct {
+	Event         string    `json:"event"`
+	CorrelationID string    `json:"correlation_id"`
+	Tool          string    `json:"tool"`
+	Tenant        string    `json:"tenant,omitempty"`
+	RequestedBy   string    `json:"requested_by,omitempty"`
+	SessionID     string    `json:"session_id,omitempty"`
+	Summary       string    `json:"summary,omitempty"`
+	Decision      string    `json:"decision,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Deadline      time.Time `json:"deadline,omitzero"`
+	Text          string    `json:"text"`
+	At            time.Time `json:"at"`
+}
+
+// Notifier mirrors approval events to a generic webhook, e.g. a Slack bridge.
+// A nil Notifier does nothing.
+type Notifier struct {
+	client *http.Client
+	url    string
+	log    *slog.Logger
+}
+
+// New creates a notifier; it returns nil when url is empty.
+func New(url string, log *slog.Logger) *Notifier {
+	if strings.TrimSpace(url) == "" {
+		return nil
+	}
+	return &Notifier{client: &http.Client{Timeout: 10 * time.Second}, url: url, log: log}
+}
+
+// Submitted mirrors a request that was posted to Telegram.
+func (n *Notifier) Submitted(approval *approvals.Approval) {
+	if n == nil || approval == nil {
+		return
+	}
+	event := n.event(EventSubmitted, approval)
+	event.Deadline = approval.Deadline
+	event.Text = fmt.Sprintf("🔐 Approval requested: %s (%s)", event.Tool, event.CorrelationID)
+	if event.RequestedBy != "" {
+		event.Text += " by " + event.RequestedBy
+	}
+	go n.post(event)
+}
+
+// Resolved mirrors the final decision of a request.
+func (n *Notifier) Resolved(approval *approvals.Approval, result approvals.Result) {
+	if n == nil || approval == nil {
+		return
+	}
+	event := n.event(EventResolved, approval)
+	event.Decision = string(result.Decision)
+	event.Reason = result.Reason
+	event.Text = fmt.Sprintf("%s %s: %s (%s)", decisionIcon(result.Decision), result.Decision, event.Tool, event.CorrelationID)
+	if result.Reason != "" {
+		event.Text += " — " + result.Reason
+	}
+	go n.post(event)
+}
+
+// event describes the approval; the request text of sensitive tools is left out like in the chat message.
+func (n *Notifier) event(kind string, approval *approvals.Approval) Event {
+	event := Event{
+		Event:         kind,
+		CorrelationID: approval.Request.CorrelationID,
+		Tool:          approval.Request.Tool,
+		Tenant:        approval.Request.Tenant,
+		RequestedBy:   approval.Request.RequestedBy,
+		SessionID:     approval.Request.SessionID,
+		At:            time.Now().UTC(),
+	}
+	if !approval.Request.Sensitive {
+		event.Summary = approval.Request.ApprovalRequest
+	}
+	return event
+}
+
+func (n *Notifier) post(event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.log.Error("Mirror webhook delivery failed", "error", err, "correlation_id", event.CorrelationID, "event", event.Event)
+		return
+	}
+	_ = resp.Body.Close()
+}
+
+func decisionIcon(decision approvals.Decision) string {
+	switch decision {
+	case approvals.DecisionApprove:
+		return "✅"
+	case approvals.DecisionDeny:
+		return "❌"
+	case approvals.DecisionCancelled:
+		return "🚫"
+	default:
+		return "⚠️"
+	}
+}
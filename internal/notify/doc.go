@@ -0,0 +1,2 @@
+// Package notify posts human-readable decision summaries to requester-provided notify URLs.
+package notify
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// Message is the JSON body posted to the notify URL.
+// The text field makes it compatible with Slack and Mattermost incoming webhooks.
+type Message struct {
+	Text          string `json:"text"`
+	CorrelationID string `json:"correlation_id"`
+	Decision      string `json:"decision"`
+}
+
+// Notifier sends decision summaries to the notify URL of each request.
+type Notifier struct {
+	client *http.Client
+	log    *slog.Logger
+}
+
+// New creates a notifier.
+func New(log *slog.Logger) *Notifier {
+	return &Notifier{client: &http.Client{Timeout: 10 * time.Second}, log: log}
+}
+
+// Resolved posts the summary when the request has a notify URL distinct from its callback URL.
+func (n *Notifier) Resolved(approval *approvals.Approval, result approvals.Result) {
+	if n == nil || approval == nil {
+		return
+	}
+	target := strings.TrimSpace(approval.Request.NotifyURL)
+	if target == "" || target == strings.TrimSpace(approval.Request.Callback.URL) {
+		return
+	}
+	message := Message{
+		Text:          Summary(approval, result),
+		CorrelationID: approval.Request.CorrelationID,
+		Decision:      string(result.Decision),
+	}
+	go n.post(target, message)
+}
+
+// Summary renders a short markdown description of the final decision.
+func Summary(approval *approvals.Approval, result approvals.Result) string {
+	req := approval.Request
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s **%s**: `%s` (`%s`)\n", decisionIcon(result.Decision), decisionTitle(result.Decision), req.Tool, req.CorrelationID)
+	if strings.TrimSpace(req.ApprovalRequest) != "" {
+		fmt.Fprintf(&builder, "> %s\n", strings.ReplaceAll(strings.TrimSpace(req.ApprovalRequest), "\n", "\n> "))
+	}
+	if result.Reason != "" {
+		fmt.Fprintf(&builder, "**Reason:** %s\n", result.Reason)
+	}
+	if result.ForcedBy != "" {
+		fmt.Fprintf(&builder, "**Resolved by admin:** %s\n", result.ForcedBy)
+	}
+	if req.RequestedBy != "" {
+		fmt.Fprintf(&builder, "**Requested by:** %s\n", req.RequestedBy)
+	}
+	if !approval.CreatedAt.IsZero() {
+		fmt.Fprintf(&builder, "**Waited:** %s\n", time.Since(approval.CreatedAt).Round(time.Second))
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func (n *Notifier) post(target string, message Message) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.log.Error("Decision summary delivery failed", "error", err, "correlation_id", message.CorrelationID)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		n.log.Error("Decision summary rejected", "status", resp.StatusCode, "correlation_id", message.CorrelationID)
+	}
+}
+
+func decisionTitle(decision approvals.Decision) string {
+	switch decision {
+	case approvals.DecisionApprove:
+		return "Approved"
+	case approvals.DecisionDeny:
+		return "Denied"
+	case approvals.DecisionCancelled:
+		return "Cancelled"
+	default:
+		return "Failed"
+	}
+}
+
+func decisionIcon(decision approvals.Decision) string {
+	switch decision {
+	case approvals.DecisionApprove:
+		return "✅"
+	case approvals.DecisionDeny:
+		return "❌"
+	case approvals.DecisionCancelled:
+		return "🚫"
+	default:
+		return "⚠️"
+	}
+}
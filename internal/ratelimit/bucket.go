@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Bucket is a token bucket that refills rate tokens per second up to burst.
+// Tokens go negative while reserved requests wait for their turn, which keeps waiters in arrival order.
+// A Bucket is not safe for concurrent use; callers guard it with their own lock.
+type Bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket.
+func NewBucket(perSecond float64, burst int, now time.Time) *Bucket {
+	return &Bucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// PerMinute converts a per-minute limit to the per-second rate of NewBucket.
+func PerMinute(n int) float64 {
+	return float64(n) / 60
+}
+
+// Delay returns how long a request taking the next token at now has to wait.
+func (b *Bucket) Delay(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Take consumes a token; call it after Delay once the request is admitted.
+func (b *Bucket) Take() {
+	b.tokens--
+}
+
+// Block postpones the next token to until, as asked by a remote rate limit.
+func (b *Bucket) Block(now, until time.Time) {
+	b.refill(now)
+	b.tokens = math.Min(b.tokens, 1-until.Sub(now).Seconds()*b.rate)
+}
+
+// Full reports whether the bucket refilled completely, so a per-key bucket can be dropped.
+func (b *Bucket) Full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
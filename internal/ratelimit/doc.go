@@ -0,0 +1,2 @@
+// Package ratelimit provides the token bucket shared by the inbound API limits and the Telegram send queue.
+package ratelimit
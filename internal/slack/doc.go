@@ -0,0 +1,2 @@
+// Package slack posts approval requests to Slack and receives decisions from its interactivity webhook.
+package slack
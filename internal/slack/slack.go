@@ -0,0 +1,340 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+)
+
+const (
+	// Name is the channel name used in requests and configuration.
+	Name = "slack"
+	// DefaultAPIURL is the Slack Web API base URL.
+	DefaultAPIURL = "https://slack.com/api"
+
+	actionApprove = "approve"
+	actionDeny    = "deny"
+	// maxSkew bounds the age of signed interaction requests to prevent replays.
+	maxSkew = 5 * time.Minute
+	// maxSectionText is kept below the Slack limit of 3000 characters per section.
+	maxSectionText = 2900
+	maxBodyBytes   = 1 << 20
+)
+
+// Options configures the Slack channel.
+type Options struct {
+	// Token is the bot token (xoxb-...).
+	Token string
+	// SigningSecret verifies interaction requests.
+	SigningSecret string
+	// Channel is the conversation ID approvals are posted to.
+	Channel string
+	// AllowedUsers are Slack user IDs allowed to decide; empty allows everyone in the channel.
+	AllowedUsers []string
+	// APIURL overrides the Web API base URL.
+	APIURL string
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Log is the application logger.
+	Log *slog.Logger
+}
+
+// Channel posts approvals as Block Kit messages with Approve and Deny buttons.
+type Channel struct {
+	client *http.Client
+	opts   Options
+	log    *slog.Logger
+}
+
+// New creates a Slack channel.
+func New(opts Options) *Channel {
+	if opts.APIURL == "" {
+		opts.APIURL = DefaultAPIURL
+	}
+	opts.APIURL = strings.TrimRight(opts.APIURL, "/")
+	return &Channel{client: &http.Client{Timeout: 10 * time.Second}, opts: opts, log: opts.Log}
+}
+
+// Name returns the channel name.
+func (c *Channel) Name() string {
+	return Name
+}
+
+// Post sends the approval to the configured Slack channel.
+func (c *Channel) Post(ctx context.Context, approval *approvals.Approval) (approvals.ChannelRef, string, error) {
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	text := summary(msg, approval.Request)
+	blocks := append(requestBlocks(msg, approval.Request), actionsBlock(msg, approval.Request.CorrelationID))
+	var resp struct {
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}
+	err := c.call(ctx, "chat.postMessage", map[string]any{
+		"channel": c.opts.Channel,
+		"text":    text,
+		"blocks":  blocks,
+	}, &resp)
+	if err != nil {
+		return approvals.ChannelRef{}, "", err
+	}
+	return approvals.ChannelRef{Channel: Name, Conversation: resp.Channel, Message: resp.TS}, text, nil
+}
+
+// Resolve replaces the buttons of the posted message with the decision.
+func (c *Channel) Resolve(ctx context.Context, approval *approvals.Approval, result approvals.Result) error {
+	ref := approval.ChannelRef
+	if ref.Message == "" {
+		return nil
+	}
+	msg := c.opts.Messages.For(approval.Request.Lang, c.opts.DefaultLang)
+	note := channel.DecisionNote(msg, result, approval.Request.TimeoutMessage)
+	blocks := append(requestBlocks(msg, approval.Request), map[string]any{
+		"type":     "context",
+		"elements": []any{mrkdwn(escape(note))},
+	})
+	return c.call(ctx, "chat.update", map[string]any{
+		"channel": ref.Conversation,
+		"ts":      ref.Message,
+		"text":    summary(msg, approval.Request) + "\n" + escape(note),
+		"blocks":  blocks,
+	}, nil)
+}
+
+// Handler returns the interactivity webhook handler.
+func (c *Channel) Handler(decider channel.Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !c.verify(r.Header, body, time.Now()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload interaction
+		if err := json.Unmarshal([]byte(formValue(body, "payload")), &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Slack expects an answer within three seconds; decisions are applied in the background.
+		w.WriteHeader(http.StatusOK)
+		if payload.Type != "block_actions" {
+			return
+		}
+		if len(c.opts.AllowedUsers) > 0 && !slices.Contains(c.opts.AllowedUsers, payload.User.ID) {
+			c.log.Warn("Slack user is not allowed to decide", "user_id", payload.User.ID)
+			return
+		}
+		for _, action := range payload.Actions {
+			result := approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved}
+			switch action.ActionID {
+			case actionApprove:
+			case actionDeny:
+				result = approvals.Result{Decision: approvals.DecisionDeny, ReasonCode: approvals.ReasonDenied}
+			default:
+				continue
+			}
+			result.Actor = &approvals.Actor{ID: payload.User.ID, Username: payload.User.Username}
+			go func(correlationID string, result approvals.Result) {
+				err := decider.Decide(context.Background(), correlationID, result)
+				if err != nil && !approvals.NotPending(err) {
+					c.log.Error("Failed to apply Slack decision", "error", err, "correlation_id", correlationID)
+				}
+			}(action.Value, result)
+		}
+	})
+}
+
+// verify checks the X-Slack-Signature header of an interaction request.
+func (c *Channel) verify(header http.Header, body []byte, now time.Time) bool {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > maxSkew || skew < -maxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(c.opts.SigningSecret))
+	_, _ = fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// call invokes a Web API method and decodes its response into out when it is not nil.
+func (c *Channel) call(ctx context.Context, method string, params map[string]any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.APIURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("slack %s: status %d: %w", method, resp.StatusCode, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+type interaction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// summary is the plain-text fallback shown in notifications.
+func summary(msg i18n.Messages, req approvals.Request) string {
+	return escape(fmt.Sprintf("%s: %s (%s)", msg.ApprovalTitle, req.Tool, req.CorrelationID))
+}
+
+func requestBlocks(msg i18n.Messages, req approvals.Request) []any {
+	blocks := []any{
+		map[string]any{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": msg.ApprovalTitle, "emoji": true},
+		},
+	}
+	fields := []any{
+		mrkdwn(fmt.Sprintf("*%s*\n`%s`", escape(msg.ApprovalTool), escape(req.Tool))),
+		mrkdwn(fmt.Sprintf("*%s*\n`%s`", escape(msg.ApprovalCorrelation), escape(req.CorrelationID))),
+	}
+	if requestedBy := strings.TrimSpace(req.RequestedBy); requestedBy != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", escape(msg.RequestedByLabel), escape(requestedBy))))
+	}
+	if session := strings.TrimSpace(req.SessionID); session != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", escape(msg.SessionLabel), escape(session))))
+	}
+	blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	if note := channel.ExecuteAfterNote(msg, req); note != "" {
+		blocks = append(blocks, section(escape(note)))
+	}
+
+	if text := strings.TrimSpace(req.Justification); text != "" {
+		blocks = append(blocks, section(fmt.Sprintf("*%s*\n%s", escape(msg.JustificationLabel), escape(text))))
+	}
+	if req.Sensitive {
+		blocks = append(blocks, section(fmt.Sprintf("*%s*\n`%s`\n%s", escape(msg.FingerprintLabel), escape(req.Fingerprint), escape(msg.SensitiveNote))))
+		return blocks
+	}
+	if text := strings.TrimSpace(req.ApprovalRequest); text != "" {
+		blocks = append(blocks, section(fmt.Sprintf("*%s*\n%s", escape(msg.SectionAction), escape(text))))
+	}
+	if text := strings.TrimSpace(req.RiskAssessment); text != "" {
+		blocks = append(blocks, section(fmt.Sprintf("*%s*\n%s", escape(msg.SectionRisks), escape(text))))
+	}
+	if len(req.Arguments) > 0 {
+		if data, err := json.MarshalIndent(req.Arguments, "", "  "); err == nil {
+			blocks = append(blocks, section(fmt.Sprintf("*%s*\n```%s```", escape(msg.SectionParams), escape(truncate(string(data), maxSectionText-200)))))
+		}
+	}
+	if len(req.LinksToCode) > 0 {
+		lines := make([]string, 0, len(req.LinksToCode))
+		for _, link := range req.LinksToCode {
+			lines = append(lines, fmt.Sprintf("• <%s|%s>", link.URL, escape(link.Text)))
+		}
+		blocks = append(blocks, section(fmt.Sprintf("*%s*\n%s", escape(msg.LinksLabel), strings.Join(lines, "\n"))))
+	}
+	return blocks
+}
+
+func actionsBlock(msg i18n.Messages, correlationID string) any {
+	return map[string]any{
+		"type":     "actions",
+		"block_id": "decision",
+		"elements": []any{
+			button(msg.ApproveButton, actionApprove, correlationID, "primary"),
+			button(msg.DenyButton, actionDeny, correlationID, "danger"),
+		},
+	}
+}
+
+func button(text, actionID, value, style string) any {
+	return map[string]any{
+		"type":      "button",
+		"text":      map[string]any{"type": "plain_text", "text": text, "emoji": true},
+		"action_id": actionID,
+		"value":     value,
+		"style":     style,
+	}
+}
+
+func section(text string) any {
+	return map[string]any{"type": "section", "text": mrkdwn(truncate(text, maxSectionText))}
+}
+
+func mrkdwn(text string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": text}
+}
+
+// escape replaces the characters Slack treats as control sequences in mrkdwn.
+func escape(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit]) + "…"
+}
+
+// formValue extracts a field from an application/x-www-form-urlencoded body.
+func formValue(body []byte, key string) string {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return values.Get(key)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// sealedRecord is the at-rest form of an approval when encryption is enabled.
+type sealedRecord struct {
+	// Sealed is base64 of the AES-GCM nonce followed by the encrypted approval JSON.
+	Sealed string `json:"sealed"`
+}
+
+// codec encodes approvals for storage, encrypting them when a key is configured.
+type codec struct {
+	aead cipher.AEAD
+}
+
+// newCodec creates a codec; an empty key stores approvals as plain JSON.
+func newCodec(key []byte) (codec, error) {
+	if len(key) == 0 {
+		return codec{}, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return codec{}, fmt.Errorf("init store cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return codec{}, fmt.Errorf("init store cipher: %w", err)
+	}
+	return codec{aead: aead}, nil
+}
+
+func (c codec) marshal(approval approvals.Approval) ([]byte, error) {
+	data, err := json.Marshal(approval)
+	if err != nil || c.aead == nil {
+		return data, err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := c.aead.Seal(nonce, nonce, data, nil)
+	return json.Marshal(sealedRecord{Sealed: base64.StdEncoding.EncodeToString(sealed)})
+}
+
+// unmarshal decodes a stored approval; plain records are accepted so existing data keeps loading after
+// encryption is turned on.
+func (c codec) unmarshal(data []byte) (approvals.Approval, error) {
+	var approval approvals.Approval
+	var record sealedRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return approval, err
+	}
+	if record.Sealed == "" {
+		err := json.Unmarshal(data, &approval)
+		return approval, err
+	}
+	if c.aead == nil {
+		return approval, errors.New("approval is encrypted but no store encryption key is configured")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(record.Sealed)
+	if err != nil {
+		return approval, fmt.Errorf("decode sealed approval: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return approval, errors.New("sealed approval is truncated")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return approval, fmt.Errorf("decrypt approval: %w", err)
+	}
+	err = json.Unmarshal(plain, &approval)
+	return approval, err
+}
@@ -0,0 +1,2 @@
+// Package storage provides persistence backends for pending approvals.
+package storage
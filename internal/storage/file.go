@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// compactSlack is how many stale journal records are tolerated on top of the live ones before compaction.
+const compactSlack = 256
+
+// File persists pending approvals on disk as a journal of JSON lines.
+// Every change appends one record; concurrent changes share an fsync, and the journal is rewritten atomically via
+// a temporary file and rename once stale records outnumber the live ones.
+type File struct {
+	path  string
+	codec codec
+
+	// mu guards the state below and appends to the journal.
+	mu        sync.Mutex
+	approvals map[string]approvals.Approval
+	journal   *os.File
+	records   int
+	written   uint64
+
+	// syncMu serializes fsync and compaction; it is taken before mu.
+	syncMu sync.Mutex
+	synced uint64
+}
+
+// fileRecord is one journal line. The document of earlier versions, which listed all approvals at once, reads as a
+// single record.
+type fileRecord struct {
+	Approvals []json.RawMessage `json:"approvals,omitempty"`
+	Save      json.RawMessage   `json:"save,omitempty"`
+	Delete    string            `json:"delete,omitempty"`
+}
+
+// NewFile opens or creates a file store at path; a non-empty key encrypts stored approvals with AES-GCM.
+func NewFile(path string, key []byte) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create store directory: %w", err)
+	}
+	codec, err := newCodec(key)
+	if err != nil {
+		return nil, err
+	}
+	store := &File{path: path, codec: codec, approvals: make(map[string]approvals.Approval)}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read store file: %w", err)
+	}
+	if err := store.replay(data); err != nil {
+		return nil, fmt.Errorf("parse store file: %w", err)
+	}
+	// Start from a compact journal, which also drops a record torn by a crash.
+	if err := store.compact(); err != nil {
+		return nil, fmt.Errorf("write store file: %w", err)
+	}
+	return store, nil
+}
+
+// Ping checks that the store directory is still writable.
+func (f *File) Ping(context.Context) error {
+	probe, err := os.CreateTemp(filepath.Dir(f.path), ".probe-*")
+	if err != nil {
+		return fmt.Errorf("store directory is not writable: %w", err)
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// Save creates or replaces the approval.
+func (f *File) Save(approval approvals.Approval) error {
+	raw, err := f.codec.marshal(approval)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.approvals[approval.Request.CorrelationID] = approval
+	seq, err := f.append(fileRecord{Save: raw})
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return f.sync(seq)
+}
+
+// Delete removes the approval.
+func (f *File) Delete(correlationID string) error {
+	f.mu.Lock()
+	if _, ok := f.approvals[correlationID]; !ok {
+		f.mu.Unlock()
+		return nil
+	}
+	delete(f.approvals, correlationID)
+	seq, err := f.append(fileRecord{Delete: correlationID})
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return f.sync(seq)
+}
+
+// Load returns all stored approvals ordered by creation time.
+func (f *File) Load() ([]approvals.Approval, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sorted(), nil
+}
+
+// Close closes the journal.
+func (f *File) Close() error {
+	f.syncMu.Lock()
+	defer f.syncMu.Unlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.journal.Close()
+}
+
+func (f *File) sorted() []approvals.Approval {
+	list := make([]approvals.Approval, 0, len(f.approvals))
+	for _, approval := range f.approvals {
+		list = append(list, approval)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// replay applies the journal to the in-memory state. A last line without a newline is a record torn by a crash
+// and is ignored when it does not parse.
+func (f *File) replay(data []byte) error {
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte("\n"))
+		data = rest
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if !complete && !bytes.HasPrefix(line, []byte(`{"approvals"`)) {
+				return nil
+			}
+			return err
+		}
+		for _, raw := range append(record.Approvals, record.Save) {
+			if len(raw) == 0 {
+				continue
+			}
+			approval, err := f.codec.unmarshal(raw)
+			if err != nil {
+				return err
+			}
+			f.approvals[approval.Request.CorrelationID] = approval
+		}
+		if record.Delete != "" {
+			delete(f.approvals, record.Delete)
+		}
+	}
+	return nil
+}
+
+// append writes a record to the journal and returns its sequence number; f.mu must be held.
+func (f *File) append(record fileRecord) (uint64, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.journal.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	f.records++
+	f.written++
+	return f.written, nil
+}
+
+// sync makes the record seq durable. Whoever syncs first covers every record written so far, so concurrent writers
+// wait for one fsync instead of one each.
+func (f *File) sync(seq uint64) error {
+	f.syncMu.Lock()
+	defer f.syncMu.Unlock()
+	if f.synced >= seq {
+		return nil
+	}
+	f.mu.Lock()
+	written, journal := f.written, f.journal
+	stale := f.records > 2*len(f.approvals)+compactSlack
+	f.mu.Unlock()
+	if stale {
+		return f.compact()
+	}
+	if err := journal.Sync(); err != nil {
+		return err
+	}
+	f.synced = written
+	return nil
+}
+
+// compact rewrites the journal with one record per pending approval; f.syncMu must be held or the store not yet
+// shared.
+func (f *File) compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buf bytes.Buffer
+	for _, approval := range f.sorted() {
+		raw, err := f.codec.marshal(approval)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(fileRecord{Save: raw})
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	journal, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if f.journal != nil {
+		_ = f.journal.Close()
+	}
+	f.journal = journal
+	f.records = len(f.approvals)
+	f.synced = f.written
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript renews the lease when the caller owns it and takes it when it is free.
+var acquireScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if not owner then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes a key only when it holds the caller's value: the lease of its owner or a duplicate index
+// entry of its approval.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLease is a lease stored under a single Redis key.
+type RedisLease struct {
+	client *redis.Client
+	key    string
+}
+
+// NewLease returns a lease sharing the connection of a Redis store.
+func NewLease(store approvals.Store, key string) (*RedisLease, error) {
+	r, ok := store.(*Redis)
+	if !ok {
+		return nil, fmt.Errorf("standby lease requires the redis store")
+	}
+	return &RedisLease{client: r.client, key: key}, nil
+}
+
+// Acquire takes a free lease or renews one held by holder and reports whether holder owns it.
+func (l *RedisLease) Acquire(holder string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	owned, err := acquireScript.Run(ctx, l.client, []string{l.key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return owned == 1, nil
+}
+
+// Force hands the lease to holder regardless of the current owner.
+func (l *RedisLease) Force(holder string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return l.client.Set(ctx, l.key, holder, ttl).Err()
+}
+
+// Release frees the lease if holder owns it.
+func (l *RedisLease) Release(holder string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return releaseScript.Run(ctx, l.client, []string{l.key}, holder).Err()
+}
+
+// RedisPresence announces instances with one expiring Redis key each.
+type RedisPresence struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewPresence returns instance presence sharing the connection of a Redis store.
+func NewPresence(store approvals.Store, prefix string) (*RedisPresence, error) {
+	r, ok := store.(*Redis)
+	if !ok {
+		return nil, fmt.Errorf("webhook handoff requires the redis store")
+	}
+	return &RedisPresence{client: r.client, prefix: prefix}, nil
+}
+
+// Register announces holder for ttl.
+func (p *RedisPresence) Register(holder string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return p.client.Set(ctx, p.prefix+holder, time.Now().UTC().Format(time.RFC3339), ttl).Err()
+}
+
+// Deregister removes holder.
+func (p *RedisPresence) Deregister(holder string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return p.client.Del(ctx, p.prefix+holder).Err()
+}
+
+// Count returns the number of announced instances.
+func (p *RedisPresence) Count() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	count := 0
+	iter := p.client.Scan(ctx, 0, p.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	return count, iter.Err()
+}
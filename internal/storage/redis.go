@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisOpTimeout = 5 * time.Second
+	// redisTTLGrace keeps keys a bit past the deadline so the timeout handler can still claim them.
+	redisTTLGrace = 5 * time.Minute
+)
+
+// Redis shares pending approvals and their duplicate index between replicas.
+// Keys expire shortly after the approval deadline.
+type Redis struct {
+	client      *redis.Client
+	prefix      string
+	indexPrefix string
+	codec       codec
+}
+
+// NewRedis connects to Redis using a redis:// or rediss:// URL; a non-empty key encrypts stored approvals.
+// Duplicate index entries are kept under indexPrefix, which must not start with prefix.
+func NewRedis(rawURL, prefix, indexPrefix string, key []byte) (*Redis, error) {
+	codec, err := newCodec(key)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
+	return &Redis{client: client, prefix: prefix, indexPrefix: indexPrefix, codec: codec}, nil
+}
+
+// Ping checks the Redis connection.
+func (r *Redis) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, redisOpTimeout)
+	defer cancel()
+	return r.client.Ping(ctx).Err()
+}
+
+// Save creates or replaces the approval.
+func (r *Redis) Save(approval approvals.Approval) error {
+	data, err := r.codec.marshal(approval)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return r.client.Set(ctx, r.key(approval.Request.CorrelationID), data, ttl(approval)).Err()
+}
+
+// Create saves the approval only if the correlation ID is unused.
+func (r *Redis) Create(approval approvals.Approval) (bool, error) {
+	data, err := r.codec.marshal(approval)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return r.client.SetNX(ctx, r.key(approval.Request.CorrelationID), data, ttl(approval)).Result()
+}
+
+// Get returns the approval or nil when it is missing.
+func (r *Redis) Get(correlationID string) (*approvals.Approval, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	data, err := r.client.Get(ctx, r.key(correlationID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	approval, err := r.codec.unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// Claim deletes the approval and reports whether this caller removed it.
+func (r *Redis) Claim(correlationID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	removed, err := r.client.Del(ctx, r.key(correlationID)).Result()
+	return removed > 0, err
+}
+
+// Delete removes the approval.
+func (r *Redis) Delete(correlationID string) error {
+	_, err := r.Claim(correlationID)
+	return err
+}
+
+// Load returns all stored approvals ordered by creation time.
+func (r *Redis) Load() ([]approvals.Approval, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	var list []approvals.Approval
+	iter := r.client.Scan(ctx, 0, r.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := r.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		approval, err := r.codec.unmarshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", iter.Val(), err)
+		}
+		list = append(list, approval)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// Index points key at the approval, replacing an older entry; the entry expires with the approval.
+func (r *Redis) Index(key, correlationID string, deadline time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return r.client.Set(ctx, r.indexPrefix+key, correlationID, expiry(deadline)).Err()
+}
+
+// Indexed returns the correlation ID key points at, or "" when there is none.
+func (r *Redis) Indexed(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	correlationID, err := r.client.Get(ctx, r.indexPrefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return correlationID, err
+}
+
+// Unindex removes key if it still points at the approval.
+func (r *Redis) Unindex(key, correlationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return releaseScript.Run(ctx, r.client, []string{r.indexPrefix + key}, correlationID).Err()
+}
+
+// Close releases the Redis connection pool.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func (r *Redis) key(correlationID string) string {
+	return r.prefix + correlationID
+}
+
+func ttl(approval approvals.Approval) time.Duration {
+	return expiry(approval.Deadline)
+}
+
+// expiry is the TTL of keys that live until deadline; a zero deadline never expires.
+func expiry(deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return 0
+	}
+	remaining := time.Until(deadline) + redisTTLGrace
+	if remaining < redisTTLGrace {
+		return redisTTLGrace
+	}
+	return remaining
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+)
+
+// New creates the approval store selected by configuration; memory mode returns nil.
+func New(cfg config.Config) (approvals.Store, error) {
+	switch cfg.Store {
+	case config.StoreMemory:
+		return nil, nil
+	case config.StoreFile:
+		return NewFile(cfg.StoreFile, cfg.StoreEncryptionKeyBytes)
+	case config.StoreRedis:
+		return NewRedis(cfg.RedisURL, cfg.RedisPrefix, cfg.DuplicateKeyPrefix, cfg.StoreEncryptionKeyBytes)
+	default:
+		return nil, fmt.Errorf("unsupported store %q", cfg.Store)
+	}
+}
+
+// pinger is implemented by stores backed by an external resource.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the store backend is reachable; memory mode always is.
+func Ping(ctx context.Context, store approvals.Store) error {
+	target, ok := store.(pinger)
+	if !ok {
+		return nil
+	}
+	return target.Ping(ctx)
+}
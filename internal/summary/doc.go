@@ -0,0 +1,2 @@
+// Package summary asks an OpenAI chat model for a plain-language summary and the main risks of an approval request.
+package summary
@@ -0,0 +1,147 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+const (
+	// maxInput bounds the characters of one request field sent to the model.
+	maxInput = 4000
+	// maxSummary bounds the characters of the generated summary.
+	maxSummary = 600
+	// maxRisks is how many risk highlights are kept.
+	maxRisks = 3
+	// maxRisk bounds the characters of one risk highlight.
+	maxRisk = 160
+)
+
+// prompt instructs the model; the request follows as the user message.
+const prompt = `You help a human approve or deny actions of an automated agent from a phone.
+Read the tool call and explain it in plain language.
+Answer with a JSON object {"summary": string, "risks": [string]}.
+"summary" is one short paragraph of at most three sentences saying what will happen and to what.
+"risks" lists at most three concrete risks of running the call, most serious first, each a short phrase; use [] when there are none.
+Do not repeat the justification of the agent and do not give advice. Write in the language named in the request.`
+
+// Summary is the generated text of one request.
+type Summary struct {
+	// Text is the plain-language summary.
+	Text string `json:"summary"`
+	// Risks are the main risks, most serious first.
+	Risks []string `json:"risks"`
+}
+
+// Summarizer generates summaries with an OpenAI chat model. A nil Summarizer generates nothing.
+type Summarizer struct {
+	client  openai.Client
+	model   string
+	timeout time.Duration
+	log     *slog.Logger
+}
+
+// New creates a summarizer; it returns nil when model is empty.
+func New(apiKey, model string, timeout time.Duration, httpClient *http.Client, log *slog.Logger) *Summarizer {
+	if strings.TrimSpace(model) == "" {
+		return nil
+	}
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+	return &Summarizer{client: openai.NewClient(opts...), model: model, timeout: timeout, log: log}
+}
+
+// Summarize describes req in language, e.g. "en". Failures are logged and leave the summary empty, so a slow or
+// unavailable model never blocks an approval.
+func (s *Summarizer) Summarize(ctx context.Context, req approvals.Request, language string) Summary {
+	if s == nil {
+		return Summary{}
+	}
+	started := time.Now()
+	summary, err := s.ask(ctx, req, language)
+	if err != nil {
+		s.log.Warn("Failed to summarize approval request", "error", err, "correlation_id", req.CorrelationID)
+		return Summary{}
+	}
+	s.log.Debug("Summarized approval request", "correlation_id", req.CorrelationID, "elapsed", time.Since(started))
+	return summary
+}
+
+func (s *Summarizer) ask(ctx context.Context, req approvals.Request, language string) (Summary, error) {
+	input, err := describe(req, language)
+	if err != nil {
+		return Summary{}, err
+	}
+	askCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	resp, err := s.client.Chat.Completions.New(askCtx, openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(s.model),
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(prompt), openai.UserMessage(input)},
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		},
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Summary{}, errors.New("empty summary result")
+	}
+	var summary Summary
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &summary); err != nil {
+		return Summary{}, fmt.Errorf("parse summary result: %w", err)
+	}
+	summary.Text = truncate(strings.TrimSpace(summary.Text), maxSummary)
+	risks := make([]string, 0, maxRisks)
+	for _, risk := range summary.Risks {
+		if risk = strings.TrimSpace(risk); risk != "" && len(risks) < maxRisks {
+			risks = append(risks, truncate(risk, maxRisk))
+		}
+	}
+	summary.Risks = risks
+	return summary, nil
+}
+
+// describe renders the request fields the model needs as the user message.
+func describe(req approvals.Request, language string) (string, error) {
+	arguments, err := json.MarshalIndent(req.Arguments, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode arguments: %w", err)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Language: %s\nTool: %s\n", language, req.Tool)
+	fmt.Fprintf(&b, "Arguments:\n%s\n", truncate(string(arguments), maxInput))
+	for _, field := range []struct{ name, value string }{
+		{"Request", req.ApprovalRequest},
+		{"Justification", req.Justification},
+		{"Risk assessment by the agent", req.RiskAssessment},
+		{"Task", req.TaskSummary},
+		{"Diff", req.Diff},
+	} {
+		if value := strings.TrimSpace(field.value); value != "" {
+			fmt.Fprintf(&b, "%s:\n%s\n", field.name, truncate(value, maxInput))
+		}
+	}
+	return b.String(), nil
+}
+
+// truncate shortens text to at most limit runes.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
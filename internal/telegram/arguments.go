@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxTableKeyWidth caps key column padding so a single long key doesn't push values off narrow screens.
+	maxTableKeyWidth = 24
+	// maxCodeChunk is the largest snippet, in runes, rendered as a single fenced block.
+	maxCodeChunk = 1000
+)
+
+// codeArgument is a string argument rendered as a fenced block with a language hint.
+type codeArgument struct {
+	Name     string
+	Language string
+	Chunks   []string
+}
+
+// splitCodeArguments separates arguments marked with a language from the rest.
+// Only string values are treated as code; other marked values stay in the table.
+func splitCodeArguments(args map[string]any, languages map[string]string) (map[string]any, []codeArgument) {
+	if len(languages) == 0 {
+		return args, nil
+	}
+	rest := make(map[string]any, len(args))
+	var code []codeArgument
+	for name, value := range args {
+		text, ok := value.(string)
+		language, marked := languages[name]
+		if !ok || !marked {
+			rest[name] = value
+			continue
+		}
+		code = append(code, codeArgument{Name: name, Language: language, Chunks: chunkCode(text, maxCodeChunk)})
+	}
+	sort.Slice(code, func(i, j int) bool { return code[i].Name < code[j].Name })
+	return rest, code
+}
+
+// chunkCode splits a snippet at line boundaries into parts of at most limit runes.
+// Lines longer than limit are split as-is.
+func chunkCode(text string, limit int) []string {
+	text = strings.TrimRight(text, "\n")
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+	var chunks []string
+	var current strings.Builder
+	size := 0
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, strings.TrimSuffix(current.String(), "\n"))
+			current.Reset()
+			size = 0
+		}
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		for len(runes) > limit {
+			flush()
+			chunks = append(chunks, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		if size+len(runes) > limit {
+			flush()
+		}
+		current.WriteString(string(runes))
+		size += len(runes)
+	}
+	flush()
+	return chunks
+}
+
+// renderArguments formats tool arguments for a monospace block.
+// Flat maps become an aligned key/value table; nested values fall back to indented JSON.
+func renderArguments(args map[string]any) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if rows, ok := argumentRows(args); ok {
+		return formatTable(rows)
+	}
+	data, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", args)
+	}
+	return string(data)
+}
+
+// argumentRows returns sorted key/value rows when every value is a scalar.
+func argumentRows(args map[string]any) ([][2]string, bool) {
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	rows := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		value, ok := scalarText(args[key])
+		if !ok {
+			return nil, false
+		}
+		rows = append(rows, [2]string{key, value})
+	}
+	return rows, true
+}
+
+func scalarText(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "null", true
+	case string:
+		if strings.Contains(v, "\n") {
+			return "", false
+		}
+		return v, true
+	case bool, float64, float32, int, int64, json.Number:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+func formatTable(rows [][2]string) string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, utf8.RuneCountInString(row[0]))
+	}
+	width = min(width, maxTableKeyWidth)
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		padding := max(width-utf8.RuneCountInString(row[0]), 0)
+		lines = append(lines, row[0]+strings.Repeat(" ", padding)+"  "+row[1])
+	}
+	return strings.Join(lines, "\n")
+}
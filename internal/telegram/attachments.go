@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// withoutData returns attachments without their content, so pending approvals keep only the names.
+func withoutData(attachments []approvals.Attachment) []approvals.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+	names := make([]approvals.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		names[i] = approvals.Attachment{Name: attachment.Name, URL: attachment.URL, Caption: attachment.Caption}
+	}
+	return names
+}
+
+// sendAttachments uploads attachments as documents replying to the approval message.
+// A failed upload is logged and does not affect the approval.
+func (s *Service) sendAttachments(ctx context.Context, message *telego.Message, correlationID string, attachments []approvals.Attachment) {
+	for _, attachment := range attachments {
+		file := tu.FileFromURL(attachment.URL)
+		if attachment.URL == "" {
+			file = tu.File(tu.NameReader(bytes.NewReader(attachment.Data), attachment.Name))
+		}
+		params := &telego.SendDocumentParams{
+			ChatID:              tu.ID(message.Chat.ID),
+			MessageThreadID:     message.MessageThreadID,
+			Document:            file,
+			Caption:             attachment.Caption,
+			ReplyParameters:     &telego.ReplyParameters{MessageID: message.MessageID, AllowSendingWithoutReply: true},
+			DisableNotification: true,
+		}
+		if _, err := s.bot.SendDocument(ctx, params); err != nil {
+			s.log.Error("Failed to upload approval attachment", "error", err, "correlation_id", correlationID, "name", attachment.Name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+)
+
+var (
+	// ErrUnknownChannel is returned when a request names a channel that is not configured.
+	ErrUnknownChannel = errors.New("unknown channel")
+	// ErrChannelApproval is returned when a Telegram-only operation targets an approval posted to another channel.
+	ErrChannelApproval = errors.New("approval is posted to another channel")
+)
+
+// HasChannel reports whether approvals can be posted to the named channel.
+func (s *Service) HasChannel(name string) bool {
+	if name == "" || name == channel.Telegram {
+		return true
+	}
+	_, ok := s.channels[name]
+	return ok
+}
+
+// Decide applies a decision made in a channel other than Telegram.
+func (s *Service) Decide(ctx context.Context, correlationID string, result approvals.Result) error {
+	if !s.Serving() {
+		return ErrStandby
+	}
+	approval, prompt, ok := s.registry.Resolve(correlationID)
+	if !ok {
+		return s.notPending(correlationID)
+	}
+	s.stopTimeout(correlationID)
+	s.stopEscalation(correlationID)
+	_ = s.handler.DeleteMessage(ctx, prompt)
+	s.handler.FinalizeApproval(ctx, approval, result, "")
+	s.log.Info("Approval decided in channel", "correlation_id", correlationID, "channel", approval.Request.Channel, "decision", result.Decision)
+	return nil
+}
+
+// submitToChannel registers an approval and posts it to a channel other than Telegram.
+// Escalation, quorum, and discussion are Telegram features and do not apply.
+func (s *Service) submitToChannel(ctx context.Context, ch channel.Channel, req approvals.Request, deadline time.Time) (approvals.Result, error) {
+	req.Escalation = approvals.Escalation{Disabled: true}
+	approval, err := s.registry.Add(req, deadline)
+	if err != nil {
+		return approvals.Result{Decision: approvals.DecisionError, Reason: err.Error()}, err
+	}
+	s.audit.Requested(approval)
+	ref, text, err := ch.Post(ctx, approval)
+	if err != nil {
+		s.log.Error("Failed to post approval", "error", err, "channel", ch.Name(), "correlation_id", req.CorrelationID)
+		result := approvals.Result{Decision: approvals.DecisionError, Reason: "failed to post " + ch.Name() + " message"}
+		if failed, _, ok := s.registry.Resolve(req.CorrelationID); ok {
+			s.audit.Resolved(failed, result)
+		}
+		return result, err
+	}
+	s.registry.SetChannelRef(req.CorrelationID, ref, text)
+	s.metrics.Requested(req)
+	s.mirror.Submitted(approval)
+	s.scheduleTimeout(req.CorrelationID, deadline)
+	return approvals.Result{Decision: approvals.DecisionPending, Reason: "queued"}, nil
+}
@@ -0,0 +1,222 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/storage"
+)
+
+// conformanceChatID is the approval chat the fixtures are recorded in.
+const conformanceChatID = "-1001234567890"
+
+// quietPeriod is how long a step without expected calls waits for calls that should not come.
+const quietPeriod = 200 * time.Millisecond
+
+// scenario is a recorded conversation in testdata/conformance: a request, the updates approvers sent in reply and
+// what the service must do in return.
+type scenario struct {
+	// Request is submitted through SubmitApproval; its callback URL points at the test receiver.
+	Request approvals.Request `json:"request"`
+	// Transcript is what the speech-to-text backend returns for voice messages.
+	Transcript string `json:"transcript"`
+	// Submitted are the Bot API calls made while posting the request.
+	Submitted []expectedCall `json:"submitted"`
+	// Steps are the updates replayed one by one.
+	Steps []step `json:"steps"`
+	// Callback is the expected callback body; nil means no callback is sent.
+	Callback map[string]any `json:"callback"`
+	// Pending reports that the approval is still pending after the last step.
+	Pending bool `json:"pending"`
+}
+
+// step is an update and the Bot API calls it must cause, in order.
+type step struct {
+	Update json.RawMessage `json:"update"`
+	Calls  []expectedCall  `json:"calls"`
+}
+
+// expectedCall matches a Bot API call; Params lists only the parameters that matter.
+type expectedCall struct {
+	Method string         `json:"method"`
+	Params map[string]any `json:"params"`
+}
+
+// TestConformance replays the recorded Telegram updates through the service against the fake Bot API.
+func TestConformance(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "conformance", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no conformance fixtures found")
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			runScenario(t, loadScenario(t, path))
+		})
+	}
+}
+
+func loadScenario(t *testing.T, path string) scenario {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sc scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	return sc
+}
+
+func runScenario(t *testing.T, sc scenario) {
+	bot := newFakeBot(t, sc.Transcript)
+	receiver := newCallbackReceiver(t)
+	service := newConformanceService(t, bot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := service.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	req := sc.Request
+	req.Callback.URL = receiver.server.URL
+	result, err := service.SubmitApproval(ctx, req, time.Hour, "")
+	if err != nil {
+		t.Fatalf("SubmitApproval: %v", err)
+	}
+	if result.Decision != approvals.DecisionPending {
+		t.Fatalf("SubmitApproval decision = %q, want pending", result.Decision)
+	}
+	seen := expectCalls(t, bot, 0, sc.Submitted, "submit")
+	for i, step := range sc.Steps {
+		bot.waitConfirmed(t, bot.deliver(t, step.Update))
+		seen = expectCalls(t, bot, seen, step.Calls, fmt.Sprintf("step %d", i+1))
+	}
+	if pending := service.registry.Get(req.CorrelationID) != nil; pending != sc.Pending {
+		t.Errorf("approval pending = %v, want %v", pending, sc.Pending)
+	}
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stopCancel()
+	if err := service.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	cancel()
+	if extra := bot.recorded()[seen:]; len(extra) > 0 {
+		t.Errorf("unexpected calls after the last step: %s", describeCalls(extra))
+	}
+
+	callbacks := receiver.received()
+	switch {
+	case sc.Callback == nil && len(callbacks) > 0:
+		t.Errorf("unexpected callbacks: %v", callbacks)
+	case sc.Callback != nil && len(callbacks) != 1:
+		t.Errorf("got %d callbacks, want 1: %v", len(callbacks), callbacks)
+	case sc.Callback != nil && !matches(sc.Callback, callbacks[0]):
+		t.Errorf("callback = %v, want %v", callbacks[0], sc.Callback)
+	}
+}
+
+// expectCalls waits for the calls following the first seen ones and compares them with want.
+// It returns the number of calls seen so far.
+func expectCalls(t *testing.T, bot *fakeBot, seen int, want []expectedCall, stage string) int {
+	t.Helper()
+	if len(want) == 0 {
+		time.Sleep(quietPeriod)
+	}
+	calls := bot.waitCalls(seen + len(want))
+	got := calls[seen:]
+	if len(got) < len(want) {
+		t.Fatalf("%s: got calls %s, want %d calls", stage, describeCalls(got), len(want))
+	}
+	got = got[:len(want)]
+	if len(want) == 0 && len(calls) > seen {
+		t.Fatalf("%s: unexpected calls %s", stage, describeCalls(calls[seen:]))
+	}
+	for i, call := range want {
+		if got[i].Method != call.Method || !matches(call.Params, got[i].Params) {
+			t.Fatalf("%s: call %d = %s %v, want %s %v", stage, i+1, got[i].Method, got[i].Params, call.Method, call.Params)
+		}
+	}
+	return seen + len(want)
+}
+
+// matches reports whether got contains every value of want; maps may hold more keys than wanted.
+func matches(want, got any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		got, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			if !matches(value, got[key]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		got, ok := got.([]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !matches(want[i], got[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// newConformanceService builds the service from environment configuration the way main does, against bot.
+func newConformanceService(t *testing.T, bot *fakeBot) *Service {
+	t.Helper()
+	t.Setenv("TG_APPROVER_TOKEN", fakeToken)
+	t.Setenv("TG_APPROVER_HTTP_HOST", "127.0.0.1")
+	t.Setenv("TG_APPROVER_CHAT_ID", conformanceChatID)
+	t.Setenv("TG_APPROVER_API_URL", bot.URL())
+	t.Setenv("TG_APPROVER_LANG", "en")
+	t.Setenv("TG_APPROVER_OPENAI_API_KEY", "test")
+	t.Setenv("OPENAI_BASE_URL", bot.URL()+"/openai/")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.DiscardHandler)
+	bundle, err := i18n.Load(cfg.Lang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := i18n.NewLive(bundle, cfg.I18nDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := New(cfg, messages, approvals.NewRegistry(store, log), approvals.NewDecisionCache(cfg.DecisionCacheTTL),
+		approvals.NewHistory(cfg.HistorySize, cfg.HistoryRetention), metrics.New(metrics.Options{}), Cluster{}, nil, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+const (
+	// maxDiffText bounds the part of a diff shown in the message, in characters.
+	maxDiffText = 2500
+	// fullDiffName is the name of the document holding a diff too long for the message.
+	fullDiffName = "full.diff"
+)
+
+// fitDiff truncates the request diff to what fits into the message and attaches the full diff when it was cut.
+// The shown part shrinks until the rendered message fits; other oversized fields are left to renderChecked.
+func (s *Service) fitDiff(req *approvals.Request) {
+	full := strings.TrimRight(req.Diff, "\n")
+	if strings.TrimSpace(full) == "" || req.Sensitive {
+		req.Diff = ""
+		return
+	}
+	limit := maxDiffText
+	shown, omitted := truncateDiff(full, limit)
+	for range 3 {
+		candidate := *req
+		candidate.Diff, candidate.DiffOmitted = shown, omitted
+		if omitted > 0 {
+			candidate.Attachments = append(slices.Clone(req.Attachments), approvals.Attachment{Name: fullDiffName})
+		}
+		excess := len([]rune(s.renderMessage(candidate))) - maxMessageText
+		if excess <= 0 {
+			break
+		}
+		limit -= excess
+		if limit <= 0 {
+			shown, omitted = "", strings.Count(full, "\n")+1
+			break
+		}
+		shown, omitted = truncateDiff(full, limit)
+	}
+	req.Diff, req.DiffOmitted = shown, omitted
+	if omitted > 0 {
+		req.Attachments = append(req.Attachments, approvals.Attachment{Name: fullDiffName, Data: []byte(full + "\n")})
+	}
+}
+
+// truncateDiff keeps whole leading lines of the diff within limit characters and reports how many lines were cut.
+// The cut moves back to the last file or hunk header when that keeps at least half of the lines, so the message
+// does not end in the middle of a hunk.
+func truncateDiff(diff string, limit int) (string, int) {
+	if len([]rune(diff)) <= limit {
+		return diff, 0
+	}
+	lines := strings.Split(diff, "\n")
+	size, kept := 0, 0
+	for kept < len(lines) {
+		next := len([]rune(lines[kept])) + 1
+		if size+next > limit {
+			break
+		}
+		size += next
+		kept++
+	}
+	if kept == 0 {
+		return "", len(lines)
+	}
+	for i := kept - 1; i >= kept/2 && i > 0; i-- {
+		if strings.HasPrefix(lines[i], "@@") || strings.HasPrefix(lines[i], "diff --git") {
+			kept = i
+			break
+		}
+	}
+	return strings.Join(lines[:kept], "\n"), len(lines) - kept
+}
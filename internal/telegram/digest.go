@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// digestLoop periodically posts a summary of long-pending approvals into each chat.
+func (s *Service) digestLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.DigestInterval)
+	defer ticker.Stop()
+	last := make(map[int64]int)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.postDigests(ctx, last)
+		}
+	}
+}
+
+// postDigests sends one digest per chat and replaces the previous digest message there.
+func (s *Service) postDigests(ctx context.Context, last map[int64]int) {
+	if !s.Active() {
+		return
+	}
+	now := time.Now()
+	byChat := make(map[int64][]approvals.Approval)
+	for _, approval := range s.registry.List() {
+		if approval.MessageID == 0 || now.Sub(approval.CreatedAt) < s.cfg.DigestMinAge {
+			continue
+		}
+		byChat[approval.ChatID] = append(byChat[approval.ChatID], approval)
+	}
+	for chatID, messageID := range last {
+		if _, ok := byChat[chatID]; !ok {
+			_ = s.handler.DeleteMessage(ctx, approvals.MessageRef{ChatID: chatID, MessageID: messageID})
+			delete(last, chatID)
+		}
+	}
+	for chatID, pending := range byChat {
+		msg, err := s.bot.SendMessage(ctx, &telego.SendMessageParams{
+			ChatID:              tu.ID(chatID),
+			Text:                s.renderDigest(chatID, pending, now),
+			ParseMode:           telego.ModeHTML,
+			DisableNotification: s.handler.Muted(chatID),
+			LinkPreviewOptions:  &telego.LinkPreviewOptions{IsDisabled: true},
+		})
+		if err != nil {
+			s.log.Error("Failed to send approval digest", "error", err, "chat_id", chatID)
+			continue
+		}
+		if previous, ok := last[chatID]; ok {
+			_ = s.handler.DeleteMessage(ctx, approvals.MessageRef{ChatID: chatID, MessageID: previous})
+		}
+		last[chatID] = msg.MessageID
+	}
+}
+
+// renderDigest groups pending approvals by tool with links to the approval messages. The most severe requests come
+// first, then the oldest.
+func (s *Service) renderDigest(chatID int64, pending []approvals.Approval, now time.Time) string {
+	msg := s.messagesFor(s.lang)
+	pending = slices.Clone(pending)
+	sort.SliceStable(pending, func(i, j int) bool { return moreUrgent(pending[i], pending[j]) })
+	groups := make(map[string][]approvals.Approval)
+	tools := make([]string, 0)
+	for _, approval := range pending {
+		if _, ok := groups[approval.Request.Tool]; !ok {
+			// Tools follow their most urgent request.
+			tools = append(tools, approval.Request.Tool)
+		}
+		groups[approval.Request.Tool] = append(groups[approval.Request.Tool], approval)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("<b>")
+	builder.WriteString(shared.EscapeHTML(fmt.Sprintf(msg.DigestTitle, len(pending), shared.FormatAge(s.cfg.DigestMinAge))))
+	builder.WriteString("</b>\n")
+	for _, tool := range tools {
+		builder.WriteString("\n<b>")
+		builder.WriteString(shared.EscapeHTML(tool))
+		builder.WriteString("</b>\n")
+		for _, approval := range groups[tool] {
+			label := shared.EscapeHTML(approval.Request.CorrelationID)
+			if link := shared.MessageLink(chatID, approval.MessageID); link != "" {
+				label = fmt.Sprintf(`<a href="%s">%s</a>`, link, label)
+			}
+			bullet := "•"
+			if badge := approval.Request.Severity.Badge(); badge != "" {
+				bullet = badge
+			}
+			fmt.Fprintf(&builder, "%s %s — %s\n", bullet, label, shared.EscapeHTML(shared.FormatAge(now.Sub(approval.CreatedAt))))
+		}
+	}
+	return builder.String()
+}
+
+// moreUrgent reports whether a is reminded of before b: higher severity first, then the older request.
+func moreUrgent(a, b approvals.Approval) bool {
+	if ra, rb := a.Request.Severity.Rank(), b.Request.Severity.Rank(); ra != rb {
+		return ra > rb
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
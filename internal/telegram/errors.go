@@ -0,0 +1,33 @@
+package telegram
+
+import (
+	"fmt"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// NotPending returns the error for an approval that is not pending: ErrAlreadyResolved when its decision
+// is still in history and ErrNotFound otherwise.
+func (s *Service) NotPending(correlationID string) error {
+	return s.notPending(correlationID)
+}
+
+func (s *Service) notPending(correlationID string) error {
+	if s.registry.Get(correlationID) != nil {
+		// Resolve keeps the approval when the shared store could not confirm the claim.
+		return approvals.ErrStoreUnavailable
+	}
+	if _, ok := s.history.Lookup(correlationID); ok {
+		return approvals.ErrAlreadyResolved
+	}
+	return approvals.ErrNotFound
+}
+
+// sendError wraps a failed Telegram call in ErrTelegramUnavailable when retrying may help and records it.
+func (s *Service) sendError(err error, failure *approvals.Failure) error {
+	if failure != nil && failure.Retryable {
+		err = fmt.Errorf("%w: %w", approvals.ErrTelegramUnavailable, err)
+	}
+	s.metrics.Failed(err)
+	return err
+}
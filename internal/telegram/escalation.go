@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// resolveEscalation fills escalation defaults for a request sent to chatID with the given timeout.
+// The returned escalation has a zero At when the request must not be escalated.
+func (s *Service) resolveEscalation(escalation approvals.Escalation, chatID int64, timeout time.Duration) approvals.Escalation {
+	if escalation.Disabled {
+		return escalation
+	}
+	if escalation.ChatID == 0 {
+		escalation.ChatID = s.cfg.EscalationChatID
+	}
+	if escalation.After <= 0 {
+		escalation.After = time.Duration(float64(timeout) * s.cfg.EscalationAfter)
+	}
+	if len(escalation.Mentions) == 0 {
+		escalation.Mentions = s.cfg.EscalationMentions
+	}
+	if escalation.ChatID == 0 || escalation.ChatID == chatID || escalation.After >= timeout {
+		return escalation
+	}
+	escalation.At = s.calendar.Add(time.Now(), escalation.After)
+	return escalation
+}
+
+// scheduleEscalation arms the escalation timer of a pending approval that has not been escalated yet.
+func (s *Service) scheduleEscalation(approval *approvals.Approval) {
+	if approval.Request.Escalation.At.IsZero() || approval.Escalated.Valid() {
+		return
+	}
+	correlationID := approval.Request.CorrelationID
+	s.escalations.Schedule(correlationID, approval.Request.Escalation.At, func() {
+		ctx, done := s.handler.Operation(context.Background())
+		defer done()
+		s.escalate(ctx, correlationID)
+	})
+}
+
+// escalate posts a copy of an unanswered approval with working buttons into the escalation chat.
+func (s *Service) escalate(ctx context.Context, correlationID string) {
+	approval := s.registry.Get(correlationID)
+	if approval == nil || approval.Escalated.Valid() {
+		return
+	}
+	escalation := approval.Request.Escalation
+	msg, err := s.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:      tu.ID(escalation.ChatID),
+		Text:        s.renderEscalation(approval) + "\n\n" + approval.MessageText,
+		ParseMode:   parseMode(approval.Request.Markup),
+		ReplyMarkup: s.handler.ApprovalKeyboard(approval.Request),
+	})
+	if err != nil {
+		s.log.Error("Failed to escalate approval", "error", err, "correlation_id", correlationID, "chat_id", escalation.ChatID)
+		return
+	}
+	ref := approvals.MessageRef{ChatID: escalation.ChatID, MessageID: msg.MessageID}
+	if !s.registry.SetEscalated(correlationID, ref) {
+		// Resolved while escalating.
+		_ = s.handler.DeleteMessage(ctx, ref)
+		return
+	}
+	s.log.Info("Approval escalated", "correlation_id", correlationID, "chat_id", escalation.ChatID)
+}
+
+// renderEscalation builds the escalation header with mentions, escaped for the request markup.
+func (s *Service) renderEscalation(approval *approvals.Approval) string {
+	msg := s.messagesFor(approval.Request.Lang)
+	escalation := approval.Request.Escalation
+	header := fmt.Sprintf(msg.EscalationNote, shared.FormatAge(escalation.After))
+	if reminder := strings.TrimSpace(approval.Request.ReminderMessage); reminder != "" {
+		header = "🚨 " + reminder
+	}
+	if len(escalation.Mentions) > 0 {
+		header += "\n" + strings.Join(escalation.Mentions, " ")
+	}
+	if strings.EqualFold(strings.TrimSpace(approval.Request.Markup), "html") {
+		return shared.EscapeHTML(header)
+	}
+	return shared.EscapeMarkdownV2(header)
+}
+
+// stopEscalation cancels the pending escalation of an approval, if any.
+func (s *Service) stopEscalation(correlationID string) {
+	s.escalations.Cancel(correlationID)
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+// classifySendError tells callers why Telegram did not accept the approval message and whether re-submitting may help.
+func classifySendError(err error) *approvals.Failure {
+	var apiErr *ta.Error
+	if !errors.As(err, &apiErr) {
+		// The request never got an answer from Telegram: network failure or cancelled context.
+		return &approvals.Failure{Class: approvals.FailureUnavailable, Retryable: true}
+	}
+	switch {
+	case apiErr.ErrorCode == http.StatusTooManyRequests:
+		failure := &approvals.Failure{Class: approvals.FailureRateLimited, Retryable: true}
+		if apiErr.Parameters != nil {
+			failure.RetryAfterSec = apiErr.Parameters.RetryAfter
+		}
+		return failure
+	case apiErr.ErrorCode == http.StatusForbidden:
+		return &approvals.Failure{Class: approvals.FailureForbidden}
+	case apiErr.ErrorCode == http.StatusBadRequest && strings.Contains(apiErr.Description, "can't parse entities"):
+		return &approvals.Failure{Class: approvals.FailureEntityParse}
+	case apiErr.ErrorCode >= http.StatusInternalServerError:
+		return &approvals.Failure{Class: approvals.FailureUnavailable, Retryable: true}
+	default:
+		return &approvals.Failure{Class: approvals.FailureBadRequest}
+	}
+}
@@ -0,0 +1,281 @@
+package telegram
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeToken passes the token format check of the bot client.
+const fakeToken = "123456:conformance-token-0123456789abcdefg"
+
+// botCall is one Bot API request the service made.
+type botCall struct {
+	Method string
+	Params map[string]any
+}
+
+// fakeBot serves the Bot API methods the service uses, hands out queued updates through getUpdates, serves file
+// downloads and answers OpenAI transcription requests with a fixed text.
+type fakeBot struct {
+	server     *httptest.Server
+	transcript string
+
+	mu      sync.Mutex
+	calls   []botCall
+	updates []queuedUpdate
+	offset  int64
+	nextID  int64
+	queued  chan struct{}
+}
+
+// queuedUpdate is an update waiting to be confirmed by a getUpdates offset.
+type queuedUpdate struct {
+	id  int64
+	raw json.RawMessage
+}
+
+func newFakeBot(t *testing.T, transcript string) *fakeBot {
+	t.Helper()
+	bot := &fakeBot{transcript: transcript, queued: make(chan struct{}, 1)}
+	bot.server = httptest.NewServer(http.HandlerFunc(bot.serve))
+	t.Cleanup(bot.server.Close)
+	return bot
+}
+
+// URL is the Bot API server URL.
+func (b *fakeBot) URL() string {
+	return b.server.URL
+}
+
+// deliver queues an update for the next getUpdates call and returns its ID.
+func (b *fakeBot) deliver(t *testing.T, update json.RawMessage) int64 {
+	t.Helper()
+	var head struct {
+		UpdateID int64 `json:"update_id"`
+	}
+	if err := json.Unmarshal(update, &head); err != nil || head.UpdateID == 0 {
+		t.Fatalf("fixture update has no update_id: %s", update)
+	}
+	b.mu.Lock()
+	b.updates = append(b.updates, queuedUpdate{id: head.UpdateID, raw: update})
+	b.mu.Unlock()
+	select {
+	case b.queued <- struct{}{}:
+	default:
+	}
+	return head.UpdateID
+}
+
+// waitConfirmed waits until the client has confirmed receiving the update through the getUpdates offset.
+func (b *fakeBot) waitConfirmed(t *testing.T, id int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.mu.Lock()
+		offset := b.offset
+		b.mu.Unlock()
+		if offset > id {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("update %d was not received, offset %d", id, offset)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitCalls waits until at least n calls were made and returns all of them.
+func (b *fakeBot) waitCalls(n int) []botCall {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		calls := b.recorded()
+		if len(calls) >= n || time.Now().After(deadline) {
+			return calls
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (b *fakeBot) recorded() []botCall {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]botCall(nil), b.calls...)
+}
+
+func (b *fakeBot) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/bot"+fakeToken+"/"):
+		method := strings.TrimPrefix(r.URL.Path, "/bot"+fakeToken+"/")
+		params, err := requestParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if method == "getUpdates" {
+			writeResult(w, b.getUpdates(r, params))
+			return
+		}
+		writeResult(w, b.call(method, params))
+	case strings.HasPrefix(r.URL.Path, "/file/bot"+fakeToken+"/"):
+		_, _ = w.Write([]byte("fake audio"))
+	case r.URL.Path == "/openai/audio/transcriptions":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"text": b.transcript})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// call records a Bot API call and builds its result.
+func (b *fakeBot) call(method string, params map[string]any) any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, botCall{Method: method, Params: params})
+	switch method {
+	case "getMe":
+		return map[string]any{"id": 1, "is_bot": true, "first_name": "Approver", "username": "approver_bot"}
+	case "sendMessage", "sendPhoto", "sendDocument":
+		b.nextID++
+		return map[string]any{
+			"message_id": b.nextID,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]any{"id": params["chat_id"], "type": "supergroup"},
+			"text":       params["text"],
+		}
+	case "editMessageText", "editMessageReplyMarkup":
+		return map[string]any{
+			"message_id": params["message_id"],
+			"date":       time.Now().Unix(),
+			"chat":       map[string]any{"id": params["chat_id"], "type": "supergroup"},
+			"text":       params["text"],
+		}
+	case "getFile":
+		id, _ := params["file_id"].(string)
+		return map[string]any{"file_id": id, "file_unique_id": id, "file_path": "voice/" + id + ".mp3"}
+	}
+	return true
+}
+
+// getUpdates drops the updates confirmed by the offset and returns the rest, waiting briefly when there are none.
+func (b *fakeBot) getUpdates(r *http.Request, params map[string]any) []json.RawMessage {
+	if offset, ok := params["offset"].(float64); ok {
+		b.confirm(int64(offset))
+	}
+	if pending := b.pending(); len(pending) > 0 {
+		return pending
+	}
+	select {
+	case <-b.queued:
+	case <-time.After(50 * time.Millisecond):
+	case <-r.Context().Done():
+	}
+	return b.pending()
+}
+
+func (b *fakeBot) confirm(offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offset = max(b.offset, offset)
+	kept := b.updates[:0]
+	for _, update := range b.updates {
+		if update.id >= b.offset {
+			kept = append(kept, update)
+		}
+	}
+	b.updates = kept
+}
+
+func (b *fakeBot) pending() []json.RawMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]json.RawMessage, 0, len(b.updates))
+	for _, update := range b.updates {
+		result = append(result, update.raw)
+	}
+	return result
+}
+
+// requestParams decodes a JSON or multipart Bot API request into a map.
+func requestParams(r *http.Request) (map[string]any, error) {
+	params := make(map[string]any)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			return nil, err
+		}
+		for key, values := range r.MultipartForm.Value {
+			if len(values) > 0 {
+				params[key] = values[0]
+			}
+		}
+		for key := range r.MultipartForm.File {
+			params[key] = "file"
+		}
+		return params, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// callbackReceiver records the decision callbacks the service delivers.
+type callbackReceiver struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func newCallbackReceiver(t *testing.T) *callbackReceiver {
+	t.Helper()
+	receiver := &callbackReceiver{}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		receiver.mu.Lock()
+		receiver.bodies = append(receiver.bodies, body)
+		receiver.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.server.Close)
+	return receiver
+}
+
+func (c *callbackReceiver) received() []map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]map[string]any(nil), c.bodies...)
+}
+
+// describeCalls lists call methods for failure messages.
+func describeCalls(calls []botCall) string {
+	methods := make([]string, 0, len(calls))
+	for _, call := range calls {
+		methods = append(methods, call.Method)
+	}
+	return "[" + strings.Join(methods, ", ") + "] (" + strconv.Itoa(len(calls)) + ")"
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+)
+
+// acknowledge records that an approver has seen a critical approval; the request stays pending.
+func (h *Handler) acknowledge(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	ack := approvals.Vote{
+		UserID:   query.From.ID,
+		Username: displayName(&query.From),
+		At:       time.Now().UTC(),
+	}
+	_, added, ok := h.registry.AddAck(correlationID, ack)
+	if !ok {
+		_ = h.answerCallback(ctx, query, msg.AlreadyResolved)
+		return
+	}
+	if !added {
+		_ = h.answerCallback(ctx, query, msg.AlreadyAcked)
+		return
+	}
+	h.metrics.Acknowledged(approval, ack.At)
+	h.audit.Ack(approval, actorOf(&query.From))
+	h.showProgress(ctx, approval)
+	_ = h.answerCallback(ctx, query, msg.AckRecorded)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	// CommandApproveAll approves every pending request of the chat after a confirmation; admins only.
+	CommandApproveAll = "approve_all"
+
+	// ActionApproveAll confirms a bulk approval.
+	ActionApproveAll = "bulk_ok"
+	// ActionApproveAllCancel aborts a bulk approval.
+	ActionApproveAllCancel = "bulk_cancel"
+
+	// bulkTTL is how long a bulk approval waits for its confirmation.
+	bulkTTL = 5 * time.Minute
+)
+
+// bulkApproval is a bulk approval waiting for confirmation. Only the requests listed when the command was sent are
+// approved, so requests posted in the meantime stay pending.
+type bulkApproval struct {
+	userID  int64
+	ids     []string
+	started time.Time
+}
+
+func isBulkAction(action string) bool {
+	return action == ActionApproveAll || action == ActionApproveAllCancel
+}
+
+// approveAllCommand asks the admin to confirm approving every request pending in the chat.
+func (h *Handler) approveAllCommand(ctx context.Context, message *telego.Message) {
+	msg := h.messageFor("")
+	if !h.isAdmin(message.From) {
+		_ = h.reply(ctx, message, msg.AdminOnly)
+		return
+	}
+	chatID := message.Chat.ID
+	var ids []string
+	for _, approval := range h.registry.List() {
+		if approval.Request.Channel != "" && approval.Request.Channel != channel.Telegram {
+			continue
+		}
+		if approval.ChatID == chatID || approval.Escalated.ChatID == chatID {
+			ids = append(ids, approval.Request.CorrelationID)
+		}
+	}
+	if len(ids) == 0 {
+		_ = h.reply(ctx, message, msg.StatusEmpty)
+		return
+	}
+	h.bulkMu.Lock()
+	h.bulk[chatID] = &bulkApproval{userID: message.From.ID, ids: ids, started: time.Now()}
+	h.bulkMu.Unlock()
+	_, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:          tu.ID(chatID),
+		MessageThreadID: shared.ThreadID(message),
+		Text:            fmt.Sprintf(msg.ApproveAllConfirm, len(ids)),
+		ReplyParameters: &telego.ReplyParameters{MessageID: message.MessageID, AllowSendingWithoutReply: true},
+		ReplyMarkup: tu.InlineKeyboard(tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(msg.ApproveAllButton).WithCallbackData(ActionApproveAll),
+			tu.InlineKeyboardButton(msg.ApproveAllCancel).WithCallbackData(ActionApproveAllCancel),
+		)),
+	})
+	if err != nil {
+		h.log.Error("Failed to ask for bulk approval confirmation", "error", err, "chat_id", chatID)
+		h.takeBulk(chatID, message.From.ID)
+	}
+}
+
+// handleApproveAll processes the buttons of the bulk approval confirmation.
+func (h *Handler) handleApproveAll(ctx context.Context, query *telego.CallbackQuery, action string) {
+	msg := h.messageFor("")
+	if !h.isAdmin(&query.From) {
+		_ = h.answerCallback(ctx, query, msg.AdminOnly)
+		return
+	}
+	chatID := query.Message.GetChat().ID
+	bulk, ok := h.takeBulk(chatID, query.From.ID)
+	if !ok {
+		_ = h.answerCallback(ctx, query, msg.ApproveAllExpired)
+		return
+	}
+	if action == ActionApproveAllCancel {
+		h.editBulk(ctx, query, msg.ApproveAllCancelled)
+		_ = h.answerCallback(ctx, query, "")
+		return
+	}
+	_ = h.answerCallback(ctx, query, "")
+	approved := 0
+	for _, id := range bulk.ids {
+		result := approvals.Result{
+			Decision:   approvals.DecisionApprove,
+			Reason:     "approved",
+			ReasonCode: approvals.ReasonApproved,
+			Actor:      actorOf(&query.From),
+			ForcedBy:   displayName(&query.From),
+		}
+		if _, ok := h.decide(ctx, id, result); ok {
+			approved++
+		}
+	}
+	h.log.Warn("Pending approvals approved in bulk", "chat_id", chatID, "user_id", query.From.ID, "approved", approved, "listed", len(bulk.ids))
+	h.editBulk(ctx, query, fmt.Sprintf(msg.ApproveAllDone, approved, len(bulk.ids)))
+}
+
+// takeBulk removes and returns the chat bulk approval when the user started it and it has not expired.
+func (h *Handler) takeBulk(chatID, userID int64) (bulkApproval, bool) {
+	h.bulkMu.Lock()
+	defer h.bulkMu.Unlock()
+	bulk, ok := h.bulk[chatID]
+	if !ok || bulk.userID != userID {
+		return bulkApproval{}, false
+	}
+	delete(h.bulk, chatID)
+	if time.Since(bulk.started) > bulkTTL {
+		return bulkApproval{}, false
+	}
+	return *bulk, true
+}
+
+// editBulk replaces the confirmation message with the outcome and removes its buttons.
+func (h *Handler) editBulk(ctx context.Context, query *telego.CallbackQuery, text string) {
+	_, err := h.bot.EditMessageText(ctx, &telego.EditMessageTextParams{
+		ChatID:    tu.ID(query.Message.GetChat().ID),
+		MessageID: query.Message.GetMessageID(),
+		Text:      text,
+	})
+	if err != nil {
+		h.log.Warn("Failed to update bulk approval message", "error", err, "chat_id", query.Message.GetChat().ID)
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	// CommandCleanup deletes resolved approval messages.
+	CommandCleanup = "cleanup"
+	// CommandMute sends approval messages silently for a period.
+	CommandMute = "mute"
+	// CommandCancel, sent as a reply to a deny prompt, keeps the request pending.
+	CommandCancel = "cancel"
+	// CommandStatus lists pending approvals posted to the chat.
+	CommandStatus = "status"
+	// CommandApprove approves a request by correlation ID with an optional comment.
+	CommandApprove = "approve"
+	// CommandDeny denies a request by correlation ID with an optional reason.
+	CommandDeny = "deny"
+
+	defaultCleanupAge = 24 * time.Hour
+	cleanupBatchSize  = 100
+	cleanupBatchPause = time.Second
+)
+
+// CleanupResult reports the outcome of a cleanup run.
+type CleanupResult struct {
+	// Deleted is the number of removed messages.
+	Deleted int `json:"deleted"`
+	// Failed is the number of messages Telegram refused to delete.
+	Failed int `json:"failed"`
+}
+
+// handleCommand processes bot commands and reports whether the message was a known command.
+func (h *Handler) handleCommand(ctx context.Context, message *telego.Message) bool {
+	name, args, ok := parseCommand(message.Text)
+	if !ok {
+		return false
+	}
+	switch name {
+	case CommandCleanup:
+		h.cleanupCommand(ctx, message, args)
+	case CommandMute:
+		h.muteCommand(ctx, message, args)
+	case CommandCancel:
+		h.cancelCommand(ctx, message)
+	case CommandStatus:
+		h.statusCommand(ctx, message)
+	case CommandApprove:
+		h.decideCommand(ctx, message, args, approvals.DecisionApprove)
+	case CommandDeny:
+		h.decideCommand(ctx, message, args, approvals.DecisionDeny)
+	case CommandApproveAll:
+		h.approveAllCommand(ctx, message)
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *Handler) cleanupCommand(ctx context.Context, message *telego.Message, args []string) {
+	msg := h.messageFor("")
+	if !h.isAdmin(message.From) {
+		_ = h.reply(ctx, message, msg.AdminOnly)
+		return
+	}
+	age := defaultCleanupAge
+	if len(args) > 0 {
+		parsed, err := time.ParseDuration(args[0])
+		if err != nil || parsed < 0 {
+			_ = h.reply(ctx, message, msg.CleanupUsage)
+			return
+		}
+		age = parsed
+	}
+	result, err := h.Cleanup(ctx, age)
+	if err != nil {
+		h.log.Error("Cleanup failed", "error", err)
+	}
+	_ = h.reply(ctx, message, fmt.Sprintf(msg.CleanupDone, result.Deleted))
+}
+
+func (h *Handler) cancelCommand(ctx context.Context, message *telego.Message) {
+	approval := h.promptFor(message)
+	if approval == nil || message.From == nil || !h.isApprover(message.From.ID) {
+		return
+	}
+	_ = h.DeleteMessage(ctx, h.registry.ClearPrompt(approval.Request.CorrelationID))
+	_ = h.DeleteMessage(ctx, approvals.MessageRef{ChatID: message.Chat.ID, MessageID: message.MessageID})
+}
+
+// Cleanup deletes messages of approvals resolved more than olderThan ago in rate-limited batches.
+func (h *Handler) Cleanup(ctx context.Context, olderThan time.Duration) (CleanupResult, error) {
+	var result CleanupResult
+	byChat := make(map[int64][]int)
+	for _, ref := range h.history.Stale(time.Now().Add(-olderThan)) {
+		byChat[ref.ChatID] = append(byChat[ref.ChatID], ref.MessageID)
+	}
+	first := true
+	for chatID, ids := range byChat {
+		for batch := range slices.Chunk(ids, cleanupBatchSize) {
+			if !first {
+				select {
+				case <-ctx.Done():
+					return result, ctx.Err()
+				case <-time.After(cleanupBatchPause):
+				}
+			}
+			first = false
+			refs := make([]approvals.MessageRef, 0, len(batch))
+			for _, id := range batch {
+				refs = append(refs, approvals.MessageRef{ChatID: chatID, MessageID: id})
+			}
+			err := h.bot.DeleteMessages(ctx, &telego.DeleteMessagesParams{
+				ChatID:     tu.ID(chatID),
+				MessageIDs: batch,
+			})
+			if err != nil {
+				h.log.Warn("Failed to delete resolved messages", "error", err, "chat_id", chatID, "count", len(batch))
+				result.Failed += len(batch)
+				continue
+			}
+			h.history.MarkDeleted(refs...)
+			result.Deleted += len(batch)
+		}
+	}
+	return result, nil
+}
+
+// PurgeResult reports the outcome of a purge.
+type PurgeResult struct {
+	// Purged is the number of removed history entries.
+	Purged int `json:"purged"`
+	// CorrelationIDs lists the removed approvals.
+	CorrelationIDs []string `json:"correlation_ids"`
+	// MessagesDeleted is the number of Telegram messages removed.
+	MessagesDeleted int `json:"messages_deleted"`
+}
+
+// Purge removes resolved approvals matching the filter from history and the decision cache,
+// optionally deleting their Telegram messages.
+func (h *Handler) Purge(ctx context.Context, filter approvals.PurgeFilter, deleteMessages bool) PurgeResult {
+	removed := h.history.Purge(filter)
+	result := PurgeResult{Purged: len(removed), CorrelationIDs: make([]string, 0, len(removed))}
+	for _, entry := range removed {
+		result.CorrelationIDs = append(result.CorrelationIDs, entry.CorrelationID)
+		if !deleteMessages || entry.MessageDeleted || !entry.Message.Valid() {
+			continue
+		}
+		if err := h.DeleteMessage(ctx, entry.Message); err != nil {
+			h.log.Warn("Failed to delete purged message", "error", err, "correlation_id", entry.CorrelationID)
+			continue
+		}
+		result.MessagesDeleted++
+	}
+	h.cache.ForgetCorrelations(result.CorrelationIDs...)
+	return result
+}
+
+func (h *Handler) isAdmin(user *telego.User) bool {
+	if user == nil {
+		return false
+	}
+	_, ok := h.admins[user.ID]
+	return ok
+}
+
+// isApprover reports whether the user may decide on approvals.
+func (h *Handler) isApprover(userID int64) bool {
+	if len(h.approvers) == 0 {
+		return true
+	}
+	_, ok := h.approvers[userID]
+	return ok
+}
+
+// parseCommand splits "/name@bot arg1 arg2" into the lower-cased name and arguments.
+func parseCommand(text string) (string, []string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", nil, false
+	}
+	fields := strings.Fields(text)
+	name := strings.TrimPrefix(fields[0], "/")
+	if at := strings.IndexByte(name, '@'); at >= 0 {
+		name = name[:at]
+	}
+	if name == "" {
+		return "", nil, false
+	}
+	return strings.ToLower(name), fields[1:], true
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+)
+
+// decideCommand handles "/approve <correlation_id> [comment]" and "/deny <correlation_id> [reason]".
+// Quorum requests count an approve command as a vote.
+func (h *Handler) decideCommand(ctx context.Context, message *telego.Message, args []string, decision approvals.Decision) {
+	msg := h.messageFor("")
+	if message.From == nil || !h.isApprover(message.From.ID) {
+		_ = h.reply(ctx, message, msg.NotAllowed)
+		return
+	}
+	if len(args) == 0 {
+		_ = h.reply(ctx, message, msg.DecideUsage)
+		return
+	}
+	correlationID := args[0]
+	// The comment keeps the original spacing of the message after the correlation ID.
+	_, rest, _ := strings.Cut(strings.TrimSpace(message.Text), correlationID)
+	comment := strings.TrimSpace(rest)
+	approval := h.registry.Get(correlationID)
+	if approval == nil || (approval.Request.Channel != "" && approval.Request.Channel != channel.Telegram) {
+		_ = h.reply(ctx, message, fmt.Sprintf(msg.DecideNotFound, shared.EscapeMarkdown(correlationID)))
+		return
+	}
+	h.decideAs(ctx, message, approval, decision, comment)
+}
+
+// decideAs applies a decision the author of message made on approval through a command and replies with the outcome.
+func (h *Handler) decideAs(ctx context.Context, message *telego.Message, approval *approvals.Approval, decision approvals.Decision, comment string) {
+	correlationID := approval.Request.CorrelationID
+	msg := h.messageFor(approval.Request.Lang)
+	if !canVote(approval, message.From.ID) {
+		_ = h.reply(ctx, message, msg.NotAllowed)
+		return
+	}
+	actor := actorOf(message.From)
+
+	var result approvals.Result
+	switch decision {
+	case approvals.DecisionDeny:
+		result = channel.DenyResult(comment)
+	default:
+		result = approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved}
+		if comment != "" {
+			result.Reason = comment
+		}
+		if required := approval.Request.RequiredApprovals; required > 1 {
+			vote := approvals.Vote{UserID: message.From.ID, Username: displayName(message.From), At: time.Now().UTC()}
+			votes, added, ok := h.registry.AddVote(correlationID, vote)
+			switch {
+			case !ok:
+				_ = h.reply(ctx, message, msg.AlreadyResolved)
+				return
+			case !added:
+				_ = h.reply(ctx, message, msg.AlreadyVoted)
+				return
+			}
+			h.audit.Vote(approval, vote)
+			if len(votes) < required {
+				h.showProgress(ctx, approval)
+				_ = h.reply(ctx, message, fmt.Sprintf(msg.VoteRecorded, len(votes), required))
+				return
+			}
+			result.Reason = "approved by " + voterNames(votes)
+		}
+	}
+	result.Actor = actor
+	if _, ok := h.decide(ctx, correlationID, result); !ok {
+		_ = h.reply(ctx, message, h.notDecided(correlationID, approval.Request.Lang))
+		return
+	}
+	done := msg.DecideApproved
+	if result.Decision == approvals.DecisionDeny {
+		done = msg.DecideDenied
+	}
+	_ = h.reply(ctx, message, fmt.Sprintf(done, shared.EscapeMarkdown(correlationID)))
+}
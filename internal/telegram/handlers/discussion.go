@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+func (h *Handler) startDiscussion(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	if approval.DiscussionMessageID > 0 {
+		_ = h.answerCallback(ctx, query, msg.DiscussionPrompt)
+		return
+	}
+	prompt, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:          tu.ID(approval.ChatID),
+		MessageThreadID: approval.ThreadID,
+		Text:            msg.DiscussionPrompt,
+		ReplyParameters: (&telego.ReplyParameters{
+			MessageID: approval.MessageID,
+		}).WithAllowSendingWithoutReply(),
+	})
+	if err != nil {
+		h.log.Error("Failed to start discussion", "error", err)
+		_ = h.answerCallback(ctx, query, msg.ErrorNote)
+		return
+	}
+	if !h.registry.SetDiscussion(correlationID, prompt.MessageID) {
+		_ = h.DeleteMessage(ctx, approvals.MessageRef{ChatID: approval.ChatID, MessageID: prompt.MessageID})
+		_ = h.answerCallback(ctx, query, msg.AlreadyResolved)
+		return
+	}
+	_ = h.answerCallback(ctx, query, "")
+}
+
+// captureNote stores replies to an approval or its discussion root and reports whether the message was consumed.
+func (h *Handler) captureNote(message *telego.Message) bool {
+	if message.ReplyToMessage == nil || strings.TrimSpace(message.Text) == "" {
+		return false
+	}
+	approval := h.registry.FindByMessage(approvals.MessageRef{
+		ChatID:    message.Chat.ID,
+		MessageID: message.ReplyToMessage.MessageID,
+	})
+	if approval == nil {
+		return false
+	}
+	note := approvals.Note{Text: strings.TrimSpace(message.Text), At: time.Unix(message.Date, 0).UTC()}
+	if message.From != nil {
+		note.UserID = message.From.ID
+		note.Username = displayName(message.From)
+	}
+	return h.registry.AddNote(approval.Request.CorrelationID, note)
+}
+
+// actorOf identifies a Telegram user in decision results.
+func actorOf(user *telego.User) *approvals.Actor {
+	if user == nil {
+		return nil
+	}
+	return &approvals.Actor{ID: strconv.FormatInt(user.ID, 10), Username: displayName(user)}
+}
+
+func displayName(user *telego.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
@@ -3,21 +3,37 @@ package handlers
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/audit"
+	"github.com/codex-k8s/telegram-approver/internal/bus"
+	"github.com/codex-k8s/telegram-approver/internal/callback"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/chats"
+	"github.com/codex-k8s/telegram-approver/internal/grafana"
 	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/journal"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/mirror"
+	"github.com/codex-k8s/telegram-approver/internal/notify"
 	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/codex-k8s/telegram-approver/internal/tracing"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -27,22 +43,149 @@ const (
 	ActionDeny = "deny"
 	// ActionDenyWithMessage requests a denial reason.
 	ActionDenyWithMessage = "deny_reason"
-	// ActionCancelDeny cancels deny-with-message prompt.
+	// ActionCancelDeny cancels deny-with-message prompt; kept for prompts sent with an inline button.
 	ActionCancelDeny = "deny_cancel"
 	// ActionDelete deletes a resolved message.
 	ActionDelete = "delete"
+	// ActionDiscuss opens a discussion thread for the approval.
+	ActionDiscuss = "discuss"
+	// ActionAck marks a critical approval as seen without deciding.
+	ActionAck = "ack"
+	// ActionDetails shows request metadata in an alert.
+	ActionDetails = "details"
+	// ActionDelegate shows the chats the approval can be moved to.
+	ActionDelegate = "delegate"
+	// ActionDelegateTo moves the approval into the chosen chat.
+	ActionDelegateTo = "delegate_to"
+	// ActionDelegateBack restores the approval keyboard after the chat picker.
+	ActionDelegateBack = "delegate_back"
+	// ActionLanguage re-renders the approval message in the next bundled language.
+	ActionLanguage = "lang"
+	// ActionSnooze postpones the approval timeout and reposts the message later.
+	ActionSnooze = "snooze"
 )
 
 // Handler processes Telegram updates and resolves approvals.
 type Handler struct {
 	bot         *telego.Bot
 	registry    *approvals.Registry
-	messages    map[string]i18n.Messages
+	messages    *i18n.Live
 	defaultLang string
-	chatID      int64
+	chats       *chats.Directory
+	admins      map[int64]struct{}
+	approvers   map[int64]struct{}
 	sttLang     string
 	transcriber Transcriber
-	log         *slog.Logger
+	intents     IntentMatcher
+	voiceCmds   bool
+	callbacks   *callback.Sender
+	cache       *approvals.DecisionCache
+	history     *approvals.History
+	metrics     *metrics.Metrics
+	annotator   *grafana.Annotator
+	mirror      *mirror.Notifier
+	bus         *bus.Publisher
+	notifier    *notify.Notifier
+	waiters     *approvals.Waiters
+	journal     *journal.Journal
+	audit       *audit.Log
+	keyboard    [][]string
+
+	delegates  atomic.Pointer[delegateTargets]
+	delegate   func(ctx context.Context, correlationID string, chatID int64) error
+	snooze     func(ctx context.Context, correlationID string) error
+	snoozeFor  time.Duration
+	render     func(req approvals.Request) string
+	channels   map[string]channel.Channel
+	denyReason string
+	pin        bool
+	deepLinks  bool
+	httpClient *http.Client
+	log        *slog.Logger
+	muteMu     sync.Mutex
+	mutedUntil map[int64]time.Time
+
+	operationTimeout time.Duration
+	operations       atomic.Int64
+	workers          int
+
+	superAdmins map[int64]struct{}
+	setupMu     sync.Mutex
+	setups      map[int64]*setupSession
+	bulkMu      sync.Mutex
+	bulk        map[int64]*bulkApproval
+}
+
+// Options holds Handler dependencies.
+type Options struct {
+	// Bot is the Telegram bot client.
+	Bot *telego.Bot
+	// Registry stores pending approvals.
+	Registry *approvals.Registry
+	// Messages are localized strings keyed by language; reloads apply to later messages.
+	Messages *i18n.Live
+	// DefaultLang is the fallback language.
+	DefaultLang string
+	// Chats are the chats the bot accepts updates from.
+	Chats *chats.Directory
+	// AdminUserIDs are Telegram users allowed to run admin commands.
+	AdminUserIDs []int64
+	// SuperAdminUserIDs are Telegram users who can add new chats through onboarding.
+	SuperAdminUserIDs []int64
+	// AllowedUserIDs are Telegram users allowed to decide on approvals; empty allows everyone.
+	AllowedUserIDs []int64
+	// STTLang is the transcription language hint.
+	STTLang string
+	// Transcriber converts voice messages to text (optional).
+	Transcriber Transcriber
+	// VoiceCommands lets approvers approve or deny by voice messages outside of deny prompts.
+	VoiceCommands bool
+	// Intents detects decisions in transcribed voice commands; nil uses keywords.
+	Intents IntentMatcher
+	// Callbacks delivers decisions to requesters.
+	Callbacks *callback.Sender
+	// Cache stores recent decisions (optional).
+	Cache *approvals.DecisionCache
+	// History keeps resolved approvals.
+	History *approvals.History
+	// Metrics records decisions (optional).
+	Metrics *metrics.Metrics
+	// Annotator pushes decisions to Grafana (optional).
+	Annotator *grafana.Annotator
+	// Mirror posts decision summaries to an outbound webhook (optional).
+	Mirror *mirror.Notifier
+	// Bus publishes decisions to a message bus (optional).
+	Bus *bus.Publisher
+	// Notifier posts decision summaries to per-request notify URLs.
+	Notifier *notify.Notifier
+	// Waiters receive decisions for callers blocked on GET /approvals/{correlation_id}/wait (optional).
+	Waiters *approvals.Waiters
+	// Journal records incoming updates for debugging (optional).
+	Journal *journal.Journal
+	// Audit records decisions in the audit log (optional).
+	Audit *audit.Log
+	// Keyboard is the parsed default button layout of approval messages.
+	Keyboard [][]string
+	// DelegateChats are the named chats the Delegate button can move approvals to.
+	DelegateChats map[string]int64
+	// DenyReason overrides the localized reason sent for denials without a message.
+	DenyReason string
+	// SnoozeDuration is how long the snooze button postpones approvals; 0 hides the button.
+	SnoozeDuration time.Duration
+	// PinApprovals pins approval messages while they are pending.
+	PinApprovals bool
+	// DeepLinks opens approvals in private chats through /start deep links.
+	DeepLinks bool
+	// Channels are approval channels other than Telegram keyed by name (optional).
+	Channels map[string]channel.Channel
+	// HTTPClient downloads Telegram files.
+	HTTPClient *http.Client
+	// OperationTimeout bounds the edits, deletes and notifications that finish an approval.
+	OperationTimeout time.Duration
+	// Workers is how many updates are handled concurrently; updates of one approval keep their order.
+	Workers int
+	// Log is the application logger.
+	Log *slog.Logger
 }
 
 // Transcriber converts audio to text.
@@ -51,36 +194,75 @@ type Transcriber interface {
 }
 
 // NewHandler creates a new update handler.
-func NewHandler(bot *telego.Bot, registry *approvals.Registry, messages map[string]i18n.Messages, defaultLang string, chatID int64, sttLang string, transcriber Transcriber, log *slog.Logger) *Handler {
-	return &Handler{
-		bot:         bot,
-		registry:    registry,
-		messages:    messages,
-		defaultLang: defaultLang,
-		chatID:      chatID,
-		sttLang:     sttLang,
-		transcriber: transcriber,
-		log:         log,
-	}
-}
-
-// Run processes updates until context cancellation.
-func (h *Handler) Run(ctx context.Context, updates <-chan telego.Update) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case update, ok := <-updates:
-			if !ok {
-				return
-			}
-			h.HandleUpdate(ctx, update)
-		}
+func NewHandler(opts Options) *Handler {
+	admins := make(map[int64]struct{}, len(opts.AdminUserIDs))
+	for _, id := range opts.AdminUserIDs {
+		admins[id] = struct{}{}
+	}
+	approvers := make(map[int64]struct{}, len(opts.AllowedUserIDs))
+	for _, id := range opts.AllowedUserIDs {
+		approvers[id] = struct{}{}
+	}
+	superAdmins := make(map[int64]struct{}, len(opts.SuperAdminUserIDs))
+	for _, id := range opts.SuperAdminUserIDs {
+		superAdmins[id] = struct{}{}
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	intents := opts.Intents
+	if intents == nil {
+		intents = KeywordMatcher{}
+	}
+	h := &Handler{
+		bot:         opts.Bot,
+		registry:    opts.Registry,
+		messages:    opts.Messages,
+		defaultLang: opts.DefaultLang,
+		chats:       opts.Chats,
+		admins:      admins,
+		approvers:   approvers,
+		sttLang:     opts.STTLang,
+		transcriber: opts.Transcriber,
+		intents:     intents,
+		voiceCmds:   opts.VoiceCommands,
+		callbacks:   opts.Callbacks,
+		cache:       opts.Cache,
+		history:     opts.History,
+		metrics:     opts.Metrics,
+		annotator:   opts.Annotator,
+		mirror:      opts.Mirror,
+		bus:         opts.Bus,
+		notifier:    opts.Notifier,
+		waiters:     opts.Waiters,
+		journal:     opts.Journal,
+		audit:       opts.Audit,
+		keyboard:    opts.Keyboard,
+
+		channels:   opts.Channels,
+		denyReason: opts.DenyReason,
+		snoozeFor:  opts.SnoozeDuration,
+		pin:        opts.PinApprovals,
+		deepLinks:  opts.DeepLinks,
+		httpClient: httpClient,
+		log:        opts.Log,
+		mutedUntil: make(map[int64]time.Time),
+
+		operationTimeout: opts.OperationTimeout,
+		workers:          opts.Workers,
+
+		superAdmins: superAdmins,
+		setups:      make(map[int64]*setupSession),
+		bulk:        make(map[int64]*bulkApproval),
 	}
+	h.SetDelegateChats(opts.DelegateChats)
+	return h
 }
 
 // HandleUpdate processes a single update.
 func (h *Handler) HandleUpdate(ctx context.Context, update telego.Update) {
+	h.journal.Update(update)
 	if update.CallbackQuery != nil {
 		h.handleCallback(ctx, update.CallbackQuery)
 		return
@@ -89,96 +271,175 @@ func (h *Handler) HandleUpdate(ctx context.Context, update telego.Update) {
 		h.handleMessage(ctx, update.Message)
 		return
 	}
+	if update.MyChatMember != nil {
+		h.handleMembership(ctx, update.MyChatMember)
+	}
 }
 
 func (h *Handler) handleCallback(ctx context.Context, query *telego.CallbackQuery) {
 	if query.Message == nil {
 		return
 	}
-	if !h.allowedChat(query.Message.GetChat().ID) {
+	if action, payload := parseCallback(query.Data); isSetupAction(action) {
+		h.handleSetup(ctx, query, action, payload)
+		return
+	}
+	if !h.allowedChat(query.Message.GetChat().ID) && !h.directCallback(query) {
 		_ = h.answerCallback(ctx, query, h.messageFor("").InvalidChat)
 		return
 	}
+	if action, _ := parseCallback(query.Data); isBulkAction(action) {
+		// Admins may approve in bulk without being approvers themselves.
+		h.handleApproveAll(ctx, query, action)
+		return
+	}
+	if !h.isApprover(query.From.ID) {
+		_ = h.answerCallback(ctx, query, h.messageFor(query.From.LanguageCode).NotAllowed)
+		return
+	}
 	action, payload := parseCallback(query.Data)
+	ctx, span := tracing.Start(ctx, "telegram.callback", trace.WithAttributes(
+		attribute.String("telegram.action", action),
+		attribute.Int64("telegram.user_id", query.From.ID),
+	))
+	defer span.End()
+
+	if action == ActionApprove || action == ActionDeny || action == ActionDenyWithMessage || action == ActionAck || action == ActionDelegate || action == ActionSnooze {
+		if approval := h.registry.Get(payload); approval != nil && !canVote(approval, query.From.ID) {
+			_ = h.answerCallback(ctx, query, h.messageFor(query.From.LanguageCode).NotAllowed)
+			return
+		}
+	}
 
 	switch action {
 	case ActionApprove:
-		h.resolveDecision(ctx, query, payload, approvals.DecisionApprove, "approved")
+		h.approve(ctx, query, payload)
 	case ActionDeny:
-		h.resolveDecision(ctx, query, payload, approvals.DecisionDeny, "denied")
+		h.resolveDecision(ctx, query, payload, approvals.Result{Decision: approvals.DecisionDeny, ReasonCode: approvals.ReasonDenied})
 	case ActionDenyWithMessage:
 		h.startDenyPrompt(ctx, query, payload)
 	case ActionCancelDeny:
 		h.cancelDenyPrompt(ctx, query, payload)
 	case ActionDelete:
 		h.deleteMessage(ctx, query, payload)
+	case ActionDiscuss:
+		h.startDiscussion(ctx, query, payload)
+	case ActionAck:
+		h.acknowledge(ctx, query, payload)
+	case ActionDetails:
+		h.showDetails(ctx, query, payload)
+	case ActionDelegate:
+		h.showDelegateTargets(ctx, query, payload)
+	case ActionDelegateTo:
+		h.delegateTo(ctx, query, payload)
+	case ActionDelegateBack:
+		h.delegateBack(ctx, query, payload)
+	case ActionLanguage:
+		h.switchLanguage(ctx, query, payload)
+	case ActionSnooze:
+		h.snoozeApproval(ctx, query, payload)
 	default:
 		_ = h.answerCallback(ctx, query, h.messageFor("").InvalidAction)
 	}
 }
 
 func (h *Handler) handleMessage(ctx context.Context, message *telego.Message) {
+	if h.handleOnboarding(ctx, message) || h.handleDeepLink(ctx, message) {
+		return
+	}
 	if !h.allowedChat(message.Chat.ID) {
 		return
 	}
-	approval, _ := h.registry.CurrentPrompt()
-	if approval == nil || !approval.AwaitingReason {
+	if h.handleCommand(ctx, message) {
 		return
 	}
-	if message.Text != "" {
-		reason := strings.TrimSpace(message.Text)
-		if reason == "" {
-			reason = "denied"
+	if h.captureNote(message) {
+		return
+	}
+	approval := h.promptFor(message)
+	if approval == nil {
+		if (message.Voice != nil || message.VideoNote != nil) && h.voiceCmds {
+			h.voiceCommand(ctx, message)
 		}
-		approval, promptID, ok := h.registry.Resolve(approval.Request.CorrelationID)
+		return
+	}
+	if message.From == nil || !h.isApprover(message.From.ID) || !canVote(approval, message.From.ID) {
+		return
+	}
+	pending := approval.Request
+	if message.Text != "" {
+		approval, prompt, ok := h.registry.Resolve(approval.Request.CorrelationID)
 		if !ok {
+			h.replyNotSaved(ctx, message, pending)
 			return
 		}
-		if promptID > 0 {
-			_ = h.DeleteMessage(ctx, promptID)
-		}
-		h.FinalizeApproval(ctx, approval, approvals.Result{Decision: approvals.DecisionDeny, Reason: reason}, "")
+		_ = h.DeleteMessage(ctx, prompt)
+		result := channel.DenyResult(message.Text)
+		result.Actor = actorOf(message.From)
+		h.FinalizeApproval(ctx, approval, result, "")
 		return
 	}
-	if message.Voice != nil {
-		reason, err := h.transcribeVoice(ctx, message.Voice)
+	if speech, ok := speechOf(message); ok {
+		reason, err := h.transcribe(ctx, speech)
 		if err != nil {
 			if errors.Is(err, errTranscriberDisabled) {
-				_ = h.reply(ctx, h.messageFor(approval.Request.Lang).VoiceDisabled)
+				_ = h.reply(ctx, message, h.messageFor(approval.Request.Lang).VoiceDisabled)
 			} else {
-				_ = h.reply(ctx, h.messageFor(approval.Request.Lang).TranscriptionFailed)
+				_ = h.reply(ctx, message, h.messageFor(approval.Request.Lang).TranscriptionFailed)
 			}
 			return
 		}
-		if strings.TrimSpace(reason) == "" {
-			reason = "denied"
-		}
-		approval, promptID, ok := h.registry.Resolve(approval.Request.CorrelationID)
+		approval, prompt, ok := h.registry.Resolve(approval.Request.CorrelationID)
 		if !ok {
+			h.replyNotSaved(ctx, message, pending)
 			return
 		}
-		if promptID > 0 {
-			_ = h.DeleteMessage(ctx, promptID)
-		}
-		h.FinalizeApproval(ctx, approval, approvals.Result{Decision: approvals.DecisionDeny, Reason: reason}, "")
+		_ = h.DeleteMessage(ctx, prompt)
+		result := channel.DenyResult(reason)
+		result.Actor = actorOf(message.From)
+		h.FinalizeApproval(ctx, approval, result, "")
 		return
 	}
 }
 
-func (h *Handler) transcribeVoice(ctx context.Context, voice *telego.Voice) (string, error) {
+// speech is a message attachment to transcribe.
+type speech struct {
+	fileID   string
+	mimeType string
+	fileName string
+}
+
+// speechOf returns the voice message, round video note or audio file of message.
+func speechOf(message *telego.Message) (speech, bool) {
+	switch {
+	case message.Voice != nil:
+		return speech{fileID: message.Voice.FileID}, true
+	case message.VideoNote != nil:
+		// Video notes are always MPEG-4 video; only their audio track is transcribed.
+		return speech{fileID: message.VideoNote.FileID, mimeType: "video/mp4"}, true
+	case message.Audio != nil:
+		return speech{fileID: message.Audio.FileID, mimeType: message.Audio.MimeType, fileName: message.Audio.FileName}, true
+	}
+	return speech{}, false
+}
+
+func (h *Handler) transcribe(ctx context.Context, media speech) (string, error) {
 	if h.transcriber == nil {
 		return "", errTranscriberDisabled
 	}
-	file, err := h.bot.GetFile(ctx, &telego.GetFileParams{FileID: voice.FileID})
+	file, err := h.bot.GetFile(ctx, &telego.GetFileParams{FileID: media.fileID})
 	if err != nil {
 		return "", err
 	}
-	audioURL := h.bot.FileDownloadURL(file.FilePath)
-	data, err := tu.DownloadFile(audioURL)
+	data, err := h.downloadFile(file.FilePath)
 	if err != nil {
 		return "", err
 	}
-	normalized, mimeType, fileName, err := normalizeVoiceAudio(ctx, data, "", file.FilePath)
+	fileName := media.fileName
+	if fileName == "" {
+		fileName = filepath.Base(file.FilePath)
+	}
+	normalized, mimeType, fileName, err := normalizeVoiceAudio(ctx, data, media.mimeType, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -186,10 +447,27 @@ func (h *Handler) transcribeVoice(ctx context.Context, voice *telego.Voice) (str
 	return h.transcriber.Transcribe(ctx, reader, fileName, mimeType, h.sttLang)
 }
 
+// downloadFile fetches file content. A self-hosted Bot API server in --local mode
+// returns absolute paths on a shared volume instead of downloadable paths.
+func (h *Handler) downloadFile(filePath string) ([]byte, error) {
+	if filepath.IsAbs(filePath) {
+		return os.ReadFile(filePath)
+	}
+	resp, err := h.httpClient.Get(h.bot.FileDownloadURL(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 var errTranscriberDisabled = errors.New("transcriber disabled")
 
 func (h *Handler) allowedChat(chatID int64) bool {
-	return chatID == h.chatID
+	return h.chats.Known(chatID)
 }
 
 func (h *Handler) answerCallback(ctx context.Context, query *telego.CallbackQuery, text string) error {
@@ -200,11 +478,13 @@ func (h *Handler) answerCallback(ctx context.Context, query *telego.CallbackQuer
 	return h.bot.AnswerCallbackQuery(ctx, params)
 }
 
-func (h *Handler) reply(ctx context.Context, text string) error {
+// reply answers a message in its chat and forum topic.
+func (h *Handler) reply(ctx context.Context, message *telego.Message, text string) error {
 	_, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
-		ChatID:    tu.ID(h.chatID),
-		Text:      text,
-		ParseMode: telego.ModeMarkdown,
+		ChatID:          tu.ID(message.Chat.ID),
+		MessageThreadID: shared.ThreadID(message),
+		Text:            text,
+		ParseMode:       telego.ModeMarkdown,
 	})
 	return err
 }
@@ -215,7 +495,10 @@ func (h *Handler) deleteMessage(ctx context.Context, query *telego.CallbackQuery
 		_ = h.answerCallback(ctx, query, h.messageFor("").InvalidAction)
 		return
 	}
-	_ = h.DeleteMessage(ctx, messageID)
+	ref := approvals.MessageRef{ChatID: query.Message.GetChat().ID, MessageID: messageID}
+	if err := h.DeleteMessage(ctx, ref); err == nil {
+		h.history.MarkDeleted(ref)
+	}
 	_ = h.answerCallback(ctx, query, "")
 }
 
@@ -235,18 +518,15 @@ func parseCallback(data string) (string, string) {
 	return parts[0], parts[1]
 }
 
-func (h *Handler) resolveDecision(ctx context.Context, query *telego.CallbackQuery, correlationID string, decision approvals.Decision, reason string) {
-	approval, promptID, ok := h.registry.Resolve(correlationID)
+func (h *Handler) resolveDecision(ctx context.Context, query *telego.CallbackQuery, correlationID string, result approvals.Result) {
+	result.Actor = actorOf(&query.From)
+	approval, ok := h.decide(ctx, correlationID, result)
 	if !ok {
-		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		_ = h.answerCallback(ctx, query, h.notDecided(correlationID, ""))
 		return
 	}
-	if promptID > 0 {
-		_ = h.DeleteMessage(ctx, promptID)
-	}
-	h.FinalizeApproval(ctx, approval, approvals.Result{Decision: decision, Reason: reason}, "")
 	msg := h.messageFor(approval.Request.Lang)
-	switch decision {
+	switch result.Decision {
 	case approvals.DecisionApprove:
 		_ = h.answerCallback(ctx, query, "✅ "+msg.ApprovedNote)
 	case approvals.DecisionDeny:
@@ -256,106 +536,217 @@ func (h *Handler) resolveDecision(ctx context.Context, query *telego.CallbackQue
 	}
 }
 
+// notDecided returns the note for a decision that was not applied. The approval stays pending when the shared
+// store could not confirm the claim, and the decision can be retried.
+func (h *Handler) notDecided(correlationID, lang string) string {
+	if h.registry.Get(correlationID) != nil {
+		return h.messageFor(lang).DecisionNotSaved
+	}
+	return h.messageFor(lang).AlreadyResolved
+}
+
+// replyNotSaved tells the author of a deny reason that it was not applied while the approval is still pending.
+func (h *Handler) replyNotSaved(ctx context.Context, message *telego.Message, pending approvals.Request) {
+	if h.registry.Get(pending.CorrelationID) != nil {
+		_ = h.reply(ctx, message, h.messageFor(pending.Lang).DecisionNotSaved)
+	}
+}
+
+// decide resolves a pending approval and finalizes it; it reports false when the approval is no longer pending.
+func (h *Handler) decide(ctx context.Context, correlationID string, result approvals.Result) (*approvals.Approval, bool) {
+	ctx, done := h.Operation(ctx)
+	defer done()
+	approval, prompt, ok := h.registry.Resolve(correlationID)
+	if !ok {
+		return nil, false
+	}
+	_ = h.DeleteMessage(ctx, prompt)
+	h.FinalizeApproval(ctx, approval, result, "")
+	return approval, true
+}
+
 func (h *Handler) startDenyPrompt(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
 	approval := h.registry.Get(correlationID)
 	if approval == nil {
 		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
 		return
 	}
-	prevPromptID, ok := h.registry.StartReason(correlationID)
+	prevPrompt, ok := h.registry.StartReason(correlationID)
 	if !ok {
 		_ = h.answerCallback(ctx, query, h.messageFor(approval.Request.Lang).AlreadyResolved)
 		return
 	}
-	if prevPromptID > 0 {
-		_ = h.DeleteMessage(ctx, prevPromptID)
-	}
+	_ = h.DeleteMessage(ctx, prevPrompt)
 	msg := h.messageFor(approval.Request.Lang)
+	// The prompt goes to the chat the button was pressed in, which may be the escalation chat.
+	chatID := query.Message.GetChat().ID
 	prompt, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
-		ChatID:    tu.ID(h.chatID),
-		Text:      msg.DenyPrompt,
-		ParseMode: parseMode(approval.Request.Markup),
+		ChatID:              tu.ID(chatID),
+		MessageThreadID:     shared.ThreadID(query.Message.Message()),
+		Text:                msg.DenyPrompt,
+		ParseMode:           parseMode(approval.Request.Markup),
+		DisableNotification: h.Muted(chatID),
 		ReplyParameters: (&telego.ReplyParameters{
-			MessageID: approval.MessageID,
+			MessageID: query.Message.GetMessageID(),
 		}).WithAllowSendingWithoutReply(),
-		ReplyMarkup: h.promptKeyboard(approval.Request.Lang, approval.Request.CorrelationID),
+		// Only replies to the prompt are taken as the reason, so other chat messages are never captured.
+		ReplyMarkup: tu.ForceReply().WithInputFieldPlaceholder(msg.DenyPlaceholder),
 	})
 	if err != nil {
 		h.log.Error("Failed to send deny prompt", "error", err)
 		_ = h.answerCallback(ctx, query, msg.ErrorNote)
 		return
 	}
-	h.registry.SetPromptMessage(correlationID, prompt.MessageID)
+	h.registry.SetPromptMessage(correlationID, approvals.MessageRef{ChatID: chatID, MessageID: prompt.MessageID})
 	_ = h.answerCallback(ctx, query, "")
 }
 
-func (h *Handler) cancelDenyPrompt(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
-	promptID := h.registry.ClearPrompt(correlationID)
-	if promptID > 0 {
-		_ = h.DeleteMessage(ctx, promptID)
+// promptFor returns the approval whose deny prompt the message replies to.
+func (h *Handler) promptFor(message *telego.Message) *approvals.Approval {
+	if message.ReplyToMessage == nil {
+		return nil
 	}
+	return h.registry.PromptFor(approvals.MessageRef{ChatID: message.Chat.ID, MessageID: message.ReplyToMessage.MessageID})
+}
+
+func (h *Handler) cancelDenyPrompt(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	prompt := h.registry.ClearPrompt(correlationID)
+	_ = h.DeleteMessage(ctx, prompt)
 	_ = h.answerCallback(ctx, query, "")
 }
 
 // FinalizeApproval updates the approval message and sends a webhook callback.
 func (h *Handler) FinalizeApproval(ctx context.Context, approval *approvals.Approval, result approvals.Result, timeoutMessage string) {
+	ctx, done := h.Operation(ctx)
+	defer done()
+	ctx, span := tracing.Start(tracing.Restore(ctx, approval.Request.TraceContext), "approval.finalize",
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(
+			attribute.String("approval.correlation_id", approval.Request.CorrelationID),
+			attribute.String("approval.decision", string(result.Decision)),
+		),
+	)
+	defer span.End()
 	msg := h.messageFor(approval.Request.Lang)
-	note := h.noteForResult(msg, result, timeoutMessage)
-	text := approval.MessageText
-	if strings.TrimSpace(note) != "" {
-		text = fmt.Sprintf("%s\n\n%s", approval.MessageText, note)
-	}
-	_, err := h.bot.EditMessageText(ctx, &telego.EditMessageTextParams{
-		ChatID:      tu.ID(h.chatID),
-		MessageID:   approval.MessageID,
-		Text:        text,
-		ParseMode:   parseMode(approval.Request.Markup),
-		ReplyMarkup: h.resolvedKeyboard(approval.Request.Lang, approval.MessageID),
-	})
-	if err != nil {
-		h.log.Error("Failed to update telegram message", "error", err)
+	if result.ReasonCode == approvals.ReasonDenied && result.Reason == "" {
+		result.Reason = h.denyReason
+		if result.Reason == "" {
+			result.Reason = msg.DefaultDenyReason
+		}
 	}
-	h.sendWebhook(ctx, approval, result)
+	note := h.noteForResult(msg, result, escapeNote(approval.Request.Markup, timeoutMessage))
+	if result.ForcedBy != "" {
+		note += "\n" + escapeNote(approval.Request.Markup, fmt.Sprintf(msg.ForcedNote, result.ForcedBy))
+	}
+	h.markResolved(ctx, approval, note)
+	h.unpinMessage(ctx, approval)
+	h.resolveInChannel(ctx, approval, result)
+	h.cache.Put(approval.Request, result)
+	h.history.Record(approval, result)
+	h.metrics.Resolved(approval, result.Decision)
+	h.audit.Resolved(approval, result)
+	h.callbacks.Send(ctx, approval, result)
+	h.annotator.Annotate(ctx, approval, result)
+	h.mirror.Resolved(approval, result)
+	h.bus.Resolved(approval, result)
+	h.notifier.Resolved(approval, result)
+	h.waiters.Notify(approval.Request.CorrelationID, result)
+	h.resolveAttached(ctx, approval, result)
 }
 
-// DeleteMessage removes a Telegram message.
-func (h *Handler) DeleteMessage(ctx context.Context, messageID int) error {
-	if messageID <= 0 {
-		return nil
+// resolveAttached delivers the result to the duplicate requests attached to the approval.
+func (h *Handler) resolveAttached(ctx context.Context, approval *approvals.Approval, result approvals.Result) {
+	for _, duplicate := range approval.Attached() {
+		h.history.Record(duplicate, result)
+		h.audit.Resolved(duplicate, result)
+		h.callbacks.Send(ctx, duplicate, result)
+		h.bus.Resolved(duplicate, result)
+		h.notifier.Resolved(duplicate, result)
+		h.waiters.Notify(duplicate.Request.CorrelationID, result)
 	}
-	err := h.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
-		ChatID:    tu.ID(h.chatID),
-		MessageID: messageID,
-	})
-	return err
 }
 
-func (h *Handler) sendWebhook(ctx context.Context, approval *approvals.Approval, result approvals.Result) {
-	if approval == nil {
-		return
+// CancelDuplicate records the cancellation of a duplicate request detached from its approval.
+// The shared message stays as it is and no callback is sent.
+func (h *Handler) CancelDuplicate(duplicate *approvals.Approval) {
+	result := approvals.Result{Decision: approvals.DecisionCancelled}
+	h.history.Record(duplicate, result)
+	h.audit.Resolved(duplicate, result)
+	h.bus.Resolved(duplicate, result)
+	h.waiters.Notify(duplicate.Request.CorrelationID, result)
+}
+
+// CancelApproval marks the approval message as cancelled without sending a callback.
+// Attached duplicate requests did not ask for the cancellation, so they get a cancelled callback.
+func (h *Handler) CancelApproval(ctx context.Context, approval *approvals.Approval) {
+	ctx, done := h.Operation(ctx)
+	defer done()
+	msg := h.messageFor(approval.Request.Lang)
+	result := approvals.Result{Decision: approvals.DecisionCancelled}
+	h.markResolved(ctx, approval, h.noteForResult(msg, result, ""))
+	h.unpinMessage(ctx, approval)
+	h.resolveInChannel(ctx, approval, result)
+	h.history.Record(approval, result)
+	h.metrics.Resolved(approval, result.Decision)
+	h.audit.Resolved(approval, result)
+	h.mirror.Resolved(approval, result)
+	h.bus.Resolved(approval, result)
+	h.waiters.Notify(approval.Request.CorrelationID, result)
+	h.resolveAttached(ctx, approval, result)
+}
+
+// markResolved appends the note to the approval message, its escalation and private chat copies and replaces their
+// keyboards.
+func (h *Handler) markResolved(ctx context.Context, approval *approvals.Approval, note string) {
+	text := approval.MessageText
+	if len(approval.Acks) > 0 {
+		msg := h.messageFor(approval.Request.Lang)
+		text += "\n\n" + escapeNote(approval.Request.Markup, fmt.Sprintf(msg.AcksProgress, voterNames(approval.Acks)))
 	}
-	if strings.TrimSpace(approval.Request.Callback.URL) == "" {
-		return
+	if strings.TrimSpace(note) != "" {
+		text = fmt.Sprintf("%s\n\n%s", text, note)
 	}
-	payload := map[string]any{
-		"correlation_id": approval.Request.CorrelationID,
-		"decision":       string(result.Decision),
-		"reason":         result.Reason,
-		"tool":           approval.Request.Tool,
+	for _, ref := range []approvals.MessageRef{approval.Message(), approval.Escalated, approval.Direct} {
+		if !ref.Valid() {
+			continue
+		}
+		_, err := h.bot.EditMessageText(ctx, &telego.EditMessageTextParams{
+			ChatID:      tu.ID(ref.ChatID),
+			MessageID:   ref.MessageID,
+			Text:        text,
+			ParseMode:   parseMode(approval.Request.Markup),
+			ReplyMarkup: h.resolvedKeyboard(approval.Request.Lang, ref.MessageID),
+		})
+		if err != nil {
+			h.log.Error("Failed to update telegram message", "error", err, "chat_id", ref.ChatID)
+		}
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
+}
+
+// resolveInChannel reflects the decision on a message posted to a channel other than Telegram.
+func (h *Handler) resolveInChannel(ctx context.Context, approval *approvals.Approval, result approvals.Result) {
+	if approval.ChannelRef.Message == "" {
 		return
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, approval.Request.Callback.URL, bytes.NewReader(body))
-	if err != nil {
+	ch, ok := h.channels[approval.ChannelRef.Channel]
+	if !ok {
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 10 * time.Second}
-	if _, err := client.Do(req); err != nil {
-		h.log.Error("Webhook delivery failed", "error", err, "correlation_id", approval.Request.CorrelationID)
+	if err := ch.Resolve(ctx, approval, result); err != nil {
+		h.log.Error("Failed to update channel message", "error", err, "channel", ch.Name(), "correlation_id", approval.Request.CorrelationID)
+	}
+}
+
+// DeleteMessage removes a Telegram message.
+func (h *Handler) DeleteMessage(ctx context.Context, message approvals.MessageRef) error {
+	if !message.Valid() {
+		return nil
 	}
+	err := h.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
+		ChatID:    tu.ID(message.ChatID),
+		MessageID: message.MessageID,
+	})
+	return err
 }
 
 func (h *Handler) messageFor(lang string) i18n.Messages {
@@ -363,16 +754,27 @@ func (h *Handler) messageFor(lang string) i18n.Messages {
 }
 
 func (h *Handler) noteForResult(msg i18n.Messages, result approvals.Result, timeoutMessage string) string {
+	if result.ReasonCode == approvals.ReasonTimeout && result.Decision != approvals.DecisionError {
+		// Decided by the on_timeout policy of the request.
+		note := "⏱️ ✅ " + msg.TimeoutApprovedNote
+		if result.Decision == approvals.DecisionDeny {
+			note = "⏱️ ❌ " + msg.TimeoutDeniedNote
+		}
+		if strings.TrimSpace(timeoutMessage) != "" {
+			note += "\n" + timeoutMessage
+		}
+		return note
+	}
 	switch result.Decision {
 	case approvals.DecisionApprove:
 		return "✅ " + msg.ApprovedNote
 	case approvals.DecisionDeny:
-		if strings.TrimSpace(result.Reason) != "" && result.Reason != "denied" {
+		if result.ReasonCode != approvals.ReasonDenied && strings.TrimSpace(result.Reason) != "" {
 			return fmt.Sprintf("❌ %s\n%s", msg.DeniedNote, result.Reason)
 		}
 		return "❌ " + msg.DeniedNote
 	case approvals.DecisionError:
-		if strings.TrimSpace(result.Reason) == "approval timeout" {
+		if result.ReasonCode == approvals.ReasonTimeout {
 			if strings.TrimSpace(timeoutMessage) != "" {
 				return timeoutMessage
 			}
@@ -382,21 +784,13 @@ func (h *Handler) noteForResult(msg i18n.Messages, result approvals.Result, time
 			return fmt.Sprintf("⚠️ %s", result.Reason)
 		}
 		return "⚠️ " + msg.ErrorNote
+	case approvals.DecisionCancelled:
+		return "🚫 " + msg.CancelledNote
 	default:
 		return ""
 	}
 }
 
-func (h *Handler) promptKeyboard(lang, correlationID string) *telego.InlineKeyboardMarkup {
-	msg := h.messageFor(lang)
-	cancel := CallbackData(ActionCancelDeny, correlationID)
-	return tu.InlineKeyboard(
-		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton(msg.CancelDenyButton).WithCallbackData(cancel),
-		),
-	)
-}
-
 func (h *Handler) resolvedKeyboard(lang string, messageID int) *telego.InlineKeyboardMarkup {
 	msg := h.messageFor(lang)
 	del := CallbackData(ActionDelete, strconv.Itoa(messageID))
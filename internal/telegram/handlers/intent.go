@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// Intent is the decision carried by a transcribed voice command.
+type Intent struct {
+	// Decision is approve or deny; empty when the command was not understood.
+	Decision approvals.Decision
+	// Reason is the spoken comment or deny reason.
+	Reason string
+	// CorrelationID names the approval the command refers to; empty leaves the choice to the handler.
+	CorrelationID string
+}
+
+// IntentMatcher detects the decision in a transcribed voice command. Pending lists the approvals the speaker can
+// decide on in the chat.
+type IntentMatcher interface {
+	Match(ctx context.Context, text string, pending []approvals.Request) (Intent, error)
+}
+
+// keyword is a word sequence; a trailing "*" on the last word matches any word starting with it.
+type keyword []string
+
+// Decision keywords in English and Russian. The longest match wins and deny wins a tie, so "do not approve" is
+// a denial while "no problem" approves.
+var (
+	denyKeywords = keywords(
+		"deny", "denied", "reject*", "decline*", "refuse*", "block*", "no", "nope", "don't", "do not",
+		"not approv*", "not allow*",
+		"отклон*", "запре*", "отказ*", "нет", "не надо", "не одобр*", "не разреш*", "не подтвер*", "не соглас*",
+	)
+	approveKeywords = keywords(
+		"approv*", "allow*", "accept*", "confirm*", "yes", "ok", "okay", "go ahead", "lgtm", "no problem",
+		"no objection*", "одобр*", "разреш*", "подтвер*", "соглас*", "да", "давай*", "нет проблем",
+		"нет возражений",
+	)
+	// reasonMarkers introduce the reason. Without one the words after a denial are its reason.
+	reasonMarkers = keywords("because", "since", "reason", "потому что", "так как", "причина", "по причине")
+	// fillerWords are skipped between a denial and an unmarked reason, like negated approve keywords.
+	fillerWords = keywords("it", "this", "that", "the", "request", "please", "its", "это", "его", "её", "запрос", "пожалуйста")
+)
+
+func keywords(phrases ...string) []keyword {
+	parsed := make([]keyword, 0, len(phrases))
+	for _, phrase := range phrases {
+		parsed = append(parsed, strings.Fields(phrase))
+	}
+	return parsed
+}
+
+// KeywordMatcher detects intents with English and Russian decision keywords.
+type KeywordMatcher struct{}
+
+// word is a lowercased word of a transcript with its byte offset in the original.
+type word struct {
+	text  string
+	start int
+}
+
+// Match returns the decision of the first keyword in text and the reason spoken after it.
+func (KeywordMatcher) Match(_ context.Context, text string, _ []approvals.Request) (Intent, error) {
+	words := splitWords(text)
+	for i := range words {
+		decision := approvals.DecisionDeny
+		n := matchKeyword(words[i:], denyKeywords)
+		if m := matchKeyword(words[i:], approveKeywords); m > n {
+			decision, n = approvals.DecisionApprove, m
+		}
+		if n == 0 {
+			continue
+		}
+		return Intent{Decision: decision, Reason: reasonAfter(text, words[i+n:], decision)}, nil
+	}
+	return Intent{}, nil
+}
+
+// reasonAfter returns the text following a reason marker in rest, or for a denial the text after filler words.
+func reasonAfter(text string, rest []word, decision approvals.Decision) string {
+	marked := false
+	for j := range rest {
+		if m := matchKeyword(rest[j:], reasonMarkers); m > 0 {
+			rest, marked = rest[j+m:], true
+			break
+		}
+	}
+	if !marked {
+		if decision != approvals.DecisionDeny {
+			return ""
+		}
+		// "do not approve this" leaves no reason.
+		for len(rest) > 0 {
+			n := max(matchKeyword(rest, fillerWords), matchKeyword(rest, approveKeywords))
+			if n == 0 {
+				break
+			}
+			rest = rest[n:]
+		}
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(text[rest[0].start:])
+}
+
+// matchKeyword returns how many leading words match the longest of keywords, or 0.
+func matchKeyword(words []word, keywords []keyword) int {
+	longest := 0
+	for _, kw := range keywords {
+		if len(kw) > len(words) || len(kw) <= longest {
+			continue
+		}
+		matched := true
+		for i, part := range kw {
+			if stem, ok := strings.CutSuffix(part, "*"); ok {
+				matched = strings.HasPrefix(words[i].text, stem)
+			} else {
+				matched = words[i].text == part
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			longest = len(kw)
+		}
+	}
+	return longest
+}
+
+// splitWords splits text into lowercased words of letters, digits and apostrophes.
+func splitWords(text string) []word {
+	var words []word
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			words = append(words, word{text: strings.ToLower(strings.ReplaceAll(text[start:end], "’", "'")), start: start})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return words
+}
+
+// intentPrompt instructs the model; the transcript and pending approvals follow as the user message.
+const intentPrompt = `You read transcribed voice commands of a human approving or denying requests of automated agents.
+Answer with a JSON object {"decision": "approve" | "deny" | "unknown", "reason": string, "correlation_id": string}.
+"reason" is the comment or the reason given by the speaker, in their words and language, or "".
+"correlation_id" is the ID of the pending request the speaker refers to, taken from the list, or "" when unclear.
+Use "unknown" when the speaker does not clearly approve or deny.`
+
+// OpenAIIntentMatcher detects intents with an OpenAI chat model and falls back to keywords when the model fails.
+type OpenAIIntentMatcher struct {
+	client   openai.Client
+	model    string
+	timeout  time.Duration
+	fallback KeywordMatcher
+	log      *slog.Logger
+}
+
+// NewOpenAIIntentMatcher initializes an OpenAI-backed intent matcher.
+func NewOpenAIIntentMatcher(apiKey, model string, timeout time.Duration, httpClient *http.Client, log *slog.Logger) *OpenAIIntentMatcher {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+	return &OpenAIIntentMatcher{client: openai.NewClient(opts...), model: model, timeout: timeout, log: log}
+}
+
+// Match asks the model for the intent of text.
+func (m *OpenAIIntentMatcher) Match(ctx context.Context, text string, pending []approvals.Request) (Intent, error) {
+	intent, err := m.ask(ctx, text, pending)
+	if err != nil {
+		m.log.Warn("OpenAI intent detection failed, using keywords", "error", err)
+		return m.fallback.Match(ctx, text, pending)
+	}
+	return intent, nil
+}
+
+func (m *OpenAIIntentMatcher) ask(ctx context.Context, text string, pending []approvals.Request) (Intent, error) {
+	var input strings.Builder
+	fmt.Fprintf(&input, "Transcript: %s\n\nPending requests:\n", text)
+	for _, req := range pending {
+		fmt.Fprintf(&input, "- %s: %s %s\n", req.CorrelationID, req.Tool, truncateRunes(req.ApprovalRequest, 200))
+	}
+	askCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	resp, err := m.client.Chat.Completions.New(askCtx, openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(m.model),
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(intentPrompt), openai.UserMessage(input.String())},
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		},
+	})
+	if err != nil {
+		return Intent{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Intent{}, errors.New("empty intent result")
+	}
+	var answer struct {
+		Decision      string `json:"decision"`
+		Reason        string `json:"reason"`
+		CorrelationID string `json:"correlation_id"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &answer); err != nil {
+		return Intent{}, fmt.Errorf("parse intent result: %w", err)
+	}
+	intent := Intent{Reason: strings.TrimSpace(answer.Reason), CorrelationID: strings.TrimSpace(answer.CorrelationID)}
+	switch approvals.Decision(strings.ToLower(answer.Decision)) {
+	case approvals.DecisionApprove:
+		intent.Decision = approvals.DecisionApprove
+	case approvals.DecisionDeny:
+		intent.Decision = approvals.DecisionDeny
+	}
+	return intent, nil
+}
+
+// truncateRunes shortens text to at most limit runes.
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
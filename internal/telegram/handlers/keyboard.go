@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// maxAlert is the longest text Telegram shows in a callback alert.
+const maxAlert = 200
+
+// ApprovalKeyboard builds the inline keyboard of a pending approval from the request layout or the configured one.
+func (h *Handler) ApprovalKeyboard(req approvals.Request) *telego.InlineKeyboardMarkup {
+	layout := h.keyboard
+	if req.Keyboard != "" {
+		if rows, err := config.ParseKeyboard(req.Keyboard); err == nil {
+			layout = rows
+		}
+	}
+	msg := h.messageFor(req.Lang)
+	hasAck := false
+	rows := make([][]telego.InlineKeyboardButton, 0, len(layout)+1)
+	for _, names := range layout {
+		row := make([]telego.InlineKeyboardButton, 0, len(names))
+		for _, name := range names {
+			var text, action string
+			switch name {
+			case config.ButtonApprove:
+				text, action = msg.ApproveButton, ActionApprove
+			case config.ButtonDeny:
+				text, action = msg.DenyButton, ActionDeny
+			case config.ButtonDenyReason:
+				text, action = msg.DenyWithMessageButton, ActionDenyWithMessage
+			case config.ButtonDiscuss:
+				text, action = msg.DiscussButton, ActionDiscuss
+			case config.ButtonDetails:
+				text, action = msg.DetailsButton, ActionDetails
+			case config.ButtonDelegate:
+				if len(h.delegates.Load().chats) == 0 {
+					continue
+				}
+				text, action = msg.DelegateButton, ActionDelegate
+			case config.ButtonLanguage:
+				// Only the last part of a split message could be re-rendered.
+				next := h.nextLanguage(req.Lang)
+				if next == "" || h.render == nil || req.Split {
+					continue
+				}
+				text, action = "🌐 "+strings.ToUpper(next), ActionLanguage
+			case config.ButtonSnooze:
+				if h.snoozeFor <= 0 {
+					continue
+				}
+				text, action = msg.SnoozeButton, ActionSnooze
+			case config.ButtonAck:
+				if !req.Critical {
+					continue
+				}
+				text, action, hasAck = msg.AckButton, ActionAck, true
+			default:
+				continue
+			}
+			row = append(row, tu.InlineKeyboardButton(text).WithCallbackData(CallbackData(action, req.CorrelationID)))
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if req.Critical && !hasAck {
+		rows = append(rows, tu.InlineKeyboardRow(tu.InlineKeyboardButton(msg.AckButton).WithCallbackData(CallbackData(ActionAck, req.CorrelationID))))
+	}
+	return tu.InlineKeyboard(rows...)
+}
+
+// OnDelegate registers the function that moves an approval into another chat.
+func (h *Handler) OnDelegate(fn func(ctx context.Context, correlationID string, chatID int64) error) {
+	h.delegate = fn
+}
+
+// showDetails answers with an alert summarizing request metadata that the message may not show.
+func (h *Handler) showDetails(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	req := approval.Request
+	lines := []string{msg.ApprovalTool + ": " + req.Tool}
+	if req.RequestedBy != "" {
+		lines = append(lines, msg.RequestedByLabel+": "+req.RequestedBy)
+	}
+	if req.Tenant != "" {
+		lines = append(lines, msg.TenantLabel+": "+req.Tenant)
+	}
+	lines = append(lines, "⏳ "+fmt.Sprintf(msg.StatusRemaining, shared.FormatAge(time.Until(approval.Deadline))))
+	if req.Fingerprint != "" {
+		lines = append(lines, msg.FingerprintLabel+": "+req.Fingerprint)
+	}
+	text := strings.Join(lines, "\n")
+	if runes := []rune(text); len(runes) > maxAlert {
+		text = string(runes[:maxAlert-1]) + "…"
+	}
+	_ = h.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: text, ShowAlert: true})
+}
+
+// showDelegateTargets replaces the keyboard of the pressed message with the chats the approval can move to.
+func (h *Handler) showDelegateTargets(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	targets := h.delegates.Load()
+	rows := make([][]telego.InlineKeyboardButton, 0, len(targets.names)+1)
+	for i, name := range targets.names {
+		if targets.chats[name] == approval.ChatID {
+			continue
+		}
+		data := CallbackData(ActionDelegateTo, strconv.Itoa(i)+":"+correlationID)
+		rows = append(rows, tu.InlineKeyboardRow(tu.InlineKeyboardButton(name).WithCallbackData(data)))
+	}
+	rows = append(rows, tu.InlineKeyboardRow(tu.InlineKeyboardButton(msg.BackButton).WithCallbackData(CallbackData(ActionDelegateBack, correlationID))))
+	h.setKeyboard(ctx, query, tu.InlineKeyboard(rows...))
+	_ = h.answerCallback(ctx, query, "")
+}
+
+// delegateTo moves the approval into the chosen chat.
+func (h *Handler) delegateTo(ctx context.Context, query *telego.CallbackQuery, payload string) {
+	rawIndex, correlationID, _ := strings.Cut(payload, ":")
+	index, err := strconv.Atoi(rawIndex)
+	targets := h.delegates.Load()
+	if err != nil || index < 0 || index >= len(targets.names) || h.delegate == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").InvalidAction)
+		return
+	}
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	if !canVote(approval, query.From.ID) {
+		_ = h.answerCallback(ctx, query, msg.NotAllowed)
+		return
+	}
+	name := targets.names[index]
+	if err := h.delegate(ctx, correlationID, targets.chats[name]); err != nil {
+		h.log.Warn("Failed to delegate approval", "error", err, "correlation_id", correlationID, "chat", name)
+		h.setKeyboard(ctx, query, h.ApprovalKeyboard(approval.Request))
+		_ = h.answerCallback(ctx, query, msg.DelegateFailed)
+		return
+	}
+	h.log.Info("Approval delegated", "correlation_id", correlationID, "chat", name, "user_id", query.From.ID)
+	if ref := (approvals.MessageRef{ChatID: query.Message.GetChat().ID, MessageID: query.Message.GetMessageID()}); ref != approval.Message() {
+		// The escalation copy stays; give it its buttons back.
+		h.setKeyboard(ctx, query, h.ApprovalKeyboard(approval.Request))
+	}
+	_ = h.answerCallback(ctx, query, fmt.Sprintf(msg.DelegateDone, name))
+}
+
+// delegateBack restores the approval keyboard after the chat picker.
+func (h *Handler) delegateBack(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	h.setKeyboard(ctx, query, h.ApprovalKeyboard(approval.Request))
+	_ = h.answerCallback(ctx, query, "")
+}
+
+func (h *Handler) setKeyboard(ctx context.Context, query *telego.CallbackQuery, keyboard *telego.InlineKeyboardMarkup) {
+	_, err := h.bot.EditMessageReplyMarkup(ctx, &telego.EditMessageReplyMarkupParams{
+		ChatID:      tu.ID(query.Message.GetChat().ID),
+		MessageID:   query.Message.GetMessageID(),
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		h.log.Warn("Failed to update approval keyboard", "error", err)
+	}
+}
+
+// delegateTargets are the named chats the Delegate button can move approvals to.
+type delegateTargets struct {
+	chats map[string]int64
+	// names are sorted; delegate buttons refer to chats by their index.
+	names []string
+}
+
+// SetDelegateChats replaces the named chats the Delegate button can move approvals to.
+func (h *Handler) SetDelegateChats(chats map[string]int64) {
+	h.delegates.Store(&delegateTargets{chats: chats, names: sortedChatNames(chats)})
+}
+
+// sortedChatNames returns chat names in a stable order for delegate buttons.
+func sortedChatNames(chats map[string]int64) []string {
+	names := make([]string, 0, len(chats))
+	for name := range chats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
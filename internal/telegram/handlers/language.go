@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+)
+
+// OnRender registers the function that renders the approval message text for a request.
+func (h *Handler) OnRender(fn func(req approvals.Request) string) {
+	h.render = fn
+}
+
+// nextLanguage returns the bundled language that follows lang in alphabetical order,
+// or an empty string when only one language is bundled.
+func (h *Handler) nextLanguage(lang string) string {
+	langs := h.messages.Catalog().Languages()
+	if len(langs) < 2 {
+		return ""
+	}
+	current := strings.ToLower(strings.TrimSpace(lang))
+	if current == "" {
+		current = h.defaultLang
+	}
+	for i, code := range langs {
+		if code == current {
+			return langs[(i+1)%len(langs)]
+		}
+	}
+	return langs[0]
+}
+
+// switchLanguage re-renders a pending approval in the next language, keeping votes, acknowledgements,
+// and the keyboard. Later notes on the approval use the chosen language.
+func (h *Handler) switchLanguage(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil || h.render == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	next := h.nextLanguage(approval.Request.Lang)
+	if next == "" {
+		_ = h.answerCallback(ctx, query, "")
+		return
+	}
+	req := approval.Request
+	req.Lang = next
+	if !h.registry.SetLang(correlationID, next, h.render(req)) {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	h.showProgress(ctx, approval)
+	_ = h.answerCallback(ctx, query, "")
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// handleDeepLink opens the approval of a /start deep link in the private chat and reports whether the message was
+// consumed. The token is claimed on first use, so a forwarded link cannot be opened twice.
+func (h *Handler) handleDeepLink(ctx context.Context, message *telego.Message) bool {
+	if !h.deepLinks || message.Chat.Type != telego.ChatTypePrivate || message.From == nil {
+		return false
+	}
+	name, args, ok := parseCommand(message.Text)
+	if !ok || name != CommandStart || len(args) != 1 {
+		return false
+	}
+	token := args[0]
+	msg := h.messageFor(message.From.LanguageCode)
+	approval := h.registry.LinkApproval(token)
+	if approval == nil {
+		_ = h.reply(ctx, message, msg.LinkInvalid)
+		return true
+	}
+	if !h.isApprover(message.From.ID) || !canVote(approval, message.From.ID) {
+		_ = h.reply(ctx, message, msg.NotAllowed)
+		return true
+	}
+	correlationID := approval.Request.CorrelationID
+	if !h.registry.ClaimLink(correlationID, token) {
+		_ = h.reply(ctx, message, msg.LinkInvalid)
+		return true
+	}
+	msg = h.messageFor(approval.Request.Lang)
+	sent, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:    tu.ID(message.Chat.ID),
+		Text:      approval.MessageText,
+		ParseMode: parseMode(approval.Request.Markup),
+		ReplyMarkup: tu.InlineKeyboard(tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(msg.ApproveButton).WithCallbackData(CallbackData(ActionApprove, correlationID)),
+			tu.InlineKeyboardButton(msg.DenyButton).WithCallbackData(CallbackData(ActionDeny, correlationID)),
+		)),
+	})
+	if err != nil {
+		h.log.Error("Failed to open approval from deep link", "error", err, "correlation_id", correlationID, "user_id", message.From.ID)
+		return true
+	}
+	ref := approvals.MessageRef{ChatID: message.Chat.ID, MessageID: sent.MessageID}
+	if !h.registry.SetDirect(correlationID, ref) {
+		// Resolved while the message was being sent.
+		_ = h.DeleteMessage(ctx, ref)
+		_ = h.reply(ctx, message, msg.AlreadyResolved)
+		return true
+	}
+	h.log.Info("Approval opened from deep link", "correlation_id", correlationID, "user_id", message.From.ID)
+	return true
+}
+
+// directCallback reports whether the callback was pressed on the private chat copy of an approval opened through a
+// deep link. Only the approve and deny buttons exist there.
+func (h *Handler) directCallback(query *telego.CallbackQuery) bool {
+	if !h.deepLinks || query.Message.GetChat().Type != telego.ChatTypePrivate {
+		return false
+	}
+	action, payload := parseCallback(query.Data)
+	if action != ActionApprove && action != ActionDeny {
+		return false
+	}
+	approval := h.registry.Get(payload)
+	if approval == nil {
+		return false
+	}
+	return approval.Direct == approvals.MessageRef{ChatID: query.Message.GetChat().ID, MessageID: query.Message.GetMessageID()}
+}
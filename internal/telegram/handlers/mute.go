@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+// maxMute bounds how long a chat can be muted with a single command.
+const maxMute = 7 * 24 * time.Hour
+
+func (h *Handler) muteCommand(ctx context.Context, message *telego.Message, args []string) {
+	msg := h.messageFor("")
+	if message.From == nil || !h.isApprover(message.From.ID) {
+		_ = h.reply(ctx, message, msg.NotAllowed)
+		return
+	}
+	if len(args) == 0 {
+		_ = h.reply(ctx, message, msg.MuteUsage)
+		return
+	}
+	if strings.EqualFold(args[0], "off") {
+		h.setMute(message.Chat.ID, time.Time{})
+		_ = h.reply(ctx, message, msg.MuteOff)
+		return
+	}
+	period, err := time.ParseDuration(args[0])
+	if err != nil || period <= 0 || period > maxMute {
+		_ = h.reply(ctx, message, msg.MuteUsage)
+		return
+	}
+	until := time.Now().Add(period)
+	h.setMute(message.Chat.ID, until)
+	h.log.Info("Chat muted", "chat_id", message.Chat.ID, "until", until, "user_id", message.From.ID)
+	_ = h.reply(ctx, message, fmt.Sprintf(msg.MuteDone, until.UTC().Format("2006-01-02 15:04 MST")))
+}
+
+func (h *Handler) setMute(chatID int64, until time.Time) {
+	h.muteMu.Lock()
+	defer h.muteMu.Unlock()
+	if until.IsZero() {
+		delete(h.mutedUntil, chatID)
+		return
+	}
+	h.mutedUntil[chatID] = until
+}
+
+// Muted reports whether messages to the chat should be sent silently.
+func (h *Handler) Muted(chatID int64) bool {
+	h.muteMu.Lock()
+	defer h.muteMu.Unlock()
+	until, ok := h.mutedUntil[chatID]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(h.mutedUntil, chatID)
+		return false
+	}
+	return true
+}
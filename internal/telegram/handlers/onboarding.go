@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/chats"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	// CommandStart starts onboarding of a chat the bot does not serve yet.
+	CommandStart = "start"
+
+	// ActionSetupConfirm confirms that a new chat is used for approvals.
+	ActionSetupConfirm = "setup_ok"
+	// ActionSetupCancel aborts onboarding.
+	ActionSetupCancel = "setup_cancel"
+	// ActionSetupLang picks the default language of a new chat.
+	ActionSetupLang = "setup_lang"
+
+	// setupTTL is how long an unfinished onboarding is kept.
+	setupTTL = time.Hour
+)
+
+type setupStep int
+
+const (
+	setupConfirm setupStep = iota
+	setupLanguage
+	setupLabel
+)
+
+// setupSession is the in-memory state of a chat being onboarded.
+type setupSession struct {
+	userID  int64
+	title   string
+	step    setupStep
+	lang    string
+	prompt  int
+	started time.Time
+}
+
+func isSetupAction(action string) bool {
+	return action == ActionSetupConfirm || action == ActionSetupCancel || action == ActionSetupLang
+}
+
+func (h *Handler) isSuperAdmin(user *telego.User) bool {
+	if user == nil {
+		return false
+	}
+	_, ok := h.superAdmins[user.ID]
+	return ok
+}
+
+// handleMembership starts onboarding when a super admin adds the bot to a group it does not serve.
+func (h *Handler) handleMembership(ctx context.Context, update *telego.ChatMemberUpdated) {
+	if update.NewChatMember == nil || !update.NewChatMember.MemberIsMember() {
+		return
+	}
+	if update.OldChatMember != nil && update.OldChatMember.MemberIsMember() {
+		// A change of the bot rights, not a new membership.
+		return
+	}
+	if update.Chat.Type == telego.ChatTypePrivate || !h.isSuperAdmin(&update.From) || h.allowedChat(update.Chat.ID) {
+		return
+	}
+	h.startSetup(ctx, update.Chat, update.From)
+}
+
+// handleOnboarding runs /start and the label reply of onboarding and reports whether the message was consumed.
+func (h *Handler) handleOnboarding(ctx context.Context, message *telego.Message) bool {
+	if len(h.superAdmins) == 0 || message.Chat.Type == telego.ChatTypePrivate || !h.isSuperAdmin(message.From) {
+		return false
+	}
+	if name, _, ok := parseCommand(message.Text); ok && name == CommandStart {
+		if h.allowedChat(message.Chat.ID) {
+			_ = h.reply(ctx, message, h.messageFor(message.From.LanguageCode).SetupKnown)
+			return true
+		}
+		h.startSetup(ctx, message.Chat, *message.From)
+		return true
+	}
+	session, ok := h.setupAt(message.Chat.ID, message.From.ID, setupLabel)
+	if !ok || message.ReplyToMessage == nil || message.ReplyToMessage.MessageID != session.prompt {
+		return false
+	}
+	h.finishSetup(ctx, message, session)
+	return true
+}
+
+// startSetup asks the super admin to confirm that the chat is used for approval requests.
+func (h *Handler) startSetup(ctx context.Context, chat telego.Chat, user telego.User) {
+	msg := h.messageFor(user.LanguageCode)
+	h.setupMu.Lock()
+	h.setups[chat.ID] = &setupSession{userID: user.ID, title: chat.Title, started: time.Now()}
+	h.setupMu.Unlock()
+	_, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:    tu.ID(chat.ID),
+		Text:      msg.SetupIntro,
+		ParseMode: telego.ModeMarkdown,
+		ReplyMarkup: tu.InlineKeyboard(tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(msg.SetupConfirmButton).WithCallbackData(ActionSetupConfirm),
+			tu.InlineKeyboardButton(msg.SetupCancelButton).WithCallbackData(ActionSetupCancel),
+		)),
+	})
+	if err != nil {
+		h.log.Error("Failed to start chat onboarding", "error", err, "chat_id", chat.ID)
+		h.endSetup(chat.ID)
+		return
+	}
+	h.log.Info("Chat onboarding started", "chat_id", chat.ID, "user_id", user.ID)
+}
+
+// handleSetup processes the buttons of the onboarding message.
+func (h *Handler) handleSetup(ctx context.Context, query *telego.CallbackQuery, action, payload string) {
+	chatID := query.Message.GetChat().ID
+	msg := h.messageFor(query.From.LanguageCode)
+	switch action {
+	case ActionSetupCancel:
+		if _, ok := h.setupAt(chatID, query.From.ID, -1); !ok {
+			_ = h.answerCallback(ctx, query, msg.SetupExpired)
+			return
+		}
+		h.endSetup(chatID)
+		h.editSetup(ctx, query, msg.SetupCancelled, nil)
+	case ActionSetupConfirm:
+		if _, ok := h.advanceSetup(chatID, query.From.ID, setupConfirm, func(*setupSession) {}); !ok {
+			_ = h.answerCallback(ctx, query, msg.SetupExpired)
+			return
+		}
+		h.editSetup(ctx, query, msg.SetupLanguage, h.setupLanguages())
+	case ActionSetupLang:
+		if _, ok := h.messages.Catalog()[payload]; !ok {
+			_ = h.answerCallback(ctx, query, msg.InvalidAction)
+			return
+		}
+		if _, ok := h.advanceSetup(chatID, query.From.ID, setupLanguage, func(s *setupSession) { s.lang = payload }); !ok {
+			_ = h.answerCallback(ctx, query, msg.SetupExpired)
+			return
+		}
+		msg = h.messageFor(payload)
+		h.editSetup(ctx, query, fmt.Sprintf(msg.SetupLanguageDone, strings.ToUpper(payload)), nil)
+		prompt, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+			ChatID:          tu.ID(chatID),
+			MessageThreadID: shared.ThreadID(query.Message.Message()),
+			Text:            msg.SetupLabel,
+			ParseMode:       telego.ModeMarkdown,
+			ReplyMarkup:     tu.ForceReply().WithSelective().WithInputFieldPlaceholder(msg.SetupLabelPlaceholder),
+		})
+		if err != nil {
+			h.log.Error("Failed to ask for chat label", "error", err, "chat_id", chatID)
+			h.endSetup(chatID)
+			return
+		}
+		h.setupMu.Lock()
+		if session, ok := h.setups[chatID]; ok {
+			session.prompt = prompt.MessageID
+		}
+		h.setupMu.Unlock()
+	}
+	_ = h.answerCallback(ctx, query, "")
+}
+
+// finishSetup registers the chat under the label from the reply and persists it.
+func (h *Handler) finishSetup(ctx context.Context, message *telego.Message, session setupSession) {
+	msg := h.messageFor(session.lang)
+	label := strings.ToLower(strings.TrimSpace(message.Text))
+	err := h.chats.Add(chats.Chat{
+		ID:      message.Chat.ID,
+		Label:   label,
+		Title:   session.title,
+		Lang:    session.lang,
+		AddedBy: session.userID,
+	})
+	switch {
+	case errors.Is(err, chats.ErrInvalidLabel):
+		_ = h.reply(ctx, message, msg.SetupLabelInvalid)
+		return
+	case errors.Is(err, chats.ErrLabelTaken):
+		_ = h.reply(ctx, message, msg.SetupLabelTaken)
+		return
+	}
+	h.endSetup(message.Chat.ID)
+	switch {
+	case errors.Is(err, chats.ErrKnownChat):
+		_ = h.reply(ctx, message, msg.SetupKnown)
+		return
+	case err != nil:
+		h.log.Error("Failed to save onboarded chat", "error", err, "chat_id", message.Chat.ID)
+		_ = h.reply(ctx, message, msg.SetupFailed)
+		return
+	}
+	// Labels are plain identifiers, so they need no escaping inside code spans.
+	_ = h.reply(ctx, message, fmt.Sprintf(msg.SetupDone, label, label))
+	h.log.Info("Chat onboarded", "chat_id", message.Chat.ID, "label", label, "lang", session.lang, "user_id", session.userID)
+}
+
+// setupAt returns a copy of the chat session when the user owns it and it is at step; a negative step matches any.
+func (h *Handler) setupAt(chatID, userID int64, step setupStep) (setupSession, bool) {
+	return h.advanceSetup(chatID, userID, step, nil)
+}
+
+// advanceSetup applies fn to the chat session at step and moves it to the next step. Expired sessions are dropped.
+func (h *Handler) advanceSetup(chatID, userID int64, step setupStep, fn func(*setupSession)) (setupSession, bool) {
+	h.setupMu.Lock()
+	defer h.setupMu.Unlock()
+	session, ok := h.setups[chatID]
+	if !ok {
+		return setupSession{}, false
+	}
+	if time.Since(session.started) > setupTTL {
+		delete(h.setups, chatID)
+		return setupSession{}, false
+	}
+	if session.userID != userID || (step >= 0 && session.step != step) {
+		return setupSession{}, false
+	}
+	if fn != nil {
+		fn(session)
+		session.step++
+	}
+	return *session, true
+}
+
+func (h *Handler) endSetup(chatID int64) {
+	h.setupMu.Lock()
+	defer h.setupMu.Unlock()
+	delete(h.setups, chatID)
+}
+
+// setupLanguages returns a keyboard with one button per bundled language.
+func (h *Handler) setupLanguages() *telego.InlineKeyboardMarkup {
+	langs := h.messages.Catalog().Languages()
+	row := make([]telego.InlineKeyboardButton, 0, len(langs))
+	for _, lang := range langs {
+		row = append(row, tu.InlineKeyboardButton("🌐 "+strings.ToUpper(lang)).WithCallbackData(CallbackData(ActionSetupLang, lang)))
+	}
+	return tu.InlineKeyboard(row)
+}
+
+// editSetup replaces the text and keyboard of the onboarding message.
+func (h *Handler) editSetup(ctx context.Context, query *telego.CallbackQuery, text string, keyboard *telego.InlineKeyboardMarkup) {
+	_, err := h.bot.EditMessageText(ctx, &telego.EditMessageTextParams{
+		ChatID:      tu.ID(query.Message.GetChat().ID),
+		MessageID:   query.Message.GetMessageID(),
+		Text:        text,
+		ParseMode:   telego.ModeMarkdown,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		h.log.Warn("Failed to update onboarding message", "error", err, "chat_id", query.Message.GetChat().ID)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultOperationTimeout is used when no operation timeout is configured.
+const defaultOperationTimeout = 30 * time.Second
+
+// Operation returns a context for the Telegram edits, deletes and notifications that finish an approval.
+// It keeps the values of ctx, such as the trace, but not its cancellation, so a shutdown that cancels the update
+// context does not cut a finalization short; the operation timeout bounds it instead.
+// done must be called when the operation ends; Drain waits for it.
+func (h *Handler) Operation(ctx context.Context) (context.Context, func()) {
+	timeout := h.operationTimeout
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+	h.operations.Add(1)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	return ctx, func() {
+		cancel()
+		h.operations.Add(-1)
+	}
+}
+
+// Drain waits until running operations end or ctx is done.
+func (h *Handler) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		running := h.operations.Load()
+		if running == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d approval operations still running: %w", running, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// PinMessage pins a new approval message silently when pinning is enabled.
+// Failures, e.g. a missing Pin Messages right, are logged and do not block the approval.
+func (h *Handler) PinMessage(ctx context.Context, message approvals.MessageRef) {
+	if !h.pin || !message.Valid() {
+		return
+	}
+	err := h.bot.PinChatMessage(ctx, &telego.PinChatMessageParams{
+		ChatID:              tu.ID(message.ChatID),
+		MessageID:           message.MessageID,
+		DisableNotification: true,
+	})
+	if err != nil {
+		h.log.Warn("Failed to pin approval message", "error", err, "chat_id", message.ChatID, "message_id", message.MessageID)
+	}
+}
+
+// unpinMessage unpins a resolved approval message.
+func (h *Handler) unpinMessage(ctx context.Context, approval *approvals.Approval) {
+	message := approval.Message()
+	if !h.pin || !message.Valid() {
+		return
+	}
+	err := h.bot.UnpinChatMessage(ctx, &telego.UnpinChatMessageParams{
+		ChatID:    tu.ID(message.ChatID),
+		MessageID: message.MessageID,
+	})
+	if err != nil {
+		h.log.Warn("Failed to unpin approval message", "error", err, "correlation_id", approval.Request.CorrelationID)
+	}
+}
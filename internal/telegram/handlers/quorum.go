@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// approve handles an Approve press; requests with a quorum collect votes until enough distinct users approve.
+func (h *Handler) approve(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	required := approval.Request.RequiredApprovals
+	if required <= 1 {
+		h.resolveDecision(ctx, query, correlationID, approvals.Result{Decision: approvals.DecisionApprove, Reason: "approved", ReasonCode: approvals.ReasonApproved})
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	vote := approvals.Vote{
+		UserID:   query.From.ID,
+		Username: displayName(&query.From),
+		At:       time.Now().UTC(),
+	}
+	votes, added, ok := h.registry.AddVote(correlationID, vote)
+	if !ok {
+		_ = h.answerCallback(ctx, query, msg.AlreadyResolved)
+		return
+	}
+	if !added {
+		_ = h.answerCallback(ctx, query, msg.AlreadyVoted)
+		return
+	}
+	h.audit.Vote(approval, vote)
+	if len(votes) >= required {
+		h.resolveDecision(ctx, query, correlationID, approvals.Result{
+			Decision:   approvals.DecisionApprove,
+			Reason:     "approved by " + voterNames(votes),
+			ReasonCode: approvals.ReasonApproved,
+		})
+		return
+	}
+	h.showProgress(ctx, approval)
+	_ = h.answerCallback(ctx, query, fmt.Sprintf(msg.VoteRecorded, len(votes), required))
+}
+
+// showProgress appends quorum votes and acknowledgements to the approval message and keeps its keyboard.
+func (h *Handler) showProgress(ctx context.Context, approval *approvals.Approval) {
+	msg := h.messageFor(approval.Request.Lang)
+	votes, acks := h.registry.Progress(approval.Request.CorrelationID)
+	text := approval.MessageText
+	if len(votes) > 0 {
+		progress := fmt.Sprintf(msg.VotesProgress, len(votes), approval.Request.RequiredApprovals, voterNames(votes))
+		text += "\n\n" + escapeNote(approval.Request.Markup, progress)
+	}
+	if len(acks) > 0 {
+		text += "\n\n" + escapeNote(approval.Request.Markup, fmt.Sprintf(msg.AcksProgress, voterNames(acks)))
+	}
+	params := &telego.EditMessageTextParams{
+		ChatID:      tu.ID(approval.ChatID),
+		MessageID:   approval.MessageID,
+		Text:        text,
+		ParseMode:   parseMode(approval.Request.Markup),
+		ReplyMarkup: h.ApprovalKeyboard(approval.Request),
+	}
+	if _, err := h.bot.EditMessageText(ctx, params); err != nil {
+		h.log.Error("Failed to update vote progress", "error", err, "correlation_id", approval.Request.CorrelationID)
+	}
+}
+
+// canVote reports whether the user may decide on this approval.
+func canVote(approval *approvals.Approval, userID int64) bool {
+	return len(approval.Request.Approvers) == 0 || slices.Contains(approval.Request.Approvers, userID)
+}
+
+func voterNames(votes []approvals.Vote) string {
+	names := make([]string, 0, len(votes))
+	for _, vote := range votes {
+		name := vote.Username
+		if name == "" {
+			name = fmt.Sprintf("%d", vote.UserID)
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func escapeNote(markup, text string) string {
+	if strings.EqualFold(strings.TrimSpace(markup), "html") {
+		return shared.EscapeHTML(text)
+	}
+	return shared.EscapeMarkdown(text)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+)
+
+// OnSnooze registers the function that postpones an approval timeout and schedules its reminder.
+func (h *Handler) OnSnooze(fn func(ctx context.Context, correlationID string) error) {
+	h.snooze = fn
+}
+
+// snoozeApproval postpones the approval timeout; the message is reposted when the snooze ends.
+func (h *Handler) snoozeApproval(ctx context.Context, query *telego.CallbackQuery, correlationID string) {
+	if h.snooze == nil || h.snoozeFor <= 0 {
+		_ = h.answerCallback(ctx, query, h.messageFor("").InvalidAction)
+		return
+	}
+	approval := h.registry.Get(correlationID)
+	if approval == nil {
+		_ = h.answerCallback(ctx, query, h.messageFor("").AlreadyResolved)
+		return
+	}
+	msg := h.messageFor(approval.Request.Lang)
+	err := h.snooze(ctx, correlationID)
+	switch {
+	case errors.Is(err, approvals.ErrSnoozeLimit):
+		_ = h.answerCallback(ctx, query, msg.SnoozeLimit)
+		return
+	case errors.Is(err, approvals.ErrNotFound):
+		_ = h.answerCallback(ctx, query, msg.AlreadyResolved)
+		return
+	case err != nil:
+		h.log.Warn("Failed to snooze approval", "error", err, "correlation_id", correlationID)
+		_ = h.answerCallback(ctx, query, "⚠️ "+msg.ErrorNote)
+		return
+	}
+	h.log.Info("Approval snoozed", "correlation_id", correlationID, "user_id", query.From.ID, "for", h.snoozeFor)
+	_ = h.answerCallback(ctx, query, fmt.Sprintf(msg.SnoozeDone, shared.FormatAge(h.snoozeFor)))
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// maxStatusEntries keeps the status reply within the Telegram message length limit.
+const maxStatusEntries = 50
+
+// statusCommand lists approvals pending in the chat, closest deadline first, with links to their messages.
+func (h *Handler) statusCommand(ctx context.Context, message *telego.Message) {
+	msg := h.messageFor("")
+	if message.From == nil || !h.isApprover(message.From.ID) {
+		_ = h.reply(ctx, message, msg.NotAllowed)
+		return
+	}
+	chatID := message.Chat.ID
+	var pending []approvals.Approval
+	for _, approval := range h.registry.List() {
+		if approval.ChatID == chatID || approval.Escalated.ChatID == chatID {
+			pending = append(pending, approval)
+		}
+	}
+	_, err := h.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:             tu.ID(chatID),
+		MessageThreadID:    shared.ThreadID(message),
+		Text:               renderStatus(msg, chatID, pending, time.Now()),
+		ParseMode:          telego.ModeHTML,
+		LinkPreviewOptions: &telego.LinkPreviewOptions{IsDisabled: true},
+	})
+	if err != nil {
+		h.log.Error("Failed to send status", "error", err, "chat_id", chatID)
+	}
+}
+
+func renderStatus(msg i18n.Messages, chatID int64, pending []approvals.Approval, now time.Time) string {
+	if len(pending) == 0 {
+		return shared.EscapeHTML(msg.StatusEmpty)
+	}
+	// The most severe requests come first, then the ones closest to timing out.
+	sort.Slice(pending, func(i, j int) bool {
+		if ri, rj := pending[i].Request.Severity.Rank(), pending[j].Request.Severity.Rank(); ri != rj {
+			return ri > rj
+		}
+		return pending[i].Deadline.Before(pending[j].Deadline)
+	})
+	var builder strings.Builder
+	builder.WriteString("<b>")
+	builder.WriteString(shared.EscapeHTML(fmt.Sprintf(msg.StatusTitle, len(pending))))
+	builder.WriteString("</b>\n")
+	for i, approval := range pending {
+		if i == maxStatusEntries {
+			fmt.Fprintf(&builder, "\n%s", shared.EscapeHTML(fmt.Sprintf(msg.StatusMore, len(pending)-i)))
+			break
+		}
+		ref := approval.Message()
+		if ref.ChatID != chatID {
+			ref = approval.Escalated
+		}
+		label := shared.EscapeHTML(approval.Request.CorrelationID)
+		if link := shared.MessageLink(ref.ChatID, ref.MessageID); link != "" && ref.MessageID != 0 {
+			label = fmt.Sprintf(`<a href="%s">%s</a>`, link, label)
+		}
+		remaining := fmt.Sprintf(msg.StatusRemaining, shared.FormatAge(approval.Deadline.Sub(now)))
+		bullet := "•"
+		if badge := approval.Request.Severity.Badge(); badge != "" {
+			bullet = badge
+		}
+		fmt.Fprintf(&builder, "%s %s — %s — ⏳ %s\n", bullet, label, shared.EscapeHTML(approval.Request.Tool), shared.EscapeHTML(remaining))
+	}
+	return builder.String()
+}
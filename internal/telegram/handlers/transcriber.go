@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/openai/openai-go/v3"
@@ -22,8 +23,12 @@ type OpenAITranscriber struct {
 }
 
 // NewOpenAITranscriber initializes OpenAI transcription client.
-func NewOpenAITranscriber(apiKey, model string, timeout time.Duration, log *slog.Logger) *OpenAITranscriber {
-	client := openai.NewClient(option.WithAPIKey(apiKey))
+func NewOpenAITranscriber(apiKey, model string, timeout time.Duration, httpClient *http.Client, log *slog.Logger) *OpenAITranscriber {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+	client := openai.NewClient(opts...)
 	return &OpenAITranscriber{client: client, model: model, timeout: timeout, log: log}
 }
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -25,10 +26,22 @@ func normalizeVoiceAudio(ctx context.Context, content []byte, mimeType, filename
 		return content, mimeType, filename, nil
 	}
 
+	input := "pipe:0"
+	if strings.HasPrefix(lowerMime, "video/") {
+		// MP4 may keep its index at the end, which ffmpeg cannot seek to in a pipe.
+		path, cleanup, err := tempMedia(content)
+		if err != nil {
+			return nil, "", "", err
+		}
+		defer cleanup()
+		input = path
+	}
+
 	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-nostdin",
 		"-y",
-		"-i", "pipe:0",
+		"-i", input,
+		"-vn",
 		"-ac", ffmpegChannels,
 		"-ar", ffmpegSampleRate,
 		"-f", ffmpegFormat,
@@ -37,7 +50,9 @@ func normalizeVoiceAudio(ctx context.Context, content []byte, mimeType, filename
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	cmd.Stdin = bytes.NewReader(content)
+	if input == "pipe:0" {
+		cmd.Stdin = bytes.NewReader(content)
+	}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -59,6 +74,25 @@ func normalizeVoiceAudio(ctx context.Context, content []byte, mimeType, filename
 	return out, newMime, newName, nil
 }
 
+// tempMedia writes content to a private temporary file and returns its path and a function removing it.
+func tempMedia(content []byte) (string, func(), error) {
+	file, err := os.CreateTemp("", "telegram-approver-media-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp media file: %w", err)
+	}
+	cleanup := func() { _ = os.Remove(file.Name()) }
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("write temp media file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write temp media file: %w", err)
+	}
+	return file.Name(), cleanup, nil
+}
+
 func normalizeFilename(filename string) string {
 	if strings.TrimSpace(filename) == "" {
 		return "voice.mp3"
@@ -74,6 +108,10 @@ func normalizeFilename(filename string) string {
 }
 
 func isOpenAICompatibleAudio(mimeType, filename string) bool {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(mimeType)), "video/") {
+		// The audio track is extracted so large videos are not uploaded.
+		return false
+	}
 	if mimeType != "" {
 		switch strings.ToLower(strings.TrimSpace(mimeType)) {
 		case "audio/mpeg", "audio/mp3", "audio/mp4", "audio/mp4a-latm", "audio/x-m4a", "audio/m4a", "audio/wav", "audio/x-wav", "audio/webm":
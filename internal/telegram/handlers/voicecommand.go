@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/mymmrac/telego"
+)
+
+// voiceCommand decides an approval by a voice message or video note such as "approve" or "deny because it drops
+// the table".
+// The target is the approval the message replies to, the one the intent names, or the only one pending in the chat.
+func (h *Handler) voiceCommand(ctx context.Context, message *telego.Message) {
+	if message.From == nil || !h.isApprover(message.From.ID) {
+		return
+	}
+	pending := h.votable(message)
+	if len(pending) == 0 {
+		return
+	}
+	var target *approvals.Approval
+	if message.ReplyToMessage != nil {
+		// A reply to anything but a pending approval is a conversation, not a command.
+		ref := approvals.MessageRef{ChatID: message.Chat.ID, MessageID: message.ReplyToMessage.MessageID}
+		if target = findByRef(pending, ref); target == nil {
+			return
+		}
+	}
+	msg := h.messageFor(pending[0].Request.Lang)
+	media, _ := speechOf(message)
+	text, err := h.transcribe(ctx, media)
+	if err != nil {
+		if errors.Is(err, errTranscriberDisabled) {
+			_ = h.reply(ctx, message, msg.VoiceDisabled)
+		} else {
+			_ = h.reply(ctx, message, msg.TranscriptionFailed)
+		}
+		return
+	}
+	requests := make([]approvals.Request, 0, len(pending))
+	for _, approval := range pending {
+		requests = append(requests, approval.Request)
+	}
+	intent, err := h.intents.Match(ctx, text, requests)
+	if err != nil || intent.Decision == "" {
+		_ = h.reply(ctx, message, fmt.Sprintf(msg.VoiceNotUnderstood, shared.EscapeMarkdown(text)))
+		return
+	}
+	if target == nil {
+		target = findByID(pending, intent.CorrelationID)
+	}
+	if target == nil && len(pending) == 1 {
+		target = pending[0]
+	}
+	if target == nil {
+		_ = h.reply(ctx, message, msg.VoiceAmbiguous)
+		return
+	}
+	h.log.Info("Voice command recognized", "correlation_id", target.Request.CorrelationID, "decision", intent.Decision, "user_id", message.From.ID)
+	h.decideAs(ctx, message, target, intent.Decision, intent.Reason)
+}
+
+// votable returns the Telegram approvals posted or escalated to the chat of message that its author may vote on.
+func (h *Handler) votable(message *telego.Message) []*approvals.Approval {
+	var pending []*approvals.Approval
+	for _, approval := range h.registry.List() {
+		if approval.Request.Channel != "" && approval.Request.Channel != channel.Telegram {
+			continue
+		}
+		if approval.ChatID != message.Chat.ID && approval.Escalated.ChatID != message.Chat.ID {
+			continue
+		}
+		if !canVote(&approval, message.From.ID) {
+			continue
+		}
+		pending = append(pending, &approval)
+	}
+	return pending
+}
+
+// findByRef returns the approval whose message, escalation copy or discussion root is ref.
+func findByRef(pending []*approvals.Approval, ref approvals.MessageRef) *approvals.Approval {
+	for _, approval := range pending {
+		if approval.Message() == ref || approval.Escalated == ref ||
+			(approval.ChatID == ref.ChatID && approval.DiscussionMessageID != 0 && approval.DiscussionMessageID == ref.MessageID) {
+			return approval
+		}
+	}
+	return nil
+}
+
+// findByID returns the approval with the correlation ID, or nil.
+func findByID(pending []*approvals.Approval, correlationID string) *approvals.Approval {
+	if correlationID == "" {
+		return nil
+	}
+	for _, approval := range pending {
+		if approval.Request.CorrelationID == correlationID {
+			return approval
+		}
+	}
+	return nil
+}
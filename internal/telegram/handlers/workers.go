@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mymmrac/telego"
+)
+
+// workerQueue is how many updates may wait for one worker before dispatching blocks.
+const workerQueue = 64
+
+// Run processes updates until context cancellation or until the updates channel is closed and the queued updates
+// are handled. Updates of one approval always go to the same worker and keep their order, while a slow update,
+// e.g. a voice reason being transcribed, does not hold up buttons of other approvals.
+func (h *Handler) Run(ctx context.Context, updates <-chan telego.Update) {
+	queues := make([]chan telego.Update, max(h.workers, 1))
+	var wg sync.WaitGroup
+	for i := range queues {
+		queue := make(chan telego.Update, workerQueue)
+		queues[i] = queue
+		wg.Go(func() { h.work(ctx, queue) })
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			select {
+			case queues[workerFor(h.updateKey(update), len(queues))] <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// work handles the updates of one worker queue in order.
+func (h *Handler) work(ctx context.Context, queue <-chan telego.Update) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-queue:
+			if !ok {
+				return
+			}
+			h.HandleUpdate(ctx, update)
+		}
+	}
+}
+
+// updateKey returns the correlation ID of the approval an update acts on, or its chat when there is none.
+func (h *Handler) updateKey(update telego.Update) string {
+	switch {
+	case update.CallbackQuery != nil:
+		query := update.CallbackQuery
+		action, payload := parseCallback(query.Data)
+		if action == ActionDelegateTo {
+			_, payload, _ = strings.Cut(payload, ":")
+		}
+		if payload != "" && action != ActionDelete && !isSetupAction(action) {
+			return payload
+		}
+		if query.Message != nil {
+			return chatKey(query.Message.GetChat().ID)
+		}
+	case update.Message != nil:
+		if approval := h.promptFor(update.Message); approval != nil {
+			return approval.Request.CorrelationID
+		}
+		return chatKey(update.Message.Chat.ID)
+	case update.MyChatMember != nil:
+		return chatKey(update.MyChatMember.Chat.ID)
+	}
+	return ""
+}
+
+func chatKey(chatID int64) string {
+	return "chat:" + strconv.FormatInt(chatID, 10)
+}
+
+// workerFor spreads keys over n workers.
+func workerFor(key string, n int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(n))
+}
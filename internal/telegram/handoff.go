@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"time"
+)
+
+// drainer is implemented by update sources that can stop accepting updates without deregistering.
+type drainer interface {
+	Drain()
+}
+
+// presenceLoop keeps the instance announced while it receives updates.
+func (s *Service) presenceLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.InstanceTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.Active() {
+				s.register()
+			}
+		}
+	}
+}
+
+// register announces the instance so a stopping peer leaves the webhook in place.
+func (s *Service) register() {
+	if s.presence == nil {
+		return
+	}
+	if err := s.presence.Register(s.holder, s.cfg.InstanceTTL); err != nil {
+		s.log.Warn("Failed to announce instance", "error", err)
+	}
+}
+
+// deregister withdraws the instance announcement.
+func (s *Service) deregister() {
+	if s.presence == nil {
+		return
+	}
+	if err := s.presence.Deregister(s.holder); err != nil {
+		s.log.Warn("Failed to withdraw instance announcement", "error", err)
+	}
+}
+
+// handOff stops accepting webhook updates, processes the queued ones, and deletes the webhook
+// only when no other instance is left to receive updates.
+func (s *Service) handOff(ctx context.Context) error {
+	if source, ok := s.source.(drainer); ok {
+		source.Drain()
+	}
+	s.roleMu.Lock()
+	done := s.runDone
+	s.roleMu.Unlock()
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			s.log.Warn("Shutdown timeout reached before queued updates were processed")
+		}
+	}
+	s.deregister()
+	others, err := s.presence.Count()
+	if err != nil {
+		// Keeping the webhook is safer: Telegram retries until an instance answers.
+		s.log.Warn("Failed to count instances; keeping webhook", "error", err)
+		return nil
+	}
+	if others > 0 {
+		s.log.Info("Webhook handed off to running instances", "instances", others)
+		return nil
+	}
+	return s.source.Stop(ctx)
+}
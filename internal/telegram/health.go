@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+)
+
+// CheckTelegram verifies the bot token with getMe.
+func (s *Service) CheckTelegram(ctx context.Context) error {
+	if _, err := s.bot.GetMe(ctx); err != nil {
+		return fmt.Errorf("get bot: %w", err)
+	}
+	return nil
+}
+
+// CheckWebhook verifies that Telegram delivers updates to the configured webhook URL.
+// It passes in long polling mode and on a standby instance, which does not own the webhook.
+func (s *Service) CheckWebhook(ctx context.Context) error {
+	if !s.cfg.WebhookEnabled() || !s.Active() {
+		return nil
+	}
+	info, err := s.bot.GetWebhookInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("get webhook info: %w", err)
+	}
+	if info.URL != s.cfg.WebhookURL {
+		return fmt.Errorf("webhook is not registered")
+	}
+	return nil
+}
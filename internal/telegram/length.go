@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// maxMessageText is the Telegram limit of a message text in characters.
+const maxMessageText = 4096
+
+// MessageTooLongError reports an approval message that Telegram would reject, with the size of each request field
+// so callers know what to trim.
+type MessageTooLongError struct {
+	// Length is the rendered message length in characters, markup included.
+	Length int `json:"length"`
+	// Limit is the maximum message length.
+	Limit int `json:"limit"`
+	// Sections maps request fields to the characters they add to the message.
+	Sections map[string]int `json:"sections"`
+}
+
+func (e *MessageTooLongError) Error() string {
+	names := make([]string, 0, len(e.Sections))
+	for name := range e.Sections {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return e.Sections[names[i]] > e.Sections[names[j]] })
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, e.Sections[name]))
+	}
+	return fmt.Sprintf("approval message is %d characters, limit is %d (%s)", e.Length, e.Limit, strings.Join(parts, ", "))
+}
+
+// messageSections clear one request field each; the difference in rendered length is the size of that field.
+var messageSections = []struct {
+	name  string
+	clear func(req *approvals.Request)
+}{
+	{"approval_request", func(req *approvals.Request) { req.ApprovalRequest = "" }},
+	{"profile_template", func(req *approvals.Request) { req.Headline = "" }},
+	{"justification", func(req *approvals.Request) { req.Justification = "" }},
+	{"risk_assessment", func(req *approvals.Request) { req.RiskAssessment = "" }},
+	{"links_to_code", func(req *approvals.Request) { req.LinksToCode = nil }},
+	{"diff", func(req *approvals.Request) { req.Diff, req.DiffOmitted = "", 0 }},
+	{"arguments", func(req *approvals.Request) { req.Arguments = nil }},
+	{"task_summary", func(req *approvals.Request) { req.TaskSummary = "" }},
+	{"requested_by", func(req *approvals.Request) { req.RequestedBy = "" }},
+}
+
+// renderChecked renders the approval message and fails with MessageTooLongError when it exceeds the Telegram limit.
+func (s *Service) renderChecked(req approvals.Request) (string, error) {
+	text := s.renderMessage(req)
+	length := len([]rune(text))
+	if length <= maxMessageText {
+		return text, nil
+	}
+	sections := make(map[string]int, len(messageSections))
+	for _, section := range messageSections {
+		trimmed := req
+		section.clear(&trimmed)
+		if size := length - len([]rune(s.renderMessage(trimmed))); size > 0 {
+			sections[section.name] = size
+		}
+	}
+	return "", &MessageTooLongError{Length: length, Limit: maxMessageText, Sections: sections}
+}
@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DeepLink issues a one-time t.me link that opens the pending approval in a private chat with the bot.
+// It returns an empty link when deep links are disabled.
+func (s *Service) DeepLink(ctx context.Context, correlationID string) (string, error) {
+	if !s.cfg.DeepLinks {
+		return "", nil
+	}
+	name, err := s.botUsername(ctx)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.registry.IssueLink(correlationID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", name, url.QueryEscape(token)), nil
+}
+
+// botUsername returns the bot username, asking Telegram once.
+func (s *Service) botUsername(ctx context.Context) (string, error) {
+	s.botMu.Lock()
+	defer s.botMu.Unlock()
+	if s.botName != "" {
+		return s.botName, nil
+	}
+	me, err := s.bot.GetMe(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get bot: %w", err)
+	}
+	s.botName = me.Username
+	return s.botName, nil
+}
@@ -2,52 +2,194 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/audit"
+	"github.com/codex-k8s/telegram-approver/internal/bus"
+	"github.com/codex-k8s/telegram-approver/internal/calendar"
+	"github.com/codex-k8s/telegram-approver/internal/callback"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/chats"
 	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/codex-k8s/telegram-approver/internal/grafana"
+	"github.com/codex-k8s/telegram-approver/internal/httpclient"
 	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/journal"
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/mirror"
+	"github.com/codex-k8s/telegram-approver/internal/notify"
+	"github.com/codex-k8s/telegram-approver/internal/summary"
 	"github.com/codex-k8s/telegram-approver/internal/telegram/handlers"
 	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/throttle"
 	"github.com/codex-k8s/telegram-approver/internal/telegram/updates"
+	"github.com/codex-k8s/telegram-approver/internal/timerwheel"
+	"github.com/codex-k8s/telegram-approver/internal/tracing"
 	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const timeoutReason = "approval timeout"
+const (
+	timeoutReason = "approval timeout"
+	// unsentGrace is how long a restored approval may lack a message before it is considered lost;
+	// with a shared store another replica may still be sending it.
+	unsentGrace = time.Minute
+	// claimRetry is how long a timeout waits before retrying when the shared store could not confirm the claim.
+	claimRetry = 5 * time.Second
+	// wheelTick is the precision of approval timeouts and escalations.
+	wheelTick = 100 * time.Millisecond
+	// wheelSlots makes one wheel revolution span about seven minutes; longer deadlines wait extra rounds.
+	wheelSlots = 4096
+)
+
+var (
+	// ErrUnknownChat is returned when a chat is not configured for the bot.
+	ErrUnknownChat = errors.New("unknown chat")
+	// ErrSameChat is returned when an approval is transferred to its current chat.
+	ErrSameChat = errors.New("approval is already in this chat")
+)
 
 // Service manages Telegram bot lifecycle and approval requests.
 type Service struct {
-	bot      *telego.Bot
-	source   updates.Source
-	handler  *handlers.Handler
-	registry *approvals.Registry
-	log      *slog.Logger
-	messages map[string]i18n.Messages
-	lang     string
-	chatID   int64
+	bot       *telego.Bot
+	source    updates.Source
+	handler   *handlers.Handler
+	registry  *approvals.Registry
+	cache     *approvals.DecisionCache
+	metrics   *metrics.Metrics
+	mirror    *mirror.Notifier
+	waiters   *approvals.Waiters
+	history   *approvals.History
+	journal   *journal.Journal
+	audit     *audit.Log
+	callbacks *callback.Sender
+	bus       *bus.Publisher
+	summaries *summary.Summarizer
+	channels  map[string]channel.Channel
+	log       *slog.Logger
+	messages  *i18n.Live
+	lang      string
+	chats     *chats.Directory
+	cfg       config.Config
+	syncEvery time.Duration
+
+	topics    *topics
+	templates atomic.Pointer[messageTemplates]
+	calendar  *calendar.Calendar
+
+	timeouts    *timerwheel.Wheel
+	escalations *timerwheel.Wheel
+	reminders   *timerwheel.Wheel
+
+	lease        approvals.Lease
+	presence     approvals.Presence
+	holder       string
+	baseCtx      context.Context
+	standby      atomic.Bool
+	updatesOnly  bool
+	renewedAt    atomic.Int64
+	roleMu       sync.Mutex
+	activeCancel context.CancelFunc
+	runDone      chan struct{}
+	onRole       func(active bool)
+
+	botMu   sync.Mutex
+	botName string
+}
+
+// Cluster holds optional coordination between instances sharing a store.
+type Cluster struct {
+	// Lease enables standby mode.
+	Lease approvals.Lease
+	// UpdatesOnly limits the lease to receiving Telegram updates; instances without it keep serving approvals.
+	UpdatesOnly bool
+	// Presence enables webhook handoff between instances.
+	Presence approvals.Presence
 }
 
 // New creates a new Telegram service.
-func New(cfg config.Config, bundle i18n.Bundle, registry *approvals.Registry, log *slog.Logger) (*Service, error) {
-	bot, err := telego.NewBot(cfg.Token, telego.WithLogger(telegoLogger{log: log}))
+// Channels are additional approval channels selectable per request.
+func New(cfg config.Config, messages *i18n.Live, registry *approvals.Registry, cache *approvals.DecisionCache, history *approvals.History, metrics *metrics.Metrics, cluster Cluster, channels []channel.Channel, log *slog.Logger) (*Service, error) {
+	templates, err := newMessageTemplates(cfg.File.Templates.Markdown, cfg.File.Templates.HTML)
+	if err != nil {
+		return nil, err
+	}
+	workHours, err := calendar.New(cfg.CalendarOptions())
+	if err != nil {
+		return nil, err
+	}
+	telegramClient, err := httpclient.New(httpclient.Options{
+		ProxyURL:            cfg.TelegramProxyURL,
+		MaxIdleConns:        cfg.TelegramMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.TelegramMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.TelegramIdleConnTimeout,
+		TLSHandshakeTimeout: cfg.TelegramTLSHandshakeTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var events *journal.Journal
+	if cfg.JournalEnabled {
+		events = journal.New(cfg.JournalSize)
+	}
+	botOptions := []telego.BotOption{
+		telego.WithLogger(telegoLogger{log: log}),
+		telego.WithAPICaller(throttle.New(journal.Caller{Next: ta.HTTPCaller{Client: telegramClient}, Journal: events}, throttle.Options{
+			Rate:     cfg.TelegramSendRate,
+			ChatRate: cfg.TelegramChatRate,
+			Retries:  cfg.TelegramRetries,
+			Metrics:  metrics,
+		}, log)),
+	}
+	if cfg.APIURL != "" {
+		botOptions = append(botOptions, telego.WithAPIServer(cfg.APIURL))
+	}
+	bot, err := telego.NewBot(cfg.Token, botOptions...)
 	if err != nil {
 		return nil, err
 	}
 
 	var source updates.Source
 	if cfg.WebhookEnabled() {
-		source = updates.NewWebhook(bot, cfg.WebhookURL, cfg.WebhookSecret, log)
+		source = updates.NewWebhook(bot, updates.WebhookOptions{
+			URL:            cfg.WebhookURL,
+			Secret:         cfg.WebhookSecret,
+			Queue:          cfg.WebhookQueue,
+			QueueWait:      cfg.WebhookQueueWait,
+			MaxConnections: cfg.WebhookMaxConnections,
+			Metrics:        metrics,
+		}, log)
 	} else {
 		source = updates.NewLongPolling(bot, log)
 	}
 
 	var transcriber handlers.Transcriber
+	var intents handlers.IntentMatcher
+	var summaries *summary.Summarizer
 	if cfg.OpenAIAPIKey != "" {
-		transcriber = handlers.NewOpenAITranscriber(cfg.OpenAIAPIKey, cfg.STTModel, cfg.STTTimeout, log)
+		openAIClient, err := httpclient.New(httpclient.Options{ProxyURL: cfg.OpenAIProxyURL})
+		if err != nil {
+			return nil, err
+		}
+		transcriber = handlers.NewOpenAITranscriber(cfg.OpenAIAPIKey, cfg.STTModel, cfg.STTTimeout, openAIClient, log)
+		if model := strings.TrimSpace(cfg.VoiceIntentModel); model != "" {
+			intents = handlers.NewOpenAIIntentMatcher(cfg.OpenAIAPIKey, model, cfg.STTTimeout, openAIClient, log)
+		}
+		summaries = summary.New(cfg.OpenAIAPIKey, cfg.SummaryModel, cfg.SummaryTimeout, openAIClient, log)
 	}
 
 	sttLang := cfg.Lang
@@ -55,46 +197,219 @@ func New(cfg config.Config, bundle i18n.Bundle, registry *approvals.Registry, lo
 		sttLang = "en"
 	}
 
-	messages := map[string]i18n.Messages{
-		bundle.Lang: bundle.Messages,
+	trail, err := audit.New(audit.Options{Path: cfg.AuditFile, URL: cfg.AuditURL, Token: cfg.AuditToken, Log: log})
+	if err != nil {
+		return nil, err
 	}
-	if bundle.Lang != "en" {
-		if extra, err := i18n.Load("en"); err == nil {
-			messages[extra.Lang] = extra.Messages
-		}
+	callbacks, err := callback.NewSender(cfg, trail, metrics, log)
+	if err != nil {
+		return nil, err
 	}
-	if bundle.Lang != "ru" {
-		if extra, err := i18n.Load("ru"); err == nil {
-			messages[extra.Lang] = extra.Messages
-		}
+
+	annotator := grafana.New(grafana.Options{
+		URL:   cfg.GrafanaURL,
+		Token: cfg.GrafanaToken,
+		Tools: cfg.GrafanaTools,
+		Tags:  cfg.GrafanaTags,
+	}, log)
+
+	keyboard, err := config.ParseKeyboard(cfg.Keyboard)
+	if err != nil {
+		return nil, err
+	}
+
+	directory, err := chats.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mirrorNotifier := mirror.New(cfg.MirrorURL, log)
+	publisher, err := bus.New(cfg, metrics, log)
+	if err != nil {
+		return nil, err
+	}
+	waiters := approvals.NewWaiters()
+	byName := make(map[string]channel.Channel, len(channels))
+	for _, ch := range channels {
+		byName[ch.Name()] = ch
 	}
 
-	handler := handlers.NewHandler(bot, registry, messages, cfg.Lang, cfg.ChatID, sttLang, transcriber, log)
+	handler := handlers.NewHandler(handlers.Options{
+		Bot:               bot,
+		Registry:          registry,
+		Messages:          messages,
+		DefaultLang:       cfg.Lang,
+		Chats:             directory,
+		AdminUserIDs:      cfg.AdminUserIDs,
+		SuperAdminUserIDs: cfg.SuperAdminUserIDs,
+		AllowedUserIDs:    cfg.AllowedUserIDs,
+		STTLang:           sttLang,
+		Transcriber:       transcriber,
+		VoiceCommands:     cfg.VoiceCommands,
+		Intents:           intents,
+		Callbacks:         callbacks,
+		Cache:             cache,
+		History:           history,
+		Metrics:           metrics,
+		Annotator:         annotator,
+		Mirror:            mirrorNotifier,
+		Bus:               publisher,
+		Notifier:          notify.New(log),
+		Waiters:           waiters,
+		Journal:           events,
+		Audit:             trail,
+		Keyboard:          keyboard,
+		DelegateChats:     cfg.File.Chats,
+		Channels:          byName,
+		DenyReason:        cfg.DenyReason,
+		HTTPClient:        telegramClient,
+		OperationTimeout:  cfg.OperationTimeout,
+		Workers:           cfg.HandlerWorkers,
+		SnoozeDuration:    cfg.SnoozeDuration,
+		PinApprovals:      cfg.PinApprovals,
+		DeepLinks:         cfg.DeepLinks,
+		Log:               log,
+	})
 
-	return &Service{
-		bot:      bot,
-		source:   source,
-		handler:  handler,
-		registry: registry,
-		log:      log,
-		messages: messages,
-		lang:     cfg.Lang,
-		chatID:   cfg.ChatID,
-	}, nil
+	service := &Service{
+		bot:         bot,
+		source:      source,
+		handler:     handler,
+		registry:    registry,
+		cache:       cache,
+		metrics:     metrics,
+		mirror:      mirrorNotifier,
+		waiters:     waiters,
+		history:     history,
+		journal:     events,
+		audit:       trail,
+		callbacks:   callbacks,
+		bus:         publisher,
+		summaries:   summaries,
+		channels:    byName,
+		log:         log,
+		messages:    messages,
+		lang:        cfg.Lang,
+		chats:       directory,
+		cfg:         cfg,
+		syncEvery:   cfg.StoreSyncInterval,
+		timeouts:    timerwheel.New(wheelTick, wheelSlots),
+		escalations: timerwheel.New(wheelTick, wheelSlots),
+		reminders:   timerwheel.New(wheelTick, wheelSlots),
+		topics:      newTopics(cfg.Topics),
+		calendar:    workHours,
+		lease:       cluster.Lease,
+		presence:    cluster.Presence,
+		updatesOnly: cluster.Lease != nil && cluster.UpdatesOnly,
+		holder:      instanceID(),
+	}
+	service.templates.Store(&templates)
+	if cluster.Lease != nil {
+		service.standby.Store(true)
+	}
+	handler.OnDelegate(service.TransferApproval)
+	handler.OnSnooze(service.SnoozeApproval)
+	handler.OnRender(service.renderMessage)
+	return service, nil
 }
 
 // Start begins receiving Telegram updates.
+// In standby mode the instance only tails the shared store until it owns the lease.
 func (s *Service) Start(ctx context.Context) error {
-	if err := s.source.Start(ctx); err != nil {
-		return err
+	s.baseCtx = ctx
+	if s.lease == nil {
+		if err := s.activate(); err != nil {
+			return err
+		}
+	} else {
+		owned, err := s.lease.Acquire(s.holder, s.cfg.StandbyLeaseTTL)
+		if err != nil {
+			return err
+		}
+		if owned {
+			s.renewedAt.Store(time.Now().UnixNano())
+			if err := s.takeOver(); err != nil {
+				_ = s.lease.Release(s.holder)
+				return err
+			}
+		} else {
+			s.log.Info("Started in standby mode", "holder", s.holder)
+		}
+		go s.leaseLoop(ctx)
+	}
+	if s.presence != nil {
+		go s.presenceLoop(ctx)
+	}
+	if s.registry.Shared() {
+		go s.syncLoop(ctx)
+	}
+	if s.cfg.DigestInterval > 0 {
+		go s.digestLoop(ctx)
 	}
-	go s.handler.Run(ctx, s.source.Updates())
 	return nil
 }
 
-// Stop shuts down Telegram update processing.
+// syncLoop picks up approvals created by other replicas so their timeouts fire even if the creator is gone.
+func (s *Service) syncLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.syncEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			added, err := s.registry.Sync()
+			if err != nil {
+				s.log.Error("Failed to sync shared approvals", "error", err)
+				continue
+			}
+			if !s.Serving() {
+				continue
+			}
+			for _, approval := range added {
+				s.scheduleTimeout(approval.Request.CorrelationID, approval.Deadline)
+				s.scheduleEscalation(&approval)
+				s.scheduleReminder(&approval)
+			}
+		}
+	}
+}
+
+// Stop shuts down Telegram update processing and hands the lease over to a standby.
 func (s *Service) Stop(ctx context.Context) error {
-	return s.source.Stop(ctx)
+	defer func() {
+		s.timeouts.Stop()
+		s.escalations.Stop()
+		s.reminders.Stop()
+		// Finalizations still running may queue callbacks; let them finish before the queue closes.
+		if err := s.handler.Drain(ctx); err != nil {
+			s.log.Warn("Failed to finish approval operations", "error", err)
+		}
+		if err := s.callbacks.Close(ctx); err != nil {
+			s.log.Warn("Failed to deliver queued callbacks", "error", err)
+		}
+		if err := s.bus.Close(ctx); err != nil {
+			s.log.Warn("Failed to publish queued decisions", "error", err)
+		}
+		if err := s.audit.Close(ctx); err != nil {
+			s.log.Warn("Failed to close audit log", "error", err)
+		}
+	}()
+	if !s.Active() {
+		return nil
+	}
+	var err error
+	if s.presence != nil {
+		err = s.handOff(ctx)
+	} else {
+		err = s.source.Stop(ctx)
+	}
+	if s.lease != nil {
+		if releaseErr := s.lease.Release(s.holder); releaseErr != nil {
+			s.log.Warn("Failed to release standby lease", "error", releaseErr)
+		}
+	}
+	return err
 }
 
 // WebhookHandler returns the webhook HTTP handler if enabled.
@@ -104,77 +419,446 @@ func (s *Service) WebhookHandler() http.Handler {
 
 // SubmitApproval sends approval request to Telegram and returns immediately.
 func (s *Service) SubmitApproval(ctx context.Context, req approvals.Request, timeout time.Duration, timeoutMessage string) (approvals.Result, error) {
+	if !s.Serving() {
+		return approvals.Result{Decision: approvals.DecisionError, Reason: ErrStandby.Error()}, ErrStandby
+	}
 	if timeout <= 0 {
 		timeout = time.Hour
 	}
-	_, err := s.registry.Add(req)
+	if req.Fingerprint == "" {
+		req.Fingerprint = approvals.Fingerprint(req.Tool, req.Arguments)
+	}
+	if cached, ok := s.cache.Get(req); ok {
+		s.log.Info("Reusing cached decision", "correlation_id", req.CorrelationID, "cached_correlation_id", cached.CorrelationID, "decision", cached.Decision)
+		return approvals.Result{Decision: cached.Decision, Reason: cached.Reason, Cached: true}, nil
+	}
+	if s.cfg.Dedup {
+		primary, err := s.registry.Attach(req)
+		if err != nil {
+			return approvals.Result{Decision: approvals.DecisionError, Reason: err.Error()}, err
+		}
+		if primary != nil {
+			s.log.Info("Attached duplicate approval request", "correlation_id", req.CorrelationID, "attached_to", primary.Request.CorrelationID)
+			return approvals.Result{Decision: approvals.DecisionPending, Reason: "attached", AttachedTo: primary.Request.CorrelationID}, nil
+		}
+	}
+	if req.TimeoutMessage == "" {
+		req.TimeoutMessage = timeoutMessage
+	}
+	req.Sensitive = s.cfg.SensitiveTool(req.Tool)
+	// Timeouts only tick during working hours; without a calendar every hour counts.
+	now := time.Now()
+	deadline := s.calendar.Add(now, timeout)
+	if s.cfg.BusinessQueuedNote && !s.calendar.Open(now) {
+		req.QueuedUntil = s.calendar.NextOpen(now)
+	}
+	if req.Channel != "" && req.Channel != channel.Telegram {
+		ch, ok := s.channels[req.Channel]
+		if !ok {
+			return approvals.Result{Decision: approvals.DecisionError, Reason: "unknown channel"}, ErrUnknownChannel
+		}
+		return s.submitToChannel(ctx, ch, req, deadline)
+	}
+	chatID, ok := s.chats.Route(req.Target)
+	if !ok {
+		return approvals.Result{Decision: approvals.DecisionError, Reason: "unknown target"}, ErrUnknownChat
+	}
+	if !req.Sensitive {
+		// Arguments of sensitive tools are never sent to the model.
+		generated := s.summaries.Summarize(ctx, req, s.languageOf(req))
+		req.Summary, req.RiskHighlights = generated.Text, generated.Risks
+	}
+	s.fitDiff(&req)
+	parts, err := s.layoutMessage(&req)
+	if err != nil {
+		return approvals.Result{Decision: approvals.DecisionError, Reason: err.Error(), ReasonCode: approvals.ReasonMessageTooLong}, err
+	}
+	req.Escalation = s.resolveEscalation(req.Escalation, chatID, timeout)
+	attachments := req.Attachments
+	req.Attachments = withoutData(attachments)
+	approval, err := s.registry.Add(req, deadline)
 	if err != nil {
-		return approvals.Result{Decision: approvals.DecisionError, Reason: "approval already exists"}, nil
+		return approvals.Result{Decision: approvals.DecisionError, Reason: err.Error()}, err
 	}
+	s.audit.Requested(approval)
 
-	messageText := s.renderMessage(req)
-	keyboard := s.approvalKeyboard(req.CorrelationID, req.Lang)
+	keyboard := s.handler.ApprovalKeyboard(req)
 	parseMode := parseMode(req.Markup)
 
-	msg, err := s.bot.SendMessage(ctx, &telego.SendMessageParams{
-		ChatID:      tu.ID(s.chatID),
-		Text:        messageText,
-		ParseMode:   parseMode,
-		ReplyMarkup: keyboard,
-	})
+	sendCtx, span := tracing.Start(ctx, "telegram.send_message", trace.WithAttributes(attribute.Int64("telegram.chat_id", chatID)))
+	msg, leading, err := s.sendParts(sendCtx, chatID, req, parts, keyboard, parseMode)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+	}
+	span.End()
 	if err != nil {
 		s.log.Error("Failed to send telegram message", "error", err)
-		return approvals.Result{Decision: approvals.DecisionError, Reason: "failed to send telegram message"}, err
+		for _, part := range leading {
+			_ = s.handler.DeleteMessage(ctx, part)
+		}
+		result := approvals.Result{Decision: approvals.DecisionError, Reason: "failed to send telegram message", Failure: classifySendError(err)}
+		if failed, _, ok := s.registry.Resolve(req.CorrelationID); ok {
+			s.handler.FinalizeApproval(ctx, failed, result, "")
+		}
+		return result, s.sendError(err, result.Failure)
 	}
 
-	s.registry.SetMessage(req.CorrelationID, msg.MessageID, messageText)
-	s.scheduleTimeout(req.CorrelationID, timeout, timeoutMessage)
+	posted := approvals.MessageRef{ChatID: chatID, MessageID: msg.MessageID}
+	if s.registry.SetMessage(req.CorrelationID, posted, shared.ThreadID(msg), parts[len(parts)-1]) {
+		s.handler.PinMessage(ctx, posted)
+	}
+	s.sendAttachments(ctx, msg, req.CorrelationID, attachments)
+	s.metrics.Requested(req)
+	s.mirror.Submitted(approval)
+	s.scheduleTimeout(req.CorrelationID, deadline)
+	s.scheduleEscalation(approval)
 	return approvals.Result{Decision: approvals.DecisionPending, Reason: "queued"}, nil
 }
 
+// Preview describes how SubmitApproval would handle a request without posting or registering it.
+type Preview struct {
+	// Channel is the channel the message would be posted to.
+	Channel string `json:"channel"`
+	// ChatID is the Telegram chat the message would be posted to.
+	ChatID int64 `json:"chat_id,omitempty"`
+	// ParseMode is the Telegram parse mode of the message.
+	ParseMode string `json:"parse_mode,omitempty"`
+	// TextLength is the rendered message length in characters.
+	TextLength int `json:"text_length"`
+	// TooLong lists section sizes when the message exceeds the Telegram limit and /approve would reject it.
+	TooLong *MessageTooLongError `json:"message_too_long,omitempty"`
+	// Parts is the number of messages the text would be posted as.
+	Parts int `json:"parts,omitempty"`
+	// Sensitive reports that the tool arguments would be hidden.
+	Sensitive bool `json:"sensitive"`
+	// CachedDecision is the decision that would be reused from the decision cache instead of posting.
+	CachedDecision approvals.Decision `json:"cached_decision,omitempty"`
+	// Exists reports that an approval with the same correlation ID is already pending.
+	Exists bool `json:"exists"`
+	// EscalationChatID is the chat the approval would be escalated to.
+	EscalationChatID int64 `json:"escalation_chat_id,omitempty"`
+	// EscalateAfterSec is the delay before escalation in seconds.
+	EscalateAfterSec int `json:"escalate_after_sec,omitempty"`
+}
+
+// Preview resolves routing and renders the message of a request without side effects.
+func (s *Service) Preview(req approvals.Request, timeout time.Duration) (Preview, error) {
+	if timeout <= 0 {
+		timeout = time.Hour
+	}
+	if req.Fingerprint == "" {
+		req.Fingerprint = approvals.Fingerprint(req.Tool, req.Arguments)
+	}
+	req.Sensitive = s.cfg.SensitiveTool(req.Tool)
+	if req.Channel != "" && req.Channel != channel.Telegram {
+		if !s.HasChannel(req.Channel) {
+			return Preview{}, ErrUnknownChannel
+		}
+		preview := Preview{
+			Channel:   req.Channel,
+			Sensitive: req.Sensitive,
+			Exists:    s.registry.Get(req.CorrelationID) != nil,
+		}
+		if cached, ok := s.cache.Get(req); ok {
+			preview.CachedDecision = cached.Decision
+		}
+		return preview, nil
+	}
+	chatID, ok := s.chats.Route(req.Target)
+	if !ok {
+		return Preview{}, ErrUnknownChat
+	}
+	escalation := s.resolveEscalation(req.Escalation, chatID, timeout)
+	s.fitDiff(&req)
+	preview := Preview{
+		Channel:    channel.Telegram,
+		ChatID:     chatID,
+		ParseMode:  parseMode(req.Markup),
+		TextLength: len([]rune(s.renderMessage(req))),
+		Sensitive:  req.Sensitive,
+		Exists:     s.registry.Get(req.CorrelationID) != nil,
+	}
+	if cached, ok := s.cache.Get(req); ok {
+		preview.CachedDecision = cached.Decision
+	}
+	if parts, err := s.layoutMessage(&req); err != nil {
+		preview.TooLong, _ = err.(*MessageTooLongError)
+	} else {
+		preview.Parts = len(parts)
+	}
+	if !escalation.At.IsZero() {
+		preview.EscalationChatID = escalation.ChatID
+		preview.EscalateAfterSec = int(escalation.After / time.Second)
+	}
+	return preview, nil
+}
+
+// Restore reloads persisted approvals and reschedules their timeouts.
+// A standby instance only loads them; timers resume when it is promoted.
+// A follower of an updates-only lease resumes them at once.
+func (s *Service) Restore(ctx context.Context) error {
+	restored, err := s.registry.Restore()
+	if err != nil {
+		return err
+	}
+	if s.Serving() {
+		s.resume(ctx, restored)
+	}
+	if len(restored) > 0 {
+		s.log.Info("Restored pending approvals", "count", len(restored))
+	}
+	return nil
+}
+
+// Cleanup deletes messages of approvals resolved more than olderThan ago.
+func (s *Service) Cleanup(ctx context.Context, olderThan time.Duration) (handlers.CleanupResult, error) {
+	return s.handler.Cleanup(ctx, olderThan)
+}
+
+// Purge removes resolved approvals matching the filter and optionally their messages.
+func (s *Service) Purge(ctx context.Context, filter approvals.PurgeFilter, deleteMessages bool) handlers.PurgeResult {
+	return s.handler.Purge(ctx, filter, deleteMessages)
+}
+
+// RouteChat returns the chat a request target or team is posted to.
+func (s *Service) RouteChat(route string) (int64, bool) {
+	return s.chats.Route(route)
+}
+
+// RouteLang returns the default language of requests for a route: the language picked when its chat was onboarded,
+// or TG_APPROVER_LANG.
+func (s *Service) RouteLang(route string) string {
+	if chatID, ok := s.chats.Route(route); ok {
+		if lang := s.chats.Lang(chatID); lang != "" {
+			return lang
+		}
+	}
+	return s.cfg.Lang
+}
+
+// ResolveChat maps a configured chat name or numeric ID to a served chat ID.
+func (s *Service) ResolveChat(chat string) (int64, error) {
+	chat = strings.TrimSpace(chat)
+	if id, ok := s.chats.Lookup(chat); ok {
+		return id, nil
+	}
+	id, err := strconv.ParseInt(chat, 10, 64)
+	if err != nil || !s.chats.Known(id) {
+		return 0, ErrUnknownChat
+	}
+	return id, nil
+}
+
+// TransferApproval reposts a pending approval into another configured chat and removes the original message.
+// Correlation ID, deadline, and callback are preserved.
+func (s *Service) TransferApproval(ctx context.Context, correlationID string, chatID int64) error {
+	if !s.Serving() {
+		return ErrStandby
+	}
+	if !s.chats.Known(chatID) {
+		return ErrUnknownChat
+	}
+	approval := s.registry.Get(correlationID)
+	if approval == nil {
+		return s.notPending(correlationID)
+	}
+	if approval.Request.Channel != "" && approval.Request.Channel != channel.Telegram {
+		return ErrChannelApproval
+	}
+	if approval.ChatID == chatID {
+		return ErrSameChat
+	}
+	previous := approval.Message()
+	_ = s.handler.DeleteMessage(ctx, s.registry.ClearPrompt(correlationID))
+
+	// A topic set by the request belongs to the original chat.
+	req := approval.Request
+	req.MessageThreadID = 0
+	msg, err := s.sendApprovalMessage(ctx, chatID, req, &telego.SendMessageParams{
+		ChatID:              tu.ID(chatID),
+		Text:                approval.MessageText,
+		ParseMode:           parseMode(approval.Request.Markup),
+		ReplyMarkup:         s.handler.ApprovalKeyboard(approval.Request),
+		DisableNotification: s.handler.Muted(chatID),
+	})
+	if err != nil {
+		return s.sendError(err, classifySendError(err))
+	}
+	moved := approvals.MessageRef{ChatID: chatID, MessageID: msg.MessageID}
+	if !s.registry.SetMessage(correlationID, moved, shared.ThreadID(msg), approval.MessageText) {
+		// Resolved while reposting: drop the copy and keep the original message as-is.
+		_ = s.handler.DeleteMessage(ctx, moved)
+		return approvals.ErrAlreadyResolved
+	}
+	s.handler.PinMessage(ctx, moved)
+	if err := s.handler.DeleteMessage(ctx, previous); err != nil {
+		s.log.Warn("Failed to delete transferred approval message", "error", err, "correlation_id", correlationID)
+	}
+	s.log.Info("Approval transferred", "correlation_id", correlationID, "from_chat", previous.ChatID, "to_chat", chatID)
+	return nil
+}
+
+// CancelApproval withdraws a pending approval without notifying its callback.
+// The Telegram message is marked as cancelled and its timeout is stopped.
+func (s *Service) CancelApproval(ctx context.Context, correlationID string) error {
+	if !s.Serving() {
+		return ErrStandby
+	}
+	approval, prompt, ok := s.registry.Resolve(correlationID)
+	if !ok {
+		if duplicate, ok := s.registry.Detach(correlationID); ok {
+			s.handler.CancelDuplicate(duplicate)
+			s.log.Info("Duplicate approval request cancelled", "correlation_id", correlationID)
+			return nil
+		}
+		return s.notPending(correlationID)
+	}
+	s.stopTimeout(correlationID)
+	s.stopEscalation(correlationID)
+	_ = s.handler.DeleteMessage(ctx, prompt)
+	s.handler.CancelApproval(ctx, approval)
+	s.log.Info("Approval cancelled", "correlation_id", correlationID)
+	return nil
+}
+
+// CancelSession cancels pending approvals of an agent session accepted by allow and sends cancelled callbacks.
+// It returns the number of approvals that were cancelled.
+func (s *Service) CancelSession(ctx context.Context, sessionID, reason string, allow func(approvals.Request) bool) int {
+	cancelled := 0
+	for _, item := range s.registry.List() {
+		if item.Request.SessionID != sessionID || !allow(item.Request) {
+			continue
+		}
+		correlationID := item.Request.CorrelationID
+		approval, prompt, ok := s.registry.Resolve(correlationID)
+		if !ok {
+			continue
+		}
+		s.stopTimeout(correlationID)
+		s.stopEscalation(correlationID)
+		_ = s.handler.DeleteMessage(ctx, prompt)
+		s.handler.FinalizeApproval(ctx, approval, approvals.Result{
+			Decision: approvals.DecisionCancelled,
+			Reason:   reason,
+		}, "")
+		cancelled++
+	}
+	s.log.Info("Session approvals cancelled", "session_id", sessionID, "count", cancelled)
+	return cancelled
+}
+
+// ForceResolve resolves a pending approval on behalf of an administrator, e.g. when buttons are unusable.
+// The callback is sent as for a regular decision.
+func (s *Service) ForceResolve(ctx context.Context, correlationID string, result approvals.Result) error {
+	if !s.Serving() {
+		return ErrStandby
+	}
+	approval, prompt, ok := s.registry.Resolve(correlationID)
+	if !ok {
+		return s.notPending(correlationID)
+	}
+	s.stopTimeout(correlationID)
+	s.stopEscalation(correlationID)
+	_ = s.handler.DeleteMessage(ctx, prompt)
+	s.handler.FinalizeApproval(ctx, approval, result, "")
+	s.log.Warn("Approval force-resolved", "correlation_id", correlationID, "decision", result.Decision, "forced_by", result.ForcedBy)
+	return nil
+}
+
+// Audit returns the audit log, or nil when it is disabled.
+func (s *Service) Audit() *audit.Log {
+	return s.audit
+}
+
+// Journal returns the debug journal, or nil when it is disabled.
+func (s *Service) Journal() *journal.Journal {
+	return s.journal
+}
+
+// Wait returns a channel that receives the decision of an approval resolved by this instance
+// and a function that stops waiting.
+func (s *Service) Wait(correlationID string) (<-chan approvals.Result, func()) {
+	return s.waiters.Wait(correlationID)
+}
+
+// Approval returns a pending approval by correlation ID; for a duplicate request it returns the approval the
+// request is attached to.
+func (s *Service) Approval(correlationID string) *approvals.Approval {
+	if approval := s.registry.Get(correlationID); approval != nil {
+		return approval
+	}
+	return s.registry.AttachedTo(correlationID)
+}
+
+// Reload applies a reloaded config file: message templates, callback templates and headers, tenants, chats, routes,
+// tool profiles and severities. Pending approvals and their messages are kept; messages rendered afterwards use the
+// new settings. An invalid file is rejected and the current settings are kept.
+func (s *Service) Reload(file config.File) error {
+	templates, err := newMessageTemplates(file.Templates.Markdown, file.Templates.HTML)
+	if err != nil {
+		return err
+	}
+	if err := s.callbacks.Reload(file); err != nil {
+		return err
+	}
+	s.templates.Store(&templates)
+	s.handler.SetDelegateChats(file.Chats)
+	s.metrics.SetTenants(slices.Collect(maps.Keys(file.Tenants)))
+	s.cfg.SetFile(file)
+	return nil
+}
+
+// languageOf returns the language the message of req is rendered in.
+func (s *Service) languageOf(req approvals.Request) string {
+	for _, lang := range []string{req.Lang, s.lang} {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			return lang
+		}
+	}
+	return "en"
+}
+
+// renderMessage renders the approval message with the configured layout, falling back to the built-in one.
 func (s *Service) renderMessage(req approvals.Request) string {
 	msg := s.messagesFor(req.Lang)
-	switch strings.ToLower(strings.TrimSpace(req.Markup)) {
-	case "html":
-		return renderHTML(msg, req)
-	default:
-		return renderMarkdown(msg, req)
-	}
-}
-
-func (s *Service) approvalKeyboard(correlationID, lang string) *telego.InlineKeyboardMarkup {
-	msg := s.messagesFor(lang)
-	approve := handlers.CallbackData(handlers.ActionApprove, correlationID)
-	deny := handlers.CallbackData(handlers.ActionDeny, correlationID)
-	denyMsg := handlers.CallbackData(handlers.ActionDenyWithMessage, correlationID)
-	return tu.InlineKeyboard(
-		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton(msg.ApproveButton).WithCallbackData(approve),
-			tu.InlineKeyboardButton(msg.DenyButton).WithCallbackData(deny),
-		),
-		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton(msg.DenyWithMessageButton).WithCallbackData(denyMsg),
-		),
-	)
-}
-
-func (s *Service) scheduleTimeout(correlationID string, timeout time.Duration, timeoutMessage string) {
-	go func() {
-		timer := time.NewTimer(timeout)
-		defer timer.Stop()
-		<-timer.C
-		approval, promptID, ok := s.registry.Resolve(correlationID)
+	text, err := s.templates.Load().render(msg, req)
+	if err != nil {
+		s.log.Warn("Failed to render approval template", "error", err, "correlation_id", req.CorrelationID)
+		text, _ = builtinTemplates.render(msg, req)
+	}
+	return text
+}
+
+func (s *Service) scheduleTimeout(correlationID string, deadline time.Time) {
+	s.timeouts.Schedule(correlationID, deadline, func() {
+		s.stopEscalation(correlationID)
+		ctx, done := s.handler.Operation(context.Background())
+		defer done()
+		approval, prompt, ok := s.registry.Resolve(correlationID)
 		if !ok {
+			if s.registry.Get(correlationID) != nil {
+				// The shared store could not confirm the claim; try again instead of leaving it pending forever.
+				s.scheduleTimeout(correlationID, time.Now().Add(claimRetry))
+			}
 			return
 		}
-		if promptID > 0 {
-			_ = s.handler.DeleteMessage(context.Background(), promptID)
+		_ = s.handler.DeleteMessage(ctx, prompt)
+		decision := approval.Request.OnTimeout
+		if decision == "" {
+			decision = approvals.DecisionError
 		}
-		s.handler.FinalizeApproval(context.Background(), approval, approvals.Result{
-			Decision: approvals.DecisionError,
-			Reason:   timeoutReason,
-		}, timeoutMessage)
-	}()
+		s.handler.FinalizeApproval(ctx, approval, approvals.Result{
+			Decision:   decision,
+			Reason:     timeoutReason,
+			ReasonCode: approvals.ReasonTimeout,
+		}, approval.Request.TimeoutMessage)
+	})
+}
+
+// stopTimeout cancels the pending timeout of an approval, if any.
+func (s *Service) stopTimeout(correlationID string) {
+	s.timeouts.Cancel(correlationID)
 }
 
 func (s *Service) messagesFor(lang string) i18n.Messages {
@@ -190,37 +874,32 @@ func parseMode(markup string) string {
 	}
 }
 
-func renderMarkdown(msg i18n.Messages, req approvals.Request) string {
-	return renderApproval(msg, req, markdownApprovalWriter{})
-}
-
-func renderHTML(msg i18n.Messages, req approvals.Request) string {
-	return renderApproval(msg, req, htmlApprovalWriter{})
-}
-
-func renderApproval(msg i18n.Messages, req approvals.Request, writer approvalMessageWriter) string {
-	labels := approvalLabelsFor(msg)
-	builder := &strings.Builder{}
-	writer.WriteTitle(builder, msg.ApprovalTitle)
-
-	writer.WriteSectionHeader(builder, labels.ContextTitle)
-	if strings.TrimSpace(req.ApprovalRequest) != "" {
-		writer.WritePlain(builder, req.ApprovalRequest, true)
-	}
+// renderSensitive renders only the justification, tool, and fingerprint of a sensitive tool request.
+func renderSensitive(labels approvalLabels, req approvals.Request, writer approvalMessageWriter, builder *strings.Builder) {
 	if strings.TrimSpace(req.Justification) != "" {
+		writer.WriteSectionHeader(builder, labels.ContextTitle)
 		writer.WriteLabelValue(builder, labels.JustificationLabel, req.Justification, true)
 	}
-	if len(req.LinksToCode) > 0 {
-		writer.WriteLinks(builder, labels.LinksLabel, req.LinksToCode)
+	writer.WriteSectionHeader(builder, labels.ActionTitle)
+	writer.WriteCodeValue(builder, labels.ToolLabel, req.Tool, false)
+	writer.WriteCodeValue(builder, labels.CorrelationLabel, req.CorrelationID, false)
+	writer.WriteCodeValue(builder, labels.FingerprintLabel, req.Fingerprint, req.RequiredApprovals <= 1)
+	if req.RequiredApprovals > 1 {
+		writer.WriteLabelValue(builder, labels.QuorumLabel, strconv.Itoa(req.RequiredApprovals), true)
 	}
-	if strings.TrimSpace(req.RiskAssessment) != "" {
-		writer.WriteSectionHeader(builder, labels.RisksTitle)
-		writer.WritePlain(builder, req.RiskAssessment, true)
+	writer.WritePlain(builder, labels.SensitiveNote, false)
+}
+
+// sessionHeader renders a compact "Agent session 42 · Fixing login bug" line.
+func sessionHeader(labels approvalLabels, req approvals.Request) string {
+	parts := make([]string, 0, 2)
+	if session := strings.TrimSpace(req.SessionID); session != "" {
+		parts = append(parts, labels.SessionLabel+" "+session)
 	}
-	writer.WriteSectionHeader(builder, labels.ActionTitle)
-	writer.WriteCodeValue(builder, msg.ApprovalTool, req.Tool, false)
-	writer.WriteCodeValue(builder, msg.ApprovalCorrelation, req.CorrelationID, true)
-	return builder.String()
+	if summary := strings.TrimSpace(req.TaskSummary); summary != "" {
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, " · ")
 }
 
 type approvalMessageWriter interface {
@@ -230,6 +909,7 @@ type approvalMessageWriter interface {
 	WriteLabelValue(builder *strings.Builder, label, value string, addEmptyLine bool)
 	WriteCodeValue(builder *strings.Builder, label, value string, addEmptyLine bool)
 	WriteLinks(builder *strings.Builder, label string, links []approvals.Link)
+	WriteCodeBlock(builder *strings.Builder, language, value string)
 }
 
 type markdownApprovalWriter struct{}
@@ -284,6 +964,14 @@ func (markdownApprovalWriter) WriteLinks(builder *strings.Builder, label string,
 	builder.WriteString("\n")
 }
 
+func (markdownApprovalWriter) WriteCodeBlock(builder *strings.Builder, language, value string) {
+	builder.WriteString("```")
+	builder.WriteString(language)
+	builder.WriteString("\n")
+	builder.WriteString(shared.EscapeMarkdownV2Code(value))
+	builder.WriteString("\n```\n")
+}
+
 type htmlApprovalWriter struct{}
 
 func (htmlApprovalWriter) WriteTitle(builder *strings.Builder, title string) {
@@ -336,6 +1024,20 @@ func (htmlApprovalWriter) WriteLinks(builder *strings.Builder, label string, lin
 	builder.WriteString("<br>")
 }
 
+func (htmlApprovalWriter) WriteCodeBlock(builder *strings.Builder, language, value string) {
+	if language == "" {
+		builder.WriteString("<pre>")
+		builder.WriteString(shared.EscapeHTML(value))
+		builder.WriteString("</pre>")
+		return
+	}
+	builder.WriteString("<pre><code class=\"language-")
+	builder.WriteString(shared.EscapeHTML(language))
+	builder.WriteString("\">")
+	builder.WriteString(shared.EscapeHTML(value))
+	builder.WriteString("</code></pre>")
+}
+
 func appendOptionalLineBreak(builder *strings.Builder, lineBreak string, enabled bool) {
 	if enabled {
 		builder.WriteString(lineBreak)
@@ -344,19 +1046,43 @@ func appendOptionalLineBreak(builder *strings.Builder, lineBreak string, enabled
 
 type approvalLabels struct {
 	ContextTitle       string
+	SummaryTitle       string
+	ToolLabel          string
+	CorrelationLabel   string
 	ActionTitle        string
 	RisksTitle         string
+	ParamsTitle        string
+	DiffTitle          string
 	JustificationLabel string
 	LinksLabel         string
+	AttachmentsLabel   string
+	RequestedByLabel   string
+	SeverityLabel      string
+	SessionLabel       string
+	QuorumLabel        string
+	FingerprintLabel   string
+	SensitiveNote      string
 }
 
 func approvalLabelsFor(msg i18n.Messages) approvalLabels {
 	return approvalLabels{
 		ContextTitle:       fallbackText(msg.SectionContext, "Context"),
+		SummaryTitle:       fallbackText(msg.SectionSummary, "Summary"),
+		ToolLabel:          msg.ApprovalTool,
+		CorrelationLabel:   msg.ApprovalCorrelation,
 		ActionTitle:        fallbackText(msg.SectionAction, "Action"),
 		RisksTitle:         fallbackText(msg.SectionRisks, "Risks"),
+		ParamsTitle:        fallbackText(msg.SectionParams, "Parameters"),
+		DiffTitle:          fallbackText(msg.SectionDiff, "Changes"),
 		JustificationLabel: fallbackText(msg.JustificationLabel, "Justification"),
 		LinksLabel:         fallbackText(msg.LinksLabel, "Links"),
+		AttachmentsLabel:   fallbackText(msg.AttachmentsLabel, "Attachments"),
+		RequestedByLabel:   fallbackText(msg.RequestedByLabel, "Requested by"),
+		SeverityLabel:      fallbackText(msg.SeverityLabel, "Severity"),
+		SessionLabel:       fallbackText(msg.SessionLabel, "Agent session"),
+		QuorumLabel:        fallbackText(msg.QuorumLabel, "Required approvals"),
+		FingerprintLabel:   fallbackText(msg.FingerprintLabel, "Fingerprint"),
+		SensitiveNote:      fallbackText(msg.SensitiveNote, "Arguments are hidden for this tool."),
 	}
 }
 
@@ -0,0 +1,27 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MessageLink returns a t.me link to a message in a supergroup or channel; other chats have no public link.
+func MessageLink(chatID int64, messageID int) string {
+	id := strconv.FormatInt(chatID, 10)
+	internalID, ok := strings.CutPrefix(id, "-100")
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/c/%s/%d", internalID, messageID)
+}
+
+// FormatAge renders a duration rounded to minutes, e.g. "2h15m".
+func FormatAge(age time.Duration) string {
+	age = age.Round(time.Minute)
+	if age < time.Minute {
+		return "<1m"
+	}
+	return strings.TrimSuffix(age.String(), "0s")
+}
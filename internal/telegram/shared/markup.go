@@ -14,6 +14,11 @@ func EscapeHTML(value string) string {
 	return replacer.Replace(value)
 }
 
+// EscapeMarkdown escapes text for legacy Telegram Markdown mode.
+func EscapeMarkdown(value string) string {
+	return escapeWithSet(value, "_*`[")
+}
+
 // EscapeMarkdownV2 escapes text for Telegram MarkdownV2 mode.
 func EscapeMarkdownV2(value string) string {
 	return escapeWithSet(value, "_*[]()~`>#+-=|{}.!\\")
@@ -0,0 +1,11 @@
+package shared
+
+import "github.com/mymmrac/telego"
+
+// ThreadID returns the forum topic of the message; zero for messages outside topics and inaccessible messages.
+func ThreadID(message *telego.Message) int {
+	if message == nil || !message.IsTopicMessage {
+		return 0
+	}
+	return message.MessageThreadID
+}
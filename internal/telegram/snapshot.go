@@ -0,0 +1,26 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// ExportSnapshot returns a snapshot of pending approvals, including deny prompt state.
+func (s *Service) ExportSnapshot() approvals.Snapshot {
+	return s.registry.Export()
+}
+
+// ImportSnapshot adds approvals from a snapshot taken on another instance and resumes their timers. Approval
+// messages keep working only when both instances use the same bot.
+func (s *Service) ImportSnapshot(ctx context.Context, snapshot approvals.Snapshot) (approvals.ImportResult, error) {
+	if !s.Serving() {
+		return approvals.ImportResult{}, ErrStandby
+	}
+	result, err := s.registry.Import(snapshot)
+	s.resume(ctx, result.Imported)
+	if len(result.Imported) > 0 || len(result.Skipped) > 0 {
+		s.log.Info("Approval snapshot imported", "imported", len(result.Imported), "skipped", len(result.Skipped))
+	}
+	return result, err
+}
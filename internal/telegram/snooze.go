@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// SnoozeApproval postpones the timeout of a pending approval by the configured snooze duration and reposts its
+// message when the snooze ends, so it shows up at the bottom of the chat again.
+func (s *Service) SnoozeApproval(ctx context.Context, correlationID string) error {
+	if !s.Serving() {
+		return ErrStandby
+	}
+	snooze := s.cfg.SnoozeDuration
+	approval, err := s.registry.Snooze(correlationID, func(deadline time.Time) time.Time {
+		return s.calendar.Add(deadline, snooze)
+	}, time.Now().Add(snooze), s.cfg.SnoozeLimit)
+	if err != nil {
+		return err
+	}
+	s.scheduleTimeout(correlationID, approval.Deadline)
+	s.scheduleReminder(&approval)
+	return nil
+}
+
+// scheduleReminder arms the reminder of a snoozed approval.
+func (s *Service) scheduleReminder(approval *approvals.Approval) {
+	if approval.RemindAt.IsZero() {
+		return
+	}
+	correlationID := approval.Request.CorrelationID
+	s.reminders.Schedule(correlationID, approval.RemindAt, func() {
+		ctx, done := s.handler.Operation(context.Background())
+		defer done()
+		s.remind(ctx, correlationID)
+	})
+}
+
+// remind reposts a snoozed approval with working buttons in its chat and topic and removes the previous message.
+func (s *Service) remind(ctx context.Context, correlationID string) {
+	approval := s.registry.Get(correlationID)
+	if approval == nil || approval.RemindAt.IsZero() || !approval.Posted() {
+		return
+	}
+	previous := approval.Message()
+	msg, err := s.bot.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID:              tu.ID(previous.ChatID),
+		MessageThreadID:     approval.ThreadID,
+		Text:                approval.MessageText,
+		ParseMode:           parseMode(approval.Request.Markup),
+		ReplyMarkup:         s.handler.ApprovalKeyboard(approval.Request),
+		DisableNotification: s.handler.Muted(previous.ChatID),
+	})
+	if err != nil {
+		s.log.Error("Failed to repost snoozed approval", "error", err, "correlation_id", correlationID)
+		return
+	}
+	reposted := approvals.MessageRef{ChatID: previous.ChatID, MessageID: msg.MessageID}
+	if !s.registry.Reminded(correlationID) || !s.registry.SetMessage(correlationID, reposted, approval.ThreadID, approval.MessageText) {
+		// Resolved while reposting.
+		_ = s.handler.DeleteMessage(ctx, reposted)
+		return
+	}
+	s.handler.PinMessage(ctx, reposted)
+	if err := s.handler.DeleteMessage(ctx, previous); err != nil {
+		s.log.Warn("Failed to delete snoozed approval message", "error", err, "correlation_id", correlationID)
+	}
+	s.log.Info("Snoozed approval reposted", "correlation_id", correlationID, "chat_id", previous.ChatID)
+}
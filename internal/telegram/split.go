@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	// maxMessageParts bounds the thread a long message is split into.
+	maxMessageParts = 10
+	// noteReserve keeps room in the last part for the votes and decision notes appended on update.
+	noteReserve = 512
+	// maxRequestPreview bounds the approval request text left in the message when the full text is attached.
+	maxRequestPreview = 500
+	// minCut is the smallest piece of a long line worth leaving at the end of a part.
+	minCut = 200
+	// requestTextName is the name of the document holding the full approval request text.
+	requestTextName = "approval_request.txt"
+)
+
+// layoutMessage renders the approval message and applies the configured handling of messages over the Telegram
+// limit. It returns the parts to post in order; the keyboard goes on the last one. Requests that still do not fit
+// fail with MessageTooLongError.
+func (s *Service) layoutMessage(req *approvals.Request) ([]string, error) {
+	text := s.renderMessage(*req)
+	if len([]rune(text)) > maxMessageText && (req.Summary != "" || len(req.RiskHighlights) > 0) {
+		// The generated summary goes first; callers cannot shorten it.
+		req.Summary, req.RiskHighlights = "", nil
+		text = s.renderMessage(*req)
+	}
+	if len([]rune(text)) <= maxMessageText {
+		return []string{text}, nil
+	}
+	switch s.cfg.LongMessages {
+	case config.LongMessagesSplit:
+		html := strings.EqualFold(strings.TrimSpace(req.Markup), "html")
+		if parts := splitMessage(text, html, maxMessageText-noteReserve); len(parts) <= maxMessageParts {
+			req.Split = true
+			return parts, nil
+		}
+	case config.LongMessagesAttach:
+		attachRequestText(req)
+	}
+	text, err := s.renderChecked(*req)
+	if err != nil {
+		return nil, err
+	}
+	return []string{text}, nil
+}
+
+// sendParts posts the message parts as a thread, each replying to the previous one, with the keyboard on the last.
+// It returns the last message and the leading ones posted before a failure, so the caller can remove them.
+func (s *Service) sendParts(ctx context.Context, chatID int64, req approvals.Request, parts []string, keyboard *telego.InlineKeyboardMarkup, mode string) (*telego.Message, []approvals.MessageRef, error) {
+	var leading []approvals.MessageRef
+	var msg *telego.Message
+	for i, part := range parts {
+		params := &telego.SendMessageParams{
+			ChatID:              tu.ID(chatID),
+			Text:                part,
+			ParseMode:           mode,
+			DisableNotification: s.handler.Muted(chatID),
+		}
+		if i == len(parts)-1 {
+			params.ReplyMarkup = keyboard
+		}
+		if msg != nil {
+			params.ReplyParameters = &telego.ReplyParameters{MessageID: msg.MessageID, AllowSendingWithoutReply: true}
+			params.DisableNotification = true
+			leading = append(leading, approvals.MessageRef{ChatID: chatID, MessageID: msg.MessageID})
+		}
+		sent, err := s.sendApprovalMessage(ctx, chatID, req, params)
+		if err != nil {
+			return nil, leading, err
+		}
+		msg = sent
+	}
+	return msg, leading, nil
+}
+
+// attachRequestText moves a long approval request text into a document and leaves its beginning in the message.
+func attachRequestText(req *approvals.Request) {
+	full := strings.TrimSpace(req.ApprovalRequest)
+	if strings.TrimSpace(req.Headline) != "" || len([]rune(full)) <= maxRequestPreview {
+		return
+	}
+	head, _ := cutText(full, maxRequestPreview, false)
+	req.ApprovalRequest = strings.TrimRightFunc(head, unicode.IsSpace) + "…"
+	req.Attachments = append(req.Attachments, approvals.Attachment{Name: requestTextName, Data: []byte(full + "\n")})
+}
+
+// splitMessage splits rendered text into parts of at most limit characters, preferring line breaks.
+// A code block cut between parts is closed at the end of one part and reopened at the start of the next.
+func splitMessage(text string, html bool, limit int) []string {
+	var parts []string
+	builder := &strings.Builder{}
+	size := 0
+	block := codeBlock{html: html}
+	flush := func() {
+		if block.open != "" {
+			if !html && !strings.HasSuffix(builder.String(), "\n") {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(block.close())
+		}
+		parts = append(parts, builder.String())
+		builder.Reset()
+		size = 0
+		if block.open != "" {
+			builder.WriteString(block.open)
+			size = len([]rune(block.open))
+		}
+	}
+	for _, line := range splitLines(text, html) {
+		for line != "" {
+			reserve := 0
+			if block.open != "" {
+				reserve = len([]rune(block.close())) + 1
+			}
+			length := len([]rune(line))
+			if size+length+reserve <= limit {
+				builder.WriteString(line)
+				size += length
+				block.track(line)
+				break
+			}
+			// A line that fits into the next part starts it; a longer one fills this part first.
+			fresh := len([]rune(block.open))
+			if size > fresh && (fresh+length+reserve <= limit || limit-size-reserve < minCut) {
+				flush()
+				continue
+			}
+			head, rest := cutText(line, limit-size-reserve, html)
+			builder.WriteString(head)
+			block.track(head)
+			flush()
+			line = rest
+		}
+	}
+	if builder.Len() > 0 {
+		parts = append(parts, builder.String())
+	}
+	return parts
+}
+
+// splitLines splits text after each line break, keeping the breaks. HTML messages break on <br> as well.
+func splitLines(text string, html bool) []string {
+	var lines []string
+	for text != "" {
+		end := strings.IndexByte(text, '\n') + 1
+		if html {
+			if br := strings.Index(text, "<br>"); br >= 0 && (end == 0 || br+len("<br>") < end) {
+				end = br + len("<br>")
+			}
+		}
+		if end <= 0 {
+			end = len(text)
+		}
+		lines = append(lines, text[:end])
+		text = text[end:]
+	}
+	return lines
+}
+
+// cutText cuts text to at most limit characters, preferring the last space in the second half. The cut never falls
+// inside a MarkdownV2 escape or an HTML tag or entity.
+func cutText(text string, limit int, html bool) (string, string) {
+	runes := []rune(text)
+	if limit < 1 {
+		limit = 1
+	}
+	if len(runes) <= limit {
+		return text, ""
+	}
+	cut := limit
+	for i := limit; i > limit/2; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	head := string(runes[:cut])
+	if html {
+		if open := strings.LastIndexAny(head, "<&"); open >= 0 && !strings.ContainsAny(head[open:], ">;") {
+			cut = len([]rune(head[:open]))
+		}
+	} else {
+		slashes := 0
+		for i := cut - 1; i >= 0 && runes[i] == '\\'; i-- {
+			slashes++
+		}
+		if slashes%2 == 1 {
+			cut--
+		}
+	}
+	if cut < 1 {
+		cut = 1
+	}
+	return string(runes[:cut]), string(runes[cut:])
+}
+
+// codeBlock tracks whether the text written so far ends inside a code block and how to reopen it.
+type codeBlock struct {
+	html bool
+	// open is the markup that opened the current code block; empty outside code blocks.
+	open string
+}
+
+// track updates the state after line is written.
+func (b *codeBlock) track(line string) {
+	if !b.html {
+		if strings.HasPrefix(line, "```") {
+			if b.open == "" {
+				b.open = line
+			} else {
+				b.open = ""
+			}
+		}
+		return
+	}
+	start, end := strings.LastIndex(line, "<pre>"), strings.LastIndex(line, "</pre>")
+	switch {
+	case start > end:
+		b.open = "<pre>"
+		if rest := line[start+len("<pre>"):]; strings.HasPrefix(rest, "<code") {
+			if closing := strings.IndexByte(rest, '>'); closing >= 0 {
+				b.open += rest[:closing+1]
+			}
+		}
+	case end >= 0:
+		b.open = ""
+	}
+}
+
+// close returns the markup that ends the current code block.
+func (b *codeBlock) close() string {
+	if !b.html {
+		return "```\n"
+	}
+	if strings.Contains(b.open, "<code") {
+		return "</code></pre>"
+	}
+	return "</pre>"
+}
@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+)
+
+// ErrStandby is returned when a standby instance is asked to change approvals.
+var ErrStandby = errors.New("instance is in standby")
+
+// Active reports whether the instance receives Telegram updates.
+func (s *Service) Active() bool {
+	return !s.standby.Load()
+}
+
+// Serving reports whether the instance accepts approvals and runs their timers.
+// Followers of an updates-only lease serve everything except Telegram updates.
+func (s *Service) Serving() bool {
+	return s.updatesOnly || s.Active()
+}
+
+// OnRoleChange registers a function called whenever the instance becomes active or standby.
+func (s *Service) OnRoleChange(fn func(active bool)) {
+	s.roleMu.Lock()
+	defer s.roleMu.Unlock()
+	s.onRole = fn
+}
+
+// Promote takes the standby lease away from the current owner and activates this instance.
+// It reports false when the instance was already active.
+func (s *Service) Promote(context.Context) (bool, error) {
+	if s.lease == nil || !s.standby.Load() {
+		return false, nil
+	}
+	if err := s.lease.Force(s.holder, s.cfg.StandbyLeaseTTL); err != nil {
+		return false, fmt.Errorf("take over standby lease: %w", err)
+	}
+	s.renewedAt.Store(time.Now().UnixNano())
+	if err := s.takeOver(); err != nil {
+		_ = s.lease.Release(s.holder)
+		return false, err
+	}
+	return true, nil
+}
+
+// leaseLoop renews the lease while active and takes it over when the active instance stops renewing it.
+func (s *Service) leaseLoop(ctx context.Context) {
+	ttl := s.cfg.StandbyLeaseTTL
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			owned, err := s.lease.Acquire(s.holder, ttl)
+			if err != nil {
+				s.log.Error("Failed to renew standby lease", "error", err)
+				if s.Active() && time.Since(time.Unix(0, s.renewedAt.Load())) > ttl {
+					// A standby may already own the expired lease; stop acting before both instances do.
+					s.demote()
+				}
+				continue
+			}
+			if owned {
+				s.renewedAt.Store(time.Now().UnixNano())
+			}
+			switch {
+			case owned && !s.Active():
+				if err := s.takeOver(); err != nil {
+					s.log.Error("Failed to activate standby instance", "error", err)
+					_ = s.lease.Release(s.holder)
+				}
+			case !owned && s.Active():
+				s.log.Warn("Standby lease taken over by another instance")
+				s.demote()
+			}
+		}
+	}
+}
+
+// takeOver activates a standby instance and resumes timers of approvals it has been tailing.
+func (s *Service) takeOver() error {
+	if _, err := s.registry.Sync(); err != nil {
+		s.log.Warn("Failed to sync approvals before takeover", "error", err)
+	}
+	if err := s.activate(); err != nil {
+		return err
+	}
+	pending := s.registry.List()
+	s.resume(s.baseCtx, pending)
+	s.log.Info("Standby instance promoted to active", "holder", s.holder, "pending", len(pending))
+	return nil
+}
+
+// activate starts receiving updates under a context that demote cancels.
+func (s *Service) activate() error {
+	s.roleMu.Lock()
+	defer s.roleMu.Unlock()
+	if s.activeCancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(s.baseCtx)
+	if err := s.source.Start(ctx); err != nil {
+		cancel()
+		return err
+	}
+	s.activeCancel = cancel
+	done := make(chan struct{})
+	s.runDone = done
+	go func() {
+		defer close(done)
+		s.handler.Run(ctx, s.source.Updates())
+	}()
+	s.standby.Store(false)
+	s.register()
+	if s.onRole != nil {
+		s.onRole(true)
+	}
+	return nil
+}
+
+// demote stops update processing and timers without touching the webhook the new active instance owns.
+// Followers of an updates-only lease keep their timers.
+func (s *Service) demote() {
+	s.roleMu.Lock()
+	defer s.roleMu.Unlock()
+	if s.activeCancel == nil {
+		return
+	}
+	if source, ok := s.source.(drainer); ok {
+		source.Drain()
+	}
+	s.activeCancel()
+	s.activeCancel = nil
+	s.standby.Store(true)
+	s.deregister()
+	if !s.updatesOnly {
+		s.stopTimers()
+	}
+	if s.onRole != nil {
+		s.onRole(false)
+	}
+	s.log.Warn("Instance switched to standby", "holder", s.holder)
+}
+
+// stopTimers cancels all timeouts, escalations and reminders scheduled by this instance.
+func (s *Service) stopTimers() {
+	s.timeouts.Clear()
+	s.escalations.Clear()
+	s.reminders.Clear()
+}
+
+// resume schedules timeouts, escalations and reminders of pending approvals and fails those whose message was never sent.
+func (s *Service) resume(ctx context.Context, pending []approvals.Approval) {
+	for _, approval := range pending {
+		correlationID := approval.Request.CorrelationID
+		if !approval.Posted() && time.Since(approval.CreatedAt) > unsentGrace {
+			// The process stopped before the message was sent; nobody can answer it.
+			if unsent, _, ok := s.registry.Resolve(correlationID); ok {
+				s.handler.FinalizeApproval(ctx, unsent, approvals.Result{
+					Decision: approvals.DecisionError,
+					Reason:   "failed to send telegram message",
+				}, "")
+			}
+			continue
+		}
+		s.scheduleTimeout(correlationID, approval.Deadline)
+		s.scheduleEscalation(&approval)
+		s.scheduleReminder(&approval)
+	}
+}
+
+// instanceID identifies this process in the standby lease and instance presence.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "telegram-approver"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
@@ -0,0 +1,266 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/channel"
+	"github.com/codex-k8s/telegram-approver/internal/i18n"
+	"github.com/codex-k8s/telegram-approver/internal/telegram/shared"
+)
+
+// defaultTemplate is the built-in approval layout. Helpers escape values for the message markup,
+// so the same layout serves Markdown and HTML.
+const defaultTemplate = `{{ title .Title }}
+{{- with .Severity }}{{ field $.Labels.SeverityLabel . }}{{ end }}
+{{- with .Session }}{{ plain . }}{{ end }}
+{{- if present .Request.RequestedBy }}{{ field .Labels.RequestedByLabel .Request.RequestedBy }}{{ end }}
+{{- with .ExecuteAfter }}{{ plain . }}{{ end }}
+{{- with .Queued }}{{ plain . }}{{ end }}
+{{- with .Request.Summary }}{{ section $.Labels.SummaryTitle }}{{ plain . }}{{ end }}
+{{- with .Highlights }}{{ plain . }}{{ end }}
+{{- section .Labels.ContextTitle }}
+{{- if present .Text }}{{ plain .Text }}{{ end }}
+{{- if present .Request.Justification }}{{ field .Labels.JustificationLabel .Request.Justification }}{{ end }}
+{{- with .Request.LinksToCode }}{{ links $.Labels.LinksLabel . }}{{ end }}
+{{- with .Attachments }}{{ field $.Labels.AttachmentsLabel . }}{{ end }}
+{{- if present .Request.RiskAssessment }}{{ section .Labels.RisksTitle }}{{ plain .Request.RiskAssessment }}{{ end }}
+{{- section .Labels.ActionTitle }}
+{{- codeline .Labels.ToolLabel .Request.Tool }}
+{{- if gt .Quorum 1 }}{{ codeline .Labels.CorrelationLabel .Request.CorrelationID }}{{ field .Labels.QuorumLabel (print .Quorum) }}
+{{- else }}{{ code .Labels.CorrelationLabel .Request.CorrelationID }}{{ end }}
+{{- params . }}
+{{- with .Request.Diff }}{{ section $.Labels.DiffTitle }}{{ codeblock "diff" . }}{{ end }}
+{{- with .DiffNote }}{{ line . }}{{ end }}`
+
+// messageView is the data passed to approval templates.
+type messageView struct {
+	// Request is the approval request.
+	Request approvals.Request
+	// Labels are localized section titles and field labels.
+	Labels approvalLabels
+	// Title is the localized title with the severity badge and the tool profile emoji.
+	Title string
+	// Severity is the localized severity class with its badge; it is empty when the request has none.
+	Severity string
+	// Session is the "Agent session 42 · Fixing login bug" line.
+	Session string
+	// ExecuteAfter is the localized "will run after" note.
+	ExecuteAfter string
+	// Queued is the localized "queued until" note of a request made outside working hours.
+	Queued string
+	// Text is the tool profile headline or the approval request.
+	Text string
+	// Highlights lists the generated risk highlights as bullet lines.
+	Highlights string
+	// Quorum is the number of required approvals.
+	Quorum int
+	// Attachments lists the names of files uploaded in reply to the message.
+	Attachments string
+	// DiffNote tells how many diff lines were cut; it is empty when the whole diff is shown.
+	DiffNote string
+}
+
+// parseTemplate parses an approval layout with helpers bound to the markup writer.
+func parseTemplate(name, text string, writer approvalMessageWriter) (*template.Template, error) {
+	write := func(fn func(builder *strings.Builder)) string {
+		builder := &strings.Builder{}
+		fn(builder)
+		return builder.String()
+	}
+	funcs := template.FuncMap{
+		"present": func(value string) bool { return strings.TrimSpace(value) != "" },
+		"text": func(value string) string {
+			if _, ok := writer.(htmlApprovalWriter); ok {
+				return shared.EscapeHTML(value)
+			}
+			return shared.EscapeMarkdownV2(value)
+		},
+		"title": func(value string) string {
+			return write(func(b *strings.Builder) { writer.WriteTitle(b, value) })
+		},
+		"section": func(value string) string {
+			return write(func(b *strings.Builder) { writer.WriteSectionHeader(b, value) })
+		},
+		"plain": func(value string) string {
+			return write(func(b *strings.Builder) { writer.WritePlain(b, value, true) })
+		},
+		"line": func(value string) string {
+			return write(func(b *strings.Builder) { writer.WritePlain(b, value, false) })
+		},
+		"field": func(label, value string) string {
+			return write(func(b *strings.Builder) { writer.WriteLabelValue(b, label, value, true) })
+		},
+		"fieldline": func(label, value string) string {
+			return write(func(b *strings.Builder) { writer.WriteLabelValue(b, label, value, false) })
+		},
+		"code": func(label, value string) string {
+			return write(func(b *strings.Builder) { writer.WriteCodeValue(b, label, value, true) })
+		},
+		"codeline": func(label, value string) string {
+			return write(func(b *strings.Builder) { writer.WriteCodeValue(b, label, value, false) })
+		},
+		"links": func(label string, links []approvals.Link) string {
+			return write(func(b *strings.Builder) { writer.WriteLinks(b, label, links) })
+		},
+		"codeblock": func(language, value string) string {
+			return write(func(b *strings.Builder) { writer.WriteCodeBlock(b, language, value) })
+		},
+		"params": func(view messageView) string {
+			return write(func(b *strings.Builder) { writeParams(b, view, writer) })
+		},
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// writeParams renders the parameters section: remaining arguments as a table and marked arguments as code.
+func writeParams(builder *strings.Builder, view messageView, writer approvalMessageWriter) {
+	args, code := splitCodeArguments(view.Request.Arguments, view.Request.ArgumentLanguages)
+	if len(args) > 0 || len(code) > 0 {
+		writer.WriteSectionHeader(builder, view.Labels.ParamsTitle)
+	}
+	if arguments := renderArguments(args); arguments != "" {
+		writer.WriteCodeBlock(builder, "", arguments)
+	}
+	for _, snippet := range code {
+		writer.WriteLabelValue(builder, snippet.Name, "", false)
+		for _, chunk := range snippet.Chunks {
+			writer.WriteCodeBlock(builder, snippet.Language, chunk)
+		}
+	}
+}
+
+// messageTemplates holds the parsed Markdown and HTML approval layouts.
+type messageTemplates struct {
+	markdown *template.Template
+	html     *template.Template
+}
+
+// newMessageTemplates parses configured layouts; an empty layout keeps the built-in one.
+func newMessageTemplates(markdown, html string) (messageTemplates, error) {
+	if strings.TrimSpace(markdown) == "" {
+		markdown = defaultTemplate
+	}
+	if strings.TrimSpace(html) == "" {
+		html = defaultTemplate
+	}
+	var templates messageTemplates
+	var err error
+	if templates.markdown, err = parseTemplate("markdown", markdown, markdownApprovalWriter{}); err != nil {
+		return templates, err
+	}
+	if templates.html, err = parseTemplate("html", html, htmlApprovalWriter{}); err != nil {
+		return templates, err
+	}
+	return templates, nil
+}
+
+// attachmentNames joins the names of attached files.
+func attachmentNames(attachments []approvals.Attachment) string {
+	names := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		names = append(names, attachment.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// builtinTemplates are the built-in layouts used when no template is configured or a configured one fails.
+var builtinTemplates = func() messageTemplates {
+	templates, err := newMessageTemplates("", "")
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}()
+
+// render executes the layout of the request markup. Sensitive requests always use the fixed sensitive layout
+// so that a template cannot reveal hidden arguments.
+func (t messageTemplates) render(msg i18n.Messages, req approvals.Request) (string, error) {
+	var writer approvalMessageWriter = markdownApprovalWriter{}
+	tmpl := t.markdown
+	if strings.EqualFold(strings.TrimSpace(req.Markup), "html") {
+		writer, tmpl = htmlApprovalWriter{}, t.html
+	}
+	labels := approvalLabelsFor(msg)
+	title := msg.ApprovalTitle
+	if emoji := strings.TrimSpace(req.Emoji); emoji != "" {
+		title = emoji + " " + title
+	}
+	if badge := req.Severity.Badge(); badge != "" {
+		title = badge + " " + title
+	}
+	severity := severityName(msg, req.Severity)
+	session := sessionHeader(labels, req)
+	executeAfter := channel.ExecuteAfterNote(msg, req)
+	queued := channel.QueuedNote(msg, req)
+	builder := &strings.Builder{}
+	if req.Sensitive {
+		writer.WriteTitle(builder, title)
+		if severity != "" {
+			writer.WriteLabelValue(builder, labels.SeverityLabel, severity, true)
+		}
+		if session != "" {
+			writer.WritePlain(builder, session, true)
+		}
+		if strings.TrimSpace(req.RequestedBy) != "" {
+			writer.WriteLabelValue(builder, labels.RequestedByLabel, req.RequestedBy, true)
+		}
+		if executeAfter != "" {
+			writer.WritePlain(builder, executeAfter, true)
+		}
+		if queued != "" {
+			writer.WritePlain(builder, queued, true)
+		}
+		renderSensitive(labels, req, writer, builder)
+		return builder.String(), nil
+	}
+	view := messageView{
+		Request:      req,
+		Labels:       labels,
+		Title:        title,
+		Severity:     severity,
+		Session:      session,
+		ExecuteAfter: executeAfter,
+		Queued:       queued,
+		Text:         req.ApprovalRequest,
+		Quorum:       req.RequiredApprovals,
+		Attachments:  attachmentNames(req.Attachments),
+	}
+	if strings.TrimSpace(req.Headline) != "" {
+		view.Text = req.Headline
+	}
+	if len(req.RiskHighlights) > 0 {
+		view.Highlights = "• " + strings.Join(req.RiskHighlights, "\n• ")
+	}
+	if req.DiffOmitted > 0 {
+		view.DiffNote = fmt.Sprintf(fallbackText(msg.DiffTruncated, "%d more lines in the attached full diff"), req.DiffOmitted)
+	}
+	if err := tmpl.Execute(builder, view); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// severityName returns the localized name of severity with its badge, or "" when it is unset.
+func severityName(msg i18n.Messages, severity approvals.Severity) string {
+	var name string
+	switch severity {
+	case approvals.SeverityLow:
+		name = fallbackText(msg.SeverityLow, "low")
+	case approvals.SeverityMedium:
+		name = fallbackText(msg.SeverityMedium, "medium")
+	case approvals.SeverityHigh:
+		name = fallbackText(msg.SeverityHigh, "high")
+	case approvals.SeverityCritical:
+		name = fallbackText(msg.SeverityCritical, "critical")
+	default:
+		return ""
+	}
+	return severity.Badge() + " " + name
+}
@@ -0,0 +1,2 @@
+// Package throttle queues Bot API calls that post, edit, or delete messages so they respect Telegram rate limits.
+package throttle
@@ -0,0 +1,265 @@
+package throttle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
+	"github.com/codex-k8s/telegram-approver/internal/ratelimit"
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+const (
+	// chatBurst is how many calls a chat takes at once before its rate applies.
+	chatBurst = 5
+	// maxRetryAfter bounds the flood-control wait the queue sits out; longer waits are returned to the caller.
+	maxRetryAfter = time.Minute
+	// idleSweep is how often buckets of chats that went quiet are dropped.
+	idleSweep = time.Minute
+)
+
+// queuedPrefixes select the Bot API methods that go through the queue.
+var queuedPrefixes = []string{"send", "edit", "delete", "copy", "forward", "pin", "unpin"}
+
+// Options configures the queue.
+type Options struct {
+	// Rate is how many queued calls per second go to Telegram in total; zero disables the global limit.
+	Rate int
+	// ChatRate is how many queued calls per minute go to one chat; zero disables the per-chat limit.
+	ChatRate int
+	// Retries is how many times a call rejected by flood control is repeated after the suggested wait.
+	Retries int
+	// Metrics records queue outcomes.
+	Metrics *metrics.Metrics
+}
+
+// Caller is a ta.Caller that paces message calls per chat and globally, repeats calls rejected with 429 after
+// their retry_after, and folds an edit waiting in the queue into a newer edit of the same message.
+// Other calls, such as getUpdates, go straight to Next.
+type Caller struct {
+	next   ta.Caller
+	opts   Options
+	log    *slog.Logger
+	mu     sync.Mutex
+	global *ratelimit.Bucket
+	chats  map[string]*ratelimit.Bucket
+	edits  map[string]*pendingEdit
+	swept  time.Time
+}
+
+// pendingEdit is an edit waiting for its turn; later edits of the same message replace its body.
+type pendingEdit struct {
+	data    *ta.RequestData
+	started bool
+	done    chan struct{}
+	resp    *ta.Response
+	err     error
+}
+
+// target is the part of a call body that identifies the chat and message.
+type target struct {
+	ChatID    json.RawMessage `json:"chat_id"`
+	MessageID int             `json:"message_id"`
+}
+
+// New wraps next with the queue.
+func New(next ta.Caller, opts Options, log *slog.Logger) *Caller {
+	now := time.Now()
+	c := &Caller{
+		next:  next,
+		opts:  opts,
+		log:   log,
+		chats: make(map[string]*ratelimit.Bucket),
+		edits: make(map[string]*pendingEdit),
+		swept: now,
+	}
+	if opts.Rate > 0 {
+		c.global = ratelimit.NewBucket(float64(opts.Rate), opts.Rate, now)
+	}
+	return c
+}
+
+// Call implements ta.Caller.
+func (c *Caller) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	method := url[strings.LastIndex(url, "/")+1:]
+	if !queued(method) {
+		return c.next.Call(ctx, url, data)
+	}
+	chat, message := callTarget(data)
+	if chat != "" && message != 0 && strings.HasPrefix(method, "edit") {
+		return c.edit(ctx, url, fmt.Sprintf("%s:%s:%d", method, chat, message), chat, data)
+	}
+	if err := c.wait(ctx, chat); err != nil {
+		return nil, err
+	}
+	return c.call(ctx, url, chat, data)
+}
+
+// edit runs an edit through the queue, coalescing it with an edit of the same message that is still waiting.
+func (c *Caller) edit(ctx context.Context, url, key, chat string, data *ta.RequestData) (*ta.Response, error) {
+	c.mu.Lock()
+	if pending, ok := c.edits[key]; ok && !pending.started {
+		pending.data = data
+		c.mu.Unlock()
+		c.opts.Metrics.SendQueue(metrics.SendCoalesced)
+		select {
+		case <-pending.done:
+			return pending.resp, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	pending := &pendingEdit{data: data, done: make(chan struct{})}
+	c.edits[key] = pending
+	c.mu.Unlock()
+
+	err := c.wait(ctx, chat)
+	c.mu.Lock()
+	pending.started = true
+	if c.edits[key] == pending {
+		delete(c.edits, key)
+	}
+	data = pending.data
+	c.mu.Unlock()
+	if err == nil {
+		pending.resp, pending.err = c.call(ctx, url, chat, data)
+	} else {
+		pending.err = err
+	}
+	close(pending.done)
+	return pending.resp, pending.err
+}
+
+// wait takes the next global and chat token and sleeps until it is due.
+func (c *Caller) wait(ctx context.Context, chat string) error {
+	delay := c.reserve(chat)
+	if delay <= 0 {
+		return nil
+	}
+	c.opts.Metrics.SendQueue(metrics.SendThrottled)
+	return sleep(ctx, delay)
+}
+
+// call performs the call and repeats it after the retry_after of a flood-control rejection.
+// Multipart uploads are streamed once and cannot be repeated.
+func (c *Caller) call(ctx context.Context, url, chat string, data *ta.RequestData) (*ta.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.next.Call(ctx, url, data)
+		if err != nil || resp == nil || resp.Ok || resp.Error == nil || resp.Error.ErrorCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		retryAfter := time.Second
+		if resp.Error.Parameters != nil && resp.Error.Parameters.RetryAfter > 0 {
+			retryAfter = time.Duration(resp.Error.Parameters.RetryAfter) * time.Second
+		}
+		c.block(chat, retryAfter)
+		if attempt >= c.opts.Retries || data == nil || data.BodyRaw == nil || retryAfter > maxRetryAfter {
+			return resp, err
+		}
+		c.opts.Metrics.SendQueue(metrics.SendRetried)
+		c.log.Warn("Telegram flood control, retrying", "method", url[strings.LastIndex(url, "/")+1:], "retry_after", retryAfter, "attempt", attempt+1)
+		if err := c.wait(ctx, chat); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reserve takes a token from the global and chat buckets and returns how long the call waits for it.
+func (c *Caller) reserve(chat string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.sweep(now)
+	buckets := make([]*ratelimit.Bucket, 0, 2)
+	if c.global != nil {
+		buckets = append(buckets, c.global)
+	}
+	if bucket := c.chatBucket(chat, now); bucket != nil {
+		buckets = append(buckets, bucket)
+	}
+	var delay time.Duration
+	for _, bucket := range buckets {
+		delay = max(delay, bucket.Delay(now))
+	}
+	for _, bucket := range buckets {
+		bucket.Take()
+	}
+	return delay
+}
+
+// block holds back calls to chat, or all calls when the chat is unknown, until the flood-control wait ends.
+func (c *Caller) block(chat string, wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	bucket := c.chatBucket(chat, now)
+	if bucket == nil {
+		bucket = c.global
+	}
+	if bucket != nil {
+		bucket.Block(now, now.Add(wait))
+	}
+}
+
+func (c *Caller) chatBucket(chat string, now time.Time) *ratelimit.Bucket {
+	if chat == "" || c.opts.ChatRate <= 0 {
+		return nil
+	}
+	bucket, ok := c.chats[chat]
+	if !ok {
+		bucket = ratelimit.NewBucket(ratelimit.PerMinute(c.opts.ChatRate), chatBurst, now)
+		c.chats[chat] = bucket
+	}
+	return bucket
+}
+
+// sweep drops the buckets of chats that refilled completely.
+func (c *Caller) sweep(now time.Time) {
+	if now.Sub(c.swept) < idleSweep {
+		return
+	}
+	c.swept = now
+	for chat, bucket := range c.chats {
+		if bucket.Full(now) {
+			delete(c.chats, chat)
+		}
+	}
+}
+
+func queued(method string) bool {
+	for _, prefix := range queuedPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// callTarget returns the chat and message of a JSON call; multipart calls are paced globally only.
+func callTarget(data *ta.RequestData) (string, int) {
+	if data == nil || data.BodyRaw == nil || !strings.HasPrefix(data.ContentType, ta.ContentTypeJSON) {
+		return "", 0
+	}
+	var t target
+	if err := json.Unmarshal(data.BodyRaw, &t); err != nil {
+		return "", 0
+	}
+	return strings.Trim(string(t.ChatID), `"`), t.MessageID
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/codex-k8s/telegram-approver/internal/approvals"
+	"github.com/codex-k8s/telegram-approver/internal/config"
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// maxTopicName is the Telegram limit for forum topic names.
+const maxTopicName = 128
+
+type topicKey struct {
+	chatID int64
+	name   string
+}
+
+// topics remembers forum topics created per tool or workflow.
+// Topics are cached in memory only, so a restarted instance creates new ones.
+type topics struct {
+	mode string
+
+	mu       sync.Mutex
+	threads  map[topicKey]int
+	noForums map[int64]struct{}
+}
+
+func newTopics(mode string) *topics {
+	if mode == "" {
+		return nil
+	}
+	return &topics{mode: mode, threads: make(map[topicKey]int), noForums: make(map[int64]struct{})}
+}
+
+// name returns the topic the request belongs to; empty means the general topic.
+func (t *topics) name(req approvals.Request) string {
+	if t == nil {
+		return ""
+	}
+	name := req.Tool
+	if t.mode == config.TopicsWorkflow {
+		name = req.WorkflowID
+	}
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > maxTopicName {
+		name = string(runes[:maxTopicName])
+	}
+	return name
+}
+
+// thread returns the thread ID of the request topic in the chat, creating the topic on first use.
+// Failures fall back to the general topic so the approval is still posted.
+func (s *Service) thread(ctx context.Context, chatID int64, req approvals.Request) int {
+	name := s.topics.name(req)
+	if name == "" {
+		return 0
+	}
+	t := s.topics
+	// Creation happens under the lock so concurrent requests do not create duplicate topics.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.noForums[chatID]; ok {
+		return 0
+	}
+	key := topicKey{chatID: chatID, name: name}
+	if id, ok := t.threads[key]; ok {
+		return id
+	}
+	topic, err := s.bot.CreateForumTopic(ctx, &telego.CreateForumTopicParams{ChatID: tu.ID(chatID), Name: name})
+	if err != nil {
+		var apiErr *ta.Error
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusBadRequest {
+			// Typically the chat is not a forum; do not try again for every request.
+			t.noForums[chatID] = struct{}{}
+		}
+		s.log.Warn("Failed to create forum topic", "error", err, "chat_id", chatID, "topic", name)
+		return 0
+	}
+	t.threads[key] = topic.MessageThreadID
+	s.log.Info("Forum topic created", "chat_id", chatID, "topic", name, "thread_id", topic.MessageThreadID)
+	return topic.MessageThreadID
+}
+
+// forgetThread drops a cached topic that no longer exists.
+func (s *Service) forgetThread(chatID int64, req approvals.Request) {
+	s.topics.mu.Lock()
+	defer s.topics.mu.Unlock()
+	delete(s.topics.threads, topicKey{chatID: chatID, name: s.topics.name(req)})
+}
+
+// fixedThread returns the topic set by the request or, in the primary chat, by TG_APPROVER_THREAD_ID.
+func (s *Service) fixedThread(chatID int64, req approvals.Request) int {
+	if req.MessageThreadID > 0 {
+		return req.MessageThreadID
+	}
+	if chatID == s.cfg.ChatID {
+		return s.cfg.ThreadID
+	}
+	return 0
+}
+
+// sendApprovalMessage posts an approval message into the topic set by the request or the config, or into its
+// per-tool or per-workflow topic when topics are enabled. A created topic deleted since it was cached is created
+// again once; a missing fixed topic falls back to the general topic so the approval is still posted.
+func (s *Service) sendApprovalMessage(ctx context.Context, chatID int64, req approvals.Request, params *telego.SendMessageParams) (*telego.Message, error) {
+	fixed := s.fixedThread(chatID, req)
+	params.MessageThreadID = fixed
+	if fixed == 0 {
+		params.MessageThreadID = s.thread(ctx, chatID, req)
+	}
+	msg, err := s.bot.SendMessage(ctx, params)
+	if err != nil && params.MessageThreadID != 0 && topicGone(err) {
+		if fixed != 0 {
+			s.log.Warn("Forum topic not found, posting to the general topic", "chat_id", chatID, "thread_id", fixed, "correlation_id", req.CorrelationID)
+			params.MessageThreadID = 0
+		} else {
+			s.forgetThread(chatID, req)
+			params.MessageThreadID = s.thread(ctx, chatID, req)
+		}
+		msg, err = s.bot.SendMessage(ctx, params)
+	}
+	return msg, err
+}
+
+func topicGone(err error) bool {
+	var apiErr *ta.Error
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(apiErr.Description), "thread not found")
+}
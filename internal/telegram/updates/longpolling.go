@@ -27,6 +27,7 @@ func (l *LongPolling) Start(ctx context.Context) error {
 		AllowedUpdates: []string{
 			telego.MessageUpdates,
 			telego.CallbackQueryUpdates,
+			telego.MyChatMemberUpdates,
 		},
 	}
 	updates, err := l.bot.UpdatesViaLongPolling(ctx, params)
@@ -5,73 +5,122 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"sync/atomic"
+	"sync"
+	"time"
 
+	"github.com/codex-k8s/telegram-approver/internal/metrics"
 	"github.com/mymmrac/telego"
 )
 
+// defaultQueue is the update queue size used when WebhookOptions.Queue is not set.
+const defaultQueue = 128
+
+// WebhookOptions configures the webhook source.
+type WebhookOptions struct {
+	// URL is the public webhook URL registered with Telegram.
+	URL string
+	// Secret is the Telegram webhook secret token.
+	Secret string
+	// Queue is how many accepted updates may wait for the handler.
+	Queue int
+	// QueueWait is how long a delivery waits for room in a full queue before Telegram is asked to retry.
+	QueueWait time.Duration
+	// MaxConnections bounds concurrent deliveries from Telegram; 0 keeps the Telegram default.
+	MaxConnections int
+	// Metrics records queue outcomes (optional).
+	Metrics *metrics.Metrics
+}
+
 // Webhook delivers Telegram updates via HTTP webhook.
 type Webhook struct {
 	bot     *telego.Bot
-	url     string
-	secret  string
+	opts    WebhookOptions
+	mu      sync.RWMutex
 	updates chan telego.Update
-	closed  atomic.Bool
+	closed  bool
+	recent  *recentUpdates
 	log     *slog.Logger
 }
 
 // NewWebhook creates a new webhook source.
-func NewWebhook(bot *telego.Bot, url, secret string, log *slog.Logger) *Webhook {
-	return &Webhook{
+func NewWebhook(bot *telego.Bot, opts WebhookOptions, log *slog.Logger) *Webhook {
+	if opts.Queue <= 0 {
+		opts.Queue = defaultQueue
+	}
+	w := &Webhook{
 		bot:     bot,
-		url:     url,
-		secret:  secret,
-		updates: make(chan telego.Update, 128),
+		opts:    opts,
+		updates: make(chan telego.Update, opts.Queue),
+		recent:  newRecentUpdates(opts.Queue),
 		log:     log,
 	}
+	opts.Metrics.WebhookQueue(func() int {
+		w.mu.RLock()
+		defer w.mu.RUnlock()
+		return len(w.updates)
+	})
+	return w
 }
 
 // Start sets webhook on Telegram side.
 func (w *Webhook) Start(ctx context.Context) error {
 	params := &telego.SetWebhookParams{
-		URL:         w.url,
-		SecretToken: w.secret,
+		URL:            w.opts.URL,
+		SecretToken:    w.opts.Secret,
+		MaxConnections: w.opts.MaxConnections,
 		AllowedUpdates: []string{
 			telego.MessageUpdates,
 			telego.CallbackQueryUpdates,
+			telego.MyChatMemberUpdates,
 		},
 	}
 	if err := w.bot.SetWebhook(ctx, params); err != nil {
 		return err
 	}
-	w.log.Info("Telegram updates started via webhook", "url", w.url)
+	w.mu.Lock()
+	if w.closed {
+		w.updates = make(chan telego.Update, cap(w.updates))
+		w.closed = false
+	}
+	w.mu.Unlock()
+	w.log.Info("Telegram updates started via webhook", "url", w.opts.URL, "queue", cap(w.updates))
 	return nil
 }
 
-// Stop removes the webhook.
+// Stop stops accepting updates and removes the webhook; Telegram keeps undelivered updates.
 func (w *Webhook) Stop(ctx context.Context) error {
-	w.closed.Store(true)
-	return w.bot.DeleteWebhook(ctx, &telego.DeleteWebhookParams{DropPendingUpdates: true})
+	w.Drain()
+	return w.bot.DeleteWebhook(ctx, &telego.DeleteWebhookParams{})
+}
+
+// Drain stops accepting updates and closes the updates channel once the queued ones are read.
+// Telegram retries rejected deliveries, so another instance behind the same URL receives them.
+func (w *Webhook) Drain() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.updates)
 }
 
 // Updates returns the updates channel.
 func (w *Webhook) Updates() <-chan telego.Update {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return w.updates
 }
 
 // Handler returns HTTP handler for Telegram webhook updates.
 func (w *Webhook) Handler() http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		if w.closed.Load() {
-			rw.WriteHeader(http.StatusServiceUnavailable)
-			return
-		}
 		if r.Method != http.MethodPost {
 			rw.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
 		secret := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
-		if secret != w.secret {
+		if secret != w.opts.Secret {
 			w.log.Warn("Webhook secret mismatch")
 			rw.WriteHeader(http.StatusUnauthorized)
 			return
@@ -84,12 +133,83 @@ func (w *Webhook) Handler() http.Handler {
 			rw.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		select {
-		case w.updates <- update:
+		// Telegram redelivers updates it considers unanswered; handling them twice would repeat decisions.
+		if !w.recent.add(update.UpdateID) {
+			w.opts.Metrics.WebhookUpdate(metrics.WebhookDuplicate)
 			rw.WriteHeader(http.StatusOK)
-		default:
-			w.log.Error("Webhook update dropped: queue full")
+			return
+		}
+		outcome := w.enqueue(r.Context(), update)
+		w.opts.Metrics.WebhookUpdate(outcome)
+		if outcome != metrics.WebhookQueued {
+			w.recent.forget(update.UpdateID)
 			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
 		}
+		rw.WriteHeader(http.StatusOK)
 	})
 }
+
+// enqueue queues the update. A full queue holds the delivery for up to QueueWait, so a burst slows Telegram down
+// instead of being rejected at once; updates that still do not fit are left for Telegram to retry.
+func (w *Webhook) enqueue(ctx context.Context, update telego.Update) string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return metrics.WebhookRejected
+	}
+	select {
+	case w.updates <- update:
+		return metrics.WebhookQueued
+	default:
+	}
+	if w.opts.QueueWait > 0 {
+		timer := time.NewTimer(w.opts.QueueWait)
+		defer timer.Stop()
+		select {
+		case w.updates <- update:
+			return metrics.WebhookQueued
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	w.log.Error("Webhook update dropped: queue full", "update_id", update.UpdateID, "queue", cap(w.updates))
+	return metrics.WebhookDropped
+}
+
+// recentUpdates remembers the IDs of the last accepted updates to drop redeliveries.
+type recentUpdates struct {
+	mu   sync.Mutex
+	ids  map[int]struct{}
+	ring []int
+	next int
+}
+
+func newRecentUpdates(size int) *recentUpdates {
+	return &recentUpdates{ids: make(map[int]struct{}, size), ring: make([]int, 0, size)}
+}
+
+// add records the update ID and reports whether it was not seen before.
+func (r *recentUpdates) add(id int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, id)
+	} else {
+		delete(r.ids, r.ring[r.next])
+		r.ring[r.next] = id
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.ids[id] = struct{}{}
+	return true
+}
+
+// forget drops an update ID that was not queued, so the Telegram retry is accepted.
+func (r *recentUpdates) forget(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.ids, id)
+}
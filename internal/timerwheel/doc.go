@@ -0,0 +1,2 @@
+// Package timerwheel schedules keyed deadlines on a hashed timing wheel driven by a single goroutine.
+package timerwheel
@@ -0,0 +1,132 @@
+package timerwheel
+
+import (
+	"sync"
+	"time"
+)
+
+// Wheel runs functions at their deadlines with tick precision.
+// Scheduling and cancelling are O(1), and thousands of pending deadlines share one ticker.
+type Wheel struct {
+	tick    time.Duration
+	mu      sync.Mutex
+	slots   []map[string]*entry
+	entries map[string]*entry
+	cursor  int
+	stop    chan struct{}
+	once    sync.Once
+}
+
+type entry struct {
+	slot   int
+	rounds int
+	fn     func()
+}
+
+// New starts a wheel that advances every tick and spans tick*slots per revolution.
+func New(tick time.Duration, slots int) *Wheel {
+	if tick <= 0 {
+		tick = time.Second
+	}
+	if slots <= 0 {
+		slots = 1
+	}
+	w := &Wheel{
+		tick:    tick,
+		slots:   make([]map[string]*entry, slots),
+		entries: make(map[string]*entry),
+		stop:    make(chan struct{}),
+	}
+	for i := range w.slots {
+		w.slots[i] = make(map[string]*entry)
+	}
+	go w.run()
+	return w
+}
+
+// Schedule runs fn in its own goroutine at deadline, replacing an earlier schedule of key.
+// Deadlines in the past fire on the next tick.
+func (w *Wheel) Schedule(key string, deadline time.Time, fn func()) {
+	ticks := int((time.Until(deadline) + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.remove(key)
+	slot := (w.cursor + ticks) % len(w.slots)
+	e := &entry{slot: slot, rounds: (ticks - 1) / len(w.slots), fn: fn}
+	w.slots[slot][key] = e
+	w.entries[key] = e
+}
+
+// Cancel removes the schedule of key and reports whether it was pending.
+func (w *Wheel) Cancel(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.remove(key)
+}
+
+// Clear cancels all pending schedules.
+func (w *Wheel) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key := range w.entries {
+		w.remove(key)
+	}
+}
+
+// Len returns the number of pending schedules.
+func (w *Wheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.entries)
+}
+
+// Stop halts the wheel; pending schedules never fire.
+func (w *Wheel) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+func (w *Wheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			for _, fn := range w.advance() {
+				go fn()
+			}
+		}
+	}
+}
+
+// advance moves the cursor one slot and returns the functions that are due.
+func (w *Wheel) advance() []func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cursor = (w.cursor + 1) % len(w.slots)
+	var due []func()
+	for key, e := range w.slots[w.cursor] {
+		if e.rounds > 0 {
+			e.rounds--
+			continue
+		}
+		delete(w.slots[w.cursor], key)
+		delete(w.entries, key)
+		due = append(due, e.fn)
+	}
+	return due
+}
+
+func (w *Wheel) remove(key string) bool {
+	e, ok := w.entries[key]
+	if !ok {
+		return false
+	}
+	delete(w.slots[e.slot], key)
+	delete(w.entries, key)
+	return true
+}
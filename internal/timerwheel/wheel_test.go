@@ -0,0 +1,118 @@
+package timerwheel
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWheelFires(t *testing.T) {
+	w := New(5*time.Millisecond, 16)
+	defer w.Stop()
+	fired := make(chan string, 2)
+	w.Schedule("a", time.Now().Add(20*time.Millisecond), func() { fired <- "a" })
+	// A deadline beyond one revolution waits for its round.
+	w.Schedule("b", time.Now().Add(120*time.Millisecond), func() { fired <- "b" })
+	for _, want := range []string{"a", "b"} {
+		select {
+		case got := <-fired:
+			if got != want {
+				t.Fatalf("fired %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q did not fire", want)
+		}
+	}
+	if n := w.Len(); n != 0 {
+		t.Fatalf("Len = %d after firing, want 0", n)
+	}
+}
+
+func TestWheelCancelAndReschedule(t *testing.T) {
+	w := New(5*time.Millisecond, 16)
+	defer w.Stop()
+	var cancelled, replaced atomic.Bool
+	w.Schedule("a", time.Now().Add(10*time.Millisecond), func() { cancelled.Store(true) })
+	if !w.Cancel("a") {
+		t.Fatal("Cancel of a pending key = false")
+	}
+	if w.Cancel("a") {
+		t.Fatal("second Cancel = true")
+	}
+	done := make(chan struct{})
+	w.Schedule("b", time.Now().Add(10*time.Millisecond), func() { replaced.Store(true) })
+	w.Schedule("b", time.Now().Add(15*time.Millisecond), func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("rescheduled key did not fire")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if cancelled.Load() || replaced.Load() {
+		t.Fatalf("cancelled fired: %v, replaced fired: %v", cancelled.Load(), replaced.Load())
+	}
+}
+
+// TestWheelConcurrent schedules and cancels from many goroutines while the wheel fires; run it with -race.
+func TestWheelConcurrent(t *testing.T) {
+	const (
+		workers = 16
+		perWork = 200
+	)
+	w := New(time.Millisecond, 64)
+	defer w.Stop()
+	var fired, cancelled atomic.Int64
+	var wg sync.WaitGroup
+	for g := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWork {
+				key := fmt.Sprintf("%d-%d", g, i)
+				w.Schedule(key, time.Now().Add(time.Duration(i%20)*time.Millisecond), func() { fired.Add(1) })
+				if i%2 == 1 && w.Cancel(key) {
+					cancelled.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// Every schedule fires unless Cancel removed it first.
+	want := workers*perWork - cancelled.Load()
+	deadline := time.Now().Add(2 * time.Second)
+	for fired.Load() < want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := fired.Load(); got != want {
+		t.Fatalf("fired %d, want %d", got, want)
+	}
+}
+
+func BenchmarkWheelSchedule(b *testing.B) {
+	w := New(100*time.Millisecond, 512)
+	defer w.Stop()
+	deadline := time.Now().Add(time.Hour)
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w.Schedule(fmt.Sprintf("k-%d", n.Add(1)), deadline, func() {})
+		}
+	})
+}
+
+func BenchmarkWheelScheduleCancel(b *testing.B) {
+	w := New(100*time.Millisecond, 512)
+	defer w.Stop()
+	deadline := time.Now().Add(time.Hour)
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			key := fmt.Sprintf("k-%d", n.Add(1))
+			w.Schedule(key, deadline, func() {})
+			w.Cancel(key)
+		}
+	})
+}
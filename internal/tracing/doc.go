@@ -0,0 +1,2 @@
+// Package tracing configures OpenTelemetry tracing and carries trace context across the approval lifecycle.
+package tracing
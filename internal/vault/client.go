@@ -0,0 +1,189 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultJWTPath is the service account token mounted into Kubernetes pods.
+const DefaultJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// Options configures the Vault client.
+type Options struct {
+	// Addr is the Vault base URL, e.g. https://vault.example.com:8200.
+	Addr string
+	// Token authenticates directly; when empty the client logs in with the Kubernetes auth method.
+	Token string
+	// Role is the Kubernetes auth role used when Token is empty.
+	Role string
+	// AuthMount is the mount path of the Kubernetes auth method; empty means "kubernetes".
+	AuthMount string
+	// JWTPath is the service account token presented to the Kubernetes auth method; empty means DefaultJWTPath.
+	JWTPath string
+	// Namespace is the Vault Enterprise namespace; empty uses the root namespace.
+	Namespace string
+	// CAFile is a PEM bundle trusted for the Vault TLS certificate in addition to the system roots.
+	CAFile string
+	// Timeout limits every request; zero means 10 seconds.
+	Timeout time.Duration
+}
+
+// Client reads KV version 2 secrets.
+type Client struct {
+	client    *http.Client
+	addr      string
+	token     string
+	role      string
+	authMount string
+	jwtPath   string
+	namespace string
+}
+
+// New creates a client; it does not contact Vault.
+func New(opts Options) (*Client, error) {
+	addr := strings.TrimRight(strings.TrimSpace(opts.Addr), "/")
+	if u, err := url.Parse(addr); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("vault addr must be an absolute url")
+	}
+	if strings.TrimSpace(opts.Token) == "" && strings.TrimSpace(opts.Role) == "" {
+		return nil, fmt.Errorf("vault token or kubernetes auth role is required")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.TrimSpace(opts.CAFile) != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read vault ca file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("vault ca file has no pem certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	c := &Client{
+		client:    &http.Client{Transport: transport, Timeout: timeout},
+		addr:      addr,
+		token:     strings.TrimSpace(opts.Token),
+		role:      strings.TrimSpace(opts.Role),
+		authMount: strings.Trim(strings.TrimSpace(opts.AuthMount), "/"),
+		jwtPath:   strings.TrimSpace(opts.JWTPath),
+		namespace: strings.TrimSpace(opts.Namespace),
+	}
+	if c.authMount == "" {
+		c.authMount = "kubernetes"
+	}
+	if c.jwtPath == "" {
+		c.jwtPath = DefaultJWTPath
+	}
+	return c, nil
+}
+
+// ReadKV returns the string values of the latest version of the secret at path in the KV version 2 engine
+// mounted at mount. It logs in first when the client has no token yet.
+func (c *Client) ReadKV(ctx context.Context, mount, path string) (map[string]string, error) {
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	endpoint := "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.Trim(path, "/")
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &resp); err != nil {
+		return nil, fmt.Errorf("read vault secret %s: %w", path, err)
+	}
+	values := make(map[string]string, len(resp.Data.Data))
+	for key, value := range resp.Data.Data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+	return values, nil
+}
+
+// login exchanges the service account token for a Vault token with the Kubernetes auth method.
+func (c *Client) login(ctx context.Context) error {
+	jwt, err := os.ReadFile(c.jwtPath)
+	if err != nil {
+		return fmt.Errorf("read service account token: %w", err)
+	}
+	body := map[string]string{"role": c.role, "jwt": strings.TrimSpace(string(jwt))}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/auth/"+c.authMount+"/login", body, &resp); err != nil {
+		return fmt.Errorf("vault kubernetes login: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault kubernetes login returned no token")
+	}
+	c.token = resp.Auth.ClientToken
+	return nil
+}
+
+// do sends a request to the Vault API and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		// Vault error bodies name the failure without echoing secrets.
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if len(failure.Errors) > 0 {
+			return fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(failure.Errors, "; "))
+		}
+		return fmt.Errorf("vault returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode vault response: %w", err)
+	}
+	return nil
+}
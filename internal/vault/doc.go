@@ -0,0 +1,2 @@
+// Package vault reads startup secrets from a HashiCorp Vault KV version 2 engine.
+package vault